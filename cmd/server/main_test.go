@@ -0,0 +1,17 @@
+package server
+
+import (
+	"os"
+	"testing"
+
+	"sitewatch/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	// Templates, static files and translations are found relative to the
+	// repository root like when the server runs
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	testutil.Main(m, ".")
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sitewatch/internal/handlers"
+	"sitewatch/internal/middleware"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/auth"
+)
+
+// apiRoute is an API endpoint with the permission its token needs
+type apiRoute struct {
+	method     string
+	path       string // Relative to the API prefix
+	permission models.TokenPermission
+	handler    fiber.Handler
+}
+
+// apiRoutes returns the endpoints served under every API prefix. A new API
+// version registers its own table next to it once its routes diverge.
+func apiRoutes(healthHandler fiber.Handler) []apiRoute {
+	return []apiRoute{
+		// Site status endpoints (status permission required, redacted without read)
+		{fiber.MethodGet, "/sites", models.PermissionStatus, handlers.HandleGetSites},
+		{fiber.MethodGet, "/sites/:siteId/status", models.PermissionStatus, handlers.HandleGetSiteStatus},
+		{fiber.MethodGet, "/sites/:siteId/details", models.PermissionStatus, handlers.HandleGetSiteDetails},
+
+		// Sites endpoints (read permission required)
+		{fiber.MethodGet, "/sites/dependency-graph", models.PermissionRead, handlers.HandleGetDependencyGraph},
+		{fiber.MethodGet, "/sites/:siteId/statistics", models.PermissionRead, handlers.HandleGetSiteStatistics},
+		{fiber.MethodGet, "/sites/:siteId/distribution", models.PermissionRead, handlers.HandleGetSiteLatencyDistribution},
+		{fiber.MethodGet, "/sites/:siteId/charts", models.PermissionRead, handlers.HandleGetSiteChartData},
+		{fiber.MethodGet, "/sites/:siteId/charts/:chartType.png", models.PermissionRead, handlers.HandleGetSiteChartImage},
+		{fiber.MethodGet, "/sites/:siteId/availability-matrix", models.PermissionRead, handlers.HandleGetSiteAvailabilityMatrix},
+		{fiber.MethodGet, "/sites/:siteId/recent-checks", models.PermissionRead, handlers.HandleGetSiteRecentChecks},
+		{fiber.MethodGet, "/sites/:siteId/current", models.PermissionRead, handlers.HandleGetSiteCurrent},
+		{fiber.MethodGet, "/sites/:siteId/sla", models.PermissionRead, handlers.HandleGetSiteSLAReport},
+		{fiber.MethodGet, "/sites/:siteId/sla-report/export", models.PermissionRead, handlers.HandleExportSiteSLAReport},
+		{fiber.MethodGet, "/reports/monthly.pdf", models.PermissionRead, handlers.HandleGetMonthlyPDFReport},
+		{fiber.MethodGet, "/sites/:siteId/report", models.PermissionRead, handlers.HandleGetSiteReport},
+		{fiber.MethodGet, "/sites/:siteId/latency-baseline", models.PermissionRead, handlers.HandleGetSiteLatencyBaseline},
+		{fiber.MethodGet, "/sites/:siteId/affected-by", models.PermissionRead, handlers.HandleGetSiteAffectedBy},
+		{fiber.MethodGet, "/sites/:siteId/patterns", models.PermissionRead, handlers.HandleGetSitePatterns},
+		{fiber.MethodGet, "/sites/:siteId/diagnostics", models.PermissionRead, handlers.HandleGetSiteDiagnostics},
+		{fiber.MethodGet, "/availability-matrix", models.PermissionRead, handlers.HandleGetAvailabilityMatrix},
+		{fiber.MethodGet, "/maintenance-windows", models.PermissionRead, handlers.HandleGetMaintenanceWindows},
+		{fiber.MethodGet, "/logs", models.PermissionRead, handlers.HandleGetLogs},
+		{fiber.MethodGet, "/self/metrics", models.PermissionRead, handlers.HandleGetSelfMetrics},
+		{fiber.MethodGet, "/fleet/history", models.PermissionRead, handlers.HandleGetFleetHistory},
+
+		// Health endpoint also available for read tokens
+		{fiber.MethodGet, "/health", models.PermissionRead, healthHandler},
+
+		// Test endpoints (test permission required)
+		{fiber.MethodPost, "/sites/:siteId/test", models.PermissionTest, handlers.HandleSiteTest},
+		{fiber.MethodPost, "/test-all", models.PermissionTest, handlers.HandleTestAllSites},
+
+		// Ingest endpoint for externally measured results (ingest permission required)
+		{fiber.MethodPost, "/ingest", models.PermissionIngest, handlers.HandleIngestResults},
+
+		// Admin endpoints (admin permission required)
+		{fiber.MethodGet, "/admin/runtime", models.PermissionAdmin, handlers.HandleGetRuntime},
+		{fiber.MethodGet, "/admin/api-sla-report", models.PermissionAdmin, handlers.HandleGetAPISLAReport},
+		{fiber.MethodGet, "/admin/jobs", models.PermissionAdmin, handlers.HandleGetJobs},
+		{fiber.MethodPost, "/admin/jobs/:name/run", models.PermissionAdmin, handlers.HandleRunJob},
+		{fiber.MethodGet, "/admin/circuit-breakers", models.PermissionAdmin, handlers.HandleGetCircuitBreakers},
+		{fiber.MethodPost, "/admin/circuit-breakers/:siteId/:line/open", models.PermissionAdmin, handlers.HandleOpenCircuitBreaker},
+		{fiber.MethodPost, "/admin/circuit-breakers/:siteId/:line/reset", models.PermissionAdmin, handlers.HandleResetCircuitBreaker},
+		{fiber.MethodGet, "/admin/webhooks/dlq", models.PermissionAdmin, handlers.HandleGetWebhookDLQ},
+		{fiber.MethodPost, "/admin/webhooks/dlq/retry", models.PermissionAdmin, handlers.HandleRetryWebhookDLQ},
+		{fiber.MethodPost, "/admin/webhooks/dlq/:id/retry", models.PermissionAdmin, handlers.HandleRetryWebhookDLQEntry},
+		{fiber.MethodGet, "/admin/simulate", models.PermissionAdmin, handlers.HandleGetSimulations},
+		{fiber.MethodPost, "/admin/simulate/:siteId", models.PermissionAdmin, handlers.HandleStartSimulation},
+		{fiber.MethodDelete, "/admin/simulate/:siteId", models.PermissionAdmin, handlers.HandleCancelSimulation},
+
+		// Database maintenance (admin permission required)
+		{fiber.MethodPost, "/storage/optimize", models.PermissionAdmin, handlers.HandleOptimizeStorage},
+
+		// Notifier configuration check (admin permission required)
+		{fiber.MethodPost, "/notifications/test", models.PermissionAdmin, handlers.HandleTestNotifications},
+
+		// Subnet discovery review (admin permission required)
+		{fiber.MethodGet, "/discovery/candidates", models.PermissionAdmin, handlers.HandleGetDiscoveryCandidates},
+		{fiber.MethodPost, "/discovery/promote/:ip", models.PermissionAdmin, handlers.HandlePromoteDiscoveryCandidate},
+	}
+}
+
+// registerAPIRoutes registers routes under prefix for an API version. The
+// auth middleware is attached per route rather than per group, since group
+// middleware of /api would also run for every /api/v1 request.
+func registerAPIRoutes(app *fiber.App, authService *auth.Service, prefix, version string, routes []apiRoute, extra ...fiber.Handler) {
+	for _, route := range routes {
+		chain := []fiber.Handler{middleware.APIVersion(version)}
+		chain = append(chain, extra...)
+		chain = append(chain, middleware.APIAuthMiddleware(authService, route.permission), route.handler)
+		app.Add(route.method, prefix+route.path, chain...)
+	}
+}
+
+// registerAPI serves the API under its canonical /api/v1 prefix and, for a
+// deprecation period, under the legacy /api prefix with deprecation headers
+func registerAPI(app *fiber.App, authService *auth.Service, healthHandler fiber.Handler, legacySunset time.Time) {
+	routes := apiRoutes(healthHandler)
+	registerAPIRoutes(app, authService, "/api/v1", middleware.APIVersionV1, routes)
+	registerAPIRoutes(app, authService, "/api", middleware.APIVersionLegacy, routes,
+		middleware.LegacyAPI("/api", "/api/v1", legacySunset))
+}
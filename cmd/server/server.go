@@ -7,12 +7,17 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/template/html/v2"
 
+	"sitewatch/internal/assets"
 	"sitewatch/internal/config"
+	"sitewatch/internal/format"
 	"sitewatch/internal/handlers"
+	"sitewatch/internal/i18n"
 	"sitewatch/internal/logger"
 	"sitewatch/internal/middleware"
+	"sitewatch/internal/services/ping"
 	"sitewatch/internal/models"
 	"sitewatch/internal/services/auth"
 )
@@ -25,6 +30,14 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 	authService := auth.NewService(&appState.Config.Auth)
 	log.Info("Authentication service initialized", "enabled", authService.IsEnabled())
 	
+	// Content hashes of the static files for cache busting asset URLs
+	staticAssets, err := assets.Load("./web/static", "/static")
+	if err != nil {
+		log.Warn("Failed to hash static assets, serving them uncached", "error", err)
+	} else {
+		log.Info("Static assets hashed", "files", staticAssets.Len())
+	}
+	
 	// Initialize template engine
 	engine := html.New("./web/templates", ".html")
 	engine.Reload(true) // Enable auto-reload in development
@@ -32,12 +45,8 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 	
 	// Add custom template functions
 	engine.AddFunc("printf", fmt.Sprintf)
-	engine.AddFunc("formatLatency", func(latency *float64) string {
-		if latency == nil {
-			return ""
-		}
-		return fmt.Sprintf("%.1f", *latency)
-	})
+	engine.AddFunc("formatLatency", format.LatencyPtr)
+	engine.AddFunc("formatMs", format.Latency)
 	engine.AddFunc("add", func(a, b int) int {
 		return a + b
 	})
@@ -55,6 +64,10 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 		}
 		return dict, nil
 	})
+	// Translation lookup: {{t .Locale "key" args...}}
+	engine.AddFunc("t", i18n.T)
+	// Versioned static file URL: {{asset "js/app.js"}}
+	engine.AddFunc("asset", staticAssets.URL)
 	engine.AddFunc("until", func(count int) []int {
 		result := make([]int, count)
 		for i := range result {
@@ -65,6 +78,8 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 
 	fiberApp := fiber.New(fiber.Config{
 		Views:        engine,
+		// Hand request locals (the negotiated Locale) to every template
+		PassLocalsToViews: true,
 		ReadTimeout:  appState.Config.Server.ReadTimeout,
 		WriteTimeout: appState.Config.Server.WriteTimeout,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -87,8 +102,18 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 	// Middleware
 	fiberApp.Use(recover.New())
 	
+	// Request ID (X-Request-ID), referenced in response time SLA warnings
+	fiberApp.Use(requestid.New())
+	
+	// Security and noindex headers on every response (server.security)
+	fiberApp.Use(middleware.SecurityHeaders(appState.Config.Server.Security.ContentSecurityPolicy,
+		appState.Config.Server.Security.FrameOptions))
+	
 	// Performance metrics middleware
-	fiberApp.Use(middleware.MetricsMiddleware())
+	fiberApp.Use(middleware.MetricsMiddleware(appState.Config.Server.ResponseSLAMs))
+	
+	// Display locale from Accept-Language, falling back to server.locale
+	fiberApp.Use(middleware.LocaleMiddleware())
 	
 	// Custom structured logging middleware
 	fiberApp.Use(func(c *fiber.Ctx) error {
@@ -121,22 +146,73 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 	
 	fiberApp.Use(cors.New())
 
+	// Health handler - reports 503 while storage writes are degraded
+	healthHandler := func(c *fiber.Ctx) error {
+		pipeline := ping.GetResultPipelineHealth(appState)
+		
+		status := "ok"
+		storageStatus := "ok"
+		if appState.IsStorageDegraded() {
+			status = "degraded"
+			storageStatus = "degraded"
+		}
+		if pipeline.ProcessorStalled {
+			status = "degraded"
+		}
+		
+		// Ingest-only mode has no probe capability to report on
+		probing := "enabled"
+		if !appState.Config.IsPingEnabled() {
+			probing = "disabled"
+		}
+		
+		response := fiber.Map{
+			"status":  status,
+			"storage": storageStatus,
+			"components": fiber.Map{
+				"storage":         storageStatus,
+				"result_pipeline": pipeline,
+				"probing":         probing,
+			},
+			"uptime":  time.Since(appState.StartTime).Seconds(),
+			"version": config.Version,
+		}
+		if status != "ok" {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+		}
+		return c.JSON(response)
+	}
+
 	// Health check endpoint - accessible with metrics permission
 	fiberApp.Get("/health", 
 		middleware.APIAuthMiddleware(authService, models.PermissionMetrics), 
-		func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{
-				"status":  "ok",
-				"uptime":  time.Since(appState.StartTime).Seconds(),
-				"version": "1.0.0",
-			})
-		})
+		healthHandler)
 
-	// Static files
-	fiberApp.Static("/static", "./web/static")
+	// Static files, cached for a year when requested with the current
+	// content hash (asset template function), revalidated otherwise
+	fiberApp.Static("/static", "./web/static", fiber.Static{
+		ModifyResponse: func(c *fiber.Ctx) error {
+			if staticAssets.IsCurrent(c.Path(), c.Query("v")) {
+				c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+			} else {
+				c.Set(fiber.HeaderCacheControl, "no-cache")
+			}
+			return nil
+		},
+	})
+	
+	// Keep crawlers out, X-Robots-Tag covers those ignoring robots.txt
+	fiberApp.Get("/robots.txt", func(c *fiber.Ctx) error {
+		return c.SendString("User-agent: *\nDisallow: /\n")
+	})
 	
 	// UI Routes (Public - with session management)
 	fiberApp.Get("/", func(c *fiber.Ctx) error {
+		// Single sign-on replaces the shared UI secret cookie
+		if requiresSSOLogin(c, authService) {
+			return c.Redirect("/auth/oidc/login")
+		}
+		
 		// Set UI session cookie if auth is enabled
 		if authService.IsEnabled() {
 			sessionName := authService.GetUISessionName()
@@ -148,7 +224,7 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 					Expires:  time.Now().Add(expiry),
 					HTTPOnly: true,
 					SameSite: "Strict",
-					Secure:   false, // Set to true in production with HTTPS
+					Secure:   appState.Config.Server.TLS.Enabled,
 				})
 			}
 		}
@@ -156,6 +232,11 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 	})
 	
 	fiberApp.Get("/dashboard", func(c *fiber.Ctx) error {
+		// Single sign-on replaces the shared UI secret cookie
+		if requiresSSOLogin(c, authService) {
+			return c.Redirect("/auth/oidc/login")
+		}
+		
 		// Set UI session cookie if auth is enabled
 		if authService.IsEnabled() {
 			sessionName := authService.GetUISessionName()
@@ -167,55 +248,52 @@ func SetupFiberApp(appState *config.AppState) *fiber.App {
 					Expires:  time.Now().Add(expiry),
 					HTTPOnly: true,
 					SameSite: "Strict",
-					Secure:   false, // Set to true in production with HTTPS
+					Secure:   appState.Config.Server.TLS.Enabled,
 				})
 			}
 		}
 		return handlers.HandleDashboard(c)
 	})
 
+	// Single sign-on (OIDC authorization code flow)
+	fiberApp.Get("/auth/oidc/login", handlers.HandleOIDCLogin(authService))
+	fiberApp.Get("/auth/oidc/callback", handlers.HandleOIDCCallback(authService))
+	
 	// UI Fragment Routes (for HTMX) - Protected with UI session
 	ui := fiberApp.Group("/ui", middleware.UIAuthMiddleware(authService))
 	ui.Get("/overview", handlers.HandleUIOverview)
 	ui.Get("/sites", handlers.HandleUISites)
 	ui.Get("/details/:siteId", handlers.HandleUIDetails)
 	ui.Get("/enhanced-fragment/:siteId", handlers.HandleUIEnhancedFragment)
+	ui.Get("/chart-data/self/host/:range", handlers.HandleUIHostChartData) // Before the site charts it would match
+	ui.Get("/chart-data/fleet/:range", handlers.HandleUIFleetChartData)
 	ui.Get("/chart-data/:siteId/:chartType/:range", handlers.HandleUIChartData)
 	ui.Get("/logs", handlers.HandleUILogs)
 	ui.Get("/logs-table", handlers.HandleUILogsTable)
+	ui.Get("/logs-more", handlers.HandleUILogsMore)
 	ui.Post("/test/:siteId", handlers.HandleSiteTest)
 
-	// API Routes - Protected with API tokens
-	api := fiberApp.Group("/api")
+	// API Routes - Protected with API tokens, canonical under /api/v1 and
+	// deprecated under /api (see routes.go)
+	legacySunset, _ := time.Parse(time.DateOnly, appState.Config.Server.LegacyAPISunset)
+	registerAPI(fiberApp, authService, healthHandler, legacySunset)
 
-	// Sites endpoints (read permission required)
-	apiRead := api.Group("", middleware.APIAuthMiddleware(authService, models.PermissionRead))
-	apiRead.Get("/sites", handlers.HandleGetSites)
-	apiRead.Get("/sites/:siteId/status", handlers.HandleGetSiteStatus)
-	apiRead.Get("/sites/:siteId/details", handlers.HandleGetSiteDetails)
-	apiRead.Get("/sites/:siteId/statistics", handlers.HandleGetSiteStatistics)
-	apiRead.Get("/sites/:siteId/charts", handlers.HandleGetSiteChartData)
-	apiRead.Get("/logs", handlers.HandleGetLogs)
-	
-	// Health endpoint also available for read tokens
-	apiRead.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"uptime":  time.Since(appState.StartTime).Seconds(),
-			"version": "1.0.0",
-		})
-	})
-	
-	// Test endpoints (test permission required)
-	apiTest := api.Group("", middleware.APIAuthMiddleware(authService, models.PermissionTest))
-	apiTest.Post("/sites/:siteId/test", handlers.HandleSiteTest)
-
-	// Metrics endpoint (Prometheus format) - Protected with metrics permission
+	// Metrics endpoint (Prometheus format) - Protected with scrape permission,
+	// which metrics tokens include
 	if appState.Config.Metrics.Enabled {
 		fiberApp.Get(appState.Config.Metrics.Path, 
-			middleware.APIAuthMiddleware(authService, models.PermissionMetrics), 
+			middleware.APIAuthMiddleware(authService, models.PermissionScrape), 
 			handlers.HandlePrometheusMetrics)
 	}
 
 	return fiberApp
-}
\ No newline at end of file
+}
+
+// requiresSSOLogin reports whether OIDC is configured and the request has no valid SSO session
+func requiresSSOLogin(c *fiber.Ctx, authService *auth.Service) bool {
+	if authService.OIDC() == nil {
+		return false
+	}
+	_, ok := authService.Sessions().Get(c.Cookies(authService.GetUISessionName()))
+	return !ok
+}
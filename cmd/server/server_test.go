@@ -0,0 +1,152 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/ping"
+	"sitewatch/internal/testutil"
+)
+
+// get sends a request to app with the given headers and returns the
+// response with its body read
+func get(t *testing.T, app *fiber.App, method, target string, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestFragmentConditionalPolling(t *testing.T) {
+	for _, path := range []string{"/ui/overview", "/ui/sites"} {
+		t.Run(path, func(t *testing.T) {
+			site := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true}
+			appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{site}, Clock: true, Global: true})
+			app := SetupFiberApp(appState)
+
+			resp, body := get(t, app, fiber.MethodGet, path, nil)
+			version := resp.Header.Get("X-Fragment-Version")
+			if resp.StatusCode != fiber.StatusOK || version == "" || !strings.Contains(body, "<") {
+				t.Fatalf("first poll: status %d, version %q, body %q, want 200 with HTML and a version", resp.StatusCode, version, body)
+			}
+
+			// Idle: the rendered version gets 204 by header, query and HEAD
+			idle := []struct {
+				method, target string
+				headers        map[string]string
+			}{
+				{fiber.MethodGet, path, map[string]string{"X-Fragment-Version": version}},
+				{fiber.MethodGet, path + "?version=" + version, nil},
+				{fiber.MethodHead, path, map[string]string{"X-Fragment-Version": version}},
+			}
+			for _, poll := range idle {
+				resp, body := get(t, app, poll.method, poll.target, poll.headers)
+				if resp.StatusCode != fiber.StatusNoContent || body != "" {
+					t.Errorf("idle %s %s: status %d, body %q, want 204 without body", poll.method, poll.target, resp.StatusCode, body)
+				}
+				if got := resp.Header.Get("X-Fragment-Version"); got != version {
+					t.Errorf("idle %s %s: version %q, want %q", poll.method, poll.target, got, version)
+				}
+			}
+
+			latency := 12.5
+			ping.HandlePingResult(appState, models.PingResult{
+				SiteID: site.ID, IP: site.PrimaryIP, LineType: "primary", Success: true, Latency: &latency,
+				PacketsSent: 3, PacketsRecv: 3, Timestamp: testutil.Now.Add(-time.Second),
+			})
+
+			// After the check the old version gets fresh HTML and the new version
+			if resp, _ := get(t, app, fiber.MethodHead, path, map[string]string{"X-Fragment-Version": version}); resp.StatusCode != fiber.StatusOK {
+				t.Errorf("HEAD after a status update: status %d, want 200", resp.StatusCode)
+			}
+			resp, body = get(t, app, fiber.MethodGet, path, map[string]string{"X-Fragment-Version": version})
+			updated := resp.Header.Get("X-Fragment-Version")
+			if resp.StatusCode != fiber.StatusOK || !strings.Contains(body, "<") {
+				t.Fatalf("poll after a status update: status %d, body %q, want 200 with HTML", resp.StatusCode, body)
+			}
+			if updated == "" || updated == version {
+				t.Errorf("version after a status update = %q, want a new version", updated)
+			}
+			if path == "/ui/sites" && !strings.Contains(body, "12.5 ms") {
+				t.Errorf("sites fragment does not show the new latency:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		csp          string
+		frameOptions string
+		wantCSP      string // Empty for no header
+		wantFrame    string
+	}{
+		{"defaults", config.DefaultContentSecurityPolicy, "DENY", config.DefaultContentSecurityPolicy, "DENY"},
+		{"custom", "default-src 'self'", "SAMEORIGIN", "default-src 'self'", "SAMEORIGIN"},
+		{"iframe without policy", "off", "ALLOW", "", ""},
+	}
+	responses := []struct {
+		name, path, contentType string
+		status                  int
+	}{
+		{"HTML", "/dashboard", "text/html", fiber.StatusOK},
+		{"fragment", "/ui/overview", "text/html", fiber.StatusOK},
+		{"JSON", "/api/v1/sites", "application/json", fiber.StatusOK},
+		{"JSON error", "/api/v1/sites/missing/details", "application/json", fiber.StatusNotFound},
+		{"static", "/static/css/tailwind.css", "text/css", fiber.StatusOK},
+		{"robots", "/robots.txt", "text/plain", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true}}, Clock: true, Global: true})
+			appState.Config.Server.Security.ContentSecurityPolicy = tt.csp
+			appState.Config.Server.Security.FrameOptions = tt.frameOptions
+			app := SetupFiberApp(appState)
+
+			for _, response := range responses {
+				resp, _ := get(t, app, fiber.MethodGet, response.path, nil)
+				if resp.StatusCode != response.status {
+					t.Errorf("%s: status %d, want %d", response.name, resp.StatusCode, response.status)
+				}
+				if !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), response.contentType) {
+					t.Errorf("%s: content type %q, want %s", response.name, resp.Header.Get(fiber.HeaderContentType), response.contentType)
+				}
+
+				want := map[string]string{
+					"X-Content-Type-Options":  "nosniff",
+					"Referrer-Policy":         "strict-origin-when-cross-origin",
+					"X-Robots-Tag":            "noindex, nofollow",
+					"X-Frame-Options":         tt.wantFrame,
+					"Content-Security-Policy": tt.wantCSP,
+				}
+				for header, value := range want {
+					if got, present := resp.Header[header]; value == "" && present {
+						t.Errorf("%s: %s %q set, want no header", response.name, header, got)
+					} else if value != "" && resp.Header.Get(header) != value {
+						t.Errorf("%s: %s = %q, want %q", response.name, header, resp.Header.Get(header), value)
+					}
+				}
+			}
+		})
+	}
+}
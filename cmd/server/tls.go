@@ -0,0 +1,94 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/acme/autocert"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+// ListenTLS serves HTTPS on addr with the configured certificate files or
+// certificates obtained via ACME, and the plain HTTP redirect listener when
+// server.tls.http_redirect_port is set. It returns when the server stops.
+func ListenTLS(srv *fiber.App, appState *config.AppState, addr string) error {
+	log := logger.Default().WithComponent("server")
+	cfg := appState.Config.Server.TLS
+
+	var (
+		manager   *autocert.Manager
+		tlsConfig *tls.Config
+	)
+	if len(cfg.ACME.Domains) > 0 {
+		manager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACME.Domains...),
+			Cache:      autocert.DirCache(cfg.ACME.CacheDir),
+			Email:      cfg.ACME.Email,
+		}
+		tlsConfig = manager.TLSConfig()
+	} else {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("loading certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	tlsConfig.MinVersion = tls.VersionTLS12
+
+	ln, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	if cfg.HTTPRedirectPort > 0 {
+		redirect := startHTTPRedirect(appState, manager)
+		defer redirect.Close()
+	}
+
+	log.Info("HTTPS enabled", "acme", manager != nil, "http_redirect_port", cfg.HTTPRedirectPort)
+	return srv.Listener(ln)
+}
+
+// startHTTPRedirect serves the plain HTTP port, redirecting every request to
+// HTTPS. With ACME it also answers the http-01 challenges.
+func startHTTPRedirect(appState *config.AppState, manager *autocert.Manager) *http.Server {
+	log := logger.Default().WithComponent("server")
+	httpsPort := appState.Config.Server.Port
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+
+	addr := net.JoinHostPort(appState.Config.Server.Host, strconv.Itoa(appState.Config.Server.TLS.HTTPRedirectPort))
+	redirect := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Info("HTTP redirect listener starting", "address", addr)
+		if err := redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("HTTP redirect listener error", "error", err)
+		}
+	}()
+	return redirect
+}
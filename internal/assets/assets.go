@@ -0,0 +1,75 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hashLength is the number of hex digits of the content hash used as version
+const hashLength = 12
+
+// Manifest maps the static files to a hash of their content, so asset URLs
+// change with every deploy that changes the file and can be cached forever
+type Manifest struct {
+	prefix string            // URL prefix the directory is served under, e.g. /static
+	hashes map[string]string // Slash separated path relative to the directory → content hash
+}
+
+// Load hashes every file below dir, served under the URL prefix. On error
+// the manifest is still usable, files not hashed get unversioned URLs.
+func Load(dir, prefix string) (*Manifest, error) {
+	m := &Manifest{prefix: prefix, hashes: make(map[string]string)}
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		m.hashes[filepath.ToSlash(name)] = Hash(content)
+		return nil
+	})
+	return m, err
+}
+
+// Hash returns the version of a file content
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// Len returns the number of hashed files
+func (m *Manifest) Len() int {
+	return len(m.hashes)
+}
+
+// URL returns the versioned URL of a static file, e.g. /static/js/app.js?v=1a2b3c4d5e6f,
+// or the plain URL for a file that did not exist at startup
+func (m *Manifest) URL(name string) string {
+	url := path.Join(m.prefix, name)
+	if hash, ok := m.hashes[name]; ok {
+		return url + "?v=" + hash
+	}
+	return url
+}
+
+// IsCurrent reports whether version is the hash of the file at URL path
+// urlPath, i.e. the response may be cached as immutable
+func (m *Manifest) IsCurrent(urlPath, version string) bool {
+	name, found := strings.CutPrefix(urlPath, m.prefix+"/")
+	if !found {
+		return false
+	}
+	hash, ok := m.hashes[name]
+	return ok && version == hash
+}
@@ -0,0 +1,103 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name below dir, creating its directories
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// load hashes dir served under /static
+func load(t *testing.T, dir string) *Manifest {
+	t.Helper()
+
+	m, err := Load(dir, "/static")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return m
+}
+
+func TestHashChangesWithContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "js/app.js", "console.log('v1')")
+	writeFile(t, dir, "css/app.css", "body { margin: 0 }")
+
+	before := load(t, dir)
+	if before.Len() != 2 {
+		t.Fatalf("hashed %d files, want 2", before.Len())
+	}
+
+	// Rewriting the same content keeps the URL, so caches stay valid
+	writeFile(t, dir, "js/app.js", "console.log('v1')")
+	if same := load(t, dir); same.URL("js/app.js") != before.URL("js/app.js") {
+		t.Errorf("URL changed without a content change: %s, was %s", same.URL("js/app.js"), before.URL("js/app.js"))
+	}
+
+	writeFile(t, dir, "js/app.js", "console.log('v2')")
+	after := load(t, dir)
+	if after.URL("js/app.js") == before.URL("js/app.js") {
+		t.Errorf("URL %s unchanged after a content change", after.URL("js/app.js"))
+	}
+	if after.URL("css/app.css") != before.URL("css/app.css") {
+		t.Errorf("URL of an unchanged file changed: %s, was %s", after.URL("css/app.css"), before.URL("css/app.css"))
+	}
+	if Hash([]byte("console.log('v2')")) == Hash([]byte("console.log('v1')")) {
+		t.Error("different contents hash the same")
+	}
+}
+
+func TestURL(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "js/app.js", "console.log('v1')")
+	m := load(t, dir)
+
+	want := "/static/js/app.js?v=" + Hash([]byte("console.log('v1')"))
+	if got := m.URL("js/app.js"); got != want {
+		t.Errorf("URL(js/app.js) = %q, want %q", got, want)
+	}
+	if hash := strings.TrimPrefix(want, "/static/js/app.js?v="); len(hash) != hashLength {
+		t.Errorf("hash %q has %d digits, want %d", hash, len(hash), hashLength)
+	}
+	if got := m.URL("js/missing.js"); got != "/static/js/missing.js" {
+		t.Errorf("URL of a file added after startup = %q, want the plain URL", got)
+	}
+}
+
+func TestIsCurrent(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "js/app.js", "console.log('v1')")
+	m := load(t, dir)
+	hash := Hash([]byte("console.log('v1')"))
+
+	tests := []struct {
+		name    string
+		urlPath string
+		version string
+		want    bool
+	}{
+		{"current version", "/static/js/app.js", hash, true},
+		{"stale version", "/static/js/app.js", Hash([]byte("console.log('v0')")), false},
+		{"no version", "/static/js/app.js", "", false},
+		{"unknown file", "/static/js/other.js", hash, false},
+		{"outside the prefix", "/js/app.js", hash, false},
+	}
+	for _, tt := range tests {
+		if got := m.IsCurrent(tt.urlPath, tt.version); got != tt.want {
+			t.Errorf("%s: IsCurrent(%s, %s) = %v, want %v", tt.name, tt.urlPath, tt.version, got, tt.want)
+		}
+	}
+}
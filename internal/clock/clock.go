@@ -0,0 +1,232 @@
+// Package clock abstracts the time source of schedules and time windows, so
+// workers, circuit breakers, jobs and statistics can be driven by a Manual
+// clock instead of waiting for real time to pass
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates tickers and timers
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	NewTimer(d time.Duration) Timer
+	Sleep(d time.Duration)
+}
+
+// Ticker delivers the time on C every period, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer delivers the time on C once, like time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+var (
+	current Clock = Real{}
+	mu      sync.RWMutex
+)
+
+// Default returns the clock of the application, the real clock unless Set
+// replaced it
+func Default() Clock {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Set replaces the clock of the application. Tickers, timers and circuit
+// breakers created before keep the previous clock.
+func Set(c Clock) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = c
+}
+
+// Real is the system clock
+type Real struct{}
+
+// Now returns the current time
+func (Real) Now() time.Time { return time.Now() }
+
+// NewTicker returns a ticker firing every d
+func (Real) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+// NewTimer returns a timer firing after d
+func (Real) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+// Sleep pauses for d
+func (Real) Sleep(d time.Duration) { time.Sleep(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
+
+// Manual is a clock that only moves when told to. Tickers, timers and
+// sleeps fire in deadline order as Advance passes their deadline. Like the
+// time package, a ticker drops ticks its receiver is not ready for.
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*manualWaiter
+}
+
+// manualWaiter is a pending ticker, timer or sleep of a Manual clock
+type manualWaiter struct {
+	clock  *Manual
+	when   time.Time
+	period time.Duration // Ticker period, 0 for timers
+	c      chan time.Time
+	active bool
+}
+
+// NewManual returns a manual clock set to now
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+// Now returns the time of the clock
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Advance moves the clock forward by d, firing every ticker and timer due
+// on the way with the time of its deadline
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target := m.now.Add(d)
+	for {
+		due := m.nextDueLocked(target)
+		if due == nil {
+			break
+		}
+		m.now = due.when
+		select {
+		case due.c <- due.when:
+		default:
+		}
+		if due.period > 0 {
+			due.when = due.when.Add(due.period)
+		} else {
+			due.active = false
+		}
+	}
+	m.now = target
+}
+
+// Set moves the clock to t, firing the tickers and timers due until then
+func (m *Manual) Set(t time.Time) {
+	m.Advance(t.Sub(m.Now()))
+}
+
+// nextDueLocked returns the active waiter with the earliest deadline not
+// after target, nil if none, m.mu must be held
+func (m *Manual) nextDueLocked(target time.Time) *manualWaiter {
+	active := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.active {
+			active = append(active, w)
+		}
+	}
+	m.waiters = active
+	sort.SliceStable(m.waiters, func(i, j int) bool {
+		return m.waiters[i].when.Before(m.waiters[j].when)
+	})
+	if len(m.waiters) == 0 || m.waiters[0].when.After(target) {
+		return nil
+	}
+	return m.waiters[0]
+}
+
+// addWaiter registers a waiter firing d from now
+func (m *Manual) addWaiter(d, period time.Duration) *manualWaiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := &manualWaiter{clock: m, when: m.now.Add(d), period: period, c: make(chan time.Time, 1), active: true}
+	m.waiters = append(m.waiters, w)
+	return w
+}
+
+// NewTicker returns a ticker firing every d of clock time
+func (m *Manual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return manualTicker{m.addWaiter(d, d)}
+}
+
+// NewTimer returns a timer firing after d of clock time
+func (m *Manual) NewTimer(d time.Duration) Timer {
+	return manualTimer{m.addWaiter(d, 0)}
+}
+
+// Sleep blocks until the clock has been advanced by d
+func (m *Manual) Sleep(d time.Duration) {
+	<-m.NewTimer(d).C()
+}
+
+// Waiters returns the number of pending tickers, timers and sleeps, to
+// advance only once a goroutine is waiting on the clock
+func (m *Manual) Waiters() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, w := range m.waiters {
+		if w.active {
+			count++
+		}
+	}
+	return count
+}
+
+// stop deactivates the waiter and reports whether it was active
+func (w *manualWaiter) stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	wasActive := w.active
+	w.active = false
+	return wasActive
+}
+
+type manualTicker struct{ w *manualWaiter }
+
+func (t manualTicker) C() <-chan time.Time { return t.w.c }
+func (t manualTicker) Stop()               { t.w.stop() }
+
+type manualTimer struct{ w *manualWaiter }
+
+func (t manualTimer) C() <-chan time.Time { return t.w.c }
+func (t manualTimer) Stop() bool          { return t.w.stop() }
+
+// Reset rearms the timer to fire d from the current clock time
+func (t manualTimer) Reset(d time.Duration) bool {
+	m := t.w.clock
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasActive := t.w.active
+	t.w.when = m.now.Add(d)
+	if !wasActive {
+		t.w.active = true
+		m.waiters = append(m.waiters, t.w)
+	}
+	return wasActive
+}
@@ -17,7 +17,7 @@ var (
 			Name: "ping_checks_total",
 			Help: "Total number of ping checks performed",
 		},
-		[]string{"site_id", "line_type", "success"},
+		[]string{"site_id", "line_type", "success", "source_ip"},
 	)
 
 	PingLatencyHistogram = prometheus.NewHistogramVec(
@@ -29,6 +29,14 @@ var (
 		[]string{"site_id", "line_type"},
 	)
 
+	PingLatencyCurrentGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_latency_current_ms",
+			Help: "Latency of the last successful checks of site lines in milliseconds (mean over metrics.current_latency_window)",
+		},
+		[]string{"site_id", "line_type"},
+	)
+
 	SiteStatusGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "site_status",
@@ -57,7 +65,15 @@ var (
 	PacketLossGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "ping_packet_loss_percentage",
-			Help: "Packet loss percentage for site lines",
+			Help: "Packet loss percentage for site lines (last check or rolling mean, see metrics.packet_loss_mode)",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	PacketLossLastCheckGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_packet_loss_last_check_percentage",
+			Help: "Packet loss percentage of the last check of site lines",
 		},
 		[]string{"site_id", "line_type"},
 	)
@@ -95,7 +111,217 @@ var (
 		[]string{"site_id", "line_type"},
 	)
 	
+	PingChecksWaitingGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ping_checks_waiting",
+			Help: "Number of ping checks waiting for a concurrency slot",
+		},
+	)
+	
+	// Result pipeline self-monitoring
+	ResultChanLengthGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "result_channel_length",
+			Help: "Number of ping results waiting in the result channel",
+		},
+	)
+	
+	ResultChanCapacityGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "result_channel_capacity",
+			Help: "Capacity of the ping result channel",
+		},
+	)
+	
+	ResultChanUtilizationGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "result_channel_utilization_ratio",
+			Help: "Fill level of the ping result channel (length / capacity)",
+		},
+	)
+	
+	ResultsDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "results_dropped_total",
+			Help: "Total number of ping results dropped because the result channel was full",
+		},
+	)
+	
+	ResultsDiscardedShutdownTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "results_discarded_shutdown_total",
+			Help: "Total number of ping results discarded because SiteWatch was shutting down",
+		},
+	)
+	
+	ResultProcessorRestartsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "result_processor_restarts_total",
+			Help: "Total number of replacement result processors started by the watchdog",
+		},
+	)
+	
+	WorkerHeartbeatAgeGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_worker_heartbeat_age_seconds",
+			Help: "Seconds since the ping worker of a site last ticked",
+		},
+		[]string{"site_id"},
+	)
+	
+	PingTTLGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_reply_ttl",
+			Help: "TTL of the last ping reply for site lines",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	PingTTLChangesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ping_ttl_changes_total",
+			Help: "Total number of reply TTL shifts beyond the threshold (possible reroutes)",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	PingPacketsCorruptedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ping_packets_corrupted_total",
+			Help: "Total number of echo replies whose payload size differs from the request (ping.verify_reply_size)",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	PingConfigErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ping_config_errors_total",
+			Help: "Total number of checks not probed because the address does not parse or resolve",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	// Site health metrics
+	SiteHealthScoreGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_health_score",
+			Help: "Weighted 0-100 composite of uptime, latency, packet loss and jitter over the last 24h",
+		},
+		[]string{"site_id"},
+	)
+	
+	SiteUptime24hGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_uptime_24h_percentage",
+			Help: "Uptime percentage of site lines over the last 24h",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	StaleSitesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "stale_sites",
+			Help: "Number of enabled sites without a check for several of their intervals",
+		},
+	)
+	
+	// Latency baseline metrics
+	LatencyBaselineMeanGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_latency_baseline_mean_ms",
+			Help: "Mean latency of site lines over the latency baseline window in milliseconds",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	LatencyBaselineStdDevGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_latency_baseline_stddev_ms",
+			Help: "Standard deviation of the latency of site lines over the latency baseline window in milliseconds",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	LatencyDeviatingGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ping_latency_deviating",
+			Help: "Whether the latency of site lines currently deviates from their baseline (1=deviating, 0=normal)",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	// Notification metrics
+	OpsGenieAlertsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "opsgenie_alerts_total",
+			Help: "Total number of alert requests accepted by OpsGenie",
+		},
+		[]string{"action"},
+	)
+	
+	AMQPPublishesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "amqp_publishes_total",
+			Help: "Total number of alert events published to the AMQP broker by status",
+		},
+		[]string{"status"},
+	)
+	
+	AMQPReconnectsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "amqp_reconnects_total",
+			Help: "Total number of re-established AMQP broker connections",
+		},
+	)
+	
+	KafkaMessagesProducedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_messages_produced_total",
+			Help: "Total number of alert events produced to Kafka by status",
+		},
+		[]string{"status"},
+	)
+	
+	KafkaProduceErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "kafka_produce_errors_total",
+			Help: "Total number of failed Kafka produce requests",
+		},
+	)
+	
+	WebhookDLQSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "webhook_dlq_size",
+			Help: "Number of undelivered webhook events in the dead letter queue",
+		},
+	)
+	
+	// Authentication metrics
+	OIDCLoginsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "oidc_logins_total",
+			Help: "Total number of completed OIDC login attempts",
+		},
+		[]string{"success"},
+	)
+	
 	// Application performance metrics
+	APISLAViolationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_sla_violations_total",
+			Help: "Total number of API responses slower than the response time SLA of their route",
+		},
+		[]string{"path"},
+	)
+	
+	LegacyAPIRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_legacy_requests_total",
+			Help: "Total number of API requests through the deprecated unversioned /api prefix",
+		},
+		[]string{"path"},
+	)
+	
 	HTTPRequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
@@ -153,6 +379,112 @@ var (
 		},
 		[]string{"site_id", "line_type", "to_state"},
 	)
+	
+	CircuitBreakerTripsPerHourGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_trips_per_hour",
+			Help: "Automatic circuit breaker trips within the last hour (needs ping.circuit_breaker_trip_retention)",
+		},
+		[]string{"site_id", "line_type"},
+	)
+	
+	CircuitBreakersGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breakers",
+			Help: "Number of circuit breakers per state (closed, half-open, open)",
+		},
+		[]string{"state"},
+	)
+	
+	// Storage health metrics
+	StorageWriteFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "storage_write_failures_total",
+			Help: "Total number of failed storage writes",
+		},
+	)
+	
+	StorageDegradedGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "storage_degraded",
+			Help: "Storage degraded state (1=writes buffered in memory, 0=healthy)",
+		},
+	)
+	
+	StorageBufferedLogsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "storage_buffered_logs",
+			Help: "Number of logs buffered in memory waiting to be flushed to storage",
+		},
+	)
+	
+	StorageSizeBytesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "storage_size_bytes",
+			Help: "Size of the SQLite database including its WAL and shared memory files",
+		},
+	)
+	
+	StorageOperationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "storage_operation_duration_seconds",
+			Help:    "Storage operation latency in seconds",
+			Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"op"},
+	)
+	
+	StorageOperationErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_operation_errors_total",
+			Help: "Total number of failed storage operations",
+		},
+		[]string{"op"},
+	)
+	
+	// Configuration file metrics
+	SitesYAMLWriteTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sites_yaml_write_total",
+			Help: "Total number of attempts to write the sites file by success",
+		},
+		[]string{"success"},
+	)
+	
+	// Maintenance window import metrics
+	MaintenanceWindowsImportedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "maintenance_windows_imported_total",
+			Help: "Total number of maintenance windows imported from iCal feeds",
+		},
+	)
+	
+	// Report scheduler metrics
+	ReportsGeneratedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "reports_generated_total",
+			Help: "Total number of scheduled report runs (status success, partial or error)",
+		},
+		[]string{"schedule", "status"},
+	)
+	
+	// Background job metrics
+	JobRunsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "job_runs_total",
+			Help: "Total number of background job runs by result (success or error)",
+		},
+		[]string{"name", "result"},
+	)
+	
+	JobDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "job_duration_seconds",
+			Help:    "Duration of background job runs in seconds",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"name"},
+	)
 )
 
 // AppState represents the global application state - exported for use by other packages
@@ -165,8 +497,19 @@ type AppState struct {
 	StartTime   time.Time
 	TotalChecks int64 // Use atomic operations for this field
 	ResultChan  chan models.PingResult
+	Runtime     *models.RuntimeSummary // Effective runtime captured after startup
+	NextChecks  map[string]time.Time   // site_id -> next scheduled check, protected by Mu
+	LatencyBaselines map[string]models.LatencyBaseline // site_id/line_type -> baseline, protected by Mu
+	FailurePatterns  map[string][]models.FailurePattern // site_id -> recurring failure patterns, protected by Mu
+	MaintenanceWindows []models.MaintenanceWindow      // Imported maintenance windows, protected by Mu
+	SiteMonitoring   map[string]models.SiteMonitoring  // site_id -> monitoring dates, protected by Mu
+	Prober           models.Prober                      // Sends the echo requests of all pings, ICMP unless set
 }
 
+// Version is the application version, set at build time via
+// -ldflags "-X sitewatch/internal/config.Version=..."
+var Version = "1.0.0"
+
 // Global application state instance
 var GlobalAppState *AppState
 
@@ -174,20 +517,55 @@ func init() {
 	// Register Prometheus metrics
 	prometheus.MustRegister(PingChecksTotal)
 	prometheus.MustRegister(PingLatencyHistogram)
+	prometheus.MustRegister(PingLatencyCurrentGauge)
 	prometheus.MustRegister(SiteStatusGauge)
 	prometheus.MustRegister(SiteBothOnlineGauge)
 	prometheus.MustRegister(SiteInfoGauge)
 	
 	// Register extended ping metrics
 	prometheus.MustRegister(PacketLossGauge)
+	prometheus.MustRegister(PacketLossLastCheckGauge)
 	prometheus.MustRegister(JitterHistogram)
 	prometheus.MustRegister(PacketsSentCounter)
 	prometheus.MustRegister(PacketsReceivedCounter)
 	prometheus.MustRegister(PacketsDuplicatesCounter)
+	prometheus.MustRegister(PingChecksWaitingGauge)
+	prometheus.MustRegister(PingTTLGauge)
+	prometheus.MustRegister(ResultChanLengthGauge)
+	prometheus.MustRegister(ResultChanCapacityGauge)
+	prometheus.MustRegister(ResultChanUtilizationGauge)
+	prometheus.MustRegister(ResultsDroppedTotal)
+	prometheus.MustRegister(ResultsDiscardedShutdownTotal)
+	prometheus.MustRegister(ResultProcessorRestartsTotal)
+	prometheus.MustRegister(WorkerHeartbeatAgeGauge)
+	prometheus.MustRegister(PingTTLChangesTotal)
+	prometheus.MustRegister(PingConfigErrorsTotal)
+	prometheus.MustRegister(PingPacketsCorruptedTotal)
+	
+	// Register site health metrics
+	prometheus.MustRegister(SiteHealthScoreGauge)
+	prometheus.MustRegister(SiteUptime24hGauge)
+	prometheus.MustRegister(StaleSitesGauge)
+	
+	// Register latency baseline metrics
+	prometheus.MustRegister(LatencyBaselineMeanGauge)
+	prometheus.MustRegister(LatencyBaselineStdDevGauge)
+	prometheus.MustRegister(LatencyDeviatingGauge)
+	
+	// Register notification metrics
+	prometheus.MustRegister(OpsGenieAlertsTotal)
+	prometheus.MustRegister(AMQPPublishesTotal)
+	prometheus.MustRegister(AMQPReconnectsTotal)
+	prometheus.MustRegister(KafkaMessagesProducedTotal)
+	prometheus.MustRegister(KafkaProduceErrorsTotal)
+	prometheus.MustRegister(WebhookDLQSize)
+	prometheus.MustRegister(OIDCLoginsTotal)
 	
 	// Register application performance metrics
 	prometheus.MustRegister(HTTPRequestsTotal)
 	prometheus.MustRegister(HTTPRequestDuration)
+	prometheus.MustRegister(APISLAViolationsTotal)
+	prometheus.MustRegister(LegacyAPIRequestsTotal)
 	prometheus.MustRegister(ActiveConnectionsGauge)
 	prometheus.MustRegister(MemoryUsageGauge)
 	prometheus.MustRegister(GoroutinesGauge)
@@ -195,21 +573,87 @@ func init() {
 	// Register circuit breaker metrics
 	prometheus.MustRegister(CircuitBreakerStateGauge)
 	prometheus.MustRegister(CircuitBreakerTripsTotal)
+	prometheus.MustRegister(CircuitBreakersGauge)
+	prometheus.MustRegister(CircuitBreakerTripsPerHourGauge)
+	
+	// Register storage health metrics
+	prometheus.MustRegister(StorageWriteFailuresTotal)
+	prometheus.MustRegister(StorageDegradedGauge)
+	prometheus.MustRegister(StorageBufferedLogsGauge)
+	prometheus.MustRegister(StorageSizeBytesGauge)
+	prometheus.MustRegister(StorageOperationDuration)
+	prometheus.MustRegister(StorageOperationErrorsTotal)
+	
+	// Register configuration file metrics
+	prometheus.MustRegister(SitesYAMLWriteTotal)
+	
+	// Register maintenance window metrics
+	prometheus.MustRegister(MaintenanceWindowsImportedTotal)
+	
+	// Register report scheduler metrics
+	prometheus.MustRegister(ReportsGeneratedTotal)
+	
+	// Register background job metrics
+	prometheus.MustRegister(JobRunsTotal)
+	prometheus.MustRegister(JobDurationSeconds)
 }
 
 // InitStorage initializes the storage backend
 func (app *AppState) InitStorage() error {
-	storage, err := storage.CreateStorage(app.Config)
+	backend, err := storage.CreateStorage(app.Config)
 	if err != nil {
 		return err
 	}
-	app.Storage = storage
+	app.Storage = backend
 	
 	log := logger.Default().WithComponent("config")
+	
+	// Wire storage health into metrics
+	if fallback, ok := backend.(*storage.FallbackStorage); ok {
+		fallback.SetOnWriteFailure(func(err error) {
+			StorageWriteFailuresTotal.Inc()
+		})
+		fallback.SetOnDegradedChange(func(degraded bool) {
+			if degraded {
+				StorageDegradedGauge.Set(1)
+			} else {
+				StorageDegradedGauge.Set(0)
+			}
+		})
+	}
+	
+	// Wire storage operation instrumentation into metrics
+	if fallback, ok := backend.(*storage.FallbackStorage); ok {
+		if instrumented, ok := fallback.Backend().(*storage.InstrumentedStorage); ok {
+			instrumented.SetObserver(func(op string, duration time.Duration, err error) {
+				StorageOperationDuration.WithLabelValues(op).Observe(duration.Seconds())
+				if err != nil {
+					StorageOperationErrorsTotal.WithLabelValues(op).Inc()
+				}
+			})
+		}
+	}
+	
 	log.Info("Storage initialized", "type", app.Config.Storage.Type)
 	return nil
 }
 
+// IsStorageDegraded reports whether storage writes are currently being buffered in memory
+func (app *AppState) IsStorageDegraded() bool {
+	if degradable, ok := app.Storage.(interface{ IsDegraded() bool }); ok {
+		return degradable.IsDegraded()
+	}
+	return false
+}
+
+// StorageBufferedCount returns the number of logs buffered in memory while storage is degraded
+func (app *AppState) StorageBufferedCount() int {
+	if buffered, ok := app.Storage.(interface{ BufferedCount() int }); ok {
+		return buffered.BufferedCount()
+	}
+	return 0
+}
+
 // InitializeSiteStatus initializes status tracking for all sites
 func (app *AppState) InitializeSiteStatus() {
 	app.Mu.Lock()
@@ -231,7 +675,12 @@ func (app *AppState) InitializeSiteStatus() {
 		// Initialize Prometheus metrics
 		SiteInfoGauge.WithLabelValues(site.ID, site.Name, site.Location).Set(1)
 		SiteStatusGauge.WithLabelValues(site.ID, "primary").Set(0)
-		SiteStatusGauge.WithLabelValues(site.ID, "secondary").Set(0)
+		if site.IsDualLine() {
+			SiteStatusGauge.WithLabelValues(site.ID, "secondary").Set(0)
+		} else {
+			// A single-line site has no secondary series, 0 would read as an outage
+			SiteStatusGauge.DeleteLabelValues(site.ID, "secondary")
+		}
 		SiteBothOnlineGauge.WithLabelValues(site.ID).Set(0)
 	}
 }
@@ -38,6 +38,65 @@ func LoadEnvOverrides(cfg *models.Config) {
 			log.Info("Environment override applied", "setting", "Server.WriteTimeout", "value", d.String())
 		}
 	}
+	if v := os.Getenv("SITEWATCH_SERVER_LOCALE"); v != "" {
+		cfg.Server.Locale = v
+		log.Info("Environment override applied", "setting", "Server.Locale", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_ALLOW_SIMULATION"); v != "" {
+		cfg.Server.AllowSimulation = parseBool(v)
+		log.Info("Environment override applied", "setting", "Server.AllowSimulation", "value", cfg.Server.AllowSimulation)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_RESPONSE_SLA_MS"); v != "" {
+		var limits map[string]int
+		if err := json.Unmarshal([]byte(v), &limits); err == nil {
+			cfg.Server.ResponseSLAMs = limits
+			log.Info("Environment override applied", "setting", "Server.ResponseSLAMs", "value", limits)
+		} else {
+			log.Warn("Invalid SITEWATCH_SERVER_RESPONSE_SLA_MS, expected JSON object", "error", err)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_LEGACY_API_SUNSET"); v != "" {
+		cfg.Server.LegacyAPISunset = v
+		log.Info("Environment override applied", "setting", "Server.LegacyAPISunset", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_SECURITY_CONTENT_SECURITY_POLICY"); v != "" {
+		cfg.Server.Security.ContentSecurityPolicy = v
+		log.Info("Environment override applied", "setting", "Server.Security.ContentSecurityPolicy", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_SECURITY_FRAME_OPTIONS"); v != "" {
+		cfg.Server.Security.FrameOptions = v
+		log.Info("Environment override applied", "setting", "Server.Security.FrameOptions", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_ENABLED"); v != "" {
+		cfg.Server.TLS.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "Server.TLS.Enabled", "value", cfg.Server.TLS.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_CERT_FILE"); v != "" {
+		cfg.Server.TLS.CertFile = v
+		log.Info("Environment override applied", "setting", "Server.TLS.CertFile", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_KEY_FILE"); v != "" {
+		cfg.Server.TLS.KeyFile = v
+		log.Info("Environment override applied", "setting", "Server.TLS.KeyFile", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_ACME_DOMAINS"); v != "" {
+		cfg.Server.TLS.ACME.Domains = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Server.TLS.ACME.Domains", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_ACME_EMAIL"); v != "" {
+		cfg.Server.TLS.ACME.Email = v
+		log.Info("Environment override applied", "setting", "Server.TLS.ACME.Email", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_ACME_CACHE_DIR"); v != "" {
+		cfg.Server.TLS.ACME.CacheDir = v
+		log.Info("Environment override applied", "setting", "Server.TLS.ACME.CacheDir", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_SERVER_TLS_HTTP_REDIRECT_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			cfg.Server.TLS.HTTPRedirectPort = port
+			log.Info("Environment override applied", "setting", "Server.TLS.HTTPRedirectPort", "value", port)
+		}
+	}
 
 	// Ping configuration
 	if v := os.Getenv("SITEWATCH_PING_DEFAULT_INTERVAL"); v != "" {
@@ -52,6 +111,24 @@ func LoadEnvOverrides(cfg *models.Config) {
 			log.Info("Environment override applied", "setting", "Ping.Timeout", "value", d.String())
 		}
 	}
+	if v := os.Getenv("SITEWATCH_PING_TEST_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Ping.TestTimeout = d
+			log.Info("Environment override applied", "setting", "Ping.TestTimeout", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_TEST_ALL_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Ping.TestAllConcurrency = n
+			log.Info("Environment override applied", "setting", "Ping.TestAllConcurrency", "value", n)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_TEST_ALL_COOLDOWN"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Ping.TestAllCooldown = d
+			log.Info("Environment override applied", "setting", "Ping.TestAllCooldown", "value", d.String())
+		}
+	}
 	if v := os.Getenv("SITEWATCH_PING_PACKET_SIZE"); v != "" {
 		if size, err := strconv.Atoi(v); err == nil {
 			cfg.Ping.PacketSize = size
@@ -64,6 +141,79 @@ func LoadEnvOverrides(cfg *models.Config) {
 			log.Info("Environment override applied", "setting", "Ping.PacketCount", "value", count)
 		}
 	}
+	if v := os.Getenv("SITEWATCH_PING_ENABLED"); v != "" {
+		enabled := parseBool(v)
+		cfg.Ping.Enabled = &enabled
+		log.Info("Environment override applied", "setting", "Ping.Enabled", "value", enabled)
+	}
+	if v := os.Getenv("SITEWATCH_ENABLE_NETWORK_NAMESPACES"); v != "" {
+		cfg.Ping.EnableNetworkNamespaces = parseBool(v)
+		log.Info("Environment override applied", "setting", "Ping.EnableNetworkNamespaces", "value", cfg.Ping.EnableNetworkNamespaces)
+	}
+	if v := os.Getenv("SITEWATCH_PING_MAX_CONCURRENT"); v != "" {
+		if maxConcurrent, err := strconv.Atoi(v); err == nil {
+			cfg.Ping.MaxConcurrent = maxConcurrent
+			log.Info("Environment override applied", "setting", "Ping.MaxConcurrent", "value", maxConcurrent)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_RESULT_SEND_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.Ping.ResultSendTimeout = d
+			log.Info("Environment override applied", "setting", "Ping.ResultSendTimeout", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_TTL_CHANGE_THRESHOLD"); v != "" {
+		if threshold, err := strconv.Atoi(v); err == nil {
+			cfg.Ping.TTLChangeThreshold = threshold
+			log.Info("Environment override applied", "setting", "Ping.TTLChangeThreshold", "value", threshold)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_CONFIRM_STATE_CHANGES"); v != "" {
+		cfg.Ping.ConfirmStateChanges = parseBool(v)
+		log.Info("Environment override applied", "setting", "Ping.ConfirmStateChanges", "value", cfg.Ping.ConfirmStateChanges)
+	}
+	if v := os.Getenv("SITEWATCH_PING_FAILURES_BEFORE_DOWN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Ping.FailuresBeforeDown = n
+			log.Info("Environment override applied", "setting", "Ping.FailuresBeforeDown", "value", n)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_SUCCESSES_BEFORE_UP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Ping.SuccessesBeforeUp = n
+			log.Info("Environment override applied", "setting", "Ping.SuccessesBeforeUp", "value", n)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_EXCLUDE_CIRCUIT_OPEN_CHECKS"); v != "" {
+		cfg.Ping.ExcludeCircuitOpenChecks = parseBool(v)
+		log.Info("Environment override applied", "setting", "Ping.ExcludeCircuitOpenChecks", "value", cfg.Ping.ExcludeCircuitOpenChecks)
+	}
+	if v := os.Getenv("SITEWATCH_PING_EXCLUDE_CONFIG_ERROR_CHECKS"); v != "" {
+		cfg.Ping.ExcludeConfigErrorChecks = parseBool(v)
+		log.Info("Environment override applied", "setting", "Ping.ExcludeConfigErrorChecks", "value", cfg.Ping.ExcludeConfigErrorChecks)
+	}
+	if v := os.Getenv("SITEWATCH_PING_VERIFY_REPLY_SIZE"); v != "" {
+		cfg.Ping.VerifyReplySize = parseBool(v)
+		log.Info("Environment override applied", "setting", "Ping.VerifyReplySize", "value", cfg.Ping.VerifyReplySize)
+	}
+	if v := os.Getenv("SITEWATCH_PING_CORRUPTED_DEGRADED_PERCENT"); v != "" {
+		if percent, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.Ping.CorruptedDegradedPercent = percent
+			log.Info("Environment override applied", "setting", "Ping.CorruptedDegradedPercent", "value", percent)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_SIZE_SWEEP_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			cfg.Ping.SizeSweepRetentionDays = days
+			log.Info("Environment override applied", "setting", "Ping.SizeSweepRetentionDays", "value", days)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_PING_CIRCUIT_BREAKER_TRIP_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Ping.CircuitBreakerTripRetention = d
+			log.Info("Environment override applied", "setting", "Ping.CircuitBreakerTripRetention", "value", d.String())
+		}
+	}
 
 	// Metrics configuration
 	if v := os.Getenv("SITEWATCH_METRICS_ENABLED"); v != "" {
@@ -74,6 +224,36 @@ func LoadEnvOverrides(cfg *models.Config) {
 		cfg.Metrics.Path = v
 		log.Info("Environment override applied", "setting", "Metrics.Path", "value", v)
 	}
+	if v := os.Getenv("SITEWATCH_METRICS_PACKET_LOSS_MODE"); v != "" {
+		cfg.Metrics.PacketLossMode = strings.ToLower(v)
+		log.Info("Environment override applied", "setting", "Metrics.PacketLossMode", "value", cfg.Metrics.PacketLossMode)
+	}
+	if v := os.Getenv("SITEWATCH_METRICS_PACKET_LOSS_WINDOW"); v != "" {
+		if window, err := strconv.Atoi(v); err == nil && window > 0 {
+			cfg.Metrics.PacketLossWindow = window
+			log.Info("Environment override applied", "setting", "Metrics.PacketLossWindow", "value", window)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_METRICS_CURRENT_LATENCY"); v != "" {
+		cfg.Metrics.CurrentLatency = parseBool(v)
+		log.Info("Environment override applied", "setting", "Metrics.CurrentLatency", "value", cfg.Metrics.CurrentLatency)
+	}
+	if v := os.Getenv("SITEWATCH_METRICS_CURRENT_LATENCY_WINDOW"); v != "" {
+		if window, err := strconv.Atoi(v); err == nil && window > 0 {
+			cfg.Metrics.CurrentLatencyWindow = window
+			log.Info("Environment override applied", "setting", "Metrics.CurrentLatencyWindow", "value", window)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_METRICS_SITE_LABELS"); v != "" {
+		cfg.Metrics.SiteLabels = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Metrics.SiteLabels", "value", cfg.Metrics.SiteLabels)
+	}
+	if v := os.Getenv("SITEWATCH_METRICS_UPDATE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Metrics.UpdateInterval = d
+			log.Info("Environment override applied", "setting", "Metrics.UpdateInterval", "value", d.String())
+		}
+	}
 
 	// Storage configuration
 	if v := os.Getenv("SITEWATCH_STORAGE_TYPE"); v != "" {
@@ -84,8 +264,387 @@ func LoadEnvOverrides(cfg *models.Config) {
 		cfg.Storage.SQLitePath = v
 		log.Info("Environment override applied", "setting", "Storage.SQLitePath", "value", v)
 	}
+	if v := os.Getenv("SITEWATCH_STORAGE_FALLBACK_THRESHOLD"); v != "" {
+		if threshold, err := strconv.Atoi(v); err == nil {
+			cfg.Storage.FallbackThreshold = threshold
+			log.Info("Environment override applied", "setting", "Storage.FallbackThreshold", "value", threshold)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_STORAGE_FALLBACK_BUFFER_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			cfg.Storage.FallbackBufferSize = size
+			log.Info("Environment override applied", "setting", "Storage.FallbackBufferSize", "value", size)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_STORAGE_SLOW_QUERY_THRESHOLD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Storage.SlowQueryThreshold = d
+			log.Info("Environment override applied", "setting", "Storage.SlowQueryThreshold", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_STORAGE_OPTIMIZE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Storage.OptimizeInterval = d
+			log.Info("Environment override applied", "setting", "Storage.OptimizeInterval", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_STORAGE_VACUUM_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Storage.VacuumInterval = d
+			log.Info("Environment override applied", "setting", "Storage.VacuumInterval", "value", d.String())
+		}
+	}
 	// MaxMemoryLogs removed - only SQLite storage is used now
 
+	// Monitoring host metrics
+	if v := os.Getenv("SITEWATCH_SELF_METRICS_ENABLED"); v != "" {
+		cfg.SelfMetrics.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "SelfMetrics.Enabled", "value", cfg.SelfMetrics.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_SELF_METRICS_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.SelfMetrics.Interval = d
+			log.Info("Environment override applied", "setting", "SelfMetrics.Interval", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_SELF_METRICS_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			cfg.SelfMetrics.RetentionDays = days
+			log.Info("Environment override applied", "setting", "SelfMetrics.RetentionDays", "value", days)
+		}
+	}
+
+	// Fleet availability history
+	if v := os.Getenv("SITEWATCH_FLEET_HISTORY_ENABLED"); v != "" {
+		cfg.FleetHistory.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "FleetHistory.Enabled", "value", cfg.FleetHistory.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_FLEET_HISTORY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.FleetHistory.Interval = d
+			log.Info("Environment override applied", "setting", "FleetHistory.Interval", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_FLEET_HISTORY_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			cfg.FleetHistory.RetentionDays = days
+			log.Info("Environment override applied", "setting", "FleetHistory.RetentionDays", "value", days)
+		}
+	}
+
+	// Export configuration
+	if v := os.Getenv("SITEWATCH_EXPORT_ENABLED"); v != "" {
+		cfg.Export.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "Export.Enabled", "value", cfg.Export.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_EXPORT_DIRECTORY"); v != "" {
+		cfg.Export.Directory = v
+		log.Info("Environment override applied", "setting", "Export.Directory", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_EXPORT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Export.Interval = d
+			log.Info("Environment override applied", "setting", "Export.Interval", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_EXPORT_FORMAT"); v != "" {
+		cfg.Export.Format = strings.ToLower(v)
+		log.Info("Environment override applied", "setting", "Export.Format", "value", cfg.Export.Format)
+	}
+	if v := os.Getenv("SITEWATCH_EXPORT_RETENTION"); v != "" {
+		if retention, err := strconv.Atoi(v); err == nil {
+			cfg.Export.Retention = retention
+			log.Info("Environment override applied", "setting", "Export.Retention", "value", retention)
+		}
+	}
+
+	// Logging configuration
+	if v := os.Getenv("SITEWATCH_LOG_COMPONENT_LEVELS"); v != "" {
+		var levels map[string]string
+		if err := json.Unmarshal([]byte(v), &levels); err == nil {
+			cfg.Log.ComponentLevels = levels
+			log.Info("Environment override applied", "setting", "Log.ComponentLevels", "value", levels)
+		} else {
+			log.Warn("Invalid SITEWATCH_LOG_COMPONENT_LEVELS, expected JSON object", "error", err)
+		}
+	}
+
+	// Watchdog configuration
+	if v := os.Getenv("SITEWATCH_WATCHDOG_STALL_MULTIPLIER"); v != "" {
+		if multiplier, err := strconv.Atoi(v); err == nil {
+			cfg.Watchdog.StallMultiplier = multiplier
+			log.Info("Environment override applied", "setting", "Watchdog.StallMultiplier", "value", multiplier)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_WATCHDOG_RESTART_PROCESSOR"); v != "" {
+		cfg.Watchdog.RestartProcessor = parseBool(v)
+		log.Info("Environment override applied", "setting", "Watchdog.RestartProcessor", "value", cfg.Watchdog.RestartProcessor)
+	}
+	if v := os.Getenv("SITEWATCH_VALIDATION_STRICT"); v != "" {
+		cfg.Validation.Strict = parseBool(v)
+		log.Info("Environment override applied", "setting", "Validation.Strict", "value", cfg.Validation.Strict)
+	}
+
+	// Health score configuration
+	healthWeights := []struct {
+		env     string
+		setting string
+		weight  *float64
+	}{
+		{"SITEWATCH_HEALTH_SCORE_WEIGHT_UPTIME", "HealthScore.Weights.Uptime", &cfg.HealthScore.Weights.Uptime},
+		{"SITEWATCH_HEALTH_SCORE_WEIGHT_LATENCY", "HealthScore.Weights.Latency", &cfg.HealthScore.Weights.Latency},
+		{"SITEWATCH_HEALTH_SCORE_WEIGHT_PACKET_LOSS", "HealthScore.Weights.PacketLoss", &cfg.HealthScore.Weights.PacketLoss},
+		{"SITEWATCH_HEALTH_SCORE_WEIGHT_JITTER", "HealthScore.Weights.Jitter", &cfg.HealthScore.Weights.Jitter},
+	}
+	for _, hw := range healthWeights {
+		if v := os.Getenv(hw.env); v != "" {
+			if weight, err := strconv.ParseFloat(v, 64); err == nil {
+				*hw.weight = weight
+				log.Info("Environment override applied", "setting", hw.setting, "value", weight)
+			}
+		}
+	}
+
+	// Latency baseline configuration
+	if v := os.Getenv("SITEWATCH_LATENCY_BASELINE_ENABLED"); v != "" {
+		cfg.LatencyBaseline.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "LatencyBaseline.Enabled", "value", cfg.LatencyBaseline.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_LATENCY_BASELINE_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.LatencyBaseline.Window = d
+			log.Info("Environment override applied", "setting", "LatencyBaseline.Window", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_LATENCY_BASELINE_DEVIATION"); v != "" {
+		if deviation, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.LatencyBaseline.Deviation = deviation
+			log.Info("Environment override applied", "setting", "LatencyBaseline.Deviation", "value", deviation)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_LATENCY_BASELINE_MIN_INCREASE_MS"); v != "" {
+		if increase, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.LatencyBaseline.MinIncreaseMs = increase
+			log.Info("Environment override applied", "setting", "LatencyBaseline.MinIncreaseMs", "value", increase)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_LATENCY_BASELINE_MIN_SAMPLES"); v != "" {
+		if samples, err := strconv.Atoi(v); err == nil {
+			cfg.LatencyBaseline.MinSamples = samples
+			log.Info("Environment override applied", "setting", "LatencyBaseline.MinSamples", "value", samples)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_LATENCY_BASELINE_CONSECUTIVE"); v != "" {
+		if consecutive, err := strconv.Atoi(v); err == nil {
+			cfg.LatencyBaseline.Consecutive = consecutive
+			log.Info("Environment override applied", "setting", "LatencyBaseline.Consecutive", "value", consecutive)
+		}
+	}
+
+	// Failure pattern detection
+	if v := os.Getenv("SITEWATCH_FAILURE_PATTERNS_ENABLED"); v != "" {
+		cfg.FailurePatterns.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "FailurePatterns.Enabled", "value", cfg.FailurePatterns.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_FAILURE_PATTERNS_MIN_OCCURRENCES"); v != "" {
+		if occurrences, err := strconv.Atoi(v); err == nil {
+			cfg.FailurePatterns.MinOccurrences = occurrences
+			log.Info("Environment override applied", "setting", "FailurePatterns.MinOccurrences", "value", occurrences)
+		}
+	}
+
+	// Maintenance window import
+	if v := os.Getenv("SITEWATCH_MAINTENANCE_ICAL_FEEDS"); v != "" {
+		cfg.Maintenance.ICalFeeds = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Maintenance.ICalFeeds", "value", len(cfg.Maintenance.ICalFeeds))
+	}
+	if v := os.Getenv("SITEWATCH_MAINTENANCE_CALENDAR_TAG"); v != "" {
+		cfg.Maintenance.CalendarTag = v
+		log.Info("Environment override applied", "setting", "Maintenance.CalendarTag", "value", v)
+	}
+
+	// Subnet discovery
+	if v := os.Getenv("SITEWATCH_DISCOVERY_SUBNET_SCAN_ENABLED"); v != "" {
+		cfg.Discovery.SubnetScan.Enabled = parseBool(v)
+		log.Info("Environment override applied", "setting", "Discovery.SubnetScan.Enabled", "value", cfg.Discovery.SubnetScan.Enabled)
+	}
+	if v := os.Getenv("SITEWATCH_DISCOVERY_SUBNET_SCAN_SUBNETS"); v != "" {
+		cfg.Discovery.SubnetScan.Subnets = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Discovery.SubnetScan.Subnets", "value", cfg.Discovery.SubnetScan.Subnets)
+	}
+	if v := os.Getenv("SITEWATCH_DISCOVERY_SUBNET_SCAN_INTERVAL_HOURS"); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours > 0 {
+			cfg.Discovery.SubnetScan.IntervalHours = hours
+			log.Info("Environment override applied", "setting", "Discovery.SubnetScan.IntervalHours", "value", hours)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_DISCOVERY_SUBNET_SCAN_MAX_CONCURRENT"); v != "" {
+		if concurrent, err := strconv.Atoi(v); err == nil && concurrent > 0 {
+			cfg.Discovery.SubnetScan.MaxConcurrent = concurrent
+			log.Info("Environment override applied", "setting", "Discovery.SubnetScan.MaxConcurrent", "value", concurrent)
+		}
+	}
+
+	// Notification configuration
+	if v := os.Getenv("SITEWATCH_NOTIFY_OPSGENIE_API_KEY"); v != "" {
+		cfg.Notify.OpsGenie.APIKey = v
+		log.Info("Environment override applied", "setting", "Notify.OpsGenie.APIKey", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_OPSGENIE_API_URL"); v != "" {
+		cfg.Notify.OpsGenie.APIURL = v
+		log.Info("Environment override applied", "setting", "Notify.OpsGenie.APIURL", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_OPSGENIE_TEAM"); v != "" {
+		cfg.Notify.OpsGenie.Team = v
+		log.Info("Environment override applied", "setting", "Notify.OpsGenie.Team", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_OPSGENIE_PRIORITY"); v != "" {
+		cfg.Notify.OpsGenie.Priority = strings.ToUpper(v)
+		log.Info("Environment override applied", "setting", "Notify.OpsGenie.Priority", "value", cfg.Notify.OpsGenie.Priority)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_OPSGENIE_RESPONDERS"); v != "" {
+		cfg.Notify.OpsGenie.Responders = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Notify.OpsGenie.Responders", "value", cfg.Notify.OpsGenie.Responders)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_AMQP_URL"); v != "" {
+		cfg.Notify.AMQP.URL = v
+		log.Info("Environment override applied", "setting", "Notify.AMQP.URL", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_AMQP_EXCHANGE"); v != "" {
+		cfg.Notify.AMQP.Exchange = v
+		log.Info("Environment override applied", "setting", "Notify.AMQP.Exchange", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_AMQP_ROUTING_KEY_TEMPLATE"); v != "" {
+		cfg.Notify.AMQP.RoutingKeyTemplate = v
+		log.Info("Environment override applied", "setting", "Notify.AMQP.RoutingKeyTemplate", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_AMQP_DURABLE_EXCHANGE"); v != "" {
+		cfg.Notify.AMQP.DurableExchange = parseBool(v)
+		log.Info("Environment override applied", "setting", "Notify.AMQP.DurableExchange", "value", cfg.Notify.AMQP.DurableExchange)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_KAFKA_BROKERS"); v != "" {
+		cfg.Notify.Kafka.Brokers = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Notify.Kafka.Brokers", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_KAFKA_TOPIC"); v != "" {
+		cfg.Notify.Kafka.Topic = v
+		log.Info("Environment override applied", "setting", "Notify.Kafka.Topic", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_KAFKA_COMPRESSION"); v != "" {
+		cfg.Notify.Kafka.Compression = v
+		log.Info("Environment override applied", "setting", "Notify.Kafka.Compression", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_KAFKA_SASL_USERNAME"); v != "" {
+		cfg.Notify.Kafka.SASLUsername = v
+		log.Info("Environment override applied", "setting", "Notify.Kafka.SASLUsername", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_KAFKA_SASL_PASSWORD"); v != "" {
+		cfg.Notify.Kafka.SASLPassword = v
+		log.Info("Environment override applied", "setting", "Notify.Kafka.SASLPassword", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_WEBHOOK_URL"); v != "" {
+		cfg.Notify.Webhook.URL = v
+		log.Info("Environment override applied", "setting", "Notify.Webhook.URL", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		if attempts, err := strconv.Atoi(v); err == nil {
+			cfg.Notify.Webhook.MaxAttempts = attempts
+			log.Info("Environment override applied", "setting", "Notify.Webhook.MaxAttempts", "value", attempts)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_WEBHOOK_ATTACH_CHART"); v != "" {
+		cfg.Notify.Webhook.AttachChart = parseBool(v)
+		log.Info("Environment override applied", "setting", "Notify.Webhook.AttachChart", "value", cfg.Notify.Webhook.AttachChart)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_DLQ_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil {
+			cfg.Notify.DLQRetentionDays = days
+			log.Info("Environment override applied", "setting", "Notify.DLQRetentionDays", "value", days)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_EMAIL_SMTP_HOST"); v != "" {
+		cfg.Notify.Email.SMTPHost = v
+		log.Info("Environment override applied", "setting", "Notify.Email.SMTPHost", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_EMAIL_SMTP_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			cfg.Notify.Email.SMTPPort = port
+			log.Info("Environment override applied", "setting", "Notify.Email.SMTPPort", "value", port)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_EMAIL_USERNAME"); v != "" {
+		cfg.Notify.Email.Username = v
+		log.Info("Environment override applied", "setting", "Notify.Email.Username", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_EMAIL_PASSWORD"); v != "" {
+		cfg.Notify.Email.Password = v
+		log.Info("Environment override applied", "setting", "Notify.Email.Password", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_EMAIL_FROM"); v != "" {
+		cfg.Notify.Email.From = v
+		log.Info("Environment override applied", "setting", "Notify.Email.From", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_TEMPLATES_OUTAGE_SUBJECT"); v != "" {
+		cfg.Notify.Templates.OutageSubject = v
+		log.Info("Environment override applied", "setting", "Notify.Templates.OutageSubject", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_TEMPLATES_OUTAGE_BODY"); v != "" {
+		cfg.Notify.Templates.OutageBody = v
+		log.Info("Environment override applied", "setting", "Notify.Templates.OutageBody", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_TEMPLATES_RECOVERY_SUBJECT"); v != "" {
+		cfg.Notify.Templates.RecoverySubject = v
+		log.Info("Environment override applied", "setting", "Notify.Templates.RecoverySubject", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_NOTIFY_TEMPLATES_RECOVERY_BODY"); v != "" {
+		cfg.Notify.Templates.RecoveryBody = v
+		log.Info("Environment override applied", "setting", "Notify.Templates.RecoveryBody", "value", v)
+	}
+
+	// Report configuration
+	if v := os.Getenv("SITEWATCH_REPORTS_SIGNING_KEY"); v != "" {
+		cfg.Reports.SigningKey = v
+		log.Info("Environment override applied", "setting", "Reports.SigningKey", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_REPORTS_WKHTMLTOPDF_PATH"); v != "" {
+		cfg.Reports.WkhtmltopdfPath = v
+		log.Info("Environment override applied", "setting", "Reports.WkhtmltopdfPath", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_REPORTS_PDF_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Reports.PDF.Timeout = d
+			log.Info("Environment override applied", "setting", "Reports.PDF.Timeout", "value", d.String())
+		}
+	}
+	if v := os.Getenv("SITEWATCH_REPORTS_TIMEZONE"); v != "" {
+		cfg.Reports.Timezone = v
+		log.Info("Environment override applied", "setting", "Reports.Timezone", "value", v)
+	}
+
+	// Display configuration
+	if v := os.Getenv("SITEWATCH_DISPLAY_DECIMAL_SEPARATOR"); v != "" {
+		cfg.Display.DecimalSeparator = v
+		log.Info("Environment override applied", "setting", "Display.DecimalSeparator", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_DISPLAY_MICROSECOND_LATENCY"); v != "" {
+		cfg.Display.MicrosecondLatency = parseBool(v)
+		log.Info("Environment override applied", "setting", "Display.MicrosecondLatency", "value", cfg.Display.MicrosecondLatency)
+	}
+	if v := os.Getenv("SITEWATCH_DISPLAY_MAX_CHART_POINTS"); v != "" {
+		if points, err := strconv.Atoi(v); err == nil && points > 0 {
+			cfg.Display.MaxChartPoints = points
+			log.Info("Environment override applied", "setting", "Display.MaxChartPoints", "value", points)
+		}
+	}
+	if v := os.Getenv("SITEWATCH_DISPLAY_CHART_GAPS"); v != "" {
+		cfg.Display.ChartGaps = strings.ToLower(v)
+		log.Info("Environment override applied", "setting", "Display.ChartGaps", "value", cfg.Display.ChartGaps)
+	}
+	if v := os.Getenv("SITEWATCH_DISPLAY_COMBINED_LATENCY"); v != "" {
+		cfg.Display.CombinedLatency = strings.ToLower(v)
+		log.Info("Environment override applied", "setting", "Display.CombinedLatency", "value", cfg.Display.CombinedLatency)
+	}
+
 	// Authentication configuration
 	if v := os.Getenv("SITEWATCH_AUTH_ENABLED"); v != "" {
 		cfg.Auth.Enabled = parseBool(v)
@@ -107,6 +666,36 @@ func LoadEnvOverrides(cfg *models.Config) {
 			log.Info("Environment override applied", "setting", "Auth.UI.ExpiresHours", "value", hours)
 		}
 	}
+	
+	// OIDC single sign-on configuration
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_PROVIDER_URL"); v != "" {
+		cfg.Auth.OIDC.ProviderURL = v
+		log.Info("Environment override applied", "setting", "Auth.OIDC.ProviderURL", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_CLIENT_ID"); v != "" {
+		cfg.Auth.OIDC.ClientID = v
+		log.Info("Environment override applied", "setting", "Auth.OIDC.ClientID", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_CLIENT_SECRET"); v != "" {
+		cfg.Auth.OIDC.ClientSecret = v
+		log.Info("Environment override applied", "setting", "Auth.OIDC.ClientSecret", "value", "[REDACTED]")
+	}
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_REDIRECT_URL"); v != "" {
+		cfg.Auth.OIDC.RedirectURL = v
+		log.Info("Environment override applied", "setting", "Auth.OIDC.RedirectURL", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_SCOPES"); v != "" {
+		cfg.Auth.OIDC.Scopes = strings.Fields(strings.ReplaceAll(v, ",", " "))
+		log.Info("Environment override applied", "setting", "Auth.OIDC.Scopes", "value", cfg.Auth.OIDC.Scopes)
+	}
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_GROUPS_CLAIM"); v != "" {
+		cfg.Auth.OIDC.GroupsClaim = v
+		log.Info("Environment override applied", "setting", "Auth.OIDC.GroupsClaim", "value", v)
+	}
+	if v := os.Getenv("SITEWATCH_AUTH_OIDC_ADMIN_GROUPS"); v != "" {
+		cfg.Auth.OIDC.AdminGroups = strings.Split(v, ",")
+		log.Info("Environment override applied", "setting", "Auth.OIDC.AdminGroups", "value", cfg.Auth.OIDC.AdminGroups)
+	}
 
 	// API Tokens from environment
 	// Format 1: JSON array
@@ -147,14 +736,22 @@ func LoadEnvOverrides(cfg *models.Config) {
 			}
 		}
 		
+		var allowedIPs []string
+		if ips := os.Getenv("SITEWATCH_AUTH_API_TOKEN_ALLOWED_IPS"); ips != "" {
+			for _, ip := range strings.Split(ips, ",") {
+				allowedIPs = append(allowedIPs, strings.TrimSpace(ip))
+			}
+		}
+		
 		cfg.Auth.API.Tokens = []models.APIToken{
 			{
 				Token:       v,
 				Name:        "ENV Token",
 				Permissions: permissions,
+				AllowedIPs:  allowedIPs,
 			},
 		}
-		log.Info("Environment override applied", "setting", "Auth.API.Token", "permissions", permissions)
+		log.Info("Environment override applied", "setting", "Auth.API.Token", "permissions", permissions, "allowed_ips", allowedIPs)
 	}
 }
 
@@ -1,15 +1,51 @@
 package config
 
 import (
+	"bytes"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config/persist"
+	"sitewatch/internal/format"
+	"sitewatch/internal/i18n"
 	"sitewatch/internal/logger"
 	"sitewatch/internal/models"
 )
 
+// ErrSiteExists is returned by AddSite for a site ID that is already configured
+var ErrSiteExists = errors.New("site already exists")
+
+// ErrAddressInUse is returned by AddSite in strict validation mode for a site
+// probing an address another site or line already probes
+var ErrAddressInUse = errors.New("address already monitored")
+
+// maxScanHostBits bounds a discovery subnet to a /16 (IPv4) or /112 (IPv6)
+const maxScanHostBits = 16
+
+// DefaultContentSecurityPolicy allows the dashboard's own scripts and inline
+// handlers, the Tailwind and Chart.js CDNs and the inline styles Tailwind injects
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' https://cdn.tailwindcss.com https://cdn.jsdelivr.net; " +
+	"style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; " +
+	"object-src 'none'; base-uri 'self'; form-action 'self'"
+
+// metricLabelPattern matches valid Prometheus label names
+var metricLabelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedSiteLabels are set by SiteWatch itself and cannot come from site metadata
+var reservedSiteLabels = map[string]bool{"site_id": true, "line_type": true, "name": true, "location": true, "provider": true}
+
 // LoadConfig loads configuration from config.yaml
 func (app *AppState) LoadConfig() error {
 	// Get config path from environment or use default
@@ -31,6 +67,18 @@ func (app *AppState) LoadConfig() error {
 	if app.Config.Server.Port == 0 {
 		app.Config.Server.Port = 8080
 	}
+	if app.Config.Server.Locale == "" {
+		app.Config.Server.Locale = i18n.FallbackLocale
+	}
+	if app.Config.Server.TLS.ACME.CacheDir == "" {
+		app.Config.Server.TLS.ACME.CacheDir = "data/acme"
+	}
+	if app.Config.Server.Security.ContentSecurityPolicy == "" {
+		app.Config.Server.Security.ContentSecurityPolicy = DefaultContentSecurityPolicy
+	}
+	if app.Config.Server.Security.FrameOptions == "" {
+		app.Config.Server.Security.FrameOptions = "DENY"
+	}
 	if app.Config.Ping.DefaultInterval == 0 {
 		app.Config.Ping.DefaultInterval = 30 * time.Second
 	}
@@ -40,9 +88,122 @@ func (app *AppState) LoadConfig() error {
 	if app.Config.Ping.PacketCount <= 0 {
 		app.Config.Ping.PacketCount = 3 // Default to 3 packets for better statistics
 	}
+	if app.Config.Ping.TestTimeout <= 0 {
+		app.Config.Ping.TestTimeout = 10 * time.Second
+	}
+	if app.Config.Ping.TestAllConcurrency <= 0 {
+		app.Config.Ping.TestAllConcurrency = 4
+	}
+	if app.Config.Ping.TestAllCooldown <= 0 {
+		app.Config.Ping.TestAllCooldown = time.Minute
+	}
+	if app.Config.Ping.SizeSweepRetentionDays <= 0 {
+		app.Config.Ping.SizeSweepRetentionDays = 30
+	}
+	if app.Config.Watchdog.StallMultiplier <= 0 {
+		app.Config.Watchdog.StallMultiplier = 3
+	}
+	if app.Config.Ping.TTLChangeThreshold <= 0 {
+		app.Config.Ping.TTLChangeThreshold = 2
+	}
+	if w := app.Config.HealthScore.Weights; w.Uptime == 0 && w.Latency == 0 && w.PacketLoss == 0 && w.Jitter == 0 {
+		app.Config.HealthScore.Weights = models.HealthScoreWeights{Uptime: 0.5, Latency: 0.2, PacketLoss: 0.2, Jitter: 0.1}
+	}
+	if app.Config.LatencyBaseline.Window <= 0 {
+		app.Config.LatencyBaseline.Window = 24 * time.Hour
+	}
+	if app.Config.LatencyBaseline.Deviation <= 0 {
+		app.Config.LatencyBaseline.Deviation = 3
+	}
+	if app.Config.LatencyBaseline.MinIncreaseMs == 0 {
+		app.Config.LatencyBaseline.MinIncreaseMs = 5
+	}
+	if app.Config.LatencyBaseline.MinSamples <= 0 {
+		app.Config.LatencyBaseline.MinSamples = 100
+	}
+	if app.Config.LatencyBaseline.Consecutive <= 0 {
+		app.Config.LatencyBaseline.Consecutive = 3
+	}
+	if app.Config.FailurePatterns.MinOccurrences == 0 {
+		app.Config.FailurePatterns.MinOccurrences = 3
+	}
+	if app.Config.Maintenance.CalendarTag == "" {
+		app.Config.Maintenance.CalendarTag = "sitewatch"
+	}
+	if app.Config.Discovery.SubnetScan.IntervalHours <= 0 {
+		app.Config.Discovery.SubnetScan.IntervalHours = 24
+	}
+	if app.Config.Discovery.SubnetScan.MaxConcurrent <= 0 {
+		app.Config.Discovery.SubnetScan.MaxConcurrent = 50
+	}
+	if app.Config.Notify.OpsGenie.APIURL == "" {
+		app.Config.Notify.OpsGenie.APIURL = "https://api.opsgenie.com"
+	}
+	if app.Config.Notify.OpsGenie.Priority == "" {
+		app.Config.Notify.OpsGenie.Priority = "P3"
+	}
+	if app.Config.Notify.AMQP.Exchange == "" {
+		app.Config.Notify.AMQP.Exchange = "sitewatch"
+	}
+	if app.Config.Notify.AMQP.RoutingKeyTemplate == "" {
+		app.Config.Notify.AMQP.RoutingKeyTemplate = "sitewatch.{event_type}.{site_id}"
+	}
+	if app.Config.Notify.Kafka.Topic == "" {
+		app.Config.Notify.Kafka.Topic = "sitewatch-events"
+	}
+	if app.Config.Notify.Kafka.Compression == "" {
+		app.Config.Notify.Kafka.Compression = "none"
+	}
+	if app.Config.Notify.Webhook.MaxAttempts <= 0 {
+		app.Config.Notify.Webhook.MaxAttempts = 3
+	}
+	if app.Config.Notify.DLQRetentionDays <= 0 {
+		app.Config.Notify.DLQRetentionDays = 7
+	}
+	if app.Config.Ping.FailuresBeforeDown <= 0 {
+		app.Config.Ping.FailuresBeforeDown = 1
+	}
+	if app.Config.Ping.SuccessesBeforeUp <= 0 {
+		app.Config.Ping.SuccessesBeforeUp = 1
+	}
+	if app.Config.Reports.PDF.Timeout <= 0 {
+		app.Config.Reports.PDF.Timeout = 2 * time.Minute
+	}
 	if app.Config.Metrics.Path == "" {
 		app.Config.Metrics.Path = "/metrics"
 	}
+	if app.Config.Metrics.PacketLossMode == "" {
+		app.Config.Metrics.PacketLossMode = "instant"
+	}
+	if app.Config.Metrics.PacketLossWindow <= 0 {
+		app.Config.Metrics.PacketLossWindow = 10
+	}
+	if app.Config.Metrics.CurrentLatencyWindow <= 0 {
+		app.Config.Metrics.CurrentLatencyWindow = 1
+	}
+	if app.Config.Metrics.UpdateInterval == 0 {
+		app.Config.Metrics.UpdateInterval = 30 * time.Second
+	}
+	if app.Config.Display.MaxChartPoints <= 0 {
+		app.Config.Display.MaxChartPoints = 100 // stats.MaxChartDataPoints
+	}
+	if app.Config.Reports.WkhtmltopdfPath == "" {
+		app.Config.Reports.WkhtmltopdfPath = "wkhtmltopdf"
+	}
+	for i := range app.Config.Reports.Schedules {
+		if app.Config.Reports.Schedules[i].Attachment == "" {
+			app.Config.Reports.Schedules[i].Attachment = "html"
+		}
+	}
+	if app.Config.Notify.Email.SMTPPort == 0 {
+		app.Config.Notify.Email.SMTPPort = 587
+	}
+	if app.Config.Display.ChartGaps == "" {
+		app.Config.Display.ChartGaps = "drop" // stats.ChartGapsDrop
+	}
+	if app.Config.Display.CombinedLatency == "" {
+		app.Config.Display.CombinedLatency = "min" // stats.CombinedLatencyMin
+	}
 	
 	// Storage defaults
 	if app.Config.Storage.Type == "" {
@@ -51,8 +212,43 @@ func (app *AppState) LoadConfig() error {
 	if app.Config.Storage.SQLitePath == "" {
 		app.Config.Storage.SQLitePath = "data/ping_monitor.db"
 	}
+	if app.Config.Storage.FallbackThreshold <= 0 {
+		app.Config.Storage.FallbackThreshold = 5
+	}
+	if app.Config.Storage.FallbackBufferSize <= 0 {
+		app.Config.Storage.FallbackBufferSize = 1000
+	}
+	if app.Config.Storage.SlowQueryThreshold == 0 {
+		app.Config.Storage.SlowQueryThreshold = 500 * time.Millisecond
+	}
 	// MaxMemoryLogs removed - only SQLite storage is used now
 	
+	// Export defaults
+	if app.Config.Export.Directory == "" {
+		app.Config.Export.Directory = "data/exports"
+	}
+	if app.Config.Export.Interval == 0 {
+		app.Config.Export.Interval = 24 * time.Hour
+	}
+	if app.Config.Export.Format == "" {
+		app.Config.Export.Format = "csv"
+	}
+	if app.Config.Export.Retention == 0 {
+		app.Config.Export.Retention = 30
+	}
+	if app.Config.SelfMetrics.Interval <= 0 {
+		app.Config.SelfMetrics.Interval = time.Minute
+	}
+	if app.Config.SelfMetrics.RetentionDays <= 0 {
+		app.Config.SelfMetrics.RetentionDays = 30
+	}
+	if app.Config.FleetHistory.Interval <= 0 {
+		app.Config.FleetHistory.Interval = 5 * time.Minute
+	}
+	if app.Config.FleetHistory.RetentionDays <= 0 {
+		app.Config.FleetHistory.RetentionDays = 90
+	}
+	
 	// Auth defaults
 	if app.Config.Auth.UI.SessionName == "" {
 		app.Config.Auth.UI.SessionName = "sitewatch_session"
@@ -60,9 +256,151 @@ func (app *AppState) LoadConfig() error {
 	if app.Config.Auth.UI.ExpiresHours == 0 {
 		app.Config.Auth.UI.ExpiresHours = 24
 	}
+	if len(app.Config.Auth.OIDC.Scopes) == 0 {
+		app.Config.Auth.OIDC.Scopes = []string{"openid", "email", "profile"}
+	}
+	if app.Config.Auth.OIDC.GroupsClaim == "" {
+		app.Config.Auth.OIDC.GroupsClaim = "groups"
+	}
 	
 	// Apply environment variable overrides
 	LoadEnvOverrides(&app.Config)
+	
+	if err := validateServerTLS(&app.Config); err != nil {
+		return err
+	}
+	for route, limit := range app.Config.Server.ResponseSLAMs {
+		if limit <= 0 {
+			return fmt.Errorf("invalid server.response_sla_ms for %s: %d (expected > 0)", route, limit)
+		}
+	}
+	app.Config.Server.Security.FrameOptions = strings.ToUpper(app.Config.Server.Security.FrameOptions)
+	switch app.Config.Server.Security.FrameOptions {
+	case "DENY", "SAMEORIGIN", "ALLOW":
+	default:
+		return fmt.Errorf("invalid server.security.frame_options %q (expected DENY, SAMEORIGIN or ALLOW)", app.Config.Server.Security.FrameOptions)
+	}
+	if sunset := app.Config.Server.LegacyAPISunset; sunset != "" {
+		if _, err := time.Parse(time.DateOnly, sunset); err != nil {
+			return fmt.Errorf("invalid server.legacy_api_sunset %q (expected YYYY-MM-DD)", sunset)
+		}
+	}
+	if app.Config.Export.Format != "csv" && app.Config.Export.Format != "ndjson" {
+		return fmt.Errorf("invalid export format %q (expected csv or ndjson)", app.Config.Export.Format)
+	}
+	if mode := app.Config.Metrics.PacketLossMode; mode != "instant" && mode != "windowed" {
+		return fmt.Errorf("invalid metrics packet_loss_mode %q (expected instant or windowed)", mode)
+	}
+	for i, label := range app.Config.Metrics.SiteLabels {
+		label = strings.TrimSpace(label)
+		if !metricLabelPattern.MatchString(label) || reservedSiteLabels[label] {
+			return fmt.Errorf("invalid metrics site_labels entry %q (expected a Prometheus label name not used by SiteWatch itself)", label)
+		}
+		app.Config.Metrics.SiteLabels[i] = label
+	}
+	if app.Config.Storage.OptimizeInterval < 0 || app.Config.Storage.VacuumInterval < 0 {
+		return fmt.Errorf("invalid storage optimize_interval %s or vacuum_interval %s (must not be negative)", app.Config.Storage.OptimizeInterval, app.Config.Storage.VacuumInterval)
+	}
+	if app.Config.Ping.CircuitBreakerTripRetention < 0 {
+		return fmt.Errorf("invalid ping circuit_breaker_trip_retention %s (must not be negative)", app.Config.Ping.CircuitBreakerTripRetention)
+	}
+	if percent := app.Config.Ping.CorruptedDegradedPercent; percent < 0 || percent >= 100 {
+		return fmt.Errorf("invalid ping corrupted_degraded_percent %g (expected 0 to below 100)", percent)
+	}
+	if app.Config.Metrics.UpdateInterval <= 0 {
+		return fmt.Errorf("invalid metrics update_interval %s (must be positive)", app.Config.Metrics.UpdateInterval)
+	}
+	if w := app.Config.HealthScore.Weights; w.Uptime < 0 || w.Latency < 0 || w.PacketLoss < 0 || w.Jitter < 0 {
+		return fmt.Errorf("invalid health_score weights %+v (must not be negative)", w)
+	}
+	if b := app.Config.LatencyBaseline; b.Window <= 0 || b.Deviation <= 0 || b.MinIncreaseMs < 0 || b.MinSamples < 2 || b.Consecutive <= 0 {
+		return fmt.Errorf("invalid latency_baseline settings (window, deviation and consecutive must be positive, min_increase_ms not negative, min_samples at least 2)")
+	}
+	if app.Config.FailurePatterns.MinOccurrences < 2 {
+		return fmt.Errorf("invalid failure_patterns min_occurrences %d (must be at least 2)", app.Config.FailurePatterns.MinOccurrences)
+	}
+	for i, feed := range app.Config.Maintenance.ICalFeeds {
+		feed = strings.TrimSpace(feed)
+		if u, err := url.Parse(feed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid maintenance ical_feeds entry %q (expected an http or https URL)", feed)
+		}
+		app.Config.Maintenance.ICalFeeds[i] = feed
+	}
+	if app.Config.Discovery.SubnetScan.Enabled && len(app.Config.Discovery.SubnetScan.Subnets) == 0 {
+		return fmt.Errorf("discovery subnet_scan is enabled without subnets")
+	}
+	for i, subnet := range app.Config.Discovery.SubnetScan.Subnets {
+		subnet = strings.TrimSpace(subnet)
+		prefix, err := netip.ParsePrefix(subnet)
+		if err != nil || prefix.Addr().BitLen()-prefix.Bits() > maxScanHostBits {
+			return fmt.Errorf("invalid discovery subnet_scan subnets entry %q (expected a CIDR of at most %d addresses)", subnet, 1<<maxScanHostBits)
+		}
+		app.Config.Discovery.SubnetScan.Subnets[i] = subnet
+	}
+	if _, err := app.Config.ReportsLocation(); err != nil {
+		return fmt.Errorf("invalid reports timezone %q: %w", app.Config.Reports.Timezone, err)
+	}
+	scheduleNames := make(map[string]bool)
+	for i, schedule := range app.Config.Reports.Schedules {
+		if err := schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid reports schedules[%d]: %w", i, err)
+		}
+		if scheduleNames[schedule.Name] {
+			return fmt.Errorf("invalid reports schedules[%d]: duplicate name %q", i, schedule.Name)
+		}
+		scheduleNames[schedule.Name] = true
+		if len(schedule.Email) > 0 && (app.Config.Notify.Email.SMTPHost == "" || app.Config.Notify.Email.From == "") {
+			return fmt.Errorf("invalid reports schedules[%d]: email delivery needs notify.email.smtp_host and from", i)
+		}
+	}
+	switch app.Config.Notify.OpsGenie.Priority {
+	case "P1", "P2", "P3", "P4", "P5":
+	default:
+		return fmt.Errorf("invalid notify.opsgenie.priority %q (expected P1-P5)", app.Config.Notify.OpsGenie.Priority)
+	}
+	switch app.Config.Notify.Kafka.Compression {
+	case "none", "gzip", "snappy", "lz4":
+	default:
+		return fmt.Errorf("invalid notify.kafka.compression %q (expected none, gzip, snappy or lz4)", app.Config.Notify.Kafka.Compression)
+	}
+	if (app.Config.Notify.Kafka.SASLUsername == "") != (app.Config.Notify.Kafka.SASLPassword == "") {
+		return fmt.Errorf("notify.kafka.sasl_username and sasl_password must be set together")
+	}
+	
+	for i := range app.Config.Auth.API.Tokens {
+		if err := app.Config.Auth.API.Tokens[i].Validate(); err != nil {
+			return fmt.Errorf("invalid auth.api.tokens: %w", err)
+		}
+	}
+	
+	// Apply component-level log verbosity
+	logger.SetComponentLevels(app.Config.Log.ComponentLevels)
+	
+	// Ingest-only mode needs a way for external probes to submit results
+	if !app.Config.IsPingEnabled() {
+		log := logger.Default().WithComponent("config")
+		log.Info("Active probing disabled, running in ingest-only mode")
+		if app.Config.Auth.Enabled && !hasTokenWithPermission(app.Config.Auth.API.Tokens, models.PermissionIngest) {
+			log.Warn("Ping is disabled but no API token has the ingest permission - no results can be submitted")
+		}
+	}
+	
+	// Apply display formatting preferences
+	if sep := app.Config.Display.DecimalSeparator; sep != "" && sep != "." && sep != "," {
+		return fmt.Errorf("invalid display decimal_separator %q (expected \".\" or \",\")", sep)
+	}
+	format.Configure(app.Config.Display.DecimalSeparator, app.Config.Display.MicrosecondLatency)
+	if gaps := app.Config.Display.ChartGaps; gaps != "drop" && gaps != "null" {
+		return fmt.Errorf("invalid display chart_gaps %q (expected \"drop\" or \"null\")", gaps)
+	}
+	if mode := app.Config.Display.CombinedLatency; mode != "min" && mode != "mean" && mode != "best_available" {
+		return fmt.Errorf("invalid display combined_latency %q (expected \"min\", \"mean\" or \"best_available\")", mode)
+	}
+	
+	// Load display translations for the UI and event messages
+	if err := i18n.Load(i18n.DefaultDir, app.Config.Server.Locale); err != nil {
+		return fmt.Errorf("loading translations: %w", err)
+	}
 
 	return nil
 }
@@ -81,18 +419,321 @@ func (app *AppState) LoadSites() error {
 	if err := yaml.Unmarshal(data, &sitesConfig); err != nil {
 		return fmt.Errorf("parsing sites config: %w", err)
 	}
+	
+	siteIDs := make(map[string]bool, len(sitesConfig.Sites))
+	for i, site := range sitesConfig.Sites {
+		if siteIDs[site.ID] {
+			return fmt.Errorf("sites[%d]: duplicate site id %q", i, site.ID)
+		}
+		siteIDs[site.ID] = true
+	}
+	for _, site := range sitesConfig.Sites {
+		for i, window := range site.ExpectedOffline {
+			if err := window.Validate(); err != nil {
+				return fmt.Errorf("site %s expected_offline[%d]: %w", site.ID, i, err)
+			}
+		}
+		for i, window := range site.IntervalSchedule {
+			if err := window.Validate(); err != nil {
+				return fmt.Errorf("site %s interval_schedule[%d]: %w", site.ID, i, err)
+			}
+		}
+		if err := validateSources(site); err != nil {
+			return fmt.Errorf("site %s %w", site.ID, err)
+		}
+		for i, size := range site.SizeSweep {
+			if size <= 0 || size > maxPingPayloadSize {
+				return fmt.Errorf("site %s size_sweep[%d]: invalid payload size %d (expected 1 to %d bytes)", site.ID, i, size, maxPingPayloadSize)
+			}
+		}
+		for _, dependency := range site.DependsOn {
+			if !siteIDs[dependency] {
+				return fmt.Errorf("site %s depends_on: unknown site %q", site.ID, dependency)
+			}
+		}
+	}
+	
+	log := logger.Default().WithComponent("config")
+	if conflicts := addressConflicts(sitesConfig.Sites); len(conflicts) > 0 {
+		if app.Config.Validation.Strict {
+			return fmt.Errorf("sites sharing addresses: %s", strings.Join(conflicts, "; "))
+		}
+		for _, conflict := range conflicts {
+			log.Warn("Address probed by several sites or lines", "conflict", conflict)
+		}
+	}
 
 	// Thread-safe assignment
 	app.Mu.Lock()
 	app.Sites = sitesConfig.Sites
 	app.Mu.Unlock()
 	
-	log := logger.Default().WithComponent("config")
 	log.Info("Sites loaded", "count", len(sitesConfig.Sites), "path", sitesPath)
 
 	return nil
 }
 
+// SaveSitesAtomic writes sites to path through persist.WriteFile, so readers
+// never see a partial file, concurrent writers are serialized and the
+// previous versions are kept as path.bak, path.bak.1, ...
+func SaveSitesAtomic(sites []models.Site, path string) (err error) {
+	log := logger.Default().WithComponent("config")
+	defer func() {
+		SitesYAMLWriteTotal.WithLabelValues(fmt.Sprintf("%t", err == nil)).Inc()
+	}()
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(models.SitesConfig{Sites: sites}); err != nil {
+		return fmt.Errorf("encoding sites: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("encoding sites: %w", err)
+	}
+
+	if err := persist.WriteFile(path, buf.Bytes(), persist.Options{Backups: persist.DefaultBackups}); err != nil {
+		return fmt.Errorf("writing sites file: %w", err)
+	}
+
+	log.Info("Sites file written", "count", len(sites), "path", path)
+	return nil
+}
+
+// AddSite adds a site at runtime and writes the sites file back to disk. The
+// site is only added once the file has been written. Addresses shared with
+// other sites, or by both lines of the site, are checked like in LoadSites.
+func (app *AppState) AddSite(site models.Site) error {
+	app.Mu.Lock()
+	defer app.Mu.Unlock()
+
+	for _, existing := range app.Sites {
+		if existing.ID == site.ID {
+			return fmt.Errorf("%w: %s", ErrSiteExists, site.ID)
+		}
+	}
+
+	sites := make([]models.Site, 0, len(app.Sites)+1)
+	sites = append(append(sites, app.Sites...), site)
+	if conflicts := siteAddressConflicts(sites, site.ID); len(conflicts) > 0 {
+		if app.Config.Validation.Strict {
+			return fmt.Errorf("%w: %s", ErrAddressInUse, strings.Join(conflicts, "; "))
+		}
+		log := logger.Default().WithComponent("config")
+		for _, conflict := range conflicts {
+			log.Warn("Address probed by several sites or lines", "conflict", conflict)
+		}
+	}
+	if err := SaveSitesAtomic(sites, GetSitesPath()); err != nil {
+		return err
+	}
+	app.Sites = sites
+
+	if app.SiteStatus == nil {
+		app.SiteStatus = make(map[string]*models.SiteStatus)
+	}
+	app.SiteStatus[site.ID] = &models.SiteStatus{SiteID: site.ID}
+	app.ensureFirstSeen(site.ID, clock.Default().Now())
+	return nil
+}
+
+// maxPingPayloadSize is the largest echo payload fitting an IPv4 packet
+const maxPingPayloadSize = 65507
+
+// validateSources checks the syntax of the source settings of a site, the
+// addresses and interfaces are checked against the host by the ping workers
+func validateSources(site models.Site) error {
+	levels := []struct {
+		prefix    string
+		ip, iface string
+	}{
+		{"", site.SourceIP, site.SourceInterface},
+		{"primary_", site.PrimarySourceIP, site.PrimarySourceInterface},
+		{"secondary_", site.SecondarySourceIP, site.SecondarySourceInterface},
+	}
+	for _, level := range levels {
+		if level.ip != "" && net.ParseIP(level.ip) == nil {
+			return fmt.Errorf("%ssource_ip: invalid IP address %q", level.prefix, level.ip)
+		}
+		if level.ip != "" && level.iface != "" {
+			return fmt.Errorf("%ssource_ip and %ssource_interface are mutually exclusive", level.prefix, level.prefix)
+		}
+	}
+	return nil
+}
+
+// addressConflicts lists the addresses probed by more than one site line,
+// e.g. "10.0.0.1 (site-a primary_ip, site-b secondary_ip)"
+func addressConflicts(sites []models.Site) []string {
+	return siteAddressConflicts(sites, "")
+}
+
+// siteAddressConflicts lists the address conflicts involving the site with
+// the given ID, all conflicts when siteID is empty
+func siteAddressConflicts(sites []models.Site, siteID string) []string {
+	uses := make(map[string][]string)
+	var addresses []string
+	add := func(site models.Site, field, address string) {
+		if address == "" {
+			return
+		}
+		key := strings.ToLower(address)
+		if ip := net.ParseIP(address); ip != nil {
+			key = ip.String() // Same address in different notations
+		}
+		if _, seen := uses[key]; !seen {
+			addresses = append(addresses, key)
+		}
+		uses[key] = append(uses[key], site.ID+" "+field)
+	}
+	for _, site := range sites {
+		add(site, "primary_ip", site.PrimaryIP)
+		add(site, "secondary_ip", site.SecondaryIP)
+	}
+
+	var conflicts []string
+	for _, address := range addresses {
+		if len(uses[address]) < 2 {
+			continue
+		}
+		involved := siteID == ""
+		for _, use := range uses[address] {
+			if strings.HasPrefix(use, siteID+" ") {
+				involved = true
+			}
+		}
+		if involved {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", address, strings.Join(uses[address], ", ")))
+		}
+	}
+	return conflicts
+}
+
+// LoadSiteMonitoring reads the monitoring dates from storage and records
+// the configured sites that are seen for the first time
+func (app *AppState) LoadSiteMonitoring() error {
+	monitoring, err := app.Storage.GetSiteMonitoring()
+	if err != nil {
+		return err
+	}
+
+	app.Mu.Lock()
+	defer app.Mu.Unlock()
+
+	app.SiteMonitoring = monitoring
+	now := time.Now()
+	for _, site := range app.Sites {
+		app.ensureFirstSeen(site.ID, now)
+	}
+	return nil
+}
+
+// ensureFirstSeen records the first seen date of a site unless it is known,
+// so a site that is removed or disabled and comes back keeps its original
+// date. The caller must hold Mu.
+func (app *AppState) ensureFirstSeen(siteID string, now time.Time) {
+	if _, exists := app.SiteMonitoring[siteID]; exists {
+		return
+	}
+	if app.SiteMonitoring == nil {
+		app.SiteMonitoring = make(map[string]models.SiteMonitoring)
+	}
+	app.SiteMonitoring[siteID] = models.SiteMonitoring{FirstSeen: now}
+
+	if err := app.Storage.EnsureSiteFirstSeen(siteID, now); err != nil {
+		logger.Default().WithComponent("config").Error("Failed to record site first seen", "site_id", siteID, "error", err)
+	}
+}
+
+// RecordFirstSuccess records t as the first successful check of a site
+// unless one is known. A failed write is retried with the next success.
+func (app *AppState) RecordFirstSuccess(siteID string, t time.Time) {
+	app.Mu.RLock()
+	entry, exists := app.SiteMonitoring[siteID]
+	app.Mu.RUnlock()
+	if exists && entry.FirstSuccess != nil {
+		return
+	}
+
+	if err := app.Storage.SetSiteFirstSuccess(siteID, t); err != nil {
+		logger.Default().WithComponent("config").Error("Failed to record site first success", "site_id", siteID, "error", err)
+		return
+	}
+
+	app.Mu.Lock()
+	defer app.Mu.Unlock()
+	if app.SiteMonitoring == nil {
+		app.SiteMonitoring = make(map[string]models.SiteMonitoring)
+	}
+	entry, exists = app.SiteMonitoring[siteID]
+	if !exists {
+		entry.FirstSeen = t
+	}
+	if entry.FirstSuccess == nil {
+		entry.FirstSuccess = &t
+	}
+	app.SiteMonitoring[siteID] = entry
+}
+
+// GetSiteMonitoring returns the monitoring dates of a site
+func (app *AppState) GetSiteMonitoring(siteID string) (models.SiteMonitoring, bool) {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+
+	entry, exists := app.SiteMonitoring[siteID]
+	return entry, exists
+}
+
+// SetLatencyBaselines replaces the latency baselines, keyed by site_id/line_type
+func (app *AppState) SetLatencyBaselines(baselines map[string]models.LatencyBaseline) {
+	app.Mu.Lock()
+	app.LatencyBaselines = baselines
+	app.Mu.Unlock()
+}
+
+// GetLatencyBaseline returns the latency baseline of a site line
+func (app *AppState) GetLatencyBaseline(siteID, lineType string) (models.LatencyBaseline, bool) {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+
+	baseline, exists := app.LatencyBaselines[siteID+"/"+lineType]
+	return baseline, exists
+}
+
+// SetFailurePatterns replaces the recurring failure patterns, keyed by site_id
+func (app *AppState) SetFailurePatterns(patterns map[string][]models.FailurePattern) {
+	app.Mu.Lock()
+	app.FailurePatterns = patterns
+	app.Mu.Unlock()
+}
+
+// GetFailurePatterns returns the recurring failure patterns of a site
+func (app *AppState) GetFailurePatterns(siteID string) []models.FailurePattern {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+	return app.FailurePatterns[siteID]
+}
+
+// SetMaintenanceWindows replaces the known maintenance windows
+func (app *AppState) SetMaintenanceWindows(windows []models.MaintenanceWindow) {
+	app.Mu.Lock()
+	app.MaintenanceWindows = windows
+	app.Mu.Unlock()
+}
+
+// InMaintenance reports whether a maintenance window of the site contains t
+func (app *AppState) InMaintenance(siteID string, t time.Time) bool {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+
+	for _, window := range app.MaintenanceWindows {
+		if window.SiteID == siteID && window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetSitesSnapshot returns a thread-safe snapshot of sites
 func (app *AppState) GetSitesSnapshot() []models.Site {
 	app.Mu.RLock()
@@ -120,6 +761,21 @@ func (app *AppState) GetSiteStatusSnapshot() map[string]*models.SiteStatus {
 	return statusMap
 }
 
+// StatusVersion returns a token that changes whenever a site is checked,
+// added or removed: the latest LastCheck of all sites and the site count
+func (app *AppState) StatusVersion() string {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+	
+	var latest time.Time
+	for _, status := range app.SiteStatus {
+		if status != nil && status.LastCheck.After(latest) {
+			latest = status.LastCheck
+		}
+	}
+	return strconv.FormatInt(latest.UnixMicro(), 36) + "-" + strconv.Itoa(len(app.Sites))
+}
+
 // FindSite returns a copy of a site by ID (thread-safe)
 func (app *AppState) FindSite(siteID string) (*models.Site, bool) {
 	app.Mu.RLock()
@@ -146,4 +802,58 @@ func (app *AppState) GetSiteStatus(siteID string) (*models.SiteStatus, bool) {
 	
 	statusCopy := *status // Copy the struct
 	return &statusCopy, true
-}
\ No newline at end of file
+}
+
+// hasTokenWithPermission reports whether any configured token grants permission
+func hasTokenWithPermission(tokens []models.APIToken, permission models.TokenPermission) bool {
+	for _, token := range tokens {
+		if token.HasPermission(permission) && !token.IsExpired() {
+			return true
+		}
+	}
+	return false
+}
+
+// SetNextCheck records when the worker of a site runs its next check
+func (app *AppState) SetNextCheck(siteID string, next time.Time) {
+	app.Mu.Lock()
+	defer app.Mu.Unlock()
+	if app.NextChecks == nil {
+		app.NextChecks = make(map[string]time.Time)
+	}
+	app.NextChecks[siteID] = next
+}
+
+// GetNextCheck returns the next scheduled check of a site
+func (app *AppState) GetNextCheck(siteID string) (time.Time, bool) {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+	next, exists := app.NextChecks[siteID]
+	return next, exists
+}
+
+// validateServerTLS checks that HTTPS has either certificate files or ACME
+// domains and loads the certificate, so a broken key pair fails at startup
+func validateServerTLS(cfg *models.Config) error {
+	tlsCfg := cfg.Server.TLS
+	if !tlsCfg.Enabled {
+		return nil
+	}
+
+	if tlsCfg.HTTPRedirectPort < 0 || tlsCfg.HTTPRedirectPort > 65535 || tlsCfg.HTTPRedirectPort == cfg.Server.Port {
+		return fmt.Errorf("invalid server.tls.http_redirect_port %d", tlsCfg.HTTPRedirectPort)
+	}
+	if len(tlsCfg.ACME.Domains) > 0 {
+		if tlsCfg.CertFile != "" || tlsCfg.KeyFile != "" {
+			return fmt.Errorf("server.tls: set either cert_file/key_file or acme.domains, not both")
+		}
+		return nil
+	}
+	if tlsCfg.CertFile == "" || tlsCfg.KeyFile == "" {
+		return fmt.Errorf("server.tls.enabled needs cert_file and key_file or acme.domains")
+	}
+	if _, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile); err != nil {
+		return fmt.Errorf("loading server.tls certificate: %w", err)
+	}
+	return nil
+}
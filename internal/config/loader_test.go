@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/storage"
+)
+
+// captureLogs sends the output of the default logger to the returned buffer
+// until the test ends
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := logger.Default()
+	logger.SetDefault(logger.NewLogger(logger.Config{Level: logger.LevelWarn, Output: &buf}))
+	t.Cleanup(func() { logger.SetDefault(previous) })
+	return &buf
+}
+
+// writeSites writes content as the sites file of the test
+func writeSites(t *testing.T, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "sites.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SITEWATCH_SITES_PATH", path)
+}
+
+// conflictWarnings returns the address conflicts logged as warnings
+func conflictWarnings(logs string) []string {
+	var conflicts []string
+	for _, line := range strings.Split(logs, "\n") {
+		if !strings.Contains(line, "level=WARN") || !strings.Contains(line, "conflict=") {
+			continue
+		}
+		conflict := line[strings.Index(line, "conflict=")+len("conflict="):]
+		conflicts = append(conflicts, strings.Trim(conflict, `"`))
+	}
+	return conflicts
+}
+
+func TestLoadSitesConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		sites     string
+		conflicts []string // Warned about, or the error in strict mode
+		err       string   // Rejected in both modes
+	}{
+		{
+			name: "distinct addresses",
+			sites: `
+- {id: site-a, name: A, primary_ip: 192.0.2.1, secondary_ip: 192.0.2.2}
+- {id: site-b, name: B, primary_ip: 192.0.2.3}`,
+		},
+		{
+			name: "duplicate site id",
+			sites: `
+- {id: site-a, name: A, primary_ip: 192.0.2.1}
+- {id: site-a, name: Copy, primary_ip: 192.0.2.3}`,
+			err: `sites[1]: duplicate site id "site-a"`,
+		},
+		{
+			name: "address on two sites",
+			sites: `
+- {id: site-a, name: A, primary_ip: 192.0.2.1}
+- {id: site-b, name: B, primary_ip: 192.0.2.3, secondary_ip: 192.0.2.1}`,
+			conflicts: []string{"192.0.2.1 (site-a primary_ip, site-b secondary_ip)"},
+		},
+		{
+			name: "both lines of a site",
+			sites: `
+- {id: site-a, name: A, primary_ip: 192.0.2.1, secondary_ip: 192.0.2.1}`,
+			conflicts: []string{"192.0.2.1 (site-a primary_ip, site-a secondary_ip)"},
+		},
+		{
+			name: "address in another notation",
+			sites: `
+- {id: site-a, name: A, primary_ip: "2001:db8::1"}
+- {id: site-b, name: B, primary_ip: "2001:0db8:0:0:0:0:0:1"}`,
+			conflicts: []string{"2001:db8::1 (site-a primary_ip, site-b primary_ip)"},
+		},
+		{
+			name: "several conflicts",
+			sites: `
+- {id: site-a, name: A, primary_ip: 192.0.2.1, secondary_ip: 192.0.2.2}
+- {id: site-b, name: B, primary_ip: 192.0.2.2, secondary_ip: 192.0.2.1}
+- {id: site-c, name: C, primary_ip: 192.0.2.1}`,
+			conflicts: []string{
+				"192.0.2.1 (site-a primary_ip, site-b secondary_ip, site-c primary_ip)",
+				"192.0.2.2 (site-a secondary_ip, site-b primary_ip)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			name := tt.name
+			if strict {
+				name += ", strict"
+			}
+			t.Run(name, func(t *testing.T) {
+				writeSites(t, "sites:"+tt.sites+"\n")
+				logs := captureLogs(t)
+				app := &AppState{}
+				app.Config.Validation.Strict = strict
+
+				err := app.LoadSites()
+				switch {
+				case tt.err != "":
+					if err == nil || !strings.Contains(err.Error(), tt.err) {
+						t.Fatalf("LoadSites = %v, want %q", err, tt.err)
+					}
+				case strict && len(tt.conflicts) > 0:
+					if err == nil || !strings.Contains(err.Error(), strings.Join(tt.conflicts, "; ")) {
+						t.Fatalf("LoadSites = %v, want the conflicts %q", err, tt.conflicts)
+					}
+				case err != nil:
+					t.Fatalf("LoadSites: %v", err)
+				}
+
+				loaded := err == nil
+				if loaded != (len(app.Sites) > 0) {
+					t.Errorf("%d sites loaded with error %v", len(app.Sites), err)
+				}
+				warned := conflictWarnings(logs.String())
+				if !loaded {
+					if len(warned) > 0 {
+						t.Errorf("rejected sites warned about %q", warned)
+					}
+					return
+				}
+				if strings.Join(warned, "\n") != strings.Join(tt.conflicts, "\n") {
+					t.Errorf("warned about %q, want %q", warned, tt.conflicts)
+				}
+			})
+		}
+	}
+}
+
+// AddSite applies the rules of LoadSites to the new site only
+func TestAddSiteConflicts(t *testing.T) {
+	existing := []models.Site{
+		{ID: "site-a", Name: "A", PrimaryIP: "192.0.2.1", SecondaryIP: "192.0.2.1"}, // Conflict of its own
+		{ID: "site-b", Name: "B", PrimaryIP: "192.0.2.2"},
+	}
+
+	tests := []struct {
+		name     string
+		site     models.Site
+		strict   bool
+		err      error
+		conflict string
+	}{
+		{"new addresses", models.Site{ID: "site-c", PrimaryIP: "192.0.2.3"}, true, nil, ""},
+		{"existing site id", models.Site{ID: "site-b", PrimaryIP: "192.0.2.3"}, false, ErrSiteExists, ""},
+		{"address of another site", models.Site{ID: "site-c", PrimaryIP: "192.0.2.2"}, false, nil, "192.0.2.2 (site-b primary_ip, site-c primary_ip)"},
+		{"address of another site, strict", models.Site{ID: "site-c", PrimaryIP: "192.0.2.2"}, true, ErrAddressInUse, "192.0.2.2 (site-b primary_ip, site-c primary_ip)"},
+		{"both lines", models.Site{ID: "site-c", PrimaryIP: "192.0.2.3", SecondaryIP: "192.0.2.3"}, false, nil, "192.0.2.3 (site-c primary_ip, site-c secondary_ip)"},
+		{"both lines, strict", models.Site{ID: "site-c", PrimaryIP: "192.0.2.3", SecondaryIP: "192.0.2.3"}, true, ErrAddressInUse, "192.0.2.3 (site-c primary_ip, site-c secondary_ip)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SITEWATCH_SITES_PATH", filepath.Join(t.TempDir(), "sites.yaml"))
+			logs := captureLogs(t)
+			app := &AppState{Sites: append([]models.Site(nil), existing...), Storage: storage.NewMemoryStorage(10)}
+			app.Config.Validation.Strict = tt.strict
+
+			err := app.AddSite(tt.site)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("AddSite = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				if tt.conflict != "" && !strings.Contains(err.Error(), tt.conflict) {
+					t.Errorf("AddSite = %v, want the conflict %q", err, tt.conflict)
+				}
+				if len(app.Sites) != len(existing) {
+					t.Errorf("%d sites after a rejected add, want %d", len(app.Sites), len(existing))
+				}
+				return
+			}
+
+			var want []string
+			if tt.conflict != "" {
+				want = []string{tt.conflict}
+			}
+			if warned := conflictWarnings(logs.String()); strings.Join(warned, "\n") != strings.Join(want, "\n") {
+				t.Errorf("warned about %q, want %q", warned, want)
+			}
+			if _, exists := app.FindSite(tt.site.ID); !exists || app.SiteStatus[tt.site.ID] == nil {
+				t.Errorf("site %s not added", tt.site.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package persist
+
+import "os"
+
+// copyFileOwner is a no-op where files have no Unix owner
+func copyFileOwner(info os.FileInfo, path string) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package persist
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyFileOwner gives path the owner and group of the file described by info
+func copyFileOwner(info os.FileInfo, path string) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(stat.Uid) == os.Geteuid() && int(stat.Gid) == os.Getegid() {
+		return nil // Already ours, avoid needing CAP_CHOWN
+	}
+	return os.Chown(path, int(stat.Uid), int(stat.Gid))
+}
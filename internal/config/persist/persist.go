@@ -0,0 +1,149 @@
+// Package persist writes configuration files back to disk without ever
+// leaving a partially written file behind. Anything that writes
+// configuration back to disk should go through it.
+package persist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+)
+
+// DefaultBackups is the number of previous versions kept by callers that do not choose their own
+const DefaultBackups = 3
+
+// Options controls a single write
+type Options struct {
+	// Backups is the number of previous versions to keep as path.bak,
+	// path.bak.1, ... (newest first). 0 keeps no backup.
+	Backups int
+}
+
+// WriteFile atomically replaces path with data. The data goes to path.tmp,
+// is synced and then renamed over path, so readers see either the old or
+// the new file. A lock file (path.lock) serializes writers across
+// goroutines and processes. Permissions and ownership of an existing file
+// are kept.
+func WriteFile(path string, data []byte, opts Options) error {
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", path, err)
+	}
+	defer lock.Unlock()
+
+	previous, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	var info os.FileInfo
+	if existed {
+		if info, err = os.Stat(path); err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	if existed && opts.Backups > 0 {
+		if err := backup(path, previous, info, opts.Backups); err != nil {
+			return err
+		}
+	}
+
+	return replace(path, data, info)
+}
+
+// tempFile is the part of *os.File replace writes through
+type tempFile interface {
+	Write(data []byte) (int, error)
+	Sync() error
+	Close() error
+}
+
+// openTemp creates the temporary file of a write, tests replace it to
+// interrupt writes
+var openTemp = func(name string, mode os.FileMode) (tempFile, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+}
+
+// replace writes data to path.tmp and renames it over path. The new file
+// gets the permissions and owner of like, or mode 0644 when like is nil.
+func replace(path string, data []byte, like os.FileInfo) (err error) {
+	mode := os.FileMode(0o644)
+	if like != nil {
+		mode = like.Mode().Perm()
+	}
+
+	tmpPath := path + ".tmp"
+	tmp, err := openTemp(tmpPath, mode)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", tmpPath, err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", tmpPath, err)
+	}
+
+	// OpenFile applies the umask, so set the original mode explicitly
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("setting permissions of %s: %w", tmpPath, err)
+	}
+	if like != nil {
+		if err := copyFileOwner(like, tmpPath); err != nil {
+			return fmt.Errorf("setting owner of %s: %w", tmpPath, err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// backup rotates path.bak, path.bak.1, ... and stores content, the current
+// version of path, as path.bak, keeping at most keep versions. Backups get
+// the permissions of path since config files may hold secrets.
+func backup(path string, content []byte, info os.FileInfo, keep int) error {
+	name := func(i int) string {
+		if i == 0 {
+			return path + ".bak"
+		}
+		return fmt.Sprintf("%s.bak.%d", path, i)
+	}
+
+	os.Remove(name(keep - 1))
+	for i := keep - 2; i >= 0; i-- {
+		if err := os.Rename(name(i), name(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rotating backup %s: %w", name(i), err)
+		}
+	}
+
+	if err := replace(name(0), content, info); err != nil {
+		return fmt.Errorf("backing up %s: %w", path, err)
+	}
+	return nil
+}
+
+// syncDir flushes a directory so a completed rename survives a crash.
+// Best effort: not every platform supports syncing directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
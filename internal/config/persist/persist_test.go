@@ -0,0 +1,137 @@
+package persist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var errInjected = errors.New("injected failure")
+
+// failingFile is a temporary file that fails at one step of the write
+type failingFile struct {
+	*os.File
+	failWrite bool // Write half of the data, then fail
+	failSync  bool
+	failClose bool
+}
+
+func (f *failingFile) Write(data []byte) (int, error) {
+	if !f.failWrite {
+		return f.File.Write(data)
+	}
+	n, err := f.File.Write(data[:len(data)/2])
+	if err != nil {
+		return n, err
+	}
+	return n, errInjected
+}
+
+func (f *failingFile) Sync() error {
+	if f.failSync {
+		return errInjected
+	}
+	return f.File.Sync()
+}
+
+func (f *failingFile) Close() error {
+	err := f.File.Close()
+	if f.failClose {
+		return errInjected
+	}
+	return err
+}
+
+// interruptWrites makes writes of the temporary file of path fail like
+// broken, other temporary files are written normally
+func interruptWrites(t *testing.T, path string, broken failingFile) {
+	t.Helper()
+
+	original := openTemp
+	openTemp = func(name string, mode os.FileMode) (tempFile, error) {
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil || name != path+".tmp" {
+			return file, err
+		}
+		f := broken
+		f.File = file
+		return &f, nil
+	}
+	t.Cleanup(func() { openTemp = original })
+}
+
+// writeConfig creates a file with content and mode 0600 in a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assertContent checks that path holds want with mode 0600
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("%s mode = %v, want 0600", filepath.Base(path), mode)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := writeConfig(t, "version: 1\n")
+
+	for _, content := range []string{"version: 2\n", "version: 3\n"} {
+		if err := WriteFile(path, []byte(content), Options{Backups: 2}); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	assertContent(t, path, "version: 3\n")
+	assertContent(t, path+".bak", "version: 2\n")
+	assertContent(t, path+".bak.1", "version: 1\n")
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestWriteFileInterrupted(t *testing.T) {
+	tests := []struct {
+		name   string
+		broken failingFile
+	}{
+		{"write", failingFile{failWrite: true}},
+		{"sync", failingFile{failSync: true}},
+		{"close", failingFile{failClose: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, "sites:\n  - id: site-001\n")
+			interruptWrites(t, path, tt.broken)
+
+			err := WriteFile(path, []byte("sites:\n  - id: site-002\n  - id: site-003\n"), Options{Backups: 1})
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("WriteFile = %v, want the injected failure", err)
+			}
+
+			assertContent(t, path, "sites:\n  - id: site-001\n")
+			if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("partially written temporary file left behind: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"sitewatch/internal/models"
+)
+
+// BuildRuntimeSummary captures the effective runtime configuration and stores
+// it in app.Runtime. Call once after all initialization has finished.
+func (app *AppState) BuildRuntimeSummary(durations models.StartupDurations) models.RuntimeSummary {
+	summary := models.RuntimeSummary{
+		Version:        Version,
+		StartedAt:      app.StartTime,
+		ConfigPath:     GetConfigPath(),
+		SitesPath:      GetSitesPath(),
+		StorageType:    app.Config.Storage.Type,
+		PingEnabled:    app.Config.IsPingEnabled(),
+		AuthEnabled:    app.Config.Auth.Enabled,
+		MetricsEnabled: app.Config.Metrics.Enabled,
+		MetricsPath:    app.Config.Metrics.Path,
+		ListenAddress:  fmt.Sprintf("%s:%d", app.Config.Server.Host, app.Config.Server.Port),
+		InitDurations:  durations,
+	}
+
+	if summary.StorageType == "sqlite" {
+		summary.DBPath = app.Config.Storage.SQLitePath
+		if info, err := os.Stat(summary.DBPath); err == nil {
+			summary.DBSizeBytes = info.Size()
+		}
+	}
+
+	for _, site := range app.GetSitesSnapshot() {
+		summary.Sites.Total++
+		if site.Enabled {
+			summary.Sites.Enabled++
+		} else {
+			summary.Sites.Disabled++
+		}
+		if site.IsDualLine() {
+			summary.Sites.DualLine++
+		}
+	}
+
+	app.Runtime = &summary
+	return summary
+}
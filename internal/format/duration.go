@@ -0,0 +1,88 @@
+package format
+
+import (
+	"strings"
+	"time"
+
+	"sitewatch/internal/i18n"
+)
+
+// Calendar units use fixed lengths, as a bare duration has no start date
+const (
+	day   = 24 * time.Hour
+	week  = 7 * day
+	month = 30 * day
+	year  = 365 * day
+)
+
+// durationUnits lists the display units from largest to smallest with their translation keys
+var durationUnits = []struct {
+	size time.Duration
+	key  string
+}{
+	{year, "duration.years"},
+	{month, "duration.months"},
+	{week, "duration.weeks"},
+	{day, "duration.days"},
+	{time.Hour, "duration.hours"},
+	{time.Minute, "duration.minutes"},
+	{time.Second, "duration.seconds"},
+}
+
+// Duration formats d with its largest unit and, when not zero, the next
+// smaller one, truncating the rest: "1y 2mo", "3w 2d", "5h", "45s".
+// Durations below a second are shown in milliseconds ("850ms").
+func Duration(locale string, d time.Duration) string {
+	if d < time.Second {
+		return subSecond(locale, d)
+	}
+
+	for i, unit := range durationUnits {
+		if d < unit.size {
+			continue
+		}
+		result := i18n.T(locale, unit.key, int(d/unit.size))
+		if i+1 < len(durationUnits) {
+			next := durationUnits[i+1]
+			if count := int(d % unit.size / next.size); count > 0 {
+				result += " " + i18n.T(locale, next.key, count)
+			}
+		}
+		return result
+	}
+	return subSecond(locale, d)
+}
+
+// DurationPrecise formats d with every non-zero unit down to seconds
+// ("1d 2h 5s") for values where exactness matters, such as incident
+// durations. Durations below a second are shown in milliseconds.
+func DurationPrecise(locale string, d time.Duration) string {
+	if d < time.Second {
+		return subSecond(locale, d)
+	}
+
+	var parts []string
+	for _, unit := range durationUnits {
+		if count := int(d / unit.size); count > 0 {
+			parts = append(parts, i18n.T(locale, unit.key, count))
+			d -= time.Duration(count) * unit.size
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// TimeAgo formats an elapsed duration relative to now ("5m 30s ago", "just now")
+func TimeAgo(locale string, d time.Duration) string {
+	if d < time.Second {
+		return i18n.T(locale, "time.just_now")
+	}
+	return i18n.T(locale, "time.ago", Duration(locale, d))
+}
+
+// subSecond formats durations below a second in whole milliseconds
+func subSecond(locale string, d time.Duration) string {
+	if d <= 0 {
+		return i18n.T(locale, "duration.seconds", 0)
+	}
+	return i18n.T(locale, "duration.milliseconds", int(d/time.Millisecond))
+}
@@ -0,0 +1,106 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+// days returns n calendar days as a duration
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// leapYear is the time from 2024-02-01 to 2025-02-01, which spans 29 February
+var leapYear = time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC).Sub(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{"zero", 0, "0s"},
+		{"negative", -5 * time.Second, "0s"},
+		{"below a millisecond", 400 * time.Microsecond, "0ms"},
+		{"sub-second", 850 * time.Millisecond, "850ms"},
+		{"just below a second", 999*time.Millisecond + 999*time.Microsecond, "999ms"},
+		{"one second", time.Second, "1s"},
+		{"seconds truncate milliseconds", 1999 * time.Millisecond, "1s"},
+		{"59s", 59 * time.Second, "59s"},
+		{"one minute", time.Minute, "1m"},
+		{"59m59s", 59*time.Minute + 59*time.Second, "59m 59s"},
+		{"59m59.9s rounds down", 59*time.Minute + 59900*time.Millisecond, "59m 59s"},
+		{"one hour", time.Hour, "1h"},
+		{"hours drop seconds", time.Hour + 30*time.Second, "1h"},
+		{"23h59m", 23*time.Hour + 59*time.Minute, "23h 59m"},
+		{"23h59m59s", 23*time.Hour + 59*time.Minute + 59*time.Second, "23h 59m"},
+		{"one day", days(1), "1d"},
+		{"6d23h", days(6) + 23*time.Hour, "6d 23h"},
+		{"6d23h59m", days(6) + 23*time.Hour + 59*time.Minute, "6d 23h"},
+		{"one week", days(7), "1w"},
+		{"3w2d", days(23), "3w 2d"},
+		{"weeks drop hours", days(7) + 5*time.Hour, "1w"},
+		{"29d", days(29), "4w 1d"},
+		{"one month", days(30), "1mo"},
+		{"months drop days", days(34), "1mo"},
+		{"months and weeks", days(45), "1mo 2w"},
+		{"364d", days(364), "12mo"},
+		{"365d", days(365), "1y"},
+		{"leap year", leapYear, "1y"},
+		{"367d5h", days(367) + 5*time.Hour, "1y"},
+		{"1y2mo", days(365 + 65), "1y 2mo"},
+		{"four years with a leap day", days(4*365 + 1), "4y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Duration("en", tt.d); got != tt.want {
+				t.Errorf("Duration(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationPrecise(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{"zero", 0, "0s"},
+		{"sub-second", 850 * time.Millisecond, "850ms"},
+		{"seconds truncate milliseconds", 1999 * time.Millisecond, "1s"},
+		{"59m59s", 59*time.Minute + 59*time.Second, "59m 59s"},
+		{"skips zero units", days(1) + 2*time.Hour + 5*time.Second, "1d 2h 5s"},
+		{"23h59m", 23*time.Hour + 59*time.Minute, "23h 59m"},
+		{"6d23h", days(6) + 23*time.Hour, "6d 23h"},
+		{"364d", days(364), "12mo 4d"},
+		{"leap year", leapYear, "1y 1d"},
+		{"every unit", days(365+30+14+4) + 5*time.Hour + 6*time.Minute + 7*time.Second, "1y 1mo 2w 4d 5h 6m 7s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DurationPrecise("en", tt.d); got != tt.want {
+				t.Errorf("DurationPrecise(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeAgo(t *testing.T) {
+	tests := []struct {
+		locale string
+		d      time.Duration
+		want   string
+	}{
+		{"en", 0, "just now"},
+		{"en", 999 * time.Millisecond, "just now"},
+		{"en", 90 * time.Second, "1m 30s ago"},
+		{"en", days(400), "1y 1mo ago"},
+		{"de", 90 * time.Second, "vor 1 Min. 30 s"},
+	}
+	for _, tt := range tests {
+		if got := TimeAgo(tt.locale, tt.d); got != tt.want {
+			t.Errorf("TimeAgo(%s, %v) = %q, want %q", tt.locale, tt.d, got, tt.want)
+		}
+	}
+}
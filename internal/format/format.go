@@ -0,0 +1,74 @@
+// Package format provides shared display formatting for latency and percentage values
+package format
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	decimalSeparator   = "."
+	microsecondLatency bool
+	settingsMu         sync.RWMutex
+)
+
+// Configure sets the decimal separator (e.g. "," for German locales) and whether
+// sub-millisecond latencies are shown in microseconds
+func Configure(separator string, microseconds bool) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if separator == "" {
+		separator = "."
+	}
+	decimalSeparator = separator
+	microsecondLatency = microseconds
+}
+
+// Latency formats a latency in milliseconds including its unit.
+// Values below 1 ms get extra precision ("0.42 ms") or, when enabled,
+// microsecond display ("420 µs") so LAN sites do not show up as "0.0".
+func Latency(ms float64) string {
+	settingsMu.RLock()
+	micro := microsecondLatency
+	settingsMu.RUnlock()
+
+	switch {
+	case ms <= 0:
+		return localize("0.0") + " ms"
+	case ms < 1 && micro:
+		return localize(fmt.Sprintf("%.0f", ms*1000)) + " µs"
+	case ms < 0.01:
+		return localize(fmt.Sprintf("%.3f", ms)) + " ms"
+	case ms < 1:
+		return localize(fmt.Sprintf("%.2f", ms)) + " ms"
+	default:
+		return localize(fmt.Sprintf("%.1f", ms)) + " ms"
+	}
+}
+
+// LatencyPtr formats an optional latency, returning an empty string for nil
+func LatencyPtr(ms *float64) string {
+	if ms == nil {
+		return ""
+	}
+	return Latency(*ms)
+}
+
+// Percent formats a percentage with one decimal place
+func Percent(value float64) string {
+	return localize(fmt.Sprintf("%.1f", value)) + "%"
+}
+
+// localize replaces the decimal point with the configured separator
+func localize(value string) string {
+	settingsMu.RLock()
+	separator := decimalSeparator
+	settingsMu.RUnlock()
+
+	if separator == "." {
+		return value
+	}
+	return strings.Replace(value, ".", separator, 1)
+}
@@ -0,0 +1,77 @@
+package format
+
+import "testing"
+
+// configure applies the display settings until the test ends
+func configure(t *testing.T, separator string, microseconds bool) {
+	t.Helper()
+
+	Configure(separator, microseconds)
+	t.Cleanup(func() { Configure(".", false) })
+}
+
+func TestLatency(t *testing.T) {
+	tests := []struct {
+		name         string
+		separator    string
+		microseconds bool
+		ms           float64
+		want         string
+	}{
+		{"zero", "", false, 0, "0.0 ms"},
+		{"negative", "", false, -3, "0.0 ms"},
+		{"below 10 µs", "", false, 0.005, "0.005 ms"},
+		{"below 1 µs", "", false, 0.0004, "0.000 ms"},
+		{"sub-millisecond", "", false, 0.42, "0.42 ms"},
+		{"rounds up to a millisecond", "", false, 0.999, "1.00 ms"},
+		{"one millisecond", "", false, 1, "1.0 ms"},
+		{"milliseconds", "", false, 12.36, "12.4 ms"},
+		{"seconds", "", false, 1234.5, "1234.5 ms"},
+		{"microseconds", "", true, 0.42, "420 µs"},
+		{"microseconds round", "", true, 0.0006, "1 µs"},
+		{"microseconds below 1 µs", "", true, 0.0004, "0 µs"},
+		{"microseconds from a millisecond", "", true, 1.5, "1.5 ms"},
+		{"microseconds zero", "", true, 0, "0.0 ms"},
+		{"comma", ",", false, 12.36, "12,4 ms"},
+		{"comma sub-millisecond", ",", false, 0.42, "0,42 ms"},
+		{"comma zero", ",", false, 0, "0,0 ms"},
+		{"comma microseconds", ",", true, 0.42, "420 µs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, tt.separator, tt.microseconds)
+			if got := Latency(tt.ms); got != tt.want {
+				t.Errorf("Latency(%v) = %q, want %q", tt.ms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatencyPtr(t *testing.T) {
+	if got := LatencyPtr(nil); got != "" {
+		t.Errorf("LatencyPtr(nil) = %q, want empty", got)
+	}
+	latency := 0.42
+	if got := LatencyPtr(&latency); got != "0.42 ms" {
+		t.Errorf("LatencyPtr(0.42) = %q, want %q", got, "0.42 ms")
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		separator string
+		value     float64
+		want      string
+	}{
+		{"", 0, "0.0%"},
+		{"", 99.94, "99.9%"},
+		{"", 100, "100.0%"},
+		{",", 99.94, "99,9%"},
+	}
+	for _, tt := range tests {
+		configure(t, tt.separator, false)
+		if got := Percent(tt.value); got != tt.want {
+			t.Errorf("Percent(%v) with separator %q = %q, want %q", tt.value, tt.separator, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sitewatch/internal/i18n"
+)
+
+func TestMain(m *testing.M) {
+	if err := i18n.Load(filepath.Join("..", "..", i18n.DefaultDir), i18n.FallbackLocale); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -1,22 +1,49 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/middleware"
 	"sitewatch/internal/models"
+	"sitewatch/internal/services/chartimage"
+	"sitewatch/internal/services/export"
+	"sitewatch/internal/services/housekeeping"
+	"sitewatch/internal/services/jobs"
+	"sitewatch/internal/services/maintenance"
+	"sitewatch/internal/services/notify"
 	"sitewatch/internal/services/ping"
+	"sitewatch/internal/services/reports"
 	"sitewatch/internal/services/stats"
 )
 
 // API Handlers
 
 // HandleGetSites - GET /api/sites - List all sites with status overview
+// Optional ?status=online|degraded|offline|unknown and ?site= (site ID, glob or /regex/) filters, ?page=&per_page=
+// pagination and ?fields=id,name,status.primary_online field selection.
+// Tokens with only the status permission get the sites redacted
 func HandleGetSites(c *fiber.Ctx) error {
-	config.GlobalAppState.Mu.RLock()
-	defer config.GlobalAppState.Mu.RUnlock()
+	redact := middleware.IsStatusOnly(c)
+	query, err := parseSiteListQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Snapshots, so building the response does not block status updates
+	sites := config.GlobalAppState.GetSitesSnapshot()
+	statuses := config.GlobalAppState.GetSiteStatusSnapshot()
 	
 	type SiteOverview struct {
 		models.Site
@@ -24,8 +51,14 @@ func HandleGetSites(c *fiber.Ctx) error {
 	}
 	
 	var overview []SiteOverview
-	for _, site := range config.GlobalAppState.Sites {
-		status, exists := config.GlobalAppState.SiteStatus[site.ID]
+	for _, site := range sites {
+		if !query.site.Match(site.ID) {
+			continue
+		}
+		status, exists := statuses[site.ID]
+		if query.status != "" && stats.SiteStatusLabel(site, status) != query.status {
+			continue
+		}
 		if !exists {
 			// Default status if not found
 			status = &models.SiteStatus{
@@ -37,17 +70,49 @@ func HandleGetSites(c *fiber.Ctx) error {
 			}
 		}
 		
-		overview = append(overview, SiteOverview{
+		entry := SiteOverview{
 			Site:   site,
-			Status: *status,
-		})
+			Status: siteStatusForVersion(c, *status),
+		}
+		if redact {
+			entry.Site = entry.Site.Redacted()
+			entry.Status = entry.Status.Redacted()
+		}
+		overview = append(overview, entry)
 	}
 	
-	return c.JSON(fiber.Map{
-		"sites": overview,
-		"total": len(overview),
-		"timestamp": time.Now(),
-	})
+	total := len(overview)
+	if query.paginate {
+		start, end := query.pageBounds(total)
+		overview = overview[start:end]
+	}
+	
+	var sitesJSON any = overview
+	if len(query.fields) > 0 {
+		selected := make([]map[string]any, 0, len(overview))
+		for _, entry := range overview {
+			fields, err := selectFields(entry, query.fields)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{
+					"error": "Failed to select fields",
+				})
+			}
+			selected = append(selected, fields)
+		}
+		sitesJSON = selected
+	}
+	
+	response := fiber.Map{
+		"sites": sitesJSON,
+		"total": total,
+		"timestamp": responseTime(c),
+	}
+	if query.paginate {
+		response["page"] = query.page
+		response["per_page"] = query.perPage
+		response["links"] = pageLinks(c, query.page, query.perPage, total)
+	}
+	return c.JSON(response)
 }
 
 // HandleGetSiteStatus - GET /api/sites/{siteId}/status - Serverguard compatible endpoint
@@ -72,6 +137,7 @@ func HandleGetSiteStatus(c *fiber.Ctx) error {
 }
 
 // HandleGetSiteDetails - GET /api/sites/{siteId}/details - Detailed site information
+// Tokens with only the status permission get the site and status redacted
 func HandleGetSiteDetails(c *fiber.Ctx) error {
 	siteID := c.Params("siteId")
 	
@@ -100,17 +166,37 @@ func HandleGetSiteDetails(c *fiber.Ctx) error {
 		})
 	}
 	
+	var monitoredSince, firstSuccess *time.Time
+	if monitoring, exists := config.GlobalAppState.GetSiteMonitoring(siteID); exists {
+		monitoredSince = &monitoring.FirstSeen
+		firstSuccess = monitoring.FirstSuccess
+	}
+	
+	site, siteStatus := *siteInfo, *status
+	if middleware.IsStatusOnly(c) {
+		site, siteStatus = site.Redacted(), siteStatus.Redacted()
+	}
+	
 	return c.JSON(fiber.Map{
-		"site": siteInfo,
-		"status": status,
-		"timestamp": time.Now(),
+		"site": site,
+		"status": siteStatusForVersion(c, siteStatus),
+		"monitored_since": timePtrForVersion(c, monitoredSince),
+		"first_success": timePtrForVersion(c, firstSuccess),
+		"schedule": scheduleForVersion(c, ping.GetCheckSchedule(config.GlobalAppState, *siteInfo)),
+		"simulations": simulationsForVersion(c, ping.ActiveSimulations(siteID)),
+		"timestamp": responseTime(c),
 	})
 }
 
-// HandleGetLogs - GET /api/logs - Get ping logs with optional filtering
+// HandleGetLogs - GET /api/logs - Get ping logs with optional filtering,
+// ?site= takes a site ID, a glob (prod-*) or a regular expression (/^prod-\d+$/)
 func HandleGetLogs(c *fiber.Ctx) error {
 	// Parse query parameters
 	siteID := c.Query("site", "")
+	pattern, err := parseSitePattern(siteID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	successParam := c.Query("success", "")
 	limitParam := c.Query("limit", "100")
 	
@@ -135,7 +221,7 @@ func HandleGetLogs(c *fiber.Ctx) error {
 	}
 	
 	// Get filtered logs
-	logs, err := ping.GetFilteredLogs(config.GlobalAppState, siteID, success, limit)
+	logs, err := ping.GetFilteredLogs(config.GlobalAppState, pattern.SiteIDs(config.GlobalAppState.GetSitesSnapshot()), success, limit)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to get logs",
@@ -143,13 +229,14 @@ func HandleGetLogs(c *fiber.Ctx) error {
 	}
 	
 	return c.JSON(fiber.Map{
-		"logs":  logs,
+		"logs":  logsForVersion(c, logs),
 		"total": len(logs),
 		"filters": fiber.Map{
 			"site":    siteID,
 			"success": successParam,
 			"limit":   limit,
 		},
+		"timestamp": responseTime(c),
 	})
 }
 
@@ -158,108 +245,1299 @@ func HandleGetSiteStatistics(c *fiber.Ctx) error {
 	siteID := c.Params("siteId")
 	
 	// Calculate extended statistics
-	statistics := stats.CalculateSiteStatistics(config.GlobalAppState, siteID)
+	statistics := statisticsForVersion(c, stats.CalculateSiteStatistics(config.GlobalAppState, siteID))
+	if statistics.Unavailable {
+		return c.Status(503).JSON(fiber.Map{
+			"error":      "Statistics unavailable",
+			"site_id":    siteID,
+			"statistics": statistics,
+		})
+	}
 	
 	return c.JSON(fiber.Map{
 		"site_id":    siteID,
 		"statistics": statistics,
-		"timestamp":  time.Now(),
+		"timestamp":  responseTime(c),
 	})
 }
 
-// HandleGetSiteChartData - GET /api/sites/:siteId/charts - Get comprehensive chart data
+// HandleGetSiteChartData - GET /api/sites/:siteId/charts - Get comprehensive chart data,
+// limited to some charts with ?charts=latency,uptime.
+// ?type=&range= returns a single chart for that time range instead, and
+// ?type=&from=&to=&resolution= a time series chart for a custom range.
 func HandleGetSiteChartData(c *fiber.Ctx) error {
 	siteID := c.Params("siteId")
 	
-	// Generate comprehensive chart data
-	chartData := stats.GenerateChartData(config.GlobalAppState, siteID)
+	if chartType := c.Query("type"); c.Query("from") != "" {
+		chartData, err := customRangeChartData(c, siteID, chartType)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{
+			"site_id":    siteID,
+			"type":       chartType,
+			"chart_data": chartData,
+			"timestamp":  responseTime(c),
+		})
+	}
+	
+	if chartType, timeRange := c.Query("type"), c.Query("range"); chartType != "" || timeRange != "" {
+		if err := stats.ValidateChartRange(chartType, timeRange); err != nil {
+			return c.Status(400).JSON(chartRangeError(chartType, err))
+		}
+		return c.JSON(fiber.Map{
+			"site_id":    siteID,
+			"type":       chartType,
+			"range":      timeRange,
+			"chart_data": stats.GenerateChartDataForRange(config.GlobalAppState, siteID, chartType, timeRange),
+			"timestamp":  responseTime(c),
+		})
+	}
+	
+	// Generate comprehensive chart data, only the selected charts are computed
+	charts, err := stats.ParseChartSelection(c.Query("charts"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":        err.Error(),
+			"valid_charts": stats.BundleCharts(),
+		})
+	}
+	chartData := stats.GenerateChartData(config.GlobalAppState, siteID, charts)
 	
 	return c.JSON(fiber.Map{
 		"site_id":    siteID,
 		"chart_data": chartData,
-		"timestamp":  time.Now(),
+		"timestamp":  responseTime(c),
 	})
 }
 
-// HandleSiteTest - POST /api/sites/:siteId/test - Run manual ping test
-func HandleSiteTest(c *fiber.Ctx) error {
+// HandleGetSiteChartImage - GET /api/sites/:siteId/charts/:chartType.png - Chart of a preset range
+// rendered as PNG for chat messages. ?range= defaults to 24h, ?width=&height= to 800x300 (at most 1600x800)
+func HandleGetSiteChartImage(c *fiber.Ctx) error {
+	chartType := c.Params("chartType")
+	png, err := chartimage.SiteChart(config.GlobalAppState, c.Params("siteId"), chartType, c.Query("range", "24h"),
+		c.QueryInt("width"), c.QueryInt("height"))
+	switch {
+	case errors.Is(err, chartimage.ErrSiteNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "Site not found"})
+	case err != nil:
+		response := chartRangeError(chartType, err)
+		response["image_types"] = chartimage.Types()
+		return c.Status(400).JSON(response)
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", int(chartimage.CacheTTL/time.Second)))
+	return c.Send(png)
+}
+
+// customRangeChartData generates a chart for the from/to (RFC 3339, to defaults
+// to now) and resolution (default 1m) query parameters
+func customRangeChartData(c *fiber.Ctx, siteID, chartType string) (interface{}, error) {
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid from %q (expected RFC 3339)", c.Query("from"))
+	}
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("invalid to %q (expected RFC 3339)", value)
+		}
+	}
+	resolution, err := stats.ParsePeriod(c.Query("resolution", "1m"))
+	if err != nil {
+		return nil, err
+	}
+	return stats.GenerateChartDataForWindow(config.GlobalAppState, siteID, chartType, from, to, resolution)
+}
+
+// HandleGetSiteLatencyDistribution - GET /api/sites/:siteId/distribution - Latency histogram over a time range.
+// ?from=&to= (RFC 3339, defaults to the last 24h) and ?buckets= comma separated
+// upper bounds in ms (defaults to 10,50,100,200,500)
+func HandleGetSiteLatencyDistribution(c *fiber.Ctx) error {
 	siteID := c.Params("siteId")
 	
-	// Find the site
-	var site *models.Site
-	for _, s := range config.GlobalAppState.Sites {
-		if s.ID == siteID {
-			site = &s
-			break
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid to %q (expected RFC 3339)", value)})
+		}
+		to = parsed
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid from %q (expected RFC 3339)", value)})
+		}
+		from = parsed
+	}
+	bounds, err := stats.ParseLatencyBuckets(c.Query("buckets"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	distribution, err := stats.GenerateLatencyDistribution(config.GlobalAppState, siteID, from, to, bounds)
+	if err != nil {
+		if errors.Is(err, stats.ErrInvalidRange) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{
+		"site_id":      siteID,
+		"distribution": distribution,
+		"timestamp":    responseTime(c),
+	})
+}
+
+// chartRangeError builds the error response for an unsupported chart type or
+// range, listing the valid ranges of a known type or else the valid types
+func chartRangeError(chartType string, err error) fiber.Map {
+	response := fiber.Map{"error": err.Error()}
+	if ranges := stats.ChartRanges(chartType); ranges != nil {
+		response["valid_ranges"] = ranges
+	} else {
+		response["valid_types"] = stats.ChartTypes()
 	}
+	return response
+}
+
+// HandleGetSiteSLAReport - GET /api/sites/:siteId/sla - SLA error budgets and breach predictions
+func HandleGetSiteSLAReport(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
 	
-	if site == nil {
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Site not found",
 		})
 	}
 	
-	type TestResult struct {
-		IP        string    `json:"ip"`
-		Success   bool      `json:"success"`
-		Latency   *float64  `json:"latency,omitempty"`
-		Error     string    `json:"error,omitempty"`
-		Timestamp time.Time `json:"timestamp"`
+	statistics := stats.CalculateSiteStatistics(config.GlobalAppState, siteID)
+	if statistics.Unavailable {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "Statistics unavailable",
+		})
 	}
 	
-	type TestResponse struct {
-		Primary   *TestResult `json:"primary,omitempty"`
-		Secondary *TestResult `json:"secondary,omitempty"`
+	return c.JSON(fiber.Map{
+		"site_id":                siteID,
+		"window_hours":           stats.SLAWindowHours,
+		"burn_rate_window_hours": stats.SLABurnRateWindow.Hours(),
+		"sla":                    statistics.SLABreaches,
+		"alerts":                 stats.SLABreachEvents(siteID, statistics.SLABreaches, middleware.GetLocale(c)),
+		"timestamp":              responseTime(c),
+	})
+}
+
+// HandleGetDependencyGraph - GET /api/sites/dependency-graph - Site dependency graph with cycle warnings
+func HandleGetDependencyGraph(c *fiber.Ctx) error {
+	return c.JSON(stats.BuildDependencyGraph(config.GlobalAppState))
+}
+
+// HandleGetSiteAffectedBy - GET /api/sites/:siteId/affected-by - Sites depending on a site directly or transitively
+func HandleGetSiteAffectedBy(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
 	}
 	
-	response := TestResponse{}
-	now := time.Now()
+	sites := config.GlobalAppState.GetSitesSnapshot()
+	statuses := config.GlobalAppState.GetSiteStatusSnapshot()
+	
+	affected := []models.DependencyNode{}
+	for _, site := range stats.AffectedSites(sites, siteID) {
+		affected = append(affected, models.DependencyNode{
+			ID:     site.ID,
+			Name:   site.Name,
+			Status: stats.SiteStatusLabel(site, statuses[site.ID]),
+		})
+	}
+	
+	return c.JSON(fiber.Map{
+		"site_id":   siteID,
+		"affected":  affected,
+		"count":     len(affected),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleGetSitePatterns - GET /api/sites/:siteId/patterns - Recurring failure windows per line
+func HandleGetSitePatterns(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	cfg := config.GlobalAppState.Config.FailurePatterns
+	if !cfg.Enabled {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Failure pattern detection is disabled",
+		})
+	}
+	
+	patterns := config.GlobalAppState.GetFailurePatterns(siteID)
+	if patterns == nil {
+		patterns = []models.FailurePattern{}
+	}
+	
+	return c.JSON(fiber.Map{
+		"site_id":         siteID,
+		"window_days":     stats.FailurePatternWindowDays,
+		"min_occurrences": cfg.MinOccurrences,
+		"patterns":        patterns,
+		"timestamp":       responseTime(c),
+	})
+}
+
+// HandleGetSiteLatencyBaseline - GET /api/sites/:siteId/latency-baseline - Latency baselines and deviation state per line
+func HandleGetSiteLatencyBaseline(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	site, exists := config.GlobalAppState.FindSite(siteID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	cfg := config.GlobalAppState.Config.LatencyBaseline
+	if !cfg.Enabled {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Latency baselines are disabled",
+		})
+	}
+	
+	lineTypes := []string{"primary"}
+	if site.IsDualLine() {
+		lineTypes = append(lineTypes, "secondary")
+	}
 	
-	// Test primary IP
-	if site.PrimaryIP != "" {
-		success, latency, errorMsg := ping.PingIPSync(config.GlobalAppState, site.PrimaryIP)
-		result := &TestResult{
-			IP:        site.PrimaryIP,
-			Success:   success,
-			Timestamp: now,
+	lines := []fiber.Map{}
+	for _, lineType := range lineTypes {
+		baseline, exists := config.GlobalAppState.GetLatencyBaseline(siteID, lineType)
+		if !exists {
+			continue
 		}
-		
-		if !success {
-			result.Error = errorMsg
-		} else if latency != nil {
-			result.Latency = latency
+		lines = append(lines, fiber.Map{
+			"baseline":     baseline,
+			"threshold_ms": baseline.Threshold(cfg.Deviation, cfg.MinIncreaseMs),
+			"active":       baseline.Samples >= cfg.MinSamples,
+			"deviating":    ping.IsLatencyDeviating(siteID, lineType),
+		})
+	}
+	
+	return c.JSON(fiber.Map{
+		"site_id":         siteID,
+		"window_hours":    cfg.Window.Hours(),
+		"deviation":       cfg.Deviation,
+		"min_increase_ms": cfg.MinIncreaseMs,
+		"min_samples":     cfg.MinSamples,
+		"lines":           lines,
+		"timestamp":       responseTime(c),
+	})
+}
+
+// HandleGetMaintenanceWindows - GET /api/maintenance-windows - Current and upcoming maintenance windows imported from iCal feeds
+func HandleGetMaintenanceWindows(c *fiber.Ctx) error {
+	now := time.Now()
+	windows, err := config.GlobalAppState.Storage.GetMaintenanceWindows(now, now.Add(maintenance.ImportHorizon))
+	if err != nil {
+		logger.Default().WithComponent("api").Error("Failed to load maintenance windows", "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load maintenance windows",
+		})
+	}
+	
+	if siteID := c.Query("site_id"); siteID != "" {
+		filtered := []models.MaintenanceWindow{}
+		for _, window := range windows {
+			if window.SiteID == siteID {
+				filtered = append(filtered, window)
+			}
 		}
-		
-		response.Primary = result
+		windows = filtered
 	}
 	
-	// Test secondary IP (if exists)
-	if site.SecondaryIP != "" {
-		success, latency, errorMsg := ping.PingIPSync(config.GlobalAppState, site.SecondaryIP)
-		result := &TestResult{
-			IP:        site.SecondaryIP,
-			Success:   success,
-			Timestamp: now,
+	return c.JSON(fiber.Map{
+		"windows":   windows,
+		"count":     len(windows),
+		"timestamp": now,
+	})
+}
+
+// HandleGetSelfMetrics - GET /api/self/metrics - Load samples of the monitoring host, oldest first.
+// ?from=&to= (RFC 3339) default to the last 24h
+func HandleGetSelfMetrics(c *fiber.Ctx) error {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid to %q (expected RFC 3339)", value)})
 		}
-		
-		if !success {
-			result.Error = errorMsg
-		} else if latency != nil {
-			result.Latency = latency
+		to = parsed
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid from %q (expected RFC 3339)", value)})
 		}
-		
-		response.Secondary = result
+		from = parsed
 	}
-	
-	return c.JSON(response)
+	if !to.After(from) {
+		return c.Status(400).JSON(fiber.Map{"error": "to must be after from"})
+	}
+
+	samples, err := config.GlobalAppState.Storage.GetHostSamples(from, to)
+	if err != nil {
+		logger.Default().WithComponent("api").Error("Failed to load host samples", "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load host samples",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled":   config.GlobalAppState.Config.SelfMetrics.Enabled,
+		"interval":  config.GlobalAppState.Config.SelfMetrics.Interval.String(),
+		"from":      from,
+		"to":        to,
+		"samples":   samples,
+		"count":     len(samples),
+		"timestamp": responseTime(c),
+	})
 }
 
-// HandleHealth - GET /api/health - Health check endpoint
-func HandleHealth(c *fiber.Ctx) error {
+// HandleGetFleetHistory - GET /api/fleet/history - Snapshots of the online, offline and degraded
+// site counts, oldest first. ?from=&to= (RFC 3339) default to the last 24h
+func HandleGetFleetHistory(c *fiber.Ctx) error {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid to %q (expected RFC 3339)", value)})
+		}
+		to = parsed
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid from %q (expected RFC 3339)", value)})
+		}
+		from = parsed
+	}
+	if !to.After(from) {
+		return c.Status(400).JSON(fiber.Map{"error": "to must be after from"})
+	}
+
+	snapshots, err := config.GlobalAppState.Storage.GetFleetSnapshots(from, to)
+	if err != nil {
+		logger.Default().WithComponent("api").Error("Failed to load fleet snapshots", "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load fleet snapshots",
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"status":    "ok",
-		"timestamp": time.Now(),
-		"uptime":    time.Since(config.GlobalAppState.StartTime).Seconds(),
+		"enabled":   config.GlobalAppState.Config.FleetHistory.Enabled,
+		"interval":  config.GlobalAppState.Config.FleetHistory.Interval.String(),
+		"from":      from,
+		"to":        to,
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleGetSiteDiagnostics - GET /api/sites/:siteId/diagnostics - Reply size verification
+// state and the latest size sweep per line. ?from=&to= (RFC 3339, default the last 24h)
+// also return all sweep results of the period
+func HandleGetSiteDiagnostics(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	site, exists := config.GlobalAppState.FindSite(siteID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid to %q (expected RFC 3339)", value)})
+		}
+		to = parsed
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid from %q (expected RFC 3339)", value)})
+		}
+		from = parsed
+	}
+	if !to.After(from) {
+		return c.Status(400).JSON(fiber.Map{"error": "to must be after from"})
+	}
+
+	sweeps, err := config.GlobalAppState.Storage.GetSizeSweeps(siteID, from, to)
+	if err != nil {
+		logger.Default().WithComponent("api").Error("Failed to load size sweeps", "error", err, "site_id", siteID)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load size sweeps",
+		})
+	}
+
+	var primaryDegraded, secondaryDegraded bool
+	if status, exists := config.GlobalAppState.GetSiteStatus(siteID); exists {
+		primaryDegraded, secondaryDegraded = status.PrimaryDegraded, status.SecondaryDegraded
+	}
+
+	return c.JSON(fiber.Map{
+		"site_id": siteID,
+		"reply_size": fiber.Map{
+			"verify":             config.GlobalAppState.Config.Ping.VerifyReplySize,
+			"packet_size":        config.GlobalAppState.Config.Ping.PacketSize,
+			"degraded_percent":   config.GlobalAppState.Config.Ping.CorruptedDegradedPercent,
+			"primary_degraded":   primaryDegraded,
+			"secondary_degraded": secondaryDegraded,
+		},
+		"size_sweep": site.SizeSweep,
+		"latest":     ping.SummarizeSizeSweeps(sweeps),
+		"from":       from,
+		"to":         to,
+		"sweeps":     sweeps,
+		"count":      len(sweeps),
+		"timestamp":  responseTime(c),
+	})
+}
+
+// HandleExportSiteSLAReport - GET /api/sites/:siteId/sla-report/export - Monthly SLA compliance report as HTML or PDF
+func HandleExportSiteSLAReport(c *fiber.Ctx) error {
+	return sendSLAReport(c, c.Query("month"), "attachment")
+}
+
+// HandleGetSiteReport - GET /api/sites/:siteId/report - Monthly SLA compliance report shown inline.
+// ?period=2024-01 (default last month) and ?format=html|pdf as for the export
+func HandleGetSiteReport(c *fiber.Ctx) error {
+	return sendSLAReport(c, c.Query("period"), "inline")
+}
+
+// monthlyPDFSlot lets one monthly PDF report be generated at a time
+var monthlyPDFSlot = make(chan struct{}, 1)
+
+// HandleGetMonthlyPDFReport - GET /api/reports/monthly.pdf - SLA reports of all enabled sites as PDF.
+// ?month=2024-01 (default last month) or ?from=2024-01&to=2024-06, at most 12 months
+func HandleGetMonthlyPDFReport(c *fiber.Ctx) error {
+	appState := config.GlobalAppState
+	now := time.Now().UTC()
+	
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" && to == "" {
+		from = c.Query("month", now.AddDate(0, -1, 0).Format(stats.SLAReportMonthFormat))
+		to = from
+	}
+	if from == "" || to == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "from and to are both required",
+		})
+	}
+	first, err := stats.ParseSLAReportMonth(from, now)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	last, err := stats.ParseSLAReportMonth(to, now)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if last.Before(first) || last.After(first.AddDate(0, reports.MaxPDFMonths-1, 0)) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("from must not be after to and the period must not exceed %d months", reports.MaxPDFMonths),
+		})
+	}
+	
+	select {
+	case monthlyPDFSlot <- struct{}{}:
+		defer func() { <-monthlyPDFSlot }()
+	default:
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Another PDF report is being generated, try again later",
+		})
+	}
+	
+	ctx, cancel := context.WithTimeout(c.UserContext(), appState.Config.Reports.PDF.Timeout)
+	defer cancel()
+	document, err := reports.BuildMonthlyPDF(ctx, appState, first, last, middleware.GetLocale(c))
+	if err != nil {
+		log := logger.Default().WithComponent("api")
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("Monthly PDF report timed out", "from", from, "to", to, "timeout", appState.Config.Reports.PDF.Timeout.String())
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "PDF report generation timed out (reports.pdf.timeout)",
+			})
+		}
+		log.Error("Failed to generate monthly PDF report", "from", from, "to", to, "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to generate PDF report",
+		})
+	}
+	
+	filename := "sitewatch-report-" + from
+	if to != from {
+		filename += "-" + to
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+	
+	// The document is serialized straight into the response
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := document.Write(w); err != nil {
+			logger.Default().WithComponent("api").Warn("Failed to write monthly PDF report", "error", err)
+		}
+		w.Flush()
 	})
-}
\ No newline at end of file
+	return nil
+}
+
+// sendSLAReport renders the SLA compliance report of the :siteId site for a
+// YYYY-MM month, the last complete month when empty, as HTML or, with
+// ?format=pdf, as PDF when the converter is available. disposition is the
+// Content-Disposition type, "attachment" or "inline".
+func sendSLAReport(c *fiber.Ctx, month, disposition string) error {
+	siteID := c.Params("siteId")
+	
+	site, exists := config.GlobalAppState.FindSite(siteID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	reportFormat := c.Query("format", "html")
+	if reportFormat != "html" && reportFormat != "pdf" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "format must be html or pdf",
+		})
+	}
+	
+	// Default to the last complete month
+	now := time.Now().UTC()
+	if month == "" {
+		month = now.AddDate(0, -1, 0).Format(stats.SLAReportMonthFormat)
+	}
+	monthStart, err := stats.ParseSLAReportMonth(month, now)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	
+	locale := middleware.GetLocale(c)
+	report, err := stats.GenerateSLAReport(config.GlobalAppState, *site, monthStart, locale)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to generate SLA report",
+		})
+	}
+	
+	var html bytes.Buffer
+	if err := c.App().Config().Views.Render(&html, "reports/sla-report", fiber.Map{
+		"Report": report,
+		"Locale": locale,
+	}); err != nil {
+		return err
+	}
+	
+	filename := fmt.Sprintf("sla-report-%s-%s", siteID, report.Month)
+	if reportFormat == "pdf" {
+		pdf, err := export.HTMLToPDF(c.UserContext(), config.GlobalAppState.Config.Reports.WkhtmltopdfPath, html.Bytes())
+		if err == nil {
+			c.Set(fiber.HeaderContentType, "application/pdf")
+			c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`%s; filename="%s.pdf"`, disposition, filename))
+			return c.Send(pdf)
+		}
+		
+		// PDF conversion is optional, fall back to the HTML document
+		log := logger.Default().WithComponent("api").WithSite(siteID, site.Name)
+		log.Warn("PDF conversion failed, serving SLA report as HTML", "error", err)
+	}
+	
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`%s; filename="%s.html"`, disposition, filename))
+	return c.Send(html.Bytes())
+}
+
+// HandleGetSiteRecentChecks - GET /api/sites/:siteId/recent-checks - Last N checks per line
+func HandleGetSiteRecentChecks(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	perLine := stats.DefaultRecentChecksPerLine
+	if perLineParam := c.Query("per_line"); perLineParam != "" {
+		parsed, err := strconv.Atoi(perLineParam)
+		if err != nil || parsed <= 0 || parsed > stats.MaxRecentChecksPerLine {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "per_line must be between 1 and " + strconv.Itoa(stats.MaxRecentChecksPerLine),
+			})
+		}
+		perLine = parsed
+	}
+	
+	checks := stats.GetRecentChecks(config.GlobalAppState, siteID, perLine)
+	
+	return c.JSON(fiber.Map{
+		"site_id":   siteID,
+		"per_line":  perLine,
+		"primary":   recentChecksForVersion(c, checks.Primary),
+		"secondary": recentChecksForVersion(c, checks.Secondary),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleGetSiteCurrent - GET /api/sites/:siteId/current - Latest raw check of each line
+// The full result with the packet statistics, without any aggregation
+func HandleGetSiteCurrent(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	site, exists := config.GlobalAppState.FindSite(siteID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	logs, err := config.GlobalAppState.Storage.GetRecentLogsPerTarget(siteID, 1)
+	if err != nil {
+		logger.Default().WithComponent("api").Error("Failed to load latest checks", "site_id", siteID, "error", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":       "Check history is unavailable",
+			"unavailable": true,
+		})
+	}
+	
+	// Lines without a check yet are null, single-line sites have no secondary
+	response := fiber.Map{
+		"site_id":   siteID,
+		"primary":   nil,
+		"timestamp": responseTime(c),
+	}
+	if site.IsDualLine() {
+		response["secondary"] = nil
+	}
+	for _, pingLog := range logs {
+		if _, isLine := response[pingLog.Target]; isLine {
+			response[pingLog.Target] = pingLog
+		}
+	}
+	return c.JSON(response)
+}
+
+// HandleGetSiteAvailabilityMatrix - GET /api/sites/:siteId/availability-matrix - Uptime per time slot
+func HandleGetSiteAvailabilityMatrix(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	period, resolution, err := parseAvailabilityParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	
+	matrix, err := stats.GenerateAvailabilityMatrix(config.GlobalAppState, siteID, period, resolution)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to generate availability matrix",
+		})
+	}
+	
+	return c.JSON(fiber.Map{
+		"site_id":    siteID,
+		"period":     c.Query("period", "7d"),
+		"resolution": c.Query("resolution", "1h"),
+		"columns":    stats.AvailabilityMatrixColumns,
+		"labels":     matrix.Labels,
+		"matrix":     matrix.Rows,
+		"timestamp":  responseTime(c),
+	})
+}
+
+// HandleGetAvailabilityMatrix - GET /api/availability-matrix - Uptime per time slot for all sites
+func HandleGetAvailabilityMatrix(c *fiber.Ctx) error {
+	period, resolution, err := parseAvailabilityParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	
+	matrices, err := stats.GenerateAvailabilityMatrices(config.GlobalAppState, period, resolution)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to generate availability matrix",
+		})
+	}
+	
+	var labels []string
+	sites := make(map[string][][3]float64, len(matrices))
+	for siteID, matrix := range matrices {
+		sites[siteID] = matrix.Rows
+		labels = matrix.Labels
+	}
+	
+	return c.JSON(fiber.Map{
+		"sites":      sites,
+		"period":     c.Query("period", "7d"),
+		"resolution": c.Query("resolution", "1h"),
+		"columns":    stats.AvailabilityMatrixColumns,
+		"labels":     labels,
+		"timestamp":  responseTime(c),
+	})
+}
+
+// parseAvailabilityParams parses and validates the period/resolution query parameters
+func parseAvailabilityParams(c *fiber.Ctx) (time.Duration, time.Duration, error) {
+	period, err := stats.ParsePeriod(c.Query("period", "7d"))
+	if err != nil {
+		return 0, 0, err
+	}
+	resolution, err := stats.ParsePeriod(c.Query("resolution", "1h"))
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := stats.ValidateAvailabilityRange(period, resolution); err != nil {
+		return 0, 0, err
+	}
+	return period, resolution, nil
+}
+
+// HandleSiteTest - POST /api/sites/:siteId/test - Run manual ping test
+func HandleSiteTest(c *fiber.Ctx) error {
+	siteID := c.Params("siteId")
+	
+	if !config.GlobalAppState.Config.IsPingEnabled() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Active probing is disabled (ping.enabled: false)",
+		})
+	}
+	
+	// Find the site
+	site, exists := config.GlobalAppState.FindSite(siteID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	// Both lines are pinged concurrently within ping.test_timeout
+	response := struct {
+		models.SiteTestResult
+		Actor string `json:"actor"`
+	}{SiteTestResult: ping.TestSite(c.UserContext(), config.GlobalAppState, *site)}
+	
+	response.Actor = middleware.Audit(c, "site.test", "site_id", site.ID)
+	return c.JSON(response)
+}
+
+// HandleTestAllSites - POST /api/test-all - Test every enabled site now, e.g. after maintenance
+func HandleTestAllSites(c *fiber.Ctx) error {
+	if !config.GlobalAppState.Config.IsPingEnabled() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Active probing is disabled (ping.enabled: false)",
+		})
+	}
+	
+	results, retryAfter, err := ping.TestAllSites(c.UserContext(), config.GlobalAppState)
+	switch {
+	case errors.Is(err, ping.ErrTestAllCooldown):
+		seconds := int((retryAfter + time.Second - 1) / time.Second) // Rounded up
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":       err.Error(),
+			"retry_after": seconds,
+		})
+	case errors.Is(err, ping.ErrTestAllRunning):
+		return c.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	
+	succeeded := 0
+	for _, result := range results {
+		if result.Success() {
+			succeeded++
+		}
+	}
+	return c.JSON(fiber.Map{
+		"results":   results,
+		"count":     len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"actor":     middleware.Audit(c, "site.test_all", "sites", len(results), "failed", len(results)-succeeded),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleHealth - GET /api/health - Health check endpoint
+func HandleHealth(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":    "ok",
+		"timestamp": responseTime(c),
+		"uptime":    time.Since(config.GlobalAppState.StartTime).Seconds(),
+	})
+}
+
+// HandleGetRuntime - GET /api/admin/runtime - Effective runtime configuration captured at startup
+func HandleGetRuntime(c *fiber.Ctx) error {
+	if config.GlobalAppState.Runtime == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Startup has not completed",
+		})
+	}
+	return c.JSON(config.GlobalAppState.Runtime)
+}
+
+// HandleGetJobs - GET /api/admin/jobs - Background jobs with their schedule and last run
+func HandleGetJobs(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"jobs":      jobs.List(),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleRunJob - POST /api/admin/jobs/:name/run - Run a background job now
+func HandleRunJob(c *fiber.Ctx) error {
+	name := c.Params("name")
+	err := jobs.Trigger(name)
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, jobs.ErrJobRunning):
+		return c.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(503).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(202).JSON(fiber.Map{
+		"job":       name,
+		"triggered": true,
+		"actor":     middleware.Audit(c, "job.run", "job", name),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleOptimizeStorage - POST /api/storage/optimize - Run the database maintenance now
+// VACUUM is included unless ?vacuum=false, writes wait until it is finished
+func HandleOptimizeStorage(c *fiber.Ctx) error {
+	vacuum := c.QueryBool("vacuum", true)
+	result, err := housekeeping.OptimizeStorage(config.GlobalAppState, vacuum)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to optimize storage: " + err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"result":    result,
+		"actor":     middleware.Audit(c, "storage.optimize", "vacuum", vacuum),
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleGetAPISLAReport - GET /api/admin/api-sla-report - Response time percentiles and SLA violations per route
+func HandleGetAPISLAReport(c *fiber.Ctx) error {
+	routes, err := middleware.ResponseTimeReport(config.GlobalAppState.Config.Server.ResponseSLAMs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read response time metrics",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"routes":    routes,
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleGetCircuitBreakers - GET /api/admin/circuit-breakers - State of all circuit breakers
+func HandleGetCircuitBreakers(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"circuit_breakers": ping.GetGlobalCircuitBreakerManager().GetStats(),
+		"timestamp":        responseTime(c),
+	})
+}
+
+// HandleOpenCircuitBreaker - POST /api/admin/circuit-breakers/:siteId/:line/open - Suppress checks of a line
+func HandleOpenCircuitBreaker(c *fiber.Ctx) error {
+	siteID, line := c.Params("siteId"), c.Params("line")
+	if status, err := validateSiteLine(siteID, line); err != nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(attributedCircuitBreaker{
+		CircuitBreakerStats: ping.GetGlobalCircuitBreakerManager().ForceOpen(siteID, line),
+		Actor:               middleware.Audit(c, "circuit_breaker.open", "site_id", siteID, "line", line),
+	})
+}
+
+// HandleResetCircuitBreaker - POST /api/admin/circuit-breakers/:siteId/:line/reset - Resume checks of a line
+func HandleResetCircuitBreaker(c *fiber.Ctx) error {
+	siteID, line := c.Params("siteId"), c.Params("line")
+	if status, err := validateSiteLine(siteID, line); err != nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(attributedCircuitBreaker{
+		CircuitBreakerStats: ping.GetGlobalCircuitBreakerManager().Reset(siteID, line),
+		Actor:               middleware.Audit(c, "circuit_breaker.reset", "site_id", siteID, "line", line),
+	})
+}
+
+// attributedCircuitBreaker is a circuit breaker changed by a request, with
+// the actor that changed it
+type attributedCircuitBreaker struct {
+	ping.CircuitBreakerStats
+	Actor string `json:"actor"`
+}
+
+// HandleGetWebhookDLQ - GET /api/admin/webhooks/dlq - Undelivered webhook events (?limit=50&offset=0)
+func HandleGetWebhookDLQ(c *fiber.Ctx) error {
+	limit := 50
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 && parsed <= 500 {
+		limit = parsed
+	}
+	offset := 0
+	if parsed, err := strconv.Atoi(c.Query("offset")); err == nil && parsed > 0 {
+		offset = parsed
+	}
+	
+	entries, total, err := config.GlobalAppState.Storage.GetWebhookDLQ(limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to read dead letter queue",
+		})
+	}
+	
+	return c.JSON(fiber.Map{
+		"entries":   entries,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+		"timestamp": responseTime(c),
+	})
+}
+
+// HandleRetryWebhookDLQ - POST /api/admin/webhooks/dlq/retry - Redeliver all undelivered webhook events
+func HandleRetryWebhookDLQ(c *fiber.Ctx) error {
+	result, err := notify.RetryDeadLetters(c.UserContext(), config.GlobalAppState)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to read dead letter queue",
+		})
+	}
+	return c.JSON(attributedRetry{
+		RetryResult: result,
+		Actor:       middleware.Audit(c, "webhook_dlq.retry", "delivered", len(result.Delivered), "failed", len(result.Failed)),
+	})
+}
+
+// HandleRetryWebhookDLQEntry - POST /api/admin/webhooks/dlq/:id/retry - Redeliver one undelivered webhook event
+func HandleRetryWebhookDLQEntry(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid entry ID",
+		})
+	}
+	
+	result, err := notify.RetryDeadLetter(c.UserContext(), config.GlobalAppState, id)
+	if errors.Is(err, notify.ErrDeadLetterNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to read dead letter queue",
+		})
+	}
+	return c.JSON(attributedRetry{
+		RetryResult: result,
+		Actor:       middleware.Audit(c, "webhook_dlq.retry", "entry_id", id, "delivered", len(result.Delivered) > 0),
+	})
+}
+
+// attributedRetry is the result of a dead letter redelivery with the actor
+// that requested it
+type attributedRetry struct {
+	notify.RetryResult
+	Actor string `json:"actor"`
+}
+
+// HandleTestNotifications - POST /api/notifications/test - Send a test event through all notifiers (?notifier=webhook for one)
+func HandleTestNotifications(c *fiber.Ctx) error {
+	results, err := notify.Test(c.UserContext(), c.Query("notifier"))
+	if errors.Is(err, notify.ErrNoNotifiers) || errors.Is(err, notify.ErrUnknownNotifier) {
+		return c.Status(404).JSON(fiber.Map{
+			"error":     err.Error(),
+			"notifiers": notify.Notifiers(),
+		})
+	}
+	
+	success := true
+	for _, result := range results {
+		success = success && result.Success
+	}
+	return c.JSON(fiber.Map{
+		"success":   success,
+		"results":   results,
+		"actor":     middleware.Audit(c, "notifications.test", "notifier", c.Query("notifier"), "success", success),
+		"timestamp": responseTime(c),
+	})
+}
+
+// SimulationRequest is the body of POST /api/admin/simulate/:siteId
+type SimulationRequest struct {
+	Target   string  `json:"target"` // "primary" | "secondary", defaults to primary
+	Mode     string  `json:"mode"`   // down, latency or loss
+	Value    float64 `json:"value"`  // Latency in ms or packet loss in percent
+	Duration string  `json:"duration"`
+}
+
+// HandleStartSimulation - POST /api/admin/simulate/:siteId - Inject synthetic failures into the results of a line
+func HandleStartSimulation(c *fiber.Ctx) error {
+	if !config.GlobalAppState.Config.Server.AllowSimulation {
+		return simulationDisabled(c)
+	}
+	
+	var req SimulationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Target == "" {
+		req.Target = "primary"
+	}
+	
+	// Params point into Fiber's reused request buffer, the simulation outlives the request
+	siteID := strings.Clone(c.Params("siteId"))
+	if status, err := validateSiteLine(siteID, req.Target); err != nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "duration must be a Go duration such as 10m",
+		})
+	}
+	
+	simulation, err := ping.StartSimulation(siteID, req.Target, req.Mode, req.Value, duration)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(201).JSON(struct {
+		models.Simulation
+		Actor string `json:"actor"`
+	}{simulation, middleware.Audit(c, "simulation.start", "site_id", siteID, "target", req.Target, "mode", req.Mode, "duration", duration)})
+}
+
+// HandleGetSimulations - GET /api/admin/simulate - List active outage simulations
+func HandleGetSimulations(c *fiber.Ctx) error {
+	if !config.GlobalAppState.Config.Server.AllowSimulation {
+		return simulationDisabled(c)
+	}
+	
+	return c.JSON(fiber.Map{
+		"simulations": ping.ActiveSimulations(""),
+		"timestamp":   responseTime(c),
+	})
+}
+
+// HandleCancelSimulation - DELETE /api/admin/simulate/:siteId - Cancel the simulations of a site (?target= for one line)
+func HandleCancelSimulation(c *fiber.Ctx) error {
+	if !config.GlobalAppState.Config.Server.AllowSimulation {
+		return simulationDisabled(c)
+	}
+	
+	siteID := c.Params("siteId")
+	if _, exists := config.GlobalAppState.FindSite(siteID); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Site not found",
+		})
+	}
+	
+	cancelled := ping.CancelSimulation(siteID, c.Query("target"))
+	if len(cancelled) == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "No active simulation",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"cancelled": cancelled,
+		"actor":     middleware.Audit(c, "simulation.cancel", "site_id", siteID, "cancelled", len(cancelled)),
+	})
+}
+
+// simulationDisabled rejects simulation requests unless server.allow_simulation is set
+func simulationDisabled(c *fiber.Ctx) error {
+	return c.Status(403).JSON(fiber.Map{
+		"error": "Outage simulation is disabled (server.allow_simulation)",
+		"code":  "SIMULATION_DISABLED",
+	})
+}
+
+// validateSiteLine checks that a site exists and has the given line,
+// returning the HTTP status to respond with otherwise
+func validateSiteLine(siteID, line string) (int, error) {
+	site, exists := config.GlobalAppState.FindSite(siteID)
+	if !exists {
+		return 404, errors.New("Site not found")
+	}
+	if line != "primary" && !(line == "secondary" && site.IsDualLine()) {
+		return 400, errors.New("Invalid line, must be primary or secondary (dual-line sites only)")
+	}
+	return 0, nil
+}
+
+// HandleGetDiscoveryCandidates - GET /api/discovery/candidates - Responsive addresses found by the subnet scan
+func HandleGetDiscoveryCandidates(c *fiber.Ctx) error {
+	candidates, err := config.GlobalAppState.Storage.GetDiscoveryCandidates()
+	if err != nil {
+		logger.Default().WithComponent("api").Error("Failed to load discovery candidates", "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load discovery candidates",
+		})
+	}
+	
+	return c.JSON(fiber.Map{
+		"candidates": candidates,
+		"count":      len(candidates),
+		"timestamp":  responseTime(c),
+	})
+}
+
+// PromoteRequest is the body of POST /api/discovery/promote/:ip
+type PromoteRequest struct {
+	ID       string `json:"id"` // Defaults to discovered-<ip>
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Interval int    `json:"interval"` // Seconds, 0 uses ping.default_interval
+}
+
+// HandlePromoteDiscoveryCandidate - POST /api/discovery/promote/:ip - Create a single-line site from a discovery candidate
+func HandlePromoteDiscoveryCandidate(c *fiber.Ctx) error {
+	appState := config.GlobalAppState
+	log := logger.Default().WithComponent("api")
+	
+	var req PromoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || req.Interval < 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "name is required and interval must not be negative",
+		})
+	}
+	
+	// Params point into Fiber's reused request buffer, the site outlives the request
+	ip := strings.Clone(c.Params("ip"))
+	candidates, err := appState.Storage.GetDiscoveryCandidates()
+	if err != nil {
+		log.Error("Failed to load discovery candidates", "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to load discovery candidates",
+		})
+	}
+	known := false
+	for _, candidate := range candidates {
+		if candidate.IP == ip {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Discovery candidate not found",
+		})
+	}
+	
+	// Addresses already monitored are left to AddSite, which rejects them
+	// with validation.strict and only warns otherwise
+	site := models.Site{
+		ID:        strings.TrimSpace(req.ID),
+		Name:      req.Name,
+		Location:  strings.TrimSpace(req.Location),
+		PrimaryIP: ip,
+		Interval:  req.Interval,
+		Enabled:   true,
+	}
+	if site.ID == "" {
+		site.ID = "discovered-" + strings.NewReplacer(".", "-", ":", "-").Replace(ip)
+	}
+	
+	if err := appState.AddSite(site); err != nil {
+		if errors.Is(err, config.ErrSiteExists) || errors.Is(err, config.ErrAddressInUse) {
+			return c.Status(409).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Error("Failed to add discovered site", "site_id", site.ID, "ip", ip, "error", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to add site",
+		})
+	}
+	ping.StartSiteWorker(appState, site)
+	
+	if err := appState.Storage.DeleteDiscoveryCandidate(ip); err != nil {
+		log.Warn("Failed to remove promoted discovery candidate", "ip", ip, "error", err)
+	}
+	log.Info("Discovery candidate promoted to site", "site_id", site.ID, "site_name", site.Name, "ip", ip)
+	return c.Status(201).JSON(struct {
+		models.Site
+		Actor string `json:"actor"`
+	}{site, middleware.Audit(c, "discovery.promote", "site_id", site.ID, "ip", ip)})
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sitewatch/internal/middleware"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/stats"
+	"sitewatch/internal/storage"
+	"sitewatch/internal/testutil"
+)
+
+func TestPromoteDiscoveryCandidateConflicts(t *testing.T) {
+	monitored := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", SecondaryIP: "192.0.2.2", Enabled: true}
+
+	tests := []struct {
+		name      string
+		strict    bool
+		ip        string
+		body      string
+		status    int
+		wantError string
+		wantSites int
+	}{
+		{"new address", false, "192.0.2.10", `{"name": "Office"}`, fiber.StatusCreated, "", 2},
+		{"new address, strict", true, "192.0.2.10", `{"name": "Office"}`, fiber.StatusCreated, "", 2},
+		{"monitored address", false, "192.0.2.2", `{"name": "Office"}`, fiber.StatusCreated, "", 2},
+		{"monitored address, strict", true, "192.0.2.2", `{"name": "Office"}`, fiber.StatusConflict, "192.0.2.2 (site-001 secondary_ip", 1},
+		{"existing site ID", false, "192.0.2.10", `{"id": "site-001", "name": "Office"}`, fiber.StatusConflict, "site already exists", 1},
+		{"unknown candidate", false, "192.0.2.99", `{"name": "Office"}`, fiber.StatusNotFound, "Discovery candidate not found", 1},
+		{"missing name", false, "192.0.2.10", `{}`, fiber.StatusBadRequest, "name is required", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SITEWATCH_SITES_PATH", filepath.Join(t.TempDir(), "sites.yaml"))
+			appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{monitored}, Clock: true, Global: true})
+			appState.Config.Validation.Strict = tt.strict
+			for _, ip := range []string{"192.0.2.10", "192.0.2.2"} {
+				if err := appState.Storage.UpsertDiscoveryCandidate(models.DiscoveryCandidate{IP: ip, FirstSeen: testutil.Now, LastSeen: testutil.Now}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			app := fiber.New()
+			app.Post("/api/discovery/promote/:ip", HandlePromoteDiscoveryCandidate)
+			req := httptest.NewRequest("POST", "/api/discovery/promote/"+tt.ip, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d: %s", resp.StatusCode, tt.status, body)
+			}
+
+			if tt.wantError != "" {
+				var response struct{ Error string }
+				json.Unmarshal(body, &response)
+				if !strings.Contains(response.Error, tt.wantError) {
+					t.Errorf("error %q, want it to contain %q", response.Error, tt.wantError)
+				}
+			}
+			if sites := appState.GetSitesSnapshot(); len(sites) != tt.wantSites {
+				t.Errorf("%d sites, want %d", len(sites), tt.wantSites)
+			}
+
+			candidates, _ := appState.Storage.GetDiscoveryCandidates()
+			promoted := tt.status == fiber.StatusCreated
+			for _, candidate := range candidates {
+				if candidate.IP == tt.ip && promoted {
+					t.Error("promoted candidate still listed")
+				}
+			}
+			if !promoted && len(candidates) != 2 {
+				t.Errorf("%d candidates left after a rejected promotion, want 2", len(candidates))
+			}
+		})
+	}
+}
+
+// getJSON serves path with handler registered at route and decodes the JSON response
+func getJSON(t *testing.T, route, path string, handler fiber.Handler) map[string]interface{} {
+	t.Helper()
+
+	app := fiber.New()
+	app.Get(route, middleware.APIVersion(middleware.APIVersionV1), handler)
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", path, resp.StatusCode, body)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	return response
+}
+
+// A single-line site has no secondary data, where a dual-line site with the
+// same primary line has it next to identical primary data
+func TestSingleAndDualLineSites(t *testing.T) {
+	single := models.Site{ID: "site-single", Name: "Hamburg", PrimaryIP: "192.0.2.1", Enabled: true}
+	dual := models.Site{ID: "site-dual", Name: "Berlin", PrimaryIP: "192.0.2.2", SecondaryIP: "192.0.2.3", Enabled: true}
+	appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{single, dual}, Clock: true, Global: true})
+	latency := 12.5
+	loss := 0.0
+	for i := 1; i <= 30; i++ {
+		at := testutil.Now.Add(-time.Duration(i) * 2 * time.Minute)
+		logs := []models.PingLog{
+			{Timestamp: at, SiteID: single.ID, Target: "primary", IP: single.PrimaryIP, Success: true, Latency: &latency, PacketLoss: &loss, PacketsSent: 3, PacketsRecv: 3},
+			{Timestamp: at, SiteID: dual.ID, Target: "primary", IP: dual.PrimaryIP, Success: true, Latency: &latency, PacketLoss: &loss, PacketsSent: 3, PacketsRecv: 3},
+			{Timestamp: at, SiteID: dual.ID, Target: "secondary", IP: dual.SecondaryIP, Success: i%3 != 0, Latency: &latency, PacketLoss: &loss, PacketsSent: 3, PacketsRecv: 3},
+		}
+		for _, log := range logs {
+			if err := appState.Storage.AddPingLog(log); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	secondaryFields := []string{"uptime_secondary", "mean_latency_secondary", "secondary_uptime_24h", "current_latency_secondary", "packet_loss_secondary"}
+	statistics := map[string]map[string]interface{}{}
+	for _, site := range []models.Site{single, dual} {
+		response := getJSON(t, "/api/v1/sites/:siteId/statistics", "/api/v1/sites/"+site.ID+"/statistics", HandleGetSiteStatistics)
+		statistics[site.ID] = response["statistics"].(map[string]interface{})
+	}
+	if statistics[single.ID]["dual_line"] != false || statistics[dual.ID]["dual_line"] != true {
+		t.Errorf("dual_line %v and %v, want false and true", statistics[single.ID]["dual_line"], statistics[dual.ID]["dual_line"])
+	}
+	for _, field := range secondaryFields {
+		if value, present := statistics[single.ID][field]; present {
+			t.Errorf("single-line statistics have %s = %v", field, value)
+		}
+		if _, present := statistics[dual.ID][field]; !present {
+			t.Errorf("dual-line statistics lack %s", field)
+		}
+	}
+	for siteID, lines := range map[string]int{single.ID: 1, dual.ID: 2} {
+		if providers := statistics[siteID]["provider_stats"].(map[string]interface{}); len(providers) != lines {
+			t.Errorf("%s: provider_stats for %d lines, want %d", siteID, len(providers), lines)
+		}
+	}
+	singlePrimary := statistics[single.ID]["provider_stats"].(map[string]interface{})["primary"]
+	dualPrimary := statistics[dual.ID]["provider_stats"].(map[string]interface{})["primary"]
+	if !reflect.DeepEqual(singlePrimary, dualPrimary) {
+		t.Errorf("primary statistics differ:\nsingle %v\ndual   %v", singlePrimary, dualPrimary)
+	}
+
+	for _, query := range []string{"type=uptime&range=24h", "type=latency&range=3h", "type=distribution&range=24h"} {
+		charts := map[string]map[string]interface{}{}
+		for _, site := range []models.Site{single, dual} {
+			response := getJSON(t, "/api/v1/sites/:siteId/charts", "/api/v1/sites/"+site.ID+"/charts?"+query, HandleGetSiteChartData)
+			charts[site.ID] = response["chart_data"].(map[string]interface{})
+		}
+		if secondary := charts[single.ID]["SecondaryData"]; secondary != nil {
+			t.Errorf("%s: single-line secondary series %v, want null", query, secondary)
+		}
+		if charts[dual.ID]["PrimaryData"] == nil || charts[dual.ID]["SecondaryData"] == nil {
+			t.Errorf("%s: dual-line series %v and %v, want both", query, charts[dual.ID]["PrimaryData"], charts[dual.ID]["SecondaryData"])
+		}
+		if !reflect.DeepEqual(charts[single.ID]["PrimaryData"], charts[dual.ID]["PrimaryData"]) {
+			t.Errorf("%s: primary series differ: %v and %v", query, charts[single.ID]["PrimaryData"], charts[dual.ID]["PrimaryData"])
+		}
+	}
+
+	app := fiber.New()
+	app.Get("/metrics", HandlePrometheusMetrics)
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	lines := map[string]bool{}
+	for _, line := range strings.Split(string(body), "\n") {
+		if !strings.HasPrefix(line, "site_status{") {
+			continue
+		}
+		for _, site := range []models.Site{single, dual} {
+			for _, lineType := range []string{"primary", "secondary"} {
+				if strings.Contains(line, `site_id="`+site.ID+`"`) && strings.Contains(line, `line_type="`+lineType+`"`) {
+					lines[site.ID+"/"+lineType] = true
+				}
+			}
+		}
+	}
+	want := map[string]bool{"site-single/primary": true, "site-dual/primary": true, "site-dual/secondary": true}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("site_status series %v, want %v", lines, want)
+	}
+}
+
+// unreadableStorage is a memory storage whose log reads fail
+type unreadableStorage struct {
+	*storage.MemoryStorage
+}
+
+func (s unreadableStorage) GetAllLogs() ([]models.PingLog, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+// Statistics that cannot be read are a 503 with the unavailable marker,
+// never a 200 with zero uptime
+func TestSiteStatisticsStorageError(t *testing.T) {
+	site := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true}
+	appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{site}, Clock: true, Global: true})
+	appState.Storage = unreadableStorage{appState.Storage.(*storage.MemoryStorage)}
+
+	app := fiber.New()
+	app.Get("/api/v1/sites/:siteId/statistics", middleware.APIVersion(middleware.APIVersionV1), HandleGetSiteStatistics)
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/sites/site-001/statistics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503: %s", resp.StatusCode, body)
+	}
+
+	var response struct {
+		Error      string
+		SiteID     string `json:"site_id"`
+		Statistics map[string]interface{}
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != "Statistics unavailable" || response.SiteID != site.ID {
+		t.Errorf("response %s, want the statistics unavailable error of %s", body, site.ID)
+	}
+	if response.Statistics["unavailable"] != true {
+		t.Errorf("statistics %v, want them marked unavailable", response.Statistics)
+	}
+}
+
+// Every chart type and range of the matrix returns data from the UI and the
+// API chart endpoint, every other combination is a 400 listing the valid ones
+func TestChartRangeMatrix(t *testing.T) {
+	site := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true}
+	latency := 10.0
+	testutil.NewAppState(t, testutil.Options{
+		Sites:  []models.Site{site},
+		Logs:   []models.PingLog{{Timestamp: testutil.Now.Add(-time.Minute), SiteID: site.ID, Target: "primary", IP: site.PrimaryIP, Success: true, Latency: &latency}},
+		Clock:  true,
+		Global: true,
+	})
+
+	app := fiber.New()
+	app.Get("/ui/chart-data/:siteId/:chartType/:range", HandleUIChartData)
+	app.Get("/api/v1/sites/:siteId/charts", middleware.APIVersion(middleware.APIVersionV1), HandleGetSiteChartData)
+	endpoints := []struct {
+		name string
+		path func(chartType, timeRange string) string
+		data func(response map[string]interface{}) interface{}
+	}{
+		{
+			"ui",
+			func(chartType, timeRange string) string {
+				return "/ui/chart-data/site-001/" + chartType + "/" + timeRange
+			},
+			func(response map[string]interface{}) interface{} { return response },
+		},
+		{
+			"api",
+			func(chartType, timeRange string) string {
+				return "/api/v1/sites/site-001/charts?type=" + chartType + "&range=" + timeRange
+			},
+			func(response map[string]interface{}) interface{} { return response["chart_data"] },
+		},
+	}
+
+	ranges := []string{"1h", "3h", "12h", "24h", "7d", "30d", "12m", "2h"}
+	for _, chartType := range append(stats.ChartTypes(), "heatmap") {
+		valid := make(map[string]bool)
+		for _, r := range stats.ChartRanges(chartType) {
+			valid[r] = true
+		}
+		for _, timeRange := range ranges {
+			for _, endpoint := range endpoints {
+				t.Run(endpoint.name+"/"+chartType+"/"+timeRange, func(t *testing.T) {
+					resp, err := app.Test(httptest.NewRequest("GET", endpoint.path(chartType, timeRange), nil))
+					if err != nil {
+						t.Fatal(err)
+					}
+					body, _ := io.ReadAll(resp.Body)
+					var response map[string]interface{}
+					if err := json.Unmarshal(body, &response); err != nil {
+						t.Fatalf("response is not a JSON object: %v\n%s", err, body)
+					}
+
+					if !valid[timeRange] {
+						_, listsRanges := response["valid_ranges"]
+						_, listsTypes := response["valid_types"]
+						if resp.StatusCode != fiber.StatusBadRequest || response["error"] == nil || !(listsRanges || listsTypes) {
+							t.Errorf("status %d: %s, want 400 with the valid ranges or types", resp.StatusCode, body)
+						}
+						return
+					}
+					if resp.StatusCode != fiber.StatusOK {
+						t.Fatalf("status %d: %s, want 200", resp.StatusCode, body)
+					}
+					data, ok := endpoint.data(response).(map[string]interface{})
+					if !ok || len(data) == 0 || data["error"] != nil {
+						t.Errorf("chart data %v, want data", endpoint.data(response))
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/config"
+	"sitewatch/internal/middleware"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/ping"
+)
+
+// maxIngestBatch caps the number of results accepted per request
+const maxIngestBatch = 500
+
+// IngestResult is a single externally measured check result
+type IngestResult struct {
+	SiteID      string     `json:"site_id"`
+	Target      string     `json:"target"` // "primary" | "secondary", defaults to primary
+	Success     bool       `json:"success"`
+	Latency     *float64   `json:"latency,omitempty"` // Milliseconds, required when success is true
+	PacketLoss  *float64   `json:"packet_loss,omitempty"`
+	PacketsSent int        `json:"packets_sent,omitempty"`
+	PacketsRecv int        `json:"packets_recv,omitempty"`
+	MinLatency  *float64   `json:"min_latency,omitempty"`
+	MaxLatency  *float64   `json:"max_latency,omitempty"`
+	Jitter      *float64   `json:"jitter,omitempty"`
+	TTL         *int       `json:"ttl,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"` // Defaults to the time of receipt
+}
+
+// IngestRequest is the body of POST /api/ingest
+type IngestRequest struct {
+	Results []IngestResult `json:"results"`
+}
+
+// IngestRejection explains why a result at a given index was not accepted
+type IngestRejection struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// HandleIngestResults - POST /api/ingest - Submit externally measured check results
+func HandleIngestResults(c *fiber.Ctx) error {
+	var req IngestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Results) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "No results provided",
+		})
+	}
+	if len(req.Results) > maxIngestBatch {
+		return c.Status(413).JSON(fiber.Map{
+			"error": fmt.Sprintf("Too many results, maximum is %d per request", maxIngestBatch),
+		})
+	}
+
+	accepted := 0
+	rejected := []IngestRejection{}
+	for i, item := range req.Results {
+		result, err := buildIngestResult(config.GlobalAppState, item)
+		if err == nil && !ping.SendResult(c.Context(), config.GlobalAppState, result) {
+			err = fmt.Errorf("result pipeline full")
+		}
+		if err != nil {
+			rejected = append(rejected, IngestRejection{Index: i, Error: err.Error()})
+			continue
+		}
+		accepted++
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"accepted": accepted,
+		"rejected": rejected,
+		"actor":    middleware.Audit(c, "results.ingest", "accepted", accepted, "rejected", len(rejected)),
+	})
+}
+
+// buildIngestResult validates an ingested result and converts it to a PingResult
+func buildIngestResult(app *config.AppState, item IngestResult) (models.PingResult, error) {
+	site, exists := app.FindSite(item.SiteID)
+	if !exists {
+		return models.PingResult{}, fmt.Errorf("site %q not found", item.SiteID)
+	}
+
+	target := item.Target
+	if target == "" {
+		target = "primary"
+	}
+
+	var ip string
+	switch target {
+	case "primary":
+		ip = site.PrimaryIP
+	case "secondary":
+		if !site.IsDualLine() {
+			return models.PingResult{}, fmt.Errorf("site %q has no secondary line", item.SiteID)
+		}
+		ip = site.SecondaryIP
+	default:
+		return models.PingResult{}, fmt.Errorf("invalid target %q, must be primary or secondary", target)
+	}
+
+	if item.Success && item.Latency == nil {
+		return models.PingResult{}, fmt.Errorf("latency is required for successful results")
+	}
+
+	timestamp := time.Now()
+	if item.Timestamp != nil {
+		timestamp = *item.Timestamp
+	}
+
+	return models.PingResult{
+		SiteID:      site.ID,
+		IP:          ip,
+		LineType:    target,
+		Success:     item.Success,
+		Latency:     item.Latency,
+		Error:       item.Error,
+		Timestamp:   timestamp,
+		PacketsSent: item.PacketsSent,
+		PacketsRecv: item.PacketsRecv,
+		PacketLoss:  item.PacketLoss,
+		MinLatency:  item.MinLatency,
+		MaxLatency:  item.MaxLatency,
+		Jitter:      item.Jitter,
+		TTL:         item.TTL,
+		Source:      models.ResultSourceIngest,
+	}, nil
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"sitewatch/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m, filepath.Join("..", ".."))
+}
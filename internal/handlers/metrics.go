@@ -8,10 +8,23 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"sitewatch/internal/config"
+	"sitewatch/internal/models"
 )
 
-// HandlePrometheusMetrics - GET /metrics - Prometheus format metrics
+// missingSiteLabelValue is exported for configured site labels a site has no metadata for
+const missingSiteLabelValue = "unknown"
+
+// labelValueEscaper escapes label values for the Prometheus text format
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// HandlePrometheusMetrics - GET /metrics - Prometheus format metrics. ?site= limits the
+// per-site series to a site ID, glob or regular expression, the app totals are always included
 func HandlePrometheusMetrics(c *fiber.Ctx) error {
+	pattern, err := parseSitePattern(c.Query("site"))
+	if err != nil {
+		return c.Status(400).SendString(err.Error())
+	}
+	
 	// Set content type for Prometheus
 	c.Set("Content-Type", "text/plain; charset=utf-8")
 	
@@ -36,16 +49,18 @@ func HandlePrometheusMetrics(c *fiber.Ctx) error {
 	defer config.GlobalAppState.Mu.RUnlock()
 	
 	// Export site status metrics
+	siteLabelKeys := config.GlobalAppState.Config.Metrics.SiteLabels
 	for _, site := range config.GlobalAppState.Sites {
 		status, exists := config.GlobalAppState.SiteStatus[site.ID]
-		if !exists {
+		if !exists || !pattern.Match(site.ID) {
 			continue
 		}
+		siteLabels := siteMetricLabels(site, siteLabelKeys)
 		
 		// Site info metric
 		metrics.WriteString(fmt.Sprintf(
-			"site_info{site_id=\"%s\",name=\"%s\",location=\"%s\"} 1\n",
-			site.ID, site.Name, site.Location,
+			"site_info{site_id=\"%s\"%s,name=\"%s\",location=\"%s\"} 1\n",
+			site.ID, siteLabels, site.Name, site.Location,
 		))
 		
 		// SLA target metrics
@@ -55,8 +70,8 @@ func HandlePrometheusMetrics(c *fiber.Ctx) error {
 				provider = "Primary"
 			}
 			metrics.WriteString(fmt.Sprintf(
-				"site_sla_target{site_id=\"%s\",line_type=\"primary\",provider=\"%s\"} %.2f\n",
-				site.ID, provider, site.GetPrimarySLAUptime(),
+				"site_sla_target{site_id=\"%s\"%s,line_type=\"primary\",provider=\"%s\"} %.2f\n",
+				site.ID, siteLabels, provider, site.GetPrimarySLAUptime(),
 			))
 		}
 		
@@ -66,15 +81,15 @@ func HandlePrometheusMetrics(c *fiber.Ctx) error {
 				provider = "Secondary"
 			}
 			metrics.WriteString(fmt.Sprintf(
-				"site_sla_target{site_id=\"%s\",line_type=\"secondary\",provider=\"%s\"} %.2f\n",
-				site.ID, provider, site.GetSecondarySLAUptime(),
+				"site_sla_target{site_id=\"%s\"%s,line_type=\"secondary\",provider=\"%s\"} %.2f\n",
+				site.ID, siteLabels, provider, site.GetSecondarySLAUptime(),
 			))
 		}
 		
 		if site.IsDualLine() && site.SLA.Combined.Uptime > 0 {
 			metrics.WriteString(fmt.Sprintf(
-				"site_sla_target{site_id=\"%s\",line_type=\"combined\",provider=\"Combined\"} %.2f\n",
-				site.ID, site.GetCombinedSLAUptime(),
+				"site_sla_target{site_id=\"%s\"%s,line_type=\"combined\",provider=\"Combined\"} %.2f\n",
+				site.ID, siteLabels, site.GetCombinedSLAUptime(),
 			))
 		}
 		
@@ -94,16 +109,18 @@ func HandlePrometheusMetrics(c *fiber.Ctx) error {
 		}
 		
 		metrics.WriteString(fmt.Sprintf(
-			"site_status{site_id=\"%s\",line_type=\"primary\"} %d\n",
-			site.ID, primaryOnline,
-		))
-		metrics.WriteString(fmt.Sprintf(
-			"site_status{site_id=\"%s\",line_type=\"secondary\"} %d\n",
-			site.ID, secondaryOnline,
+			"site_status{site_id=\"%s\"%s,line_type=\"primary\"} %d\n",
+			site.ID, siteLabels, primaryOnline,
 		))
+		if site.IsDualLine() {
+			metrics.WriteString(fmt.Sprintf(
+				"site_status{site_id=\"%s\"%s,line_type=\"secondary\"} %d\n",
+				site.ID, siteLabels, secondaryOnline,
+			))
+		}
 		metrics.WriteString(fmt.Sprintf(
-			"site_both_lines_online{site_id=\"%s\"} %d\n",
-			site.ID, bothOnline,
+			"site_both_lines_online{site_id=\"%s\"%s} %d\n",
+			site.ID, siteLabels, bothOnline,
 		))
 	}
 	
@@ -133,4 +150,18 @@ func HandlePrometheusMetrics(c *fiber.Ctx) error {
 	metrics.WriteString(fmt.Sprintf("app_active_sites %d\n", activeSites))
 	
 	return c.SendString(metrics.String())
-}
\ No newline at end of file
+}
+
+// siteMetricLabels renders the configured metadata labels of a site as
+// ",key=\"value\"" pairs, using missingSiteLabelValue for keys the site lacks
+func siteMetricLabels(site models.Site, keys []string) string {
+	var labels strings.Builder
+	for _, key := range keys {
+		value, exists := site.Metadata[key]
+		if !exists || value == "" {
+			value = missingSiteLabelValue
+		}
+		labels.WriteString(fmt.Sprintf(",%s=\"%s\"", key, labelValueEscaper.Replace(value)))
+	}
+	return labels.String()
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/services/auth"
+)
+
+// oidcStateCookie binds a pending login to the browser that started it
+const oidcStateCookie = "sitewatch_oidc_state"
+
+// HandleOIDCLogin - GET /auth/oidc/login - Redirect to the OIDC provider.
+// ?prompt=login forces the provider to ask for credentials again.
+func HandleOIDCLogin(authService *auth.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		provider := authService.OIDC()
+		if provider == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Single sign-on is not configured"})
+		}
+
+		forceLogin := c.Query("prompt") == "login"
+		state, authURL, err := provider.AuthCodeURL(c.UserContext(), oidcRedirectURL(c), forceLogin)
+		if err != nil {
+			logger.Default().WithComponent("auth").Error("Failed to start OIDC login", "error", err)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Identity provider unavailable"})
+		}
+
+		c.Cookie(&fiber.Cookie{
+			Name:     oidcStateCookie,
+			Value:    state,
+			Path:     "/auth/oidc",
+			Expires:  time.Now().Add(10 * time.Minute),
+			HTTPOnly: true,
+			SameSite: "Lax", // Sent on the provider's redirect back to the callback
+			Secure:   c.Protocol() == "https",
+		})
+		return c.Redirect(authURL)
+	}
+}
+
+// HandleOIDCCallback - GET /auth/oidc/callback - Complete the OIDC login and create a UI session
+func HandleOIDCCallback(authService *auth.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		log := logger.Default().WithComponent("auth")
+
+		provider := authService.OIDC()
+		if provider == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Single sign-on is not configured"})
+		}
+
+		fail := func(status int, message string) error {
+			config.OIDCLoginsTotal.WithLabelValues("false").Inc()
+			return c.Status(status).JSON(fiber.Map{"error": message})
+		}
+
+		if providerError := c.Query("error"); providerError != "" {
+			log.Warn("OIDC provider rejected login", "error", providerError, "description", c.Query("error_description"))
+			return fail(fiber.StatusUnauthorized, "Login rejected by identity provider")
+		}
+
+		state := c.Query("state")
+		if state == "" || state != c.Cookies(oidcStateCookie) {
+			log.Warn("OIDC callback with mismatched state")
+			return fail(fiber.StatusBadRequest, "Invalid login state")
+		}
+		c.ClearCookie(oidcStateCookie)
+
+		identity, err := provider.Exchange(c.UserContext(), state, c.Query("code"))
+		if err != nil {
+			if errors.Is(err, auth.ErrOIDCTokenInvalid) {
+				log.Warn("OIDC token verification failed", "error", err)
+			} else {
+				log.Error("OIDC login failed", "error", err)
+			}
+			return fail(fiber.StatusUnauthorized, "Login failed")
+		}
+
+		permissions := provider.Permissions(identity)
+		if len(permissions) == 0 {
+			log.Warn("OIDC login denied, no permissions mapped", "subject", identity.Subject, "email", identity.Email)
+			return fail(fiber.StatusForbidden, "No access granted for this account")
+		}
+
+		expiry := authService.GetUISessionExpiry()
+		session, err := authService.Sessions().Create(identity.Subject, identity.Email, permissions, expiry)
+		if err != nil {
+			log.Error("Failed to create session", "error", err)
+			return fail(fiber.StatusInternalServerError, "Failed to create session")
+		}
+
+		c.Cookie(&fiber.Cookie{
+			Name:     authService.GetUISessionName(),
+			Value:    session.ID,
+			Expires:  session.Expires,
+			HTTPOnly: true,
+			SameSite: "Lax", // Strict would drop the cookie on the redirect chain from the provider
+			Secure:   c.Protocol() == "https",
+		})
+
+		config.OIDCLoginsTotal.WithLabelValues("true").Inc()
+		log.Info("OIDC login succeeded", "subject", identity.Subject, "email", identity.Email, "permissions", permissions)
+		return c.Redirect("/")
+	}
+}
+
+// oidcRedirectURL returns the configured callback URL or derives it from the request
+func oidcRedirectURL(c *fiber.Ctx) string {
+	if redirectURL := config.GlobalAppState.Config.Auth.OIDC.RedirectURL; redirectURL != "" {
+		return redirectURL
+	}
+	return c.BaseURL() + "/auth/oidc/callback"
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page sizes of GET /api/sites when paginated
+const (
+	defaultSitesPerPage = 50
+	maxSitesPerPage     = 500
+)
+
+// siteStatusFilters are the values accepted by the status= filter of GET /api/sites
+var siteStatusFilters = map[string]bool{"online": true, "degraded": true, "offline": true, "unknown": true}
+
+// siteListQuery holds the optional filter, paging and field selection of GET /api/sites
+type siteListQuery struct {
+	status   string
+	site     *sitePattern
+	paginate bool // Set by page or per_page, the whole list is returned otherwise
+	page     int
+	perPage  int
+	fields   []string // JSON field paths such as id or status.primary_online
+}
+
+// parseSiteListQuery reads and validates the query parameters of GET /api/sites
+func parseSiteListQuery(c *fiber.Ctx) (siteListQuery, error) {
+	query := siteListQuery{
+		status:  c.Query("status"),
+		page:    1,
+		perPage: defaultSitesPerPage,
+	}
+	if query.status != "" && !siteStatusFilters[query.status] {
+		return query, fmt.Errorf("status must be online, degraded, offline or unknown")
+	}
+	site, err := parseSitePattern(c.Query("site"))
+	if err != nil {
+		return query, err
+	}
+	query.site = site
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return query, fmt.Errorf("page must be a positive number")
+		}
+		query.page, query.paginate = page, true
+	}
+	if value := c.Query("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > maxSitesPerPage {
+			return query, fmt.Errorf("per_page must be between 1 and %d", maxSitesPerPage)
+		}
+		query.perPage, query.paginate = perPage, true
+	}
+
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			query.fields = append(query.fields, field)
+		}
+	}
+	return query, nil
+}
+
+// pageBounds returns the slice bounds of the query's page in a list of total entries
+func (q siteListQuery) pageBounds(total int) (int, int) {
+	start := min((q.page-1)*q.perPage, total)
+	return start, min(start+q.perPage, total)
+}
+
+// pageLinks returns the self, first, last and, where they exist, prev and
+// next URLs of a paginated list, keeping the other query parameters
+func pageLinks(c *fiber.Ctx, page, perPage, total int) fiber.Map {
+	params := url.Values{}
+	for key, value := range c.Queries() {
+		params.Set(key, value)
+	}
+	params.Set("per_page", strconv.Itoa(perPage))
+	link := func(page int) string {
+		params.Set("page", strconv.Itoa(page))
+		return c.Path() + "?" + params.Encode()
+	}
+
+	last := max((total+perPage-1)/perPage, 1)
+	links := fiber.Map{
+		"self":  link(page),
+		"first": link(1),
+		"last":  link(last),
+	}
+	if page > 1 {
+		links["prev"] = link(min(page-1, last))
+	}
+	if page < last {
+		links["next"] = link(page + 1)
+	}
+	return links
+}
+
+// selectFields returns the JSON encoding of v reduced to the given field
+// paths, where a dot selects a field of a nested object. Paths that do not
+// exist, e.g. omitted empty values, are left out.
+func selectFields(v any, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var full map[string]any
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		keys := strings.Split(field, ".")
+		value, ok := any(full), true
+		for _, key := range keys {
+			object, isObject := value.(map[string]any)
+			if value, ok = object[key]; !isObject || !ok {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		// Rebuild the nesting of the path in the result
+		target := selected
+		for _, key := range keys[:len(keys)-1] {
+			next, exists := target[key].(map[string]any)
+			if !exists {
+				next = make(map[string]any)
+				target[key] = next
+			}
+			target = next
+		}
+		target[keys[len(keys)-1]] = value
+	}
+	return selected, nil
+}
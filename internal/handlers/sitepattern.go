@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sitewatch/internal/models"
+)
+
+// maxSitePatternLength bounds site= patterns. Go regexps run in linear time,
+// the bound keeps the compiled programs small.
+const maxSitePatternLength = 256
+
+// sitePattern is the site= filter: a site ID, a glob with * and ? such as
+// prod-*, or a regular expression between slashes such as /^prod-\d+$/.
+// Globs and regular expressions must match the whole ID.
+type sitePattern struct {
+	raw string
+	re  *regexp.Regexp // nil for a plain site ID
+}
+
+// parseSitePattern validates a site= value, nil when it is empty
+func parseSitePattern(value string) (*sitePattern, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if len(value) > maxSitePatternLength {
+		return nil, fmt.Errorf("site pattern is longer than %d characters", maxSitePatternLength)
+	}
+
+	pattern := &sitePattern{raw: value}
+	switch {
+	case len(value) > 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/"):
+		re, err := regexp.Compile("^(?:" + value[1:len(value)-1] + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid site pattern %s: %w", value, err)
+		}
+		pattern.re = re
+	case strings.ContainsAny(value, "*?"):
+		var expr strings.Builder
+		expr.WriteString("^")
+		for _, r := range value {
+			switch r {
+			case '*':
+				expr.WriteString(".*")
+			case '?':
+				expr.WriteString(".")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		expr.WriteString("$")
+		pattern.re = regexp.MustCompile(expr.String())
+	}
+	return pattern, nil
+}
+
+// Match reports whether a site ID matches, a nil pattern matches every site
+func (p *sitePattern) Match(siteID string) bool {
+	switch {
+	case p == nil:
+		return true
+	case p.re == nil:
+		return siteID == p.raw
+	default:
+		return p.re.MatchString(siteID)
+	}
+}
+
+// SiteIDs returns the IDs to filter stored logs by: nil (all sites) for a
+// nil pattern, the ID itself for a plain site ID, so logs of removed sites
+// stay reachable, and the matching configured sites otherwise
+func (p *sitePattern) SiteIDs(sites []models.Site) []string {
+	switch {
+	case p == nil:
+		return nil
+	case p.re == nil:
+		return []string{p.raw}
+	}
+	ids := []string{}
+	for _, site := range sites {
+		if p.Match(site.ID) {
+			ids = append(ids, site.ID)
+		}
+	}
+	return ids
+}
@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"sitewatch/internal/config"
+	"sitewatch/internal/format"
+	"sitewatch/internal/middleware"
 	"sitewatch/internal/models"
 	"sitewatch/internal/services/ping"
 	"sitewatch/internal/services/stats"
@@ -25,14 +26,46 @@ func HandleDashboard(c *fiber.Ctx) error {
 	})
 }
 
-// HandleUIOverview - GET /ui/overview - Overview stats fragment
+// fragmentVersionHeader carries the status version of the polled dashboard fragments
+const fragmentVersionHeader = "X-Fragment-Version"
+
+// fragmentUnchanged sets the current status version on the response and
+// reports whether the client already rendered it. The client sends the
+// version it has in the X-Fragment-Version header or the version query
+// parameter; polling then gets 204 No Content, which HTMX does not swap.
+func fragmentUnchanged(c *fiber.Ctx) bool {
+	version := config.GlobalAppState.StatusVersion()
+	c.Set(fragmentVersionHeader, version)
+	
+	known := c.Get(fragmentVersionHeader)
+	if known == "" {
+		known = c.Query("version")
+	}
+	return known == version
+}
+
+// HandleUIOverview - GET|HEAD /ui/overview - Overview stats fragment
 func HandleUIOverview(c *fiber.Ctx) error {
+	if fragmentUnchanged(c) {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	if c.Method() == fiber.MethodHead {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	
 	overview := stats.CalculateOverviewData(config.GlobalAppState)
 	return c.Render("fragments/overview", overview)
 }
 
-// HandleUISites - GET /ui/sites - Sites grid fragment
+// HandleUISites - GET|HEAD /ui/sites - Sites grid fragment
 func HandleUISites(c *fiber.Ctx) error {
+	if fragmentUnchanged(c) {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	if c.Method() == fiber.MethodHead {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	
 	// Use thread-safe snapshots instead of direct locking
 	sites := config.GlobalAppState.GetSitesSnapshot()
 	statusMap := config.GlobalAppState.GetSiteStatusSnapshot()
@@ -43,6 +76,8 @@ func HandleUISites(c *fiber.Ctx) error {
 		Stats                  models.SiteStatistics `json:"stats"`
 		PrimaryLatencyString   string                `json:"primary_latency_string,omitempty"`
 		SecondaryLatencyString string                `json:"secondary_latency_string,omitempty"`
+		Schedule               models.CheckSchedule  `json:"schedule"`
+		NextCheckString        string                `json:"next_check_string"`
 	}
 	
 	var sitesWithStatus []SiteWithStatus
@@ -63,17 +98,19 @@ func HandleUISites(c *fiber.Ctx) error {
 		siteStats := stats.CalculateSiteStatistics(config.GlobalAppState, site.ID)
 		
 		siteWithStatus := SiteWithStatus{
-			Site:   site,
-			Status: *status,
-			Stats:  siteStats,
+			Site:     site,
+			Status:   *status,
+			Stats:    siteStats,
+			Schedule: ping.GetCheckSchedule(config.GlobalAppState, site),
 		}
+		siteWithStatus.NextCheckString = nextCheckLabel(siteWithStatus.Schedule.Primary)
 		
 		// Format latency strings
 		if status.PrimaryLatency != nil {
-			siteWithStatus.PrimaryLatencyString = fmt.Sprintf("%.1f", *status.PrimaryLatency)
+			siteWithStatus.PrimaryLatencyString = format.Latency(*status.PrimaryLatency)
 		}
 		if status.SecondaryLatency != nil {
-			siteWithStatus.SecondaryLatencyString = fmt.Sprintf("%.1f", *status.SecondaryLatency)
+			siteWithStatus.SecondaryLatencyString = format.Latency(*status.SecondaryLatency)
 		}
 		
 		sitesWithStatus = append(sitesWithStatus, siteWithStatus)
@@ -100,9 +137,13 @@ func HandleUIDetails(c *fiber.Ctx) error {
 		return c.SendString("<p class='text-red-600'>Site status not found</p>")
 	}
 	
+	recentChecks := stats.GetRecentChecks(config.GlobalAppState, siteID, stats.DefaultRecentChecksPerLine)
+	
 	return c.Render("fragments/details", fiber.Map{
-		"Site":   *siteInfo,
-		"Status": *status,
+		"Site":         *siteInfo,
+		"Status":       *status,
+		"RecentChecks": recentChecks,
+		"IsDualLine":   siteInfo.IsDualLine(),
 	})
 }
 
@@ -114,10 +155,26 @@ func HandleUILogs(c *fiber.Ctx) error {
 	})
 }
 
+// maxLogsWindow caps offset + limit so paging through the logs cannot turn into runaway queries
+const maxLogsWindow = 10000
+
 // HandleUILogsTable - GET /ui/logs-table - Logs table with data
 func HandleUILogsTable(c *fiber.Ctx) error {
+	return c.Render("fragments/logs-table", logsPage(c))
+}
+
+// HandleUILogsMore - GET /ui/logs-more - Next page of log table rows for infinite scroll
+func HandleUILogsMore(c *fiber.Ctx) error {
+	return c.Render("fragments/logs-rows", logsPage(c))
+}
+
+// logsPage loads the page of logs selected by the site, success, limit and
+// offset query parameters and sets the X-Has-More and X-Next-Offset headers.
+// An invalid site pattern selects no logs.
+func logsPage(c *fiber.Ctx) fiber.Map {
 	// Parse query parameters (same as API)
 	siteID := c.Query("site", "")
+	pattern, patternErr := parseSitePattern(siteID)
 	successParam := c.Query("success", "")
 	limitParam := c.Query("limit", "100")
 	
@@ -141,24 +198,50 @@ func HandleUILogsTable(c *fiber.Ctx) error {
 		}
 	}
 	
-	// Get filtered logs
-	logs, err := ping.GetFilteredLogs(config.GlobalAppState, siteID, success, limit)
-	if err != nil {
-		logs = []models.PingLog{}
+	// Parse offset
+	offset := 0
+	if parsedOffset, err := strconv.Atoi(c.Query("offset", "0")); err == nil && parsedOffset > 0 {
+		offset = parsedOffset
+	}
+	
+	// Never read past the paging window
+	pageLimit := limit
+	if offset+pageLimit > maxLogsWindow {
+		pageLimit = maxLogsWindow - offset
 	}
 	
-	return c.Render("fragments/logs-table", fiber.Map{
-		"Logs":  logs,
-		"Total": len(logs),
+	logs := []models.PingLog{}
+	total := 0
+	if pageLimit > 0 && patternErr == nil {
+		siteIDs := pattern.SiteIDs(config.GlobalAppState.GetSitesSnapshot())
+		if page, count, err := ping.GetFilteredLogsPage(config.GlobalAppState, siteIDs, success, pageLimit, offset); err == nil {
+			logs, total = page, count
+		}
+	}
+	
+	nextOffset := offset + len(logs)
+	hasMore := len(logs) > 0 && nextOffset < total && nextOffset < maxLogsWindow
+	
+	c.Set("X-Has-More", strconv.FormatBool(hasMore))
+	c.Set("X-Next-Offset", strconv.Itoa(nextOffset))
+	
+	return fiber.Map{
+		"Logs":       logs,
+		"Total":      total,
+		"From":       offset + 1,
+		"To":         nextOffset,
+		"NextOffset": nextOffset,
+		"HasMore":    hasMore,
 		"Filters": fiber.Map{
 			"site":    siteID,
 			"success": successParam,
 			"limit":   limit,
 		},
-	})
+	}
 }
 
-// HandleUIChartData - GET /ui/chart-data/:siteId/:chartType/:range - Dynamic chart data for time ranges
+// HandleUIChartData - GET /ui/chart-data/:siteId/:chartType/:range - Dynamic chart data for time ranges.
+// The range "custom" takes ?from=&to=&resolution= like the charts API.
 func HandleUIChartData(c *fiber.Ctx) error {
 	siteID := c.Params("siteId")
 	chartType := c.Params("chartType")
@@ -168,6 +251,16 @@ func HandleUIChartData(c *fiber.Ctx) error {
 	if siteID == "" || chartType == "" || timeRange == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "Missing parameters"})
 	}
+	if timeRange == "custom" {
+		chartData, err := customRangeChartData(c, siteID, chartType)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(chartData)
+	}
+	if err := stats.ValidateChartRange(chartType, timeRange); err != nil {
+		return c.Status(400).JSON(chartRangeError(chartType, err))
+	}
 	
 	// Generate chart data based on type and range
 	chartData := stats.GenerateChartDataForRange(config.GlobalAppState, siteID, chartType, timeRange)
@@ -175,6 +268,26 @@ func HandleUIChartData(c *fiber.Ctx) error {
 	return c.JSON(chartData)
 }
 
+// HandleUIHostChartData - GET /ui/chart-data/self/host/:range - Load of the monitoring host,
+// bucketed like the site charts of the same range for overlays
+func HandleUIHostChartData(c *fiber.Ctx) error {
+	chartData, err := stats.GenerateHostChart(config.GlobalAppState, c.Params("range"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(chartData)
+}
+
+// HandleUIFleetChartData - GET /ui/chart-data/fleet/:range - Online, offline and degraded
+// site counts over time, bucketed like the site charts of the same range
+func HandleUIFleetChartData(c *fiber.Ctx) error {
+	chartData, err := stats.GenerateFleetChart(config.GlobalAppState, c.Params("range"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(chartData)
+}
+
 // HandleUIEnhancedFragment - GET /ui/enhanced-fragment/:siteId - Enhanced details fragment for dashboard tab
 func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 	siteID := c.Params("siteId")
@@ -195,10 +308,14 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 		}
 	}
 	
-	// Calculate statistics and chart data
-	statistics := stats.CalculateSiteStatistics(config.GlobalAppState, siteID)
-	chartData := stats.GenerateChartData(config.GlobalAppState, siteID)
-	recentEvents := stats.GetRecentEvents(config.GlobalAppState, siteID, 10)
+	// Calculate statistics and chart data from a single pass over the logs.
+	// Latency, packet transmission and jitter are generated per range below,
+	// the bundle only computes the charts the fragment renders from it
+	statistics, chartData := stats.CalculateSiteDetails(config.GlobalAppState, siteID, fragmentCharts)
+	locale := middleware.GetLocale(c)
+	recentEvents := stats.GetRecentEvents(config.GlobalAppState, siteID, 10, locale)
+	recentEvents = append(stats.SLABreachEvents(siteID, statistics.SLABreaches, locale), recentEvents...)
+	recentChecks := stats.GetRecentChecks(config.GlobalAppState, siteID, stats.DefaultRecentChecksPerLine)
 	
 	// Generate initial chart data using the same API as the button clicks
 	// Use 24h as default for consistent behavior with button "24h" being active
@@ -207,7 +324,7 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 	jitterChartData := stats.GenerateChartDataForRange(config.GlobalAppState, siteID, "jitter", "24h")
 	
 	// Convert chart data to JSON strings for templates
-	var latencyLabelsJSON, latencyPrimaryJSON, latencySecondaryJSON []byte
+	var latencyLabelsJSON, latencyPrimaryJSON, latencySecondaryJSON, latencyCombinedJSON []byte
 	var packetTransmissionLabelsJSON, packetTransmissionPrimaryJSON, packetTransmissionSecondaryJSON []byte  
 	var jitterLabelsJSON, jitterPrimaryJSON, jitterSecondaryJSON []byte
 	
@@ -216,11 +333,13 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 		latencyLabelsJSON, _ = json.Marshal(latencyResult.Labels)
 		latencyPrimaryJSON, _ = json.Marshal(latencyResult.PrimaryData)
 		latencySecondaryJSON, _ = json.Marshal(latencyResult.SecondaryData)
+		latencyCombinedJSON, _ = json.Marshal(latencyResult.CombinedData)
 	} else {
 		// Fallback to old method
 		latencyLabelsJSON, _ = json.Marshal(chartData.LatencyChartLabels)
 		latencyPrimaryJSON, _ = json.Marshal(chartData.LatencyChartDataPrimary)
 		latencySecondaryJSON, _ = json.Marshal(chartData.LatencyChartDataSecondary)
+		latencyCombinedJSON, _ = json.Marshal(chartData.LatencyChartDataCombined)
 	}
 	
 	// Handle packet transmission chart data
@@ -266,6 +385,7 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 	latencyMaxPrimaryJSON, _ := json.Marshal(chartData.LatencyMaxChartDataPrimary)
 	latencyMinSecondaryJSON, _ := json.Marshal(chartData.LatencyMinChartDataSecondary)
 	latencyMaxSecondaryJSON, _ := json.Marshal(chartData.LatencyMaxChartDataSecondary)
+	incidentMarkersJSON, _ := json.Marshal(chartData.IncidentMarkers)
 
 	return c.Render("components/enhanced-fragment", fiber.Map{
 		"Site":         *siteInfo,
@@ -273,6 +393,9 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 		"Statistics":   statistics,
 		"ChartData":    chartData,
 		"RecentEvents": recentEvents,
+		"RecentChecks": recentChecks,
+		"ProbingEnabled": config.GlobalAppState.Config.IsPingEnabled(),
+		"IsDualLine":   siteInfo.IsDualLine(), // Secondary panels and series are only rendered for dual-line sites
 		// SLA Configuration 
 		"PrimarySLA":   siteInfo.GetPrimarySLAUptime(),
 		"SecondarySLA": siteInfo.GetSecondarySLAUptime(),
@@ -281,6 +404,7 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 		"LatencyChartLabels":        string(latencyLabelsJSON),
 		"LatencyChartDataPrimary":   string(latencyPrimaryJSON),
 		"LatencyChartDataSecondary": string(latencySecondaryJSON),
+		"LatencyChartDataCombined":  string(latencyCombinedJSON),
 		"UptimeChartLabels":         string(uptimeLabelsJSON),
 		"UptimeChartData":           string(uptimeDataJSON),
 		"UptimeChartPrimaryData":    string(uptimePrimaryJSON),
@@ -308,5 +432,28 @@ func HandleUIEnhancedFragment(c *fiber.Ctx) error {
 		"LatencyMaxChartDataPrimary":   string(latencyMaxPrimaryJSON),
 		"LatencyMinChartDataSecondary": string(latencyMinSecondaryJSON),
 		"LatencyMaxChartDataSecondary": string(latencyMaxSecondaryJSON),
+		// Incident boundaries for chart annotations
+		"IncidentMarkers":              string(incidentMarkersJSON),
 	})
-}
\ No newline at end of file
+}
+
+// fragmentCharts are the bundle charts rendered by the enhanced fragment
+var fragmentCharts = stats.ChartSelection{"uptime": true, "yearly": true, "distribution": true, "incidents": true}
+
+// nextCheckLabel describes a line schedule for the sites grid
+func nextCheckLabel(schedule models.LineSchedule) string {
+	switch schedule.Reason {
+	case "":
+		return "next in " + stats.FormatDuration(time.Duration(*schedule.SecondsRemaining*float64(time.Second)))
+	case ping.ScheduleReasonPaused:
+		return "paused"
+	case ping.ScheduleReasonProbingDisabled:
+		return "ingest only"
+	case ping.ScheduleReasonSuppressed:
+		return "suppressed"
+	case ping.ScheduleReasonCircuitOpen:
+		return "circuit open"
+	default:
+		return "not scheduled"
+	}
+}
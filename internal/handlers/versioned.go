@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/middleware"
+	"sitewatch/internal/models"
+)
+
+// Response shapes differ between API versions only in these serializers.
+// The legacy /api prefix keeps its fields, v1 moves relative time strings
+// ("5m ago") to *_human fields next to the machine readable values and
+// reports timestamps in UTC, independent of the server time zone.
+
+// timeForVersion returns t in the time zone of the request's API version
+func timeForVersion(c *fiber.Ctx, t time.Time) time.Time {
+	if middleware.GetAPIVersion(c) == middleware.APIVersionLegacy {
+		return t
+	}
+	return t.UTC()
+}
+
+// responseTime returns the server time reported as timestamp of a response
+func responseTime(c *fiber.Ctx) time.Time {
+	return timeForVersion(c, clock.Default().Now())
+}
+
+// statisticsForVersion returns the statistics in the shape of the request's API version
+func statisticsForVersion(c *fiber.Ctx, s models.SiteStatistics) models.SiteStatistics {
+	if middleware.GetAPIVersion(c) == middleware.APIVersionLegacy {
+		return s
+	}
+	s.LastIncidentHuman, s.LastIncident = s.LastIncident, ""
+	s.LastIncidentDurationHuman, s.LastIncidentDuration = s.LastIncidentDuration, ""
+	if s.LastIncidentAt != nil {
+		at := s.LastIncidentAt.UTC()
+		s.LastIncidentAt = &at
+	}
+	return s
+}
+
+// recentChecksForVersion returns the checks in the shape of the request's API version
+func recentChecksForVersion(c *fiber.Ctx, checks []models.RecentCheck) []models.RecentCheck {
+	if middleware.GetAPIVersion(c) == middleware.APIVersionLegacy {
+		return checks
+	}
+	versioned := make([]models.RecentCheck, len(checks))
+	for i, check := range checks {
+		check.TimeAgoHuman, check.TimeAgo = check.TimeAgo, ""
+		check.Timestamp = check.Timestamp.UTC()
+		versioned[i] = check
+	}
+	return versioned
+}
+
+// timePtrForVersion is timeForVersion for optional times
+func timePtrForVersion(c *fiber.Ctx, t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	versioned := timeForVersion(c, *t)
+	return &versioned
+}
+
+// logsForVersion returns the logs with their timestamps in the time zone of the request's API version
+func logsForVersion(c *fiber.Ctx, logs []models.PingLog) []models.PingLog {
+	if middleware.GetAPIVersion(c) == middleware.APIVersionLegacy || len(logs) == 0 {
+		return logs
+	}
+	versioned := make([]models.PingLog, len(logs))
+	for i, log := range logs {
+		log.Timestamp = log.Timestamp.UTC()
+		versioned[i] = log
+	}
+	return versioned
+}
+
+// siteStatusForVersion returns the status in the shape of the request's API version
+func siteStatusForVersion(c *fiber.Ctx, s models.SiteStatus) models.SiteStatus {
+	s.LastCheck = timeForVersion(c, s.LastCheck)
+	return s
+}
+
+// scheduleForVersion returns the check schedule in the shape of the request's API version
+func scheduleForVersion(c *fiber.Ctx, s models.CheckSchedule) models.CheckSchedule {
+	s.Primary.NextCheckAt = timePtrForVersion(c, s.Primary.NextCheckAt)
+	if s.Secondary != nil {
+		secondary := *s.Secondary
+		secondary.NextCheckAt = timePtrForVersion(c, secondary.NextCheckAt)
+		s.Secondary = &secondary
+	}
+	return s
+}
+
+// simulationsForVersion returns the simulations in the shape of the request's API version
+func simulationsForVersion(c *fiber.Ctx, simulations []models.Simulation) []models.Simulation {
+	if middleware.GetAPIVersion(c) == middleware.APIVersionLegacy || len(simulations) == 0 {
+		return simulations
+	}
+	versioned := make([]models.Simulation, len(simulations))
+	for i, simulation := range simulations {
+		simulation.StartedAt = simulation.StartedAt.UTC()
+		simulation.ExpiresAt = simulation.ExpiresAt.UTC()
+		versioned[i] = simulation
+	}
+	return versioned
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/middleware"
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// versionedApp serves handler under the legacy /api and the /api/v1 prefix
+func versionedApp(path string, handler fiber.Handler) *fiber.App {
+	app := fiber.New()
+	app.Get("/api/v1"+path, middleware.APIVersion(middleware.APIVersionV1), handler)
+	app.Get("/api"+path, middleware.APIVersion(middleware.APIVersionLegacy), handler)
+	return app
+}
+
+// assertGolden compares the indented JSON body with testdata/name, or
+// rewrites the file with -update
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, body)
+	}
+	indented.WriteByte('\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, indented.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(indented.Bytes(), want) {
+		t.Errorf("response differs from %s:\n%s", path, indented.Bytes())
+	}
+}
+
+// berlin is a zone east of UTC the versioned tests store their times in
+var berlin = time.FixedZone("CET", 3600)
+
+// newBerlinAppState installs an app state with a dual-line site whose
+// checks, status, monitoring dates and next check are all in berlin
+func newBerlinAppState(t *testing.T) *config.AppState {
+	t.Helper()
+
+	site := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", SecondaryIP: "192.0.2.2", Enabled: true}
+	latency := 12.5
+	loss := 0.0
+	appState := testutil.NewAppState(t, testutil.Options{
+		Sites: []models.Site{site},
+		Logs: []models.PingLog{
+			{Timestamp: testutil.Now.Add(-2 * time.Minute).In(berlin), SiteID: "site-001", Target: "primary", IP: "192.0.2.1", Success: true, Latency: &latency, PacketLoss: &loss},
+			{Timestamp: testutil.Now.Add(-90 * time.Second).In(berlin), SiteID: "site-001", Target: "secondary", IP: "192.0.2.2", Error: "no packets received"},
+		},
+		Clock:  true,
+		Global: true,
+	})
+
+	firstSuccess := testutil.Now.Add(-2 * time.Minute).In(berlin)
+	appState.SiteStatus[site.ID] = &models.SiteStatus{SiteID: site.ID, PrimaryOnline: true, PrimaryLatency: &latency, LastCheck: testutil.Now.Add(-90 * time.Second).In(berlin)}
+	appState.SiteMonitoring[site.ID] = models.SiteMonitoring{FirstSeen: testutil.Now.Add(-time.Hour).In(berlin), FirstSuccess: &firstSuccess}
+	appState.SetNextCheck(site.ID, testutil.Now.Add(15*time.Second).In(berlin))
+	return appState
+}
+
+// assertVersionedGolden requests path under both prefixes of app and
+// compares the responses with testdata/name_legacy.json and name_v1.json
+func assertVersionedGolden(t *testing.T, app *fiber.App, path, name string) {
+	t.Helper()
+
+	for _, tt := range []struct{ prefix, golden string }{
+		{"/api", name + "_legacy.json"},
+		{"/api/v1", name + "_v1.json"},
+	} {
+		t.Run(tt.prefix, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.prefix+path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status %d, want 200: %s", resp.StatusCode, body)
+			}
+			assertGolden(t, tt.golden, body)
+		})
+	}
+}
+
+// Times stored in a zone east of UTC are reported in that zone on the
+// legacy prefix and in UTC on /api/v1. Relative times move to *_human fields.
+func TestRecentChecksGolden(t *testing.T) {
+	newBerlinAppState(t)
+	assertVersionedGolden(t, versionedApp("/sites/:siteId/recent-checks", HandleGetSiteRecentChecks), "/sites/site-001/recent-checks", "recent_checks")
+}
+
+func TestLogsGolden(t *testing.T) {
+	newBerlinAppState(t)
+	assertVersionedGolden(t, versionedApp("/logs", HandleGetLogs), "/logs?site=site-001", "logs")
+}
+
+func TestSitesGolden(t *testing.T) {
+	newBerlinAppState(t)
+	assertVersionedGolden(t, versionedApp("/sites", HandleGetSites), "/sites", "sites")
+}
+
+func TestSiteDetailsGolden(t *testing.T) {
+	newBerlinAppState(t)
+	assertVersionedGolden(t, versionedApp("/sites/:siteId/details", HandleGetSiteDetails), "/sites/site-001/details", "site_details")
+}
+
+func TestStatisticsForVersion(t *testing.T) {
+	at := time.Date(2024, 1, 15, 11, 0, 0, 0, time.FixedZone("CET", 3600))
+	statistics := models.SiteStatistics{LastIncident: "30m ago", LastIncidentDuration: "~5min", LastIncidentAt: &at}
+
+	app := versionedApp("/statistics", func(c *fiber.Ctx) error {
+		return c.JSON(statisticsForVersion(c, statistics))
+	})
+	for _, tt := range []struct{ prefix, golden string }{
+		{"/api", "statistics_legacy.json"},
+		{"/api/v1", "statistics_v1.json"},
+	} {
+		t.Run(tt.prefix, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.prefix+"/statistics", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			assertGolden(t, tt.golden, body)
+		})
+	}
+	if statistics.LastIncidentAt.Location() == time.UTC {
+		t.Error("statisticsForVersion changed the statistics of the caller")
+	}
+}
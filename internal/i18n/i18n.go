@@ -0,0 +1,138 @@
+// Package i18n provides localized display strings loaded from one YAML bundle per locale
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FallbackLocale is used for keys missing from the requested locale
+	FallbackLocale = "en"
+
+	// DefaultDir holds one <locale>.yaml bundle per supported locale
+	DefaultDir = "web/locales"
+)
+
+var (
+	bundles       = map[string]map[string]string{}
+	defaultLocale = FallbackLocale
+	mu            sync.RWMutex
+)
+
+// Load reads every <locale>.yaml bundle in dir and sets the default locale
+// used when a request does not negotiate a supported one. The English bundle
+// is required as it backs up missing keys in all other locales.
+func Load(dir, locale string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return fmt.Errorf("listing translation bundles: %w", err)
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading translation bundle %s: %w", file, err)
+		}
+
+		var messages map[string]string
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("parsing translation bundle %s: %w", file, err)
+		}
+		loaded[strings.TrimSuffix(filepath.Base(file), ".yaml")] = messages
+	}
+
+	if _, ok := loaded[FallbackLocale]; !ok {
+		return fmt.Errorf("translation bundle %s.yaml not found in %s", FallbackLocale, dir)
+	}
+	if locale == "" {
+		locale = FallbackLocale
+	}
+	if _, ok := loaded[locale]; !ok {
+		return fmt.Errorf("no translation bundle for locale %q in %s", locale, dir)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	bundles = loaded
+	defaultLocale = locale
+	return nil
+}
+
+// DefaultLocale returns the configured server locale
+func DefaultLocale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// T returns the message for key in locale, formatted with args. Keys missing
+// from the locale fall back to English, and unknown keys are returned as is.
+func T(locale, key string, args ...interface{}) string {
+	mu.RLock()
+	message, ok := bundles[locale][key]
+	if !ok {
+		message, ok = bundles[FallbackLocale][key]
+	}
+	mu.RUnlock()
+
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Negotiate picks the best supported locale from an Accept-Language header
+// ("de-DE,de;q=0.9,en;q=0.8"), matching region tags by their language. Falls
+// back to the default locale when nothing matches.
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{tag: strings.ToLower(tag), quality: quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, pref := range preferences {
+		if _, ok := bundles[pref.tag]; ok {
+			return pref.tag
+		}
+		if language, _, found := strings.Cut(pref.tag, "-"); found {
+			if _, ok := bundles[language]; ok {
+				return language
+			}
+		}
+	}
+	return defaultLocale
+}
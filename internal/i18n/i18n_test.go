@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBundles writes the given locale bundles to a temporary directory
+func writeBundles(t *testing.T, bundles map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for locale, content := range bundles {
+		if err := os.WriteFile(filepath.Join(dir, locale+".yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestTFallsBackToEnglish(t *testing.T) {
+	dir := writeBundles(t, map[string]string{
+		"en": "event.restored: \"%s connection restored\"\nlabel.site: \"Site\"\n",
+		"de": "label.site: \"Standort\"\n",
+	})
+	if err := Load(dir, "de"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		locale string
+		key    string
+		args   []interface{}
+		want   string
+	}{
+		{"translated", "de", "label.site", nil, "Standort"},
+		{"missing in locale", "de", "event.restored", []interface{}{"Primary"}, "Primary connection restored"},
+		{"unknown locale", "fr", "label.site", nil, "Site"},
+		{"unknown key", "de", "label.missing", nil, "label.missing"},
+		{"english", "en", "label.site", nil, "Site"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.locale, tt.key, tt.args...); got != tt.want {
+				t.Errorf("T(%s, %s) = %q, want %q", tt.locale, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRequiresEnglish(t *testing.T) {
+	dir := writeBundles(t, map[string]string{"de": "label.site: \"Standort\"\n"})
+	if err := Load(dir, "de"); err == nil || !strings.Contains(err.Error(), "en.yaml") {
+		t.Errorf("Load without an English bundle = %v, want an error", err)
+	}
+
+	dir = writeBundles(t, map[string]string{"en": "label.site: \"Site\"\n"})
+	if err := Load(dir, "de"); err == nil {
+		t.Error("Load with an unknown default locale succeeded")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	dir := writeBundles(t, map[string]string{"en": "", "de": ""})
+	if err := Load(dir, "en"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"fr-FR,en;q=0.5,de;q=0.7", "de"},
+		{"fr, es", "en"},
+		{"de;q=0, en", "en"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// Every key of the shipped bundles exists in English, so a fallback never
+// ends in the raw key
+func TestBundlesHaveEnglishKeys(t *testing.T) {
+	if err := Load(filepath.Join("..", "..", DefaultDir), FallbackLocale); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for locale, messages := range bundles {
+		for key := range messages {
+			if _, ok := bundles[FallbackLocale][key]; !ok {
+				t.Errorf("key %s of locale %s missing in English", key, locale)
+			}
+		}
+	}
+}
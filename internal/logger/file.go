@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// ReopenableFile is a log file that can be reopened by path, so logging
+// continues in the new file after an external tool (logrotate) has moved
+// the old one away
+type ReopenableFile struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// OpenReopenableFile opens path for appending, creating it and its directory if needed
+func OpenReopenableFile(path string) (*ReopenableFile, error) {
+	f := &ReopenableFile{path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write appends p to the current file
+func (f *ReopenableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Write(p)
+}
+
+// Reopen closes the current file and opens path again
+func (f *ReopenableFile) Reopen() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file %s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	old := f.file
+	f.file = file
+	f.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Close closes the current file
+func (f *ReopenableFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// Log file of the default logger, nil when logging to stdout
+var logFile *ReopenableFile
+
+// reopenOnSIGHUP reopens the log file whenever the process receives SIGHUP
+func reopenOnSIGHUP(f *ReopenableFile) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			if err := f.Reopen(); err != nil {
+				// The old file stays in use, report on stderr as the log may be gone
+				fmt.Fprintf(os.Stderr, "sitewatch: reopening log file failed: %v\n", err)
+				continue
+			}
+			Default().WithComponent("logger").Info("Log file reopened", "path", f.path)
+		}
+	}()
+}
+
+// Close closes the log file of the default logger, if any
+func Close() error {
+	if logFile == nil {
+		return nil
+	}
+	return logFile.Close()
+}
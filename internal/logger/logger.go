@@ -1,10 +1,12 @@
 package logger
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 )
 
 // Logger is a structured logger wrapper
@@ -40,6 +42,12 @@ type Config struct {
 // Default logger instance
 var defaultLogger *Logger
 
+// Per-component level overrides (component name -> level)
+var (
+	componentLevels   map[string]slog.Level
+	componentLevelsMu sync.RWMutex
+)
+
 // NewLogger creates a new structured logger
 func NewLogger(config Config) *Logger {
 	// Set default output to stdout
@@ -76,10 +84,28 @@ func InitDefault() {
 		Output: os.Stdout,
 	}
 	
+	// Optional file output, reopened on SIGHUP for external log rotation
+	var fileErr error
+	if path := os.Getenv("SITEWATCH_LOG_FILE"); path != "" && logFile == nil {
+		if f, err := OpenReopenableFile(path); err == nil {
+			logFile = f
+			reopenOnSIGHUP(f)
+		} else {
+			fileErr = err
+		}
+	}
+	if logFile != nil {
+		config.Output = logFile
+	}
+	
 	defaultLogger = NewLogger(config)
 	
 	// Replace standard log output with structured logger
 	slog.SetDefault(defaultLogger.Logger)
+	
+	if fileErr != nil {
+		defaultLogger.Warn("Failed to open log file, logging to stdout", "error", fileErr)
+	}
 }
 
 // Default returns the default logger instance
@@ -90,13 +116,64 @@ func Default() *Logger {
 	return defaultLogger
 }
 
+// SetDefault replaces the default logger, e.g. to capture the output in tests
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// SetComponentLevels configures per-component log level overrides
+func SetComponentLevels(levels map[string]string) {
+	parsed := make(map[string]slog.Level, len(levels))
+	for component, level := range levels {
+		parsed[component] = parseLogLevel(LogLevel(level))
+	}
+	
+	componentLevelsMu.Lock()
+	componentLevels = parsed
+	componentLevelsMu.Unlock()
+}
+
+// getComponentLevel returns the level override for a component, if any
+func getComponentLevel(component string) (slog.Level, bool) {
+	componentLevelsMu.RLock()
+	defer componentLevelsMu.RUnlock()
+	level, ok := componentLevels[component]
+	return level, ok
+}
+
 // Component-specific loggers
 func (l *Logger) WithComponent(component string) *Logger {
+	base := l.Logger
+	if level, ok := getComponentLevel(component); ok {
+		base = slog.New(&levelHandler{level: level, handler: l.Handler()})
+	}
 	return &Logger{
-		Logger: l.With("component", component),
+		Logger: base.With("component", component),
 	}
 }
 
+// levelHandler overrides the minimum level of a wrapped handler
+type levelHandler struct {
+	level   slog.Level
+	handler slog.Handler
+}
+
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
+
 // Request-specific logger
 func (l *Logger) WithRequest(method, path string) *Logger {
 	return &Logger{
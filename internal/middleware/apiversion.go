@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/config"
+)
+
+// API versions a request can be routed through
+const (
+	APIVersionLegacy = "legacy" // Unversioned /api prefix, deprecated
+	APIVersionV1     = "v1"
+)
+
+// APIVersion stores the API version of the request, so handlers can keep
+// the legacy response shape while v1 responses change
+func APIVersion(version string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("apiVersion", version)
+		return c.Next()
+	}
+}
+
+// GetAPIVersion returns the API version the request was routed through,
+// APIVersionV1 outside the API routes
+func GetAPIVersion(c *fiber.Ctx) string {
+	if version, ok := c.Locals("apiVersion").(string); ok {
+		return version
+	}
+	return APIVersionV1
+}
+
+// LegacyAPI marks responses of the legacy prefix as deprecated with the
+// Deprecation, Sunset (unless sunset is zero) and successor Link headers,
+// and counts the requests per route in api_legacy_requests_total
+func LegacyAPI(legacyPrefix, successorPrefix string, sunset time.Time) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			c.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		successor := successorPrefix + strings.TrimPrefix(c.Path(), legacyPrefix)
+		c.Set("Link", "<"+successor+`>; rel="successor-version"`)
+		config.LegacyAPIRequestsTotal.WithLabelValues(c.Route().Path).Inc()
+		return c.Next()
+	}
+}
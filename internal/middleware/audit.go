@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"sitewatch/internal/logger"
+)
+
+// Audit logs a state-changing action with the actor of the request and
+// returns the actor, so handlers can include it in their response. args are
+// additional key-value pairs describing the action.
+func Audit(c *fiber.Ctx, action string, args ...any) string {
+	auth := GetAuthContext(c)
+	actor := auth.Actor()
+	requestID, _ := c.Locals("requestid").(string)
+
+	fields := append([]any{
+		"action", action,
+		"actor", actor,
+		"auth_type", auth.AuthType,
+		"ip", c.IP(),
+		"request_id", requestID,
+	}, args...)
+	logger.Default().WithComponent("audit").Info("Action performed", fields...)
+	return actor
+}
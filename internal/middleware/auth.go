@@ -12,7 +12,23 @@ import (
 type AuthContext struct {
 	IsAuthenticated bool
 	Token          *models.APIToken
-	AuthType       string // "ui" or "api"
+	AuthType       string // "ui", "sso", "api", "disabled" or "none"
+}
+
+// Actor returns who a request's actions are attributed to: the token name
+// for API and single sign-on requests, "ui" for the shared UI secret and
+// "anonymous" when authentication is disabled
+func (a *AuthContext) Actor() string {
+	switch {
+	case a.AuthType == "disabled":
+		return "anonymous"
+	case a.Token != nil:
+		return a.Token.Name
+	case a.AuthType == "ui":
+		return "ui"
+	default:
+		return "unknown"
+	}
 }
 
 // UIAuthMiddleware validates UI session cookies
@@ -38,6 +54,25 @@ func UIAuthMiddleware(authService *auth.Service) fiber.Handler {
 			})
 		}
 
+		// Single sign-on sessions carry the permissions mapped from the user's claims
+		if session, ok := authService.Sessions().Get(sessionSecret); ok {
+			c.Locals("auth", &AuthContext{
+				IsAuthenticated: true,
+				Token:          session.Token(),
+				AuthType:       "sso",
+			})
+			return c.Next()
+		}
+
+		// Single sign-on replaces the shared UI secret, which must not
+		// bypass the provider login
+		if authService.OIDC() != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Single sign-on session required",
+				"code":  "SSO_REQUIRED",
+			})
+		}
+
 		// Validate UI secret
 		if !authService.ValidateUISecret(sessionSecret) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -102,6 +137,14 @@ func APIAuthMiddleware(authService *auth.Service, requiredPermission models.Toke
 			})
 		}
 
+		// Tokens restricted to source addresses (e.g. the Prometheus server)
+		if !token.AllowsIP(c.IP()) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Token not allowed from this address",
+				"code":  "IP_NOT_ALLOWED",
+			})
+		}
+
 		// Check permissions
 		if !authService.HasPermission(token, requiredPermission) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
@@ -132,4 +175,11 @@ func GetAuthContext(c *fiber.Ctx) *AuthContext {
 		IsAuthenticated: false,
 		AuthType:       "none",
 	}
-}
\ No newline at end of file
+}
+
+// IsStatusOnly reports whether the request's token holds the status
+// permission but not read, so handlers redact infrastructure details
+func IsStatusOnly(c *fiber.Ctx) bool {
+	token := GetAuthContext(c).Token
+	return token != nil && !token.HasPermission(models.PermissionRead)
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/auth"
+)
+
+const testUISecret = "ui-secret"
+
+// uiAuthApp serves the auth type of the request behind UIAuthMiddleware
+func uiAuthApp(authService *auth.Service) *fiber.App {
+	app := fiber.New()
+	app.Use(UIAuthMiddleware(authService))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(GetAuthContext(c).AuthType)
+	})
+	return app
+}
+
+func TestUIAuthMiddlewareModes(t *testing.T) {
+	disabled := auth.NewService(&models.AuthConfig{
+		UI: models.UIAuthConfig{Secret: testUISecret},
+	})
+	secret := auth.NewService(&models.AuthConfig{
+		Enabled: true,
+		UI:      models.UIAuthConfig{Secret: testUISecret},
+	})
+	// The provider is discovered on the first login only, so no network is needed
+	sso := auth.NewService(&models.AuthConfig{
+		Enabled: true,
+		UI:      models.UIAuthConfig{Secret: testUISecret},
+		OIDC: models.OIDCAuthConfig{
+			ProviderURL: "https://idp.example.com",
+			ClientID:    "sitewatch",
+		},
+	})
+
+	session, err := sso.Sessions().Create("user-1", "user@example.com", []string{"read"}, time.Hour)
+	if err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	foreign, err := secret.Sessions().Create("user-1", "user@example.com", []string{"read"}, time.Hour)
+	if err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		service  *auth.Service
+		cookie   string // Empty sends no cookie
+		status   int
+		authType string
+	}{
+		{"disabled without cookie", disabled, "", fiber.StatusOK, "disabled"},
+		{"disabled with wrong secret", disabled, "wrong", fiber.StatusOK, "disabled"},
+		{"secret without cookie", secret, "", fiber.StatusUnauthorized, ""},
+		{"secret with wrong secret", secret, "wrong", fiber.StatusUnauthorized, ""},
+		{"secret with secret", secret, testUISecret, fiber.StatusOK, "ui"},
+		{"secret with session", secret, foreign.ID, fiber.StatusOK, "sso"},
+		{"sso without cookie", sso, "", fiber.StatusUnauthorized, ""},
+		{"sso with wrong secret", sso, "wrong", fiber.StatusUnauthorized, ""},
+		{"sso with secret", sso, testUISecret, fiber.StatusUnauthorized, ""},
+		{"sso with session", sso, session.ID, fiber.StatusOK, "sso"},
+		{"sso with session of another service", sso, foreign.ID, fiber.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: tt.service.GetUISessionName(), Value: tt.cookie})
+			}
+			resp, err := uiAuthApp(tt.service).Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != fiber.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.authType {
+				t.Errorf("auth type = %q, want %q", body, tt.authType)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/i18n"
+)
+
+// LocaleMiddleware negotiates the display locale from the Accept-Language
+// header and stores it as the "Locale" local, which templates receive as .Locale
+func LocaleMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("Locale", i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage)))
+		return c.Next()
+	}
+}
+
+// GetLocale returns the locale negotiated for the request
+func GetLocale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals("Locale").(string); ok {
+		return locale
+	}
+	return i18n.DefaultLocale()
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"runtime"
 	"strconv"
 	"time"
@@ -8,10 +9,13 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"sitewatch/internal/config"
 	"sitewatch/internal/logger"
+	"sitewatch/internal/services/jobs"
+	"sitewatch/internal/services/stats"
 )
 
-// MetricsMiddleware collects HTTP request metrics
-func MetricsMiddleware() fiber.Handler {
+// MetricsMiddleware collects HTTP request metrics and counts responses slower
+// than the response time SLA of their route (server.response_sla_ms)
+func MetricsMiddleware(responseSLA map[string]int) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
 		
@@ -30,17 +34,28 @@ func MetricsMiddleware() fiber.Handler {
 		statusStr := strconv.Itoa(status)
 		
 		// Record metrics
+		path := c.Route().Path
 		config.HTTPRequestsTotal.WithLabelValues(
 			c.Method(),
-			c.Route().Path,
+			path,
 			statusStr,
 		).Inc()
 		
 		config.HTTPRequestDuration.WithLabelValues(
 			c.Method(),
-			c.Route().Path,
+			path,
 		).Observe(duration)
 		
+		if limit, ok := responseSLA[path]; ok && duration*1000 > float64(limit) {
+			config.APISLAViolationsTotal.WithLabelValues(path).Inc()
+			requestID, _ := c.Locals("requestid").(string)
+			logger.Default().WithComponent("http").WithRequest(c.Method(), path).Warn("Response time SLA exceeded",
+				"request_id", requestID,
+				"duration_ms", duration*1000,
+				"sla_ms", limit,
+				"status", status)
+		}
+		
 		return err
 	}
 }
@@ -73,16 +88,31 @@ func UpdateSystemMetrics() {
 	)
 }
 
-// StartMetricsUpdater starts a goroutine that periodically updates system metrics
-func StartMetricsUpdater(interval time.Duration) {
-	log := logger.Default().WithComponent("metrics")
-	log.Info("Starting metrics updater", "interval", interval)
+// UpdateResultChannelMetrics publishes the fill level of the ping result channel.
+// A utilization near 1 means results are produced faster than they are processed.
+func UpdateResultChannelMetrics(appState *config.AppState) {
+	length := len(appState.ResultChan)
+	capacity := cap(appState.ResultChan)
 	
-	ticker := time.NewTicker(interval)
-	go func() {
-		defer ticker.Stop()
-		for range ticker.C {
+	config.ResultChanLengthGauge.Set(float64(length))
+	config.ResultChanCapacityGauge.Set(float64(capacity))
+	if capacity > 0 {
+		config.ResultChanUtilizationGauge.Set(float64(length) / float64(capacity))
+	}
+}
+
+// RegisterMetricsUpdater registers the job that periodically updates the
+// system metrics and the gauges derived from storage and application state,
+// so scrapes never trigger the recomputation themselves
+func RegisterMetricsUpdater(appState *config.AppState, interval time.Duration) error {
+	return jobs.Register(jobs.Job{
+		Name:     "metrics-updater",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
 			UpdateSystemMetrics()
-		}
-	}()
+			UpdateResultChannelMetrics(appState)
+			stats.UpdateDerivedMetrics(appState)
+			return nil
+		},
+	})
 }
\ No newline at end of file
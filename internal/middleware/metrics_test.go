@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/jobs"
+	"sitewatch/internal/storage"
+)
+
+// metricsUpdaterRuns returns how often the metrics updater ran and whether it is registered
+func metricsUpdaterRuns() (int, bool) {
+	for _, status := range jobs.List() {
+		if status.Name == "metrics-updater" {
+			return status.Runs, true
+		}
+	}
+	return 0, false
+}
+
+func TestMetricsUpdaterStopsOnShutdown(t *testing.T) {
+	appState := &config.AppState{
+		SiteStatus: make(map[string]*models.SiteStatus),
+		Storage:    storage.NewMemoryStorage(10),
+		ResultChan: make(chan models.PingResult, 4),
+	}
+	// The job stays registered with the application runner across -count runs
+	runs, registered := metricsUpdaterRuns()
+	if !registered {
+		if err := RegisterMetricsUpdater(appState, time.Hour); err != nil {
+			t.Fatalf("RegisterMetricsUpdater: %v", err)
+		}
+	}
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs.Start(ctx)
+
+	// The first update runs at start rather than after an interval
+	deadline := time.Now().Add(time.Second)
+	for {
+		if current, _ := metricsUpdaterRuns(); current > runs {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("metrics updater did not run at start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if !jobs.Wait(time.Second) {
+		t.Fatal("metrics updater still running after shutdown")
+	}
+	deadline = time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after shutdown, %d before start", runtime.NumGoroutine(), baseline)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// SecurityHeaders sets the security headers on every response and marks it
+// noindex, so crawlers on the network do not index the dashboard or the API.
+// csp "off" omits the Content-Security-Policy, frameOptions "ALLOW" the
+// X-Frame-Options header (server.security).
+func SecurityHeaders(csp, frameOptions string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("X-Content-Type-Options", "nosniff")
+		c.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		c.Set("X-Robots-Tag", "noindex, nofollow")
+		if frameOptions != "ALLOW" {
+			c.Set("X-Frame-Options", frameOptions)
+		}
+		if csp != "off" {
+			c.Set("Content-Security-Policy", csp)
+		}
+		return c.Next()
+	}
+}
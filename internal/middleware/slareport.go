@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"math"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sitewatch/internal/models"
+)
+
+// ResponseTimeReport returns the P50/P95/P99 response times of every route
+// from the http_request_duration_seconds histogram, together with the
+// configured SLA and its violations
+func ResponseTimeReport(responseSLA map[string]int) ([]models.RouteResponseTimes, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make(map[string]float64)
+	var durations []*dto.Metric
+	for _, family := range families {
+		switch family.GetName() {
+		case "api_sla_violations_total":
+			for _, metric := range family.GetMetric() {
+				violations[labelValue(metric, "path")] = metric.GetCounter().GetValue()
+			}
+		case "http_request_duration_seconds":
+			durations = family.GetMetric()
+		}
+	}
+
+	routes := make([]models.RouteResponseTimes, 0, len(durations))
+	for _, metric := range durations {
+		histogram := metric.GetHistogram()
+		if histogram.GetSampleCount() == 0 {
+			continue
+		}
+
+		route := models.RouteResponseTimes{
+			Method:   labelValue(metric, "method"),
+			Path:     labelValue(metric, "path"),
+			Requests: histogram.GetSampleCount(),
+			P50Ms:    quantileMs(0.50, histogram),
+			P95Ms:    quantileMs(0.95, histogram),
+			P99Ms:    quantileMs(0.99, histogram),
+		}
+		if limit, ok := responseSLA[route.Path]; ok {
+			count := violations[route.Path]
+			route.SLAMs = &limit
+			route.Violations = &count
+		}
+		routes = append(routes, route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// quantileMs returns a quantile of a seconds histogram in milliseconds, rounded to 0.01 ms
+func quantileMs(q float64, histogram *dto.Histogram) float64 {
+	return math.Round(histogramQuantile(q, histogram)*1000*100) / 100
+}
+
+// histogramQuantile estimates a quantile by linear interpolation within the
+// bucket it falls into, like PromQL histogram_quantile. A quantile in the
+// +Inf bucket is reported as the largest finite bucket bound.
+func histogramQuantile(q float64, histogram *dto.Histogram) float64 {
+	rank := q * float64(histogram.GetSampleCount())
+
+	var lowerBound, lowerCount float64
+	for _, bucket := range histogram.GetBucket() {
+		upperBound := bucket.GetUpperBound()
+		count := float64(bucket.GetCumulativeCount())
+		if count >= rank {
+			if math.IsInf(upperBound, 1) || count == lowerCount {
+				return lowerBound
+			}
+			return lowerBound + (upperBound-lowerBound)*(rank-lowerCount)/(count-lowerCount)
+		}
+		lowerBound, lowerCount = upperBound, count
+	}
+	return lowerBound
+}
+
+// labelValue returns the value of a metric label, empty if not set
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"sitewatch/internal/models"
+)
+
+// oidcLoginTimeout bounds the time between redirecting to the provider and the callback
+const oidcLoginTimeout = 10 * time.Minute
+
+// ErrOIDCTokenInvalid is returned when the ID token of a login cannot be verified
+var ErrOIDCTokenInvalid = errors.New("invalid ID token")
+
+// OIDCIdentity is a user authenticated by the OIDC provider
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+// pendingLogin is a login redirected to the provider and waiting for its callback
+type pendingLogin struct {
+	nonce       string
+	redirectURL string
+	created     time.Time
+}
+
+// OIDCProvider runs the OpenID Connect authorization code flow. Provider
+// discovery happens on first use, so an unreachable provider at startup does
+// not prevent SiteWatch from starting.
+type OIDCProvider struct {
+	cfg      models.OIDCAuthConfig
+	mu       sync.Mutex
+	provider *oidc.Provider
+	pending  map[string]pendingLogin // state -> login
+}
+
+// NewOIDCProvider creates an OIDC provider for cfg
+func NewOIDCProvider(cfg models.OIDCAuthConfig) *OIDCProvider {
+	return &OIDCProvider{
+		cfg:     cfg,
+		pending: make(map[string]pendingLogin),
+	}
+}
+
+// AuthCodeURL starts a login and returns the state to bind to the browser and
+// the provider URL to redirect to. forceLogin adds prompt=login so the
+// provider asks for credentials even when the user has a provider session.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, redirectURL string, forceLogin bool) (string, string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := GenerateUISecret()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := GenerateUISecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	p.mu.Lock()
+	for key, login := range p.pending {
+		if time.Since(login.created) > oidcLoginTimeout {
+			delete(p.pending, key)
+		}
+	}
+	p.pending[state] = pendingLogin{nonce: nonce, redirectURL: redirectURL, created: time.Now()}
+	p.mu.Unlock()
+
+	options := []oauth2.AuthCodeOption{oidc.Nonce(nonce)}
+	if forceLogin {
+		options = append(options, oauth2.SetAuthURLParam("prompt", "login"))
+	}
+	return state, p.oauth2Config(provider, redirectURL).AuthCodeURL(state, options...), nil
+}
+
+// Exchange completes the login started with state: it redeems the
+// authorization code and verifies the returned ID token and its nonce
+func (p *OIDCProvider) Exchange(ctx context.Context, state, code string) (*OIDCIdentity, error) {
+	p.mu.Lock()
+	login, exists := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !exists || time.Since(login.created) > oidcLoginTimeout {
+		return nil, errors.New("unknown or expired login state")
+	}
+
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider, login.redirectURL).Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("exchanging authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrOIDCTokenInvalid)
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+	if idToken.Nonce != login.nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCTokenInvalid)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: decoding claims: %v", ErrOIDCTokenInvalid, err)
+	}
+
+	identity := &OIDCIdentity{Subject: idToken.Subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	switch groups := claims[p.cfg.GroupsClaim].(type) {
+	case string:
+		identity.Groups = []string{groups}
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	}
+	return identity, nil
+}
+
+// Permissions maps an identity to permissions: a verified email grants read,
+// membership in one of the admin groups grants admin
+func (p *OIDCProvider) Permissions(identity *OIDCIdentity) []string {
+	var permissions []string
+	if identity.EmailVerified {
+		permissions = append(permissions, string(models.PermissionRead))
+	}
+	for _, group := range identity.Groups {
+		if slices.Contains(p.cfg.AdminGroups, group) {
+			permissions = append(permissions, string(models.PermissionAdmin))
+			break
+		}
+	}
+	return permissions
+}
+
+// discover fetches the provider metadata once and caches it
+func (p *OIDCProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+	provider, err := oidc.NewProvider(ctx, p.cfg.ProviderURL)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OIDC provider %s: %w", p.cfg.ProviderURL, err)
+	}
+	p.provider = provider
+	return provider, nil
+}
+
+// oauth2Config returns the OAuth2 client configuration for a redirect URL
+func (p *OIDCProvider) oauth2Config(provider *oidc.Provider, redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       p.cfg.Scopes,
+	}
+}
@@ -11,14 +11,21 @@ import (
 
 // Service handles authentication operations
 type Service struct {
-	config *models.AuthConfig
+	config   *models.AuthConfig
+	sessions *SessionStore
+	oidc     *OIDCProvider // nil unless OIDC is configured
 }
 
 // NewService creates a new authentication service
 func NewService(config *models.AuthConfig) *Service {
-	return &Service{
-		config: config,
+	s := &Service{
+		config:   config,
+		sessions: NewSessionStore(),
 	}
+	if config != nil && config.OIDC.IsEnabled() {
+		s.oidc = NewOIDCProvider(config.OIDC)
+	}
+	return s
 }
 
 // IsEnabled returns whether authentication is enabled
@@ -26,6 +33,19 @@ func (s *Service) IsEnabled() bool {
 	return s.config != nil && s.config.Enabled
 }
 
+// OIDC returns the OIDC provider, nil when single sign-on is not configured
+func (s *Service) OIDC() *OIDCProvider {
+	if !s.IsEnabled() {
+		return nil
+	}
+	return s.oidc
+}
+
+// Sessions returns the store of single sign-on sessions
+func (s *Service) Sessions() *SessionStore {
+	return s.sessions
+}
+
 // ValidateUISecret validates UI session secret
 func (s *Service) ValidateUISecret(secret string) bool {
 	if !s.IsEnabled() {
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"sitewatch/internal/models"
+)
+
+// Session is a UI login created by single sign-on
+type Session struct {
+	ID          string
+	Subject     string
+	Email       string
+	Permissions []string
+	Expires     time.Time
+}
+
+// Token returns the session as an API token so permission checks work the same way
+func (s *Session) Token() *models.APIToken {
+	return &models.APIToken{
+		Token:       "session",
+		Name:        "SSO: " + s.Email,
+		Permissions: s.Permissions,
+	}
+}
+
+// SessionStore keeps UI sessions in memory. Sessions do not survive a restart;
+// users simply log in again.
+type SessionStore struct {
+	sessions map[string]*Session
+	mu       sync.Mutex
+}
+
+// NewSessionStore creates an empty session store
+func NewSessionStore() *SessionStore {
+	return &SessionStore{sessions: make(map[string]*Session)}
+}
+
+// Create stores a new session for the given identity and returns it
+func (s *SessionStore) Create(subject, email string, permissions []string, ttl time.Duration) (*Session, error) {
+	id, err := GenerateUISecret()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		ID:          id,
+		Subject:     subject,
+		Email:       email,
+		Permissions: permissions,
+		Expires:     time.Now().Add(ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	s.sessions[id] = session
+	return session, nil
+}
+
+// Get returns the session with the given ID if it exists and has not expired
+func (s *SessionStore) Get(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return nil, false
+	}
+	if time.Now().After(session.Expires) {
+		delete(s.sessions, id)
+		return nil, false
+	}
+	return session, true
+}
+
+// Delete removes a session
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// pruneLocked drops expired sessions, callers must hold s.mu
+func (s *SessionStore) pruneLocked() {
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.Expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
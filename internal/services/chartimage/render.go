@@ -0,0 +1,248 @@
+package chartimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"sitewatch/internal/models"
+)
+
+// Image size limits in pixels, requests outside are clamped
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 300
+	MinWidth      = 200
+	MinHeight     = 120
+	MaxWidth      = 1600
+	MaxHeight     = 800
+)
+
+// Plot area margins in pixels, the left one holds the axis labels
+const (
+	marginLeft   = 56
+	marginRight  = 16
+	marginTop    = 28
+	marginBottom = 24
+)
+
+// Line colors, as in the dashboard charts
+var (
+	colorPrimary   = color.RGBA{59, 130, 246, 255}
+	colorSecondary = color.RGBA{16, 185, 129, 255}
+	colorCombined  = color.RGBA{107, 114, 128, 255}
+	colorGrid      = color.RGBA{229, 231, 235, 255}
+	colorText      = color.RGBA{55, 65, 81, 255}
+)
+
+// Series is one line of a chart, NaN values leave a gap
+type Series struct {
+	Label  string
+	Values models.ChartSeries
+	Color  color.RGBA
+	Dashed bool
+}
+
+// Chart describes a line chart over labelled buckets
+type Chart struct {
+	Title   string
+	Unit    string // Appended to the axis labels, e.g. "ms" or "%"
+	Percent bool   // The axis ends at 100 and starts at the next 10% below the lowest value
+	Labels  []string
+	Series  []Series
+}
+
+// ClampSize returns the width and height within the limits, zero selects the default
+func ClampSize(width, height int) (int, int) {
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	return min(max(width, MinWidth), MaxWidth), min(max(height, MinHeight), MaxHeight)
+}
+
+// Render draws the chart as PNG, the size is clamped to the limits
+func Render(chart Chart, width, height int) ([]byte, error) {
+	width, height = ClampSize(width, height)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+	low, high := axisRange(chart)
+	y := func(value float64) int {
+		share := (value - low) / (high - low)
+		return plot.Max.Y - int(math.Round(math.Max(0, math.Min(1, share))*float64(plot.Dy())))
+	}
+
+	// Horizontal grid lines with their values
+	const gridLines = 4
+	for i := 0; i <= gridLines; i++ {
+		value := low + (high-low)*float64(i)/gridLines
+		row := y(value)
+		line(img, plot.Min.X, row, plot.Max.X, row, colorGrid, false)
+		label := formatValue(value) + chart.Unit
+		text(img, plot.Min.X-6-textWidth(label), row+4, label, colorText)
+	}
+
+	// First, middle and last bucket label
+	if n := len(chart.Labels); n > 0 {
+		for _, i := range []int{0, n / 2, n - 1} {
+			label := chart.Labels[i]
+			x := plot.Min.X + bucketX(i, n, plot.Dx()) - textWidth(label)/2
+			x = min(max(x, plot.Min.X), plot.Max.X-textWidth(label))
+			text(img, x, height-8, label, colorText)
+		}
+	}
+
+	for _, series := range chart.Series {
+		n := len(series.Values)
+		previous := image.Point{X: -1}
+		for i, value := range series.Values {
+			if math.IsNaN(value) {
+				previous.X = -1
+				continue
+			}
+			point := image.Point{X: plot.Min.X + bucketX(i, n, plot.Dx()), Y: y(value)}
+			if previous.X < 0 {
+				// Isolated points stay visible as a dot
+				draw.Draw(img, image.Rect(point.X-1, point.Y-1, point.X+2, point.Y+2), &image.Uniform{series.Color}, image.Point{}, draw.Src)
+			} else {
+				line(img, previous.X, previous.Y, point.X, point.Y, series.Color, series.Dashed)
+				line(img, previous.X, previous.Y+1, point.X, point.Y+1, series.Color, series.Dashed)
+			}
+			previous = point
+		}
+	}
+
+	// Title on the left, legend on the right
+	text(img, plot.Min.X, 17, chart.Title, colorText)
+	x := width - marginRight
+	for i := len(chart.Series) - 1; i >= 0; i-- {
+		series := chart.Series[i]
+		x -= textWidth(series.Label)
+		text(img, x, 17, series.Label, colorText)
+		x -= 14
+		draw.Draw(img, image.Rect(x, 9, x+10, 19), &image.Uniform{series.Color}, image.Point{}, draw.Src)
+		x -= 12
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// axisRange returns the bounds of the value axis: 0 to a round value above
+// the highest value, for percentages the next 10% below the lowest value to 100
+func axisRange(chart Chart) (float64, float64) {
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, series := range chart.Series {
+		for _, value := range series.Values {
+			if !math.IsNaN(value) {
+				lowest = math.Min(lowest, value)
+				highest = math.Max(highest, value)
+			}
+		}
+	}
+	if chart.Percent {
+		if math.IsInf(lowest, 1) {
+			return 0, 100
+		}
+		return math.Min(90, math.Max(0, math.Floor(lowest/10)*10)), 100
+	}
+	if math.IsInf(highest, -1) || highest <= 0 {
+		return 0, 1
+	}
+	return 0, niceCeil(highest * 1.1)
+}
+
+// niceCeil rounds up to 1, 2, 2.5 or 5 times a power of ten
+func niceCeil(value float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+	for _, step := range []float64{1, 2, 2.5, 5, 10} {
+		if value <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// formatValue formats an axis value without needless decimals
+func formatValue(value float64) string {
+	if value == math.Trunc(value) {
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+// bucketX returns the horizontal offset of bucket i of n in a plot of the given width
+func bucketX(i, n, width int) int {
+	if n <= 1 {
+		return width / 2
+	}
+	return int(math.Round(float64(i) * float64(width) / float64(n-1)))
+}
+
+// line draws a one pixel line (Bresenham), dashed lines skip every other 6 pixels
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, dashed bool) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for step := 0; ; step++ {
+		if !dashed || (step/6)%2 == 0 {
+			img.Set(x0, y0, c)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// text draws s with its baseline at y
+func text(img *image.RGBA, x, y int, s string, c color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(s)
+}
+
+// textWidth returns the width of s in pixels
+func textWidth(s string) int {
+	return font.MeasureString(basicfont.Face7x13, s).Ceil()
+}
@@ -0,0 +1,142 @@
+package chartimage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/services/stats"
+)
+
+// CacheTTL is how long a rendered chart is served again, as long as the site
+// has no newer check
+const CacheTTL = time.Minute
+
+// maxCachedCharts bounds the cache, expired charts are dropped first
+const maxCachedCharts = 256
+
+// ErrSiteNotFound is returned for charts of unknown sites
+var ErrSiteNotFound = errors.New("site not found")
+
+// chartTitles name the chart types rendered as images
+var chartTitles = map[string]string{
+	"latency":             "Latency",
+	"jitter":              "Jitter",
+	"uptime":              "Uptime",
+	"packet_transmission": "Packet delivery",
+	"ttl":                 "Reply TTL",
+}
+
+// Types returns the chart types available as images
+func Types() []string {
+	return []string{"latency", "uptime", "packet_transmission", "jitter", "ttl"}
+}
+
+type cachedChart struct {
+	png     []byte
+	expires time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cachedChart)
+)
+
+// SiteChart renders a chart of a site for a preset range, e.g. "24h", as PNG.
+// Charts are cached for CacheTTL per site, type, range, size and time of the
+// last check, so a new check renders a fresh chart.
+func SiteChart(app *config.AppState, siteID, chartType, timeRange string, width, height int) ([]byte, error) {
+	title, supported := chartTitles[chartType]
+	if !supported {
+		return nil, fmt.Errorf("chart type %q is not available as image (expected one of %s)", chartType, strings.Join(Types(), ", "))
+	}
+	if err := stats.ValidateChartRange(chartType, timeRange); err != nil {
+		return nil, err
+	}
+	site, exists := app.FindSite(siteID)
+	if !exists {
+		return nil, ErrSiteNotFound
+	}
+
+	width, height = ClampSize(width, height)
+	var lastCheck time.Time
+	if status, exists := app.GetSiteStatus(siteID); exists {
+		lastCheck = status.LastCheck
+	}
+	key := fmt.Sprintf("%s|%s|%s|%dx%d|%d", siteID, chartType, timeRange, width, height, lastCheck.UnixNano())
+
+	now := clock.Default().Now()
+	cacheMu.Lock()
+	cached, hit := cache[key]
+	cacheMu.Unlock()
+	if hit && now.Before(cached.expires) {
+		return cached.png, nil
+	}
+
+	result, ok := stats.GenerateChartDataForRange(app, siteID, chartType, timeRange).(stats.ChartDataResult)
+	if !ok {
+		return nil, fmt.Errorf("chart type %q is not available as image", chartType)
+	}
+
+	locale := app.Config.Server.Locale
+	lineLabel := func(provider, line string) string {
+		if provider != "" {
+			return provider
+		}
+		return i18n.T(locale, "line."+line)
+	}
+	chart := Chart{
+		Title:  fmt.Sprintf("%s - %s (%s)", site.Name, title, timeRange),
+		Labels: result.Labels,
+		Series: []Series{{Label: lineLabel(site.PrimaryProvider, "primary"), Values: result.PrimaryData, Color: colorPrimary}},
+	}
+	switch chartType {
+	case "latency", "jitter":
+		chart.Unit = "ms"
+	case "uptime", "packet_transmission":
+		chart.Unit, chart.Percent = "%", true
+	}
+	if site.IsDualLine() {
+		chart.Series = append(chart.Series, Series{Label: lineLabel(site.SecondaryProvider, "secondary"), Values: result.SecondaryData, Color: colorSecondary})
+		if len(result.CombinedData) > 0 {
+			chart.Series = append(chart.Series, Series{Label: i18n.T(locale, "line.combined"), Values: result.CombinedData, Color: colorCombined, Dashed: true})
+		}
+	}
+
+	png, err := Render(chart, width, height)
+	if err != nil {
+		return nil, err
+	}
+	storeChart(key, png, now)
+	return png, nil
+}
+
+// NotificationChart renders the 24h latency chart of a site in the default
+// size, attached to alert notifications
+func NotificationChart(app *config.AppState, siteID string) ([]byte, error) {
+	return SiteChart(app, siteID, "latency", "24h", 0, 0)
+}
+
+// storeChart caches a rendered chart, dropping expired ones when the cache is
+// full and everything when that is not enough
+func storeChart(key string, png []byte, now time.Time) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if len(cache) >= maxCachedCharts {
+		for k, cached := range cache {
+			if !now.Before(cached.expires) {
+				delete(cache, k)
+			}
+		}
+		if len(cache) >= maxCachedCharts {
+			cache = make(map[string]cachedChart)
+		}
+	}
+	cache[key] = cachedChart{png: png, expires: now.Add(CacheTTL)}
+}
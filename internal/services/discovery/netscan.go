@@ -0,0 +1,146 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/ping"
+)
+
+// probeTimeout bounds the wait for the reply of a single scanned address
+const probeTimeout = time.Second
+
+// StartSubnetScanner scans the configured subnets now and then every
+// discovery.subnet_scan.interval_hours
+func StartSubnetScanner(ctx context.Context, app *config.AppState) {
+	cfg := app.Config.Discovery.SubnetScan
+	interval := time.Duration(cfg.IntervalHours) * time.Hour
+
+	log := logger.Default().WithComponent("discovery")
+	log.Info("Starting subnet discovery",
+		"subnets", cfg.Subnets,
+		"interval", interval,
+		"max_concurrent", cfg.MaxConcurrent)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := ScanSubnets(ctx, app); err != nil && ctx.Err() == nil {
+				log.Error("Subnet scan aborted", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				log.Info("Stopping subnet discovery")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ScanSubnets pings every host address of the configured subnets and records
+// the responsive ones that are not monitored yet as discovery candidates. The
+// scan is aborted when ICMP cannot be used at all.
+func ScanSubnets(ctx context.Context, app *config.AppState) error {
+	cfg := app.Config.Discovery.SubnetScan
+	log := logger.Default().WithComponent("discovery")
+
+	monitored := make(map[string]bool)
+	for _, site := range app.GetSitesSnapshot() {
+		monitored[site.PrimaryIP] = true
+		if site.IsDualLine() {
+			monitored[site.SecondaryIP] = true
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		scanErr error
+		scanned int
+		found   int
+		slots   = make(chan struct{}, cfg.MaxConcurrent)
+		started = time.Now()
+	)
+
+	probe := func(ip string) {
+		defer wg.Done()
+		defer func() { <-slots }()
+
+		latency, ok, err := ping.PingIPFull(app, ip, probeTimeout)
+		mu.Lock()
+		defer mu.Unlock()
+		scanned++
+		switch {
+		case err != nil:
+			if scanErr == nil {
+				scanErr = err
+				cancel()
+			}
+		case ok:
+			now := time.Now()
+			candidate := models.DiscoveryCandidate{IP: ip, FirstSeen: now, LastSeen: now, LatencyMs: latency}
+			if err := app.Storage.UpsertDiscoveryCandidate(candidate); err != nil {
+				log.Error("Failed to store discovery candidate", "ip", ip, "error", err)
+				return
+			}
+			found++
+		}
+	}
+
+scan:
+	for _, subnet := range cfg.Subnets {
+		prefix, err := netip.ParsePrefix(subnet)
+		if err != nil {
+			return fmt.Errorf("invalid subnet %q: %w", subnet, err)
+		}
+		for _, addr := range hostAddresses(prefix) {
+			ip := addr.String()
+			if monitored[ip] {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				break scan
+			case slots <- struct{}{}:
+			}
+			wg.Add(1)
+			go probe(ip)
+		}
+	}
+	wg.Wait()
+
+	if scanErr != nil {
+		return scanErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log.Info("Subnet scan finished", "scanned", scanned, "responsive", found, "duration", time.Since(started).Round(time.Second))
+	return nil
+}
+
+// hostAddresses returns the addresses of a prefix, without the network and
+// broadcast address of IPv4 subnets larger than /31
+func hostAddresses(prefix netip.Prefix) []netip.Addr {
+	prefix = prefix.Masked()
+	var addrs []netip.Addr
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		addrs = append(addrs, addr)
+	}
+	if prefix.Addr().Is4() && prefix.Bits() < 31 && len(addrs) > 2 {
+		addrs = addrs[1 : len(addrs)-1]
+	}
+	return addrs
+}
@@ -0,0 +1,216 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	filePrefix = "sitewatch-export-"
+)
+
+// StartExportScheduler starts a goroutine that periodically exports the log history to disk
+func StartExportScheduler(ctx context.Context, appState *config.AppState) {
+	log := logger.Default().WithComponent("export")
+	cfg := appState.Config.Export
+
+	log.Info("Starting export scheduler",
+		"interval", cfg.Interval,
+		"directory", cfg.Directory,
+		"format", cfg.Format,
+		"retention", cfg.Retention)
+
+	ticker := time.NewTicker(cfg.Interval)
+	go func() {
+		defer ticker.Stop()
+		windowStart := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("Stopping export scheduler")
+				return
+			case now := <-ticker.C:
+				if _, err := RunExport(appState, windowStart, now); err != nil {
+					log.Error("Export failed", "error", err)
+					continue
+				}
+				windowStart = now
+			}
+		}
+	}()
+}
+
+// RunExport writes all logs in [start, end) to a timestamped file and prunes old exports
+func RunExport(appState *config.AppState, start, end time.Time) (string, error) {
+	log := logger.Default().WithComponent("export")
+	cfg := appState.Config.Export
+
+	logs, err := appState.Storage.GetLogsInRange(start, end)
+	if err != nil {
+		return "", fmt.Errorf("querying logs: %w", err)
+	}
+
+	if err := os.MkdirAll(cfg.Directory, 0755); err != nil {
+		return "", fmt.Errorf("creating export directory %s: %w", cfg.Directory, err)
+	}
+
+	fileName := filePrefix + end.UTC().Format("20060102T150405Z") + "." + cfg.Format
+	path := filepath.Join(cfg.Directory, fileName)
+
+	// Write to a temp file first so partially written exports never look complete
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("creating export file: %w", err)
+	}
+
+	switch cfg.Format {
+	case FormatNDJSON:
+		err = writeNDJSON(file, logs)
+	default:
+		err = writeCSV(file, logs)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("writing export file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("finalizing export file: %w", err)
+	}
+
+	log.Info("Export written", "path", path, "records", len(logs), "from", start.UTC(), "to", end.UTC())
+
+	if err := pruneExports(cfg.Directory, cfg.Retention); err != nil {
+		log.Warn("Failed to prune old exports", "error", err)
+	}
+
+	return path, nil
+}
+
+// writeCSV writes logs as CSV with a header row
+func writeCSV(w io.Writer, logs []models.PingLog) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"id", "timestamp", "site_id", "site_name", "target", "ip", "success", "latency", "error",
+		"packets_sent", "packets_recv", "packets_duplicates", "packet_loss",
+		"min_latency", "max_latency", "jitter", "ttl",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, l := range logs {
+		record := []string{
+			strconv.Itoa(l.ID),
+			l.Timestamp.UTC().Format(time.RFC3339Nano),
+			l.SiteID,
+			l.SiteName,
+			l.Target,
+			l.IP,
+			strconv.FormatBool(l.Success),
+			formatOptionalFloat(l.Latency),
+			l.Error,
+			strconv.Itoa(l.PacketsSent),
+			strconv.Itoa(l.PacketsRecv),
+			strconv.Itoa(l.PacketsDuplicates),
+			formatOptionalFloat(l.PacketLoss),
+			formatOptionalFloat(l.MinLatency),
+			formatOptionalFloat(l.MaxLatency),
+			formatOptionalFloat(l.Jitter),
+			formatOptionalInt(l.TTL),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeNDJSON writes logs as newline-delimited JSON
+func writeNDJSON(w io.Writer, logs []models.PingLog) error {
+	encoder := json.NewEncoder(w)
+	for _, l := range logs {
+		if err := encoder.Encode(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatOptionalFloat formats nullable values, leaving missing values empty
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// formatOptionalInt formats nullable integers, leaving missing values empty
+func formatOptionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// pruneExports removes the oldest export files beyond the retention count
+func pruneExports(dir string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var exports []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		exports = append(exports, name)
+	}
+
+	if len(exports) <= retention {
+		return nil
+	}
+
+	// File names embed a sortable UTC timestamp
+	sort.Strings(exports)
+
+	log := logger.Default().WithComponent("export")
+	for _, name := range exports[:len(exports)-retention] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+		log.Debug("Removed old export", "file", name)
+	}
+
+	return nil
+}
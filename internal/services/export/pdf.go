@@ -0,0 +1,38 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// pdfTimeout bounds a single wkhtmltopdf run
+const pdfTimeout = 30 * time.Second
+
+// ErrPDFUnavailable is returned when the wkhtmltopdf binary cannot be found
+var ErrPDFUnavailable = errors.New("wkhtmltopdf not available")
+
+// HTMLToPDF converts an HTML document to PDF with the wkhtmltopdf binary
+func HTMLToPDF(ctx context.Context, binary string, html []byte) ([]byte, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPDFUnavailable, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pdfTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "--quiet", "--encoding", "utf-8", "-", "-")
+	cmd.Stdin = bytes.NewReader(html)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running wkhtmltopdf: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+package housekeeping
+
+import (
+	"context"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/jobs"
+)
+
+// RegisterStorageMaintenance registers the jobs running PRAGMA optimize every
+// storage.optimize_interval and VACUUM every storage.vacuum_interval, a zero
+// interval leaves its job out
+func RegisterStorageMaintenance(app *config.AppState) error {
+	if interval := app.Config.Storage.OptimizeInterval; interval > 0 {
+		err := jobs.Register(jobs.Job{
+			Name:     "storage-optimize",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				_, err := OptimizeStorage(app, false)
+				return err
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if interval := app.Config.Storage.VacuumInterval; interval > 0 {
+		return jobs.Register(jobs.Job{
+			Name:     "storage-vacuum",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				_, err := OptimizeStorage(app, true)
+				return err
+			},
+		})
+	}
+	return nil
+}
+
+// OptimizeStorage runs the database maintenance, with a vacuum if requested,
+// and logs the space it reclaimed
+func OptimizeStorage(app *config.AppState, vacuum bool) (models.StorageOptimizeResult, error) {
+	log := logger.Default().WithComponent("housekeeping")
+
+	result, err := app.Storage.Optimize(vacuum)
+	if err != nil {
+		log.Error("Storage optimization failed", "vacuum", vacuum, "error", err)
+		return result, err
+	}
+	log.Info("Storage optimized",
+		"vacuum", result.Vacuum,
+		"size_before_bytes", result.SizeBefore,
+		"size_after_bytes", result.SizeAfter,
+		"reclaimed_bytes", result.ReclaimedBytes,
+		"duration_ms", result.DurationMs)
+	return result, nil
+}
@@ -0,0 +1,301 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+var (
+	// ErrJobNotFound is returned when triggering a job that is not registered
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrJobRunning is returned when triggering a job that is already running
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Job is a named background task run on an interval or a cron schedule
+type Job struct {
+	Name     string
+	Interval time.Duration // Run at start and then every interval
+	Cron     string        // Standard 5-field cron spec, used when Interval is 0
+	Run      func(ctx context.Context) error
+}
+
+// Status is the state of a registered job
+type Status struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"` // "every 30s" or the cron spec
+	Running        bool       `json:"running"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMs float64    `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+	Runs           int        `json:"runs"`
+	Failures       int        `json:"failures"`
+}
+
+// entry is a registered job with its schedule and state
+type entry struct {
+	job      Job
+	schedule cron.Schedule // nil for interval jobs
+	trigger  chan struct{}
+	clock    clock.Clock
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Runner runs the registered jobs, each in its own goroutine. A job never
+// runs concurrently with itself, a run that falls due while the previous one
+// is still running is skipped.
+type Runner struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	ctx     context.Context // Set by Start, jobs registered later start immediately
+	wg      sync.WaitGroup  // Running job loops
+	clock   clock.Clock     // Time source of the schedules, nil uses clock.Default at Register
+}
+
+// globalRunner is the runner of the application
+var globalRunner = &Runner{entries: make(map[string]*entry)}
+
+// NewRunner creates a job runner scheduling on the given clock
+func NewRunner(c clock.Clock) *Runner {
+	return &Runner{entries: make(map[string]*entry), clock: c}
+}
+
+// Register adds a job to the application runner
+func Register(job Job) error {
+	return globalRunner.Register(job)
+}
+
+// Start runs the jobs of the application runner until ctx is done
+func Start(ctx context.Context) {
+	globalRunner.Start(ctx)
+}
+
+// Wait waits up to timeout for the jobs of the application runner to stop
+func Wait(timeout time.Duration) bool {
+	return globalRunner.Wait(timeout)
+}
+
+// List returns the status of the jobs of the application runner
+func List() []Status {
+	return globalRunner.List()
+}
+
+// Trigger runs a job of the application runner now
+func Trigger(name string) error {
+	return globalRunner.Trigger(name)
+}
+
+// Register validates and adds a job
+func (r *Runner) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job needs a name and a run function")
+	}
+
+	clk := r.clock
+	if clk == nil {
+		clk = clock.Default()
+	}
+	e := &entry{job: job, trigger: make(chan struct{}, 1), clock: clk}
+	switch {
+	case job.Interval > 0:
+		e.status.Schedule = "every " + job.Interval.String()
+	case job.Cron != "":
+		schedule, err := cron.ParseStandard(job.Cron)
+		if err != nil {
+			return fmt.Errorf("job %s: invalid cron spec %q: %w", job.Name, job.Cron, err)
+		}
+		e.schedule = schedule
+		e.status.Schedule = job.Cron
+	default:
+		return fmt.Errorf("job %s needs an interval or a cron spec", job.Name)
+	}
+	e.status.Name = job.Name
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.entries[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	r.entries[job.Name] = e
+	if r.ctx != nil {
+		r.startLoop(e)
+	}
+	return nil
+}
+
+// Start runs the registered jobs until ctx is done
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ctx = ctx
+	for _, e := range r.entries {
+		r.startLoop(e)
+	}
+	logger.Default().WithComponent("jobs").Info("Job runner started", "jobs", len(r.entries))
+}
+
+// startLoop runs the loop of a job in its own goroutine, r.mu must be held
+func (r *Runner) startLoop(e *entry) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		e.loop(r.ctx)
+	}()
+}
+
+// Wait waits for the job loops to return after the context passed to Start
+// is done. It returns false if a job is still running after timeout.
+func (r *Runner) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// List returns the status of all jobs, sorted by name
+func (r *Runner) List() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(r.entries))
+	for _, e := range r.entries {
+		e.mu.Lock()
+		statuses = append(statuses, e.status)
+		e.mu.Unlock()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Trigger runs a job now in the background, outside its schedule
+func (r *Runner) Trigger(name string) error {
+	r.mu.RLock()
+	e, exists := r.entries[name]
+	started := r.ctx != nil
+	r.mu.RUnlock()
+	if !exists {
+		return ErrJobNotFound
+	}
+	if !started {
+		return fmt.Errorf("job runner is not started")
+	}
+
+	e.mu.Lock()
+	running := e.status.Running
+	e.mu.Unlock()
+	if running {
+		return ErrJobRunning
+	}
+
+	select {
+	case e.trigger <- struct{}{}:
+		return nil
+	default:
+		return ErrJobRunning // A trigger is already pending
+	}
+}
+
+// loop runs the job on its schedule and on triggers until ctx is done
+func (e *entry) loop(ctx context.Context) {
+	log := logger.Default().WithComponent("jobs")
+
+	next := e.clock.Now()
+	if e.schedule != nil {
+		next = e.schedule.Next(next)
+	}
+	timer := e.clock.NewTimer(next.Sub(e.clock.Now()))
+	defer timer.Stop()
+
+	for {
+		e.mu.Lock()
+		e.status.NextRun = &next
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			log.Info("Stopping job", "job", e.job.Name)
+			return
+		case <-e.trigger:
+			e.run(ctx, "manual")
+		case <-timer.C():
+			e.run(ctx, "scheduled")
+			if e.schedule != nil {
+				next = e.schedule.Next(e.clock.Now())
+			} else {
+				next = next.Add(e.job.Interval)
+				if now := e.clock.Now(); next.Before(now) {
+					next = now.Add(e.job.Interval) // Skip the runs missed while running
+				}
+			}
+			timer.Reset(next.Sub(e.clock.Now()))
+		}
+	}
+}
+
+// run executes the job once, recovering from panics, and records the result
+func (e *entry) run(ctx context.Context, reason string) {
+	log := logger.Default().WithComponent("jobs")
+	start := e.clock.Now()
+
+	e.mu.Lock()
+	e.status.Running = true
+	e.mu.Unlock()
+
+	err := func() (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("panic: %v", recovered)
+				log.Error("Job panicked", "job", e.job.Name, "panic", recovered, "stack", string(debug.Stack()))
+			}
+		}()
+		return e.job.Run(ctx)
+	}()
+	duration := e.clock.Now().Sub(start)
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	config.JobRunsTotal.WithLabelValues(e.job.Name, result).Inc()
+	config.JobDurationSeconds.WithLabelValues(e.job.Name).Observe(duration.Seconds())
+
+	e.mu.Lock()
+	e.status.Running = false
+	e.status.LastRun = &start
+	e.status.LastDurationMs = float64(duration.Microseconds()) / 1000
+	e.status.Runs++
+	e.status.LastError = ""
+	if err != nil {
+		e.status.Failures++
+		e.status.LastError = err.Error()
+	}
+	e.mu.Unlock()
+
+	if err != nil {
+		log.Error("Job failed", "job", e.job.Name, "trigger", reason, "duration", duration.String(), "error", err)
+		return
+	}
+	log.Debug("Job finished", "job", e.job.Name, "trigger", reason, "duration", duration.String())
+}
@@ -0,0 +1,351 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+const (
+	// ImportInterval controls how often the iCal feeds are fetched
+	ImportInterval = time.Hour
+
+	// ImportHorizon is how far ahead maintenance windows are imported
+	ImportHorizon = 30 * 24 * time.Hour
+
+	// importLookback keeps windows that started recently, so a running window
+	// survives a refresh
+	importLookback = 24 * time.Hour
+
+	fetchTimeout = 30 * time.Second
+)
+
+// StartICalImporter loads the stored maintenance windows and then imports
+// the configured iCal feeds every ImportInterval
+func StartICalImporter(ctx context.Context, app *config.AppState) {
+	log := logger.Default().WithComponent("maintenance")
+	log.Info("Starting maintenance window import",
+		"feeds", len(app.Config.Maintenance.ICalFeeds),
+		"calendar_tag", app.Config.Maintenance.CalendarTag,
+		"interval", ImportInterval)
+
+	// Windows imported before a restart apply until the feeds answer again
+	if err := RefreshWindows(app); err != nil {
+		log.Error("Failed to load maintenance windows", "error", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(ImportInterval)
+		defer ticker.Stop()
+
+		for {
+			ImportFeeds(ctx, app)
+			select {
+			case <-ctx.Done():
+				log.Info("Stopping maintenance window import")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ImportFeeds fetches every feed and replaces the windows stored for it. A
+// feed that cannot be fetched or parsed keeps its previously imported windows.
+func ImportFeeds(ctx context.Context, app *config.AppState) {
+	log := logger.Default().WithComponent("maintenance")
+	now := time.Now()
+
+	sites := make(map[string]bool)
+	for _, site := range app.GetSitesSnapshot() {
+		sites[site.ID] = true
+	}
+
+	for _, feed := range app.Config.Maintenance.ICalFeeds {
+		calendar, err := fetchCalendar(ctx, feed)
+		if err != nil {
+			log.Warn("Failed to import maintenance windows, keeping existing ones", "url", feed, "error", err)
+			continue
+		}
+
+		windows := ParseWindows(calendar, app.Config.Maintenance.CalendarTag, now.Add(-importLookback), now.Add(ImportHorizon))
+		imported := windows[:0]
+		for _, window := range windows {
+			if !sites[window.SiteID] {
+				log.Warn("Maintenance event for unknown site ignored", "url", feed, "site_id", window.SiteID, "summary", window.Summary)
+				continue
+			}
+			window.Source = feed
+			imported = append(imported, window)
+		}
+
+		if err := app.Storage.ReplaceMaintenanceWindows(feed, imported); err != nil {
+			log.Error("Failed to store maintenance windows", "url", feed, "error", err)
+			continue
+		}
+		config.MaintenanceWindowsImportedTotal.Add(float64(len(imported)))
+		log.Info("Maintenance windows imported", "url", feed, "windows", len(imported))
+	}
+
+	if err := RefreshWindows(app); err != nil {
+		log.Error("Failed to load maintenance windows", "error", err)
+	}
+}
+
+// RefreshWindows loads the current and upcoming maintenance windows from
+// storage into the application state
+func RefreshWindows(app *config.AppState) error {
+	now := time.Now()
+	windows, err := app.Storage.GetMaintenanceWindows(now.Add(-importLookback), now.Add(ImportHorizon))
+	if err != nil {
+		return err
+	}
+	app.SetMaintenanceWindows(windows)
+	return nil
+}
+
+// fetchCalendar downloads and parses an iCal feed
+func fetchCalendar(ctx context.Context, url string) (*ics.Calendar, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	calendar, err := ics.ParseCalendar(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing calendar: %w", err)
+	}
+	return calendar, nil
+}
+
+// ParseWindows returns a maintenance window for every occurrence of a tagged
+// event overlapping from..to. Events are tagged when their SUMMARY starts
+// with "<tag>:"; their LOCATION is the site ID. Recurring events are
+// expanded, honoring EXDATE and moved or cancelled single occurrences.
+func ParseWindows(calendar *ics.Calendar, tag string, from, to time.Time) []models.MaintenanceWindow {
+	log := logger.Default().WithComponent("maintenance")
+	prefix := strings.ToLower(tag) + ":"
+
+	// Occurrences of a recurring event that were changed or cancelled are
+	// separate events with the UID of the series and a RECURRENCE-ID
+	overridden := make(map[string][]time.Time)
+	for _, event := range calendar.Events() {
+		if recurrenceID, err := event.GetRecurrenceID(); err == nil {
+			overridden[event.Id()] = append(overridden[event.Id()], recurrenceID)
+		}
+	}
+
+	windows := []models.MaintenanceWindow{}
+	for _, event := range calendar.Events() {
+		summary := propertyValue(event, ics.ComponentPropertySummary)
+		if !strings.HasPrefix(strings.ToLower(summary), prefix) {
+			continue
+		}
+		if strings.EqualFold(propertyValue(event, ics.ComponentPropertyStatus), "CANCELLED") {
+			continue
+		}
+
+		siteID := strings.TrimSpace(propertyValue(event, ics.ComponentPropertyLocation))
+		start, startErr := event.GetStartAt()
+		end, endErr := event.GetEndAt()
+		if siteID == "" || startErr != nil || endErr != nil || !end.After(start) {
+			log.Warn("Maintenance event without site or valid times ignored", "uid", event.Id(), "summary", summary)
+			continue
+		}
+
+		duration := end.Sub(start)
+		starts := []time.Time{start}
+		if _, err := event.GetRecurrenceID(); err != nil {
+			starts, err = occurrences(event, start, from.Add(-duration), to)
+			if err != nil {
+				log.Warn("Unsupported recurrence, only the first occurrence is imported", "uid", event.Id(), "summary", summary, "error", err)
+				starts = []time.Time{start}
+			}
+			starts = exclude(starts, overridden[event.Id()])
+		}
+
+		for _, occurrence := range starts {
+			window := models.MaintenanceWindow{
+				SiteID:  siteID,
+				Summary: summary,
+				Start:   occurrence,
+				End:     occurrence.Add(duration),
+				UID:     event.Id(),
+			}
+			if window.Start.Before(to) && window.End.After(from) {
+				windows = append(windows, window)
+			}
+		}
+	}
+	return windows
+}
+
+// occurrences returns the start times of an event from notBefore up to before,
+// expanding its RRULE and removing its EXDATEs
+func occurrences(event *ics.VEvent, start, notBefore, before time.Time) ([]time.Time, error) {
+	rules, err := event.GetRRules()
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return []time.Time{start}, nil
+	}
+	if len(rules) > 1 {
+		return nil, fmt.Errorf("multiple RRULEs")
+	}
+
+	starts, err := expandRule(rules[0], start, notBefore, before)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := event.GetExDates()
+	if err != nil {
+		return nil, err
+	}
+	return exclude(starts, excluded), nil
+}
+
+// maxOccurrences bounds the occurrences returned for a single recurring event
+const maxOccurrences = 1000
+
+// expandRule returns the occurrences of a DAILY or WEEKLY rule starting in
+// notBefore..before. Occurrences keep the wall clock time of start across DST
+// changes.
+func expandRule(rule *ics.RecurrenceRule, start, notBefore, before time.Time) ([]time.Time, error) {
+	if len(rule.BySecond) > 0 || len(rule.ByMinute) > 0 || len(rule.ByHour) > 0 || len(rule.ByMonthDay) > 0 ||
+		len(rule.ByYearDay) > 0 || len(rule.ByWeekNo) > 0 || len(rule.ByMonth) > 0 || len(rule.BySetPos) > 0 {
+		return nil, fmt.Errorf("only FREQ, INTERVAL, COUNT, UNTIL and BYDAY are supported")
+	}
+	interval := rule.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	var days []time.Weekday
+	switch rule.Freq {
+	case ics.FrequencyDaily:
+		if len(rule.ByDay) > 0 {
+			return nil, fmt.Errorf("BYDAY is only supported for weekly rules")
+		}
+	case ics.FrequencyWeekly:
+		for _, day := range rule.ByDay {
+			weekday, ok := weekdays[day.Day]
+			if !ok || day.OrdWeek != 0 {
+				return nil, fmt.Errorf("unsupported BYDAY %s", day)
+			}
+			days = append(days, weekday)
+		}
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %s", rule.Freq)
+	}
+
+	var starts []time.Time
+	count := 0
+	accept := func(occurrence time.Time) bool {
+		if occurrence.Before(start) {
+			return true
+		}
+		if !occurrence.Before(before) || (!rule.Until.IsZero() && occurrence.After(rule.Until)) ||
+			(rule.Count > 0 && count >= rule.Count) || len(starts) >= maxOccurrences {
+			return false
+		}
+		// COUNT includes the occurrences before the imported range
+		count++
+		if !occurrence.Before(notBefore) {
+			starts = append(starts, occurrence)
+		}
+		return true
+	}
+
+	if rule.Freq == ics.FrequencyDaily {
+		for i := 0; accept(start.AddDate(0, 0, i*interval)); i++ {
+		}
+		return starts, nil
+	}
+
+	// Weekly: walk the weeks from the one containing start, weeks begin on Monday
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	for week := 0; ; week += interval {
+		for offset := 0; offset < 7; offset++ {
+			occurrence := weekStart.AddDate(0, 0, week*7+offset)
+			if !containsWeekday(days, occurrence.Weekday()) {
+				continue
+			}
+			if !accept(occurrence) {
+				return starts, nil
+			}
+		}
+	}
+}
+
+var weekdays = map[ics.Weekday]time.Weekday{
+	ics.WeekdaySunday:    time.Sunday,
+	ics.WeekdayMonday:    time.Monday,
+	ics.WeekdayTuesday:   time.Tuesday,
+	ics.WeekdayWednesday: time.Wednesday,
+	ics.WeekdayThursday:  time.Thursday,
+	ics.WeekdayFriday:    time.Friday,
+	ics.WeekdaySaturday:  time.Saturday,
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// exclude removes the times in excluded from starts
+func exclude(starts, excluded []time.Time) []time.Time {
+	if len(excluded) == 0 {
+		return starts
+	}
+	kept := starts[:0]
+	for _, start := range starts {
+		skip := false
+		for _, t := range excluded {
+			if t.Equal(start) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			kept = append(kept, start)
+		}
+	}
+	return kept
+}
+
+// propertyValue returns the value of a property or "" when it is missing
+func propertyValue(event *ics.VEvent, property ics.ComponentProperty) string {
+	if p := event.GetProperty(property); p != nil {
+		return p.Value
+	}
+	return ""
+}
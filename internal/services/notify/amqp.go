@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+const (
+	// amqpInitialBackoff is the first wait before reconnecting to the broker
+	amqpInitialBackoff = time.Second
+
+	// amqpMaxBackoff caps the exponential reconnect backoff
+	amqpMaxBackoff = time.Minute
+)
+
+// AMQPConfig holds the settings of the AMQP notifier
+type AMQPConfig struct {
+	URL                string
+	Exchange           string
+	RoutingKeyTemplate string
+	DurableExchange    bool
+	Templates          MessageTemplates
+}
+
+// amqpMessage is the published JSON body: the event with its rendered subject and body
+type amqpMessage struct {
+	AlertEvent
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// AMQPNotifier publishes alert events as JSON, including the rendered subject
+// and body, to a RabbitMQ topic exchange.
+// The broker connection is kept open and re-established with exponential
+// backoff whenever the broker closes it.
+type AMQPNotifier struct {
+	cfg     AMQPConfig
+	mu      sync.Mutex
+	channel *amqp.Channel // nil while disconnected
+}
+
+// NewAMQPNotifier creates an AMQP notifier and connects in the background until ctx is done
+func NewAMQPNotifier(ctx context.Context, cfg AMQPConfig) *AMQPNotifier {
+	n := &AMQPNotifier{cfg: cfg}
+	go n.maintainConnection(ctx)
+	return n
+}
+
+// Name returns the notifier name
+func (n *AMQPNotifier) Name() string {
+	return "amqp"
+}
+
+// Notify publishes the event with a routing key built from the configured template
+func (n *AMQPNotifier) Notify(ctx context.Context, event AlertEvent) error {
+	subject, text, err := n.cfg.Templates.Render(event)
+	if err != nil {
+		config.AMQPPublishesTotal.WithLabelValues("error").Inc()
+		return err
+	}
+	body, err := json.Marshal(amqpMessage{AlertEvent: event, Subject: subject, Body: text})
+	if err != nil {
+		return fmt.Errorf("encoding alert event: %w", err)
+	}
+
+	n.mu.Lock()
+	channel := n.channel
+	n.mu.Unlock()
+	if channel == nil {
+		config.AMQPPublishesTotal.WithLabelValues("error").Inc()
+		return errors.New("not connected to AMQP broker")
+	}
+
+	deliveryMode := amqp.Transient
+	if n.cfg.DurableExchange {
+		deliveryMode = amqp.Persistent
+	}
+
+	routingKey := AMQPRoutingKey(n.cfg.RoutingKeyTemplate, event)
+	err = channel.PublishWithContext(ctx, n.cfg.Exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: deliveryMode,
+		Timestamp:    event.Timestamp,
+		Type:         string(event.Type),
+		Body:         body,
+	})
+	if err != nil {
+		config.AMQPPublishesTotal.WithLabelValues("error").Inc()
+		return fmt.Errorf("publishing to exchange %s with key %s: %w", n.cfg.Exchange, routingKey, err)
+	}
+
+	config.AMQPPublishesTotal.WithLabelValues("success").Inc()
+	logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName).
+		Debug("AMQP event published", "exchange", n.cfg.Exchange, "routing_key", routingKey, "type", event.Type)
+	return nil
+}
+
+// AMQPRoutingKey fills the {event_type}, {site_id} and {target} placeholders of template
+func AMQPRoutingKey(template string, event AlertEvent) string {
+	return strings.NewReplacer(
+		"{event_type}", string(event.Type),
+		"{site_id}", event.SiteID,
+		"{target}", event.Target,
+	).Replace(template)
+}
+
+// maintainConnection connects to the broker and reconnects with exponential
+// backoff whenever the connection or channel is closed with an error
+func (n *AMQPNotifier) maintainConnection(ctx context.Context) {
+	log := logger.Default().WithComponent("notify")
+	backoff := amqpInitialBackoff
+	reconnecting := false
+
+	for {
+		conn, channel, err := n.connect()
+		if err != nil {
+			log.Error("AMQP connection failed", "error", err, "retry_in", backoff.String())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, amqpMaxBackoff)
+			continue
+		}
+
+		if reconnecting {
+			config.AMQPReconnectsTotal.Inc()
+		}
+		log.Info("AMQP connection established", "exchange", n.cfg.Exchange, "durable_exchange", n.cfg.DurableExchange)
+		backoff = amqpInitialBackoff
+		reconnecting = true
+
+		n.mu.Lock()
+		n.channel = channel
+		n.mu.Unlock()
+
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		channelClosed := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+		select {
+		case <-ctx.Done():
+			n.mu.Lock()
+			n.channel = nil
+			n.mu.Unlock()
+			conn.Close()
+			return
+		case amqpErr := <-connClosed:
+			log.Error("AMQP connection closed", "error", amqpErr)
+		case amqpErr := <-channelClosed:
+			log.Error("AMQP channel closed", "error", amqpErr)
+		}
+
+		n.mu.Lock()
+		n.channel = nil
+		n.mu.Unlock()
+		conn.Close()
+	}
+}
+
+// connect dials the broker, opens a channel and declares the exchange
+func (n *AMQPNotifier) connect() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(n.cfg.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dialing broker: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("opening channel: %w", err)
+	}
+
+	// The default exchange ("") always exists and cannot be declared
+	if n.cfg.Exchange != "" {
+		err = channel.ExchangeDeclare(n.cfg.Exchange, amqp.ExchangeTopic, n.cfg.DurableExchange, false, false, false, nil)
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("declaring exchange %s: %w", n.cfg.Exchange, err)
+		}
+	}
+
+	return conn, channel, nil
+}
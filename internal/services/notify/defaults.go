@@ -0,0 +1,32 @@
+package notify
+
+// Built-in message templates, used for every template left empty in the configuration
+const (
+	DefaultOutageSubject = `{{.Site.Name}}: {{.Target}} line down`
+
+	DefaultOutageBody = `The {{.Target}} line of {{.Site.Name}} ({{.IP}}) stopped answering at {{.Timestamp.Format "2006-01-02 15:04:05 MST"}}.
+{{with .Error}}Error: {{.}}
+{{end}}
+Primary latency: {{latency .Status.PrimaryLatency}}
+Uptime 24h: {{percent .Statistics.Uptime24h}}
+Last incident: {{.Statistics.LastIncident}}`
+
+	DefaultRecoverySubject = `{{.Site.Name}}: {{.Target}} line recovered`
+
+	DefaultRecoveryBody = `{{.Target}} line recovered{{with .Duration}} after {{.}}{{end}}.
+
+Primary latency: {{latency .Status.PrimaryLatency}}
+Uptime 24h: {{percent .Statistics.Uptime24h}}`
+
+	// Latency deviation messages are not configurable, the templates above describe outages
+	DefaultLatencySubject = `{{.Site.Name}}: {{.Target}} latency above baseline`
+
+	DefaultLatencyBody = `The {{.Target}} line of {{.Site.Name}} ({{.IP}}) answers in {{latency .Latency}}, above its threshold of {{printf "%.1f" .Threshold}} ms.
+{{with .Baseline}}Baseline: {{printf "%.1f" .Mean}} ms ± {{printf "%.1f" .StdDev}} ms over {{.Samples}} checks
+{{end}}
+Uptime 24h: {{percent .Statistics.Uptime24h}}`
+
+	DefaultLatencyRecoverySubject = `{{.Site.Name}}: {{.Target}} latency back to normal`
+
+	DefaultLatencyRecoveryBody = `{{.Target}} latency back within its baseline{{with .Duration}} after {{.}}{{end}}: {{latency .Latency}}.`
+)
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/storage"
+)
+
+// dlqPurgeInterval controls how often expired dead letter entries are removed
+const dlqPurgeInterval = time.Hour
+
+// ErrDeadLetterNotFound is returned when retrying an entry that does not exist
+var ErrDeadLetterNotFound = errors.New("dead letter entry not found")
+
+// deadLetterDeliverer is implemented by the notifiers whose events can be
+// redelivered from the dead letter queue
+type deadLetterDeliverer interface {
+	Deliver(ctx context.Context, payload []byte) error
+}
+
+// RetryResult is the outcome of retrying dead letter entries
+type RetryResult struct {
+	Delivered []int64 `json:"delivered"` // Removed from the queue
+	Failed    []int64 `json:"failed"`    // Still queued with the new error
+}
+
+// RetryDeadLetters redelivers every entry of the dead letter queue once.
+// Delivered entries are removed, failed ones keep their place with the new
+// error and an increased retry count.
+func RetryDeadLetters(ctx context.Context, app *config.AppState) (RetryResult, error) {
+	entries, _, err := app.Storage.GetWebhookDLQ(0, 0)
+	if err != nil {
+		return RetryResult{}, err
+	}
+
+	result := RetryResult{Delivered: []int64{}, Failed: []int64{}}
+	for _, entry := range entries {
+		if retryDeadLetter(ctx, app.Storage, entry) {
+			result.Delivered = append(result.Delivered, entry.ID)
+		} else {
+			result.Failed = append(result.Failed, entry.ID)
+		}
+	}
+	updateDLQSize(app.Storage)
+	return result, nil
+}
+
+// RetryDeadLetter redelivers one entry of the dead letter queue
+func RetryDeadLetter(ctx context.Context, app *config.AppState, id int64) (RetryResult, error) {
+	entry, exists, err := app.Storage.GetWebhookDLQEntry(id)
+	if err != nil {
+		return RetryResult{}, err
+	}
+	if !exists {
+		return RetryResult{}, ErrDeadLetterNotFound
+	}
+
+	result := RetryResult{Delivered: []int64{}, Failed: []int64{}}
+	if retryDeadLetter(ctx, app.Storage, entry) {
+		result.Delivered = append(result.Delivered, id)
+	} else {
+		result.Failed = append(result.Failed, id)
+	}
+	updateDLQSize(app.Storage)
+	return result, nil
+}
+
+// retryDeadLetter delivers an entry through its notifier and reports whether it succeeded
+func retryDeadLetter(ctx context.Context, store storage.Storage, entry models.DeadLetterEntry) bool {
+	log := logger.Default().WithComponent("notify")
+
+	err := deliverDeadLetter(ctx, entry)
+	if err == nil {
+		if err := store.DeleteWebhookDLQ(entry.ID); err != nil {
+			log.Error("Failed to remove delivered dead letter entry, it may be delivered again", "id", entry.ID, "error", err)
+		}
+		log.Info("Dead letter entry delivered", "id", entry.ID, "notifier", entry.NotifierName)
+		return true
+	}
+
+	log.Warn("Dead letter retry failed", "id", entry.ID, "notifier", entry.NotifierName, "error", err)
+	if err := store.UpdateWebhookDLQRetry(entry.ID, err.Error(), time.Now()); err != nil {
+		log.Error("Failed to record dead letter retry", "id", entry.ID, "error", err)
+	}
+	return false
+}
+
+// deliverDeadLetter sends the stored event through the configured notifier of the same name
+func deliverDeadLetter(ctx context.Context, entry models.DeadLetterEntry) error {
+	if globalDispatcher != nil {
+		for _, n := range globalDispatcher.notifiers {
+			if deliverer, ok := n.(deadLetterDeliverer); ok && n.Name() == entry.NotifierName {
+				deliveryCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+				defer cancel()
+				return deliverer.Deliver(deliveryCtx, []byte(entry.EventJSON))
+			}
+		}
+	}
+	return fmt.Errorf("notifier %s is not configured", entry.NotifierName)
+}
+
+// startDLQPurge removes dead letter entries older than the retention every hour until ctx is done
+func startDLQPurge(ctx context.Context, app *config.AppState) {
+	retention := time.Duration(app.Config.Notify.DLQRetentionDays) * 24 * time.Hour
+	go func() {
+		ticker := time.NewTicker(dlqPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			purgeDeadLetters(app.Storage, time.Now().Add(-retention))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// purgeDeadLetters removes the entries added before the given time
+func purgeDeadLetters(store storage.Storage, before time.Time) {
+	log := logger.Default().WithComponent("notify")
+
+	purged, err := store.PurgeWebhookDLQ(before)
+	if err != nil {
+		log.Error("Failed to purge dead letter queue", "error", err)
+		return
+	}
+	if purged > 0 {
+		log.Info("Expired dead letter entries purged", "count", purged)
+	}
+	updateDLQSize(store)
+}
+
+// updateDLQSize sets the webhook_dlq_size gauge to the number of queued entries
+func updateDLQSize(store storage.Storage) {
+	_, total, err := store.GetWebhookDLQ(1, 0)
+	if err != nil {
+		return
+	}
+	config.WebhookDLQSize.Set(float64(total))
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+// KafkaConfig holds the settings of the Kafka notifier
+type KafkaConfig struct {
+	Brokers      []string
+	Topic        string
+	Compression  string // none, gzip, snappy or lz4
+	SASLUsername string
+	SASLPassword string
+}
+
+// KafkaNotifier produces alert events as JSON to a Kafka topic. Messages are
+// keyed by site and target, so all events of a line land in one partition
+// and keep their order. The writer is asynchronous: Notify only queues the
+// message, produce results are reported through the metrics and the log.
+type KafkaNotifier struct {
+	cfg    KafkaConfig
+	writer *kafka.Writer
+}
+
+// kafkaCompression maps the configured compression to the kafka-go codec
+var kafkaCompression = map[string]kafka.Compression{
+	"none":   0,
+	"gzip":   kafka.Gzip,
+	"snappy": kafka.Snappy,
+	"lz4":    kafka.Lz4,
+}
+
+// NewKafkaNotifier creates a Kafka notifier, connections are opened on the first message
+func NewKafkaNotifier(cfg KafkaConfig) *KafkaNotifier {
+	transport := &kafka.Transport{}
+	if cfg.SASLUsername != "" {
+		transport.SASL = plain.Mechanism{Username: cfg.SASLUsername, Password: cfg.SASLPassword}
+	}
+
+	n := &KafkaNotifier{cfg: cfg}
+	n.writer = &kafka.Writer{
+		Addr:        kafka.TCP(cfg.Brokers...),
+		Topic:       cfg.Topic,
+		Balancer:    &kafka.Hash{},
+		Compression: kafkaCompression[cfg.Compression],
+		Transport:   transport,
+		Async:       true,
+		Completion:  n.completion,
+	}
+	return n
+}
+
+// Name returns the notifier name
+func (n *KafkaNotifier) Name() string {
+	return "kafka"
+}
+
+// Notify queues the event for the next produce request
+func (n *KafkaNotifier) Notify(ctx context.Context, event AlertEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding alert event: %w", err)
+	}
+
+	err = n.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(KafkaMessageKey(event)),
+		Value: value,
+		Time:  event.Timestamp,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(event.Type)},
+		},
+	})
+	if err != nil {
+		config.KafkaMessagesProducedTotal.WithLabelValues("error").Inc()
+		return fmt.Errorf("queueing message for topic %s: %w", n.cfg.Topic, err)
+	}
+	return nil
+}
+
+// KafkaMessageKey returns the message key of an event, "{site_id}:{target}"
+func KafkaMessageKey(event AlertEvent) string {
+	return event.SiteID + ":" + event.Target
+}
+
+// Close flushes the queued messages and closes the broker connections
+func (n *KafkaNotifier) Close() error {
+	return n.writer.Close()
+}
+
+// completion records the result of a produce request
+func (n *KafkaNotifier) completion(messages []kafka.Message, err error) {
+	log := logger.Default().WithComponent("notify")
+	if err != nil {
+		config.KafkaProduceErrorsTotal.Inc()
+		config.KafkaMessagesProducedTotal.WithLabelValues("error").Add(float64(len(messages)))
+		log.Error("Kafka produce failed", "topic", n.cfg.Topic, "messages", len(messages), "error", err)
+		return
+	}
+
+	config.KafkaMessagesProducedTotal.WithLabelValues("success").Add(float64(len(messages)))
+	for _, message := range messages {
+		log.Debug("Kafka event produced", "topic", n.cfg.Topic, "key", string(message.Key),
+			"partition", message.Partition, "offset", message.Offset)
+	}
+}
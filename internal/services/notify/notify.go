@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// EventType identifies the kind of alert event
+type EventType string
+
+const (
+	// OutageStarted is sent when a line stops answering
+	OutageStarted EventType = "outage_started"
+
+	// OutageResolved is sent when a line answers again after an outage
+	OutageResolved EventType = "outage_resolved"
+
+	// LatencyDeviationStarted is sent when the latency of a line rises beyond its baseline
+	LatencyDeviationStarted EventType = "latency_deviation_started"
+
+	// LatencyDeviationResolved is sent when the latency of a line is back within its baseline
+	LatencyDeviationResolved EventType = "latency_deviation_resolved"
+
+	// TestEvent is sent on request to verify the notifier configuration
+	TestEvent EventType = "test"
+)
+
+const (
+	// queueSize bounds the number of events waiting for delivery
+	queueSize = 100
+
+	// deliveryTimeout bounds a single delivery including rate limit waits
+	deliveryTimeout = 2 * time.Minute
+)
+
+// AlertEvent describes a state change of a site line
+type AlertEvent struct {
+	Type      EventType  `json:"type"`
+	SiteID    string     `json:"site_id"`
+	SiteName  string     `json:"site_name"`
+	Target    string     `json:"target"` // "primary" | "secondary"
+	IP        string     `json:"ip"`
+	Error     string     `json:"error,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // Start of the outage (resolved events only)
+	Duration  string     `json:"duration,omitempty"`   // Outage duration (resolved events only)
+	Simulated bool       `json:"simulated,omitempty"`  // Raised by an outage simulation, not a real failure
+
+	// Latency deviation events only
+	Latency   *float64                `json:"latency_ms,omitempty"` // Latency of the check that raised the event
+	Threshold float64                 `json:"threshold_ms,omitempty"`
+	Baseline  *models.LatencyBaseline `json:"baseline,omitempty"`
+
+	// Site context for message templates, filled in before delivery
+	Site       models.Site           `json:"-"`
+	Status     models.SiteStatus     `json:"-"`
+	Statistics models.SiteStatistics `json:"-"`
+	Chart      []byte                `json:"-"` // PNG latency chart, for notifiers attaching it
+}
+
+// StatisticsFunc calculates the statistics of a site for message templates
+type StatisticsFunc func(app *config.AppState, siteID string) models.SiteStatistics
+
+// ChartFunc renders the latency chart of a site as PNG for notifications
+type ChartFunc func(app *config.AppState, siteID string) ([]byte, error)
+
+// Notifier delivers alert events to an external system
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event AlertEvent) error
+}
+
+// Dispatcher delivers events to all configured notifiers. Events are
+// delivered one at a time so a resolve never overtakes its start.
+type Dispatcher struct {
+	notifiers  []Notifier
+	queue      chan AlertEvent
+	app        *config.AppState
+	statistics StatisticsFunc
+	chart      ChartFunc // Set when a notifier attaches charts
+}
+
+// Global dispatcher instance, nil when no notifier is configured
+var globalDispatcher *Dispatcher
+
+// Setup validates the message templates, creates the notifiers enabled in the
+// configuration and starts delivery. chart is only called when
+// notify.webhook.attach_chart is set.
+func Setup(ctx context.Context, appState *config.AppState, statistics StatisticsFunc, chart ChartFunc) error {
+	log := logger.Default().WithComponent("notify")
+	cfg := appState.Config.Notify
+
+	templates := NewMessageTemplates(cfg.Templates.OutageSubject, cfg.Templates.OutageBody,
+		cfg.Templates.RecoverySubject, cfg.Templates.RecoveryBody)
+	if err := templates.Validate(); err != nil {
+		return err
+	}
+
+	var notifiers []Notifier
+	if cfg.OpsGenie.APIKey != "" {
+		notifiers = append(notifiers, NewOpsGenieNotifier(OpsGenieConfig{
+			APIKey:     cfg.OpsGenie.APIKey,
+			APIURL:     cfg.OpsGenie.APIURL,
+			Team:       cfg.OpsGenie.Team,
+			Priority:   cfg.OpsGenie.Priority,
+			Responders: cfg.OpsGenie.Responders,
+			Templates:  templates,
+		}))
+	}
+	if cfg.AMQP.URL != "" {
+		notifiers = append(notifiers, NewAMQPNotifier(ctx, AMQPConfig{
+			URL:                cfg.AMQP.URL,
+			Exchange:           cfg.AMQP.Exchange,
+			RoutingKeyTemplate: cfg.AMQP.RoutingKeyTemplate,
+			DurableExchange:    cfg.AMQP.DurableExchange,
+			Templates:          templates,
+		}))
+	}
+	if len(cfg.Kafka.Brokers) > 0 {
+		notifiers = append(notifiers, NewKafkaNotifier(KafkaConfig{
+			Brokers:      cfg.Kafka.Brokers,
+			Topic:        cfg.Kafka.Topic,
+			Compression:  cfg.Kafka.Compression,
+			SASLUsername: cfg.Kafka.SASLUsername,
+			SASLPassword: cfg.Kafka.SASLPassword,
+		}))
+	}
+
+	if cfg.Webhook.URL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(WebhookConfig{
+			URL:         cfg.Webhook.URL,
+			MaxAttempts: cfg.Webhook.MaxAttempts,
+		}, appState.Storage))
+	}
+
+	// Entries queued earlier expire even when the webhook was removed since
+	startDLQPurge(ctx, appState)
+
+	if len(notifiers) == 0 {
+		log.Debug("No notifiers configured")
+		return nil
+	}
+
+	globalDispatcher = &Dispatcher{
+		notifiers:  notifiers,
+		queue:      make(chan AlertEvent, queueSize),
+		app:        appState,
+		statistics: statistics,
+	}
+	if cfg.Webhook.URL != "" && cfg.Webhook.AttachChart {
+		globalDispatcher.chart = chart
+	}
+	go globalDispatcher.run(ctx)
+
+	for _, n := range notifiers {
+		log.Info("Notifier enabled", "notifier", n.Name())
+	}
+	return nil
+}
+
+// Dispatch queues an event for delivery without blocking
+func Dispatch(event AlertEvent) {
+	if globalDispatcher == nil {
+		return
+	}
+
+	select {
+	case globalDispatcher.queue <- event:
+	default:
+		log := logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName)
+		log.Warn("Notification queue full, dropping event", "type", event.Type, "target", event.Target)
+	}
+}
+
+// Close closes the notifiers holding resources, flushing what they buffered
+func Close() {
+	if globalDispatcher == nil {
+		return
+	}
+
+	log := logger.Default().WithComponent("notify")
+	for _, n := range globalDispatcher.notifiers {
+		closer, ok := n.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Error("Failed to close notifier", "notifier", n.Name(), "error", err)
+		}
+	}
+}
+
+// run delivers queued events until ctx is done
+func (d *Dispatcher) run(ctx context.Context) {
+	log := logger.Default().WithComponent("notify")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.queue:
+			d.addSiteContext(&event)
+			for _, n := range d.notifiers {
+				deliveryCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+				if err := n.Notify(deliveryCtx, event); err != nil {
+					log.Error("Failed to deliver notification",
+						"notifier", n.Name(),
+						"type", event.Type,
+						"site_id", event.SiteID,
+						"target", event.Target,
+						"error", err)
+				}
+				cancel()
+			}
+		}
+	}
+}
+
+// addSiteContext fills in the site, its current status and statistics for templates
+func (d *Dispatcher) addSiteContext(event *AlertEvent) {
+	d.app.Mu.RLock()
+	for _, site := range d.app.Sites {
+		if site.ID == event.SiteID {
+			event.Site = site
+			break
+		}
+	}
+	if status, exists := d.app.SiteStatus[event.SiteID]; exists && status != nil {
+		event.Status = *status
+	}
+	d.app.Mu.RUnlock()
+
+	if event.Site.Name == "" {
+		event.Site.Name = event.SiteName
+	}
+	if d.statistics != nil {
+		event.Statistics = d.statistics(d.app, event.SiteID)
+	}
+	if d.chart != nil {
+		chart, err := d.chart(d.app, event.SiteID)
+		if err != nil {
+			logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName).Warn("Failed to render chart for notification", "error", err)
+		}
+		event.Chart = chart
+	}
+}
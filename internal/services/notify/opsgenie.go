@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+const (
+	// opsGenieMaxAttempts is the number of tries per request when rate limited
+	opsGenieMaxAttempts = 3
+
+	// opsGenieMaxRateLimitWait caps the wait derived from X-RateLimit-Reset
+	opsGenieMaxRateLimitWait = 60 * time.Second
+
+	// opsGenieMessageLimit is the maximum alert message length accepted by OpsGenie
+	opsGenieMessageLimit = 130
+)
+
+// OpsGenieConfig holds the settings of the OpsGenie notifier
+type OpsGenieConfig struct {
+	APIKey     string
+	APIURL     string
+	Team       string
+	Priority   string
+	Responders []string
+	Templates  MessageTemplates
+}
+
+// OpsGenieNotifier creates and closes OpsGenie alerts via the Alert API v2
+type OpsGenieNotifier struct {
+	cfg    OpsGenieConfig
+	client *http.Client
+}
+
+// opsGenieResponder is a team or user an alert is routed to
+type opsGenieResponder struct {
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+	Type     string `json:"type"`
+}
+
+// opsGenieCreateRequest is the body of POST /v2/alerts
+type opsGenieCreateRequest struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description"`
+	Responders  []opsGenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags"`
+	Entity      string              `json:"entity"`
+	Source      string              `json:"source"`
+	Priority    string              `json:"priority"`
+}
+
+// opsGenieCloseRequest is the body of POST /v2/alerts/{alias}/close
+type opsGenieCloseRequest struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// NewOpsGenieNotifier creates an OpsGenie notifier
+func NewOpsGenieNotifier(cfg OpsGenieConfig) *OpsGenieNotifier {
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	return &OpsGenieNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the notifier name
+func (n *OpsGenieNotifier) Name() string {
+	return "opsgenie"
+}
+
+// Notify creates an alert for OutageStarted and closes it for OutageResolved,
+// latency deviations get their own alert. The alias is derived from site and
+// target, so repeated creates are deduplicated by OpsGenie and the close
+// always finds the right alert. A test event creates an alert and closes it
+// right away.
+func (n *OpsGenieNotifier) Notify(ctx context.Context, event AlertEvent) error {
+	alias := OpsGenieAlias(event.SiteID, event.Target)
+	latencyAlias := alias + "-latency"
+
+	switch event.Type {
+	case OutageStarted:
+		return n.createAlert(ctx, alias, event)
+	case OutageResolved:
+		return n.closeAlert(ctx, alias, event)
+	case LatencyDeviationStarted:
+		return n.createAlert(ctx, latencyAlias, event)
+	case LatencyDeviationResolved:
+		return n.closeAlert(ctx, latencyAlias, event)
+	case TestEvent:
+		if err := n.createAlert(ctx, alias, event); err != nil {
+			return err
+		}
+		return n.closeAlert(ctx, alias, event)
+	default:
+		return nil
+	}
+}
+
+// OpsGenieAlias returns the alert alias used for a site line
+func OpsGenieAlias(siteID, target string) string {
+	return fmt.Sprintf("sitewatch-%s-%s", siteID, target)
+}
+
+// createAlert opens an alert for a line with the rendered subject as
+// message and body as description
+func (n *OpsGenieNotifier) createAlert(ctx context.Context, alias string, event AlertEvent) error {
+	message, description, err := n.cfg.Templates.Render(event)
+	if err != nil {
+		return err
+	}
+	if len(message) > opsGenieMessageLimit {
+		message = message[:opsGenieMessageLimit]
+	}
+
+	req := opsGenieCreateRequest{
+		Message:     message,
+		Alias:       alias,
+		Description: description,
+		Tags:        []string{"sitewatch", event.Target},
+		Entity:      event.SiteID,
+		Source:      "sitewatch",
+		Priority:    n.cfg.Priority,
+	}
+	if n.cfg.Team != "" {
+		req.Responders = append(req.Responders, opsGenieResponder{Name: n.cfg.Team, Type: "team"})
+	}
+	for _, username := range n.cfg.Responders {
+		if username = strings.TrimSpace(username); username != "" {
+			req.Responders = append(req.Responders, opsGenieResponder{Username: username, Type: "user"})
+		}
+	}
+
+	if err := n.post(ctx, "/v2/alerts", req); err != nil {
+		return fmt.Errorf("creating alert %s: %w", alias, err)
+	}
+
+	config.OpsGenieAlertsTotal.WithLabelValues("create").Inc()
+	logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName).
+		Info("OpsGenie alert created", "alias", alias, "priority", n.cfg.Priority)
+	return nil
+}
+
+// closeAlert closes the alert of a recovered line with the rendered body as note
+func (n *OpsGenieNotifier) closeAlert(ctx context.Context, alias string, event AlertEvent) error {
+	_, note, err := n.cfg.Templates.Render(event)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(alias))
+	if err := n.post(ctx, path, opsGenieCloseRequest{Source: "sitewatch", Note: note}); err != nil {
+		return fmt.Errorf("closing alert %s: %w", alias, err)
+	}
+
+	config.OpsGenieAlertsTotal.WithLabelValues("close").Inc()
+	logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName).
+		Info("OpsGenie alert closed", "alias", alias)
+	return nil
+}
+
+// post sends a JSON request, waiting and retrying when rate limited (429)
+func (n *OpsGenieNotifier) post(ctx context.Context, path string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.APIURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "GenieKey "+n.cfg.APIKey)
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("sending request: %w", err)
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= opsGenieMaxAttempts {
+			return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		}
+
+		wait := rateLimitWait(resp.Header.Get("X-RateLimit-Reset"), time.Now())
+		logger.Default().WithComponent("notify").Warn("OpsGenie rate limit reached, retrying",
+			"path", path, "attempt", attempt, "wait", wait.String())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// rateLimitWait derives the wait before retrying from the X-RateLimit-Reset
+// header, which holds either seconds until the reset or the reset time as a
+// Unix timestamp
+func rateLimitWait(header string, now time.Time) time.Duration {
+	wait := time.Second
+	if value, err := strconv.ParseInt(strings.TrimSpace(header), 10, 64); err == nil && value > 0 {
+		if value > 1_000_000_000 {
+			wait = time.Unix(value, 0).Sub(now)
+		} else {
+			wait = time.Duration(value) * time.Second
+		}
+	}
+
+	if wait < time.Second {
+		wait = time.Second
+	}
+	if wait > opsGenieMaxRateLimitWait {
+		wait = opsGenieMaxRateLimitWait
+	}
+	return wait
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"sitewatch/internal/format"
+	"sitewatch/internal/models"
+)
+
+// templateFuncs are the helper functions available in message templates
+var templateFuncs = template.FuncMap{
+	"latency": func(ms *float64) string {
+		if ms == nil {
+			return "n/a"
+		}
+		return format.Latency(*ms)
+	},
+	"percent": format.Percent,
+}
+
+// MessageTemplates holds the text/template sources of notification subjects and bodies
+type MessageTemplates struct {
+	OutageSubject   string
+	OutageBody      string
+	RecoverySubject string
+	RecoveryBody    string
+}
+
+// NewMessageTemplates returns the given templates with built-in defaults for empty ones
+func NewMessageTemplates(outageSubject, outageBody, recoverySubject, recoveryBody string) MessageTemplates {
+	withDefault := func(tmpl, fallback string) string {
+		if strings.TrimSpace(tmpl) == "" {
+			return fallback
+		}
+		return tmpl
+	}
+	return MessageTemplates{
+		OutageSubject:   withDefault(outageSubject, DefaultOutageSubject),
+		OutageBody:      withDefault(outageBody, DefaultOutageBody),
+		RecoverySubject: withDefault(recoverySubject, DefaultRecoverySubject),
+		RecoveryBody:    withDefault(recoveryBody, DefaultRecoveryBody),
+	}
+}
+
+// Render renders the subject and body for the type of event
+func (t MessageTemplates) Render(event AlertEvent) (subject, body string, err error) {
+	subjectTmpl, bodyTmpl := t.OutageSubject, t.OutageBody
+	switch event.Type {
+	case OutageResolved:
+		subjectTmpl, bodyTmpl = t.RecoverySubject, t.RecoveryBody
+	case LatencyDeviationStarted:
+		subjectTmpl, bodyTmpl = DefaultLatencySubject, DefaultLatencyBody
+	case LatencyDeviationResolved:
+		subjectTmpl, bodyTmpl = DefaultLatencyRecoverySubject, DefaultLatencyRecoveryBody
+	}
+
+	if subject, err = RenderTemplate(subjectTmpl, event); err != nil {
+		return "", "", fmt.Errorf("rendering subject: %w", err)
+	}
+	if body, err = RenderTemplate(bodyTmpl, event); err != nil {
+		return "", "", fmt.Errorf("rendering body: %w", err)
+	}
+	return strings.TrimSpace(subject), strings.TrimSpace(body), nil
+}
+
+// Validate renders all templates with a sample event so syntax errors and
+// unknown fields are reported at startup instead of on the first outage
+func (t MessageTemplates) Validate() error {
+	latency := 12.5
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	event := AlertEvent{
+		Type:       OutageStarted,
+		SiteID:     "example",
+		SiteName:   "Example Site",
+		Target:     "primary",
+		IP:         "192.0.2.1",
+		Error:      "timeout",
+		Timestamp:  started,
+		Site:       models.Site{ID: "example", Name: "Example Site"},
+		Status:     models.SiteStatus{SiteID: "example", PrimaryLatency: &latency},
+		Statistics: models.SiteStatistics{Uptime24h: 99.5, LastIncident: "1h ago"},
+		Latency:    &latency,
+		Threshold:  10,
+		Baseline:   &models.LatencyBaseline{SiteID: "example", Line: "primary", Mean: 5, StdDev: 1, Samples: 100},
+	}
+
+	checks := []struct {
+		name, tmpl string
+		eventType  EventType
+	}{
+		{"outage_subject", t.OutageSubject, OutageStarted},
+		{"outage_body", t.OutageBody, OutageStarted},
+		{"recovery_subject", t.RecoverySubject, OutageResolved},
+		{"recovery_body", t.RecoveryBody, OutageResolved},
+		{"latency_subject", DefaultLatencySubject, LatencyDeviationStarted},
+		{"latency_body", DefaultLatencyBody, LatencyDeviationStarted},
+		{"latency_recovery_subject", DefaultLatencyRecoverySubject, LatencyDeviationResolved},
+		{"latency_recovery_body", DefaultLatencyRecoveryBody, LatencyDeviationResolved},
+	}
+	for _, check := range checks {
+		event.Type = check.eventType
+		if check.eventType == OutageResolved || check.eventType == LatencyDeviationResolved {
+			event.StartedAt = &started
+			event.Duration = "5m"
+		}
+		if _, err := RenderTemplate(check.tmpl, event); err != nil {
+			return fmt.Errorf("notify template %s: %w", check.name, err)
+		}
+	}
+	return nil
+}
+
+// RenderTemplate executes a text/template source against an alert event
+func RenderTemplate(tmplStr string, event AlertEvent) (string, error) {
+	tmpl, err := template.New("message").Funcs(templateFuncs).Option("missingkey=error").Parse(tmplStr)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, event); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
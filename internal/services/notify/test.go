@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// testTimeout bounds the delivery of a test event per notifier, shorter than
+// deliveryTimeout since the caller waits for the result
+const testTimeout = 30 * time.Second
+
+// ErrNoNotifiers is returned when testing without any configured notifier
+var ErrNoNotifiers = errors.New("no notifiers configured")
+
+// ErrUnknownNotifier is returned when testing a notifier that is not configured
+var ErrUnknownNotifier = errors.New("notifier not configured")
+
+// TestResult is the outcome of sending a test event through one notifier
+type TestResult struct {
+	Notifier   string  `json:"notifier"`
+	Success    bool    `json:"success"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Notifiers returns the names of the configured notifiers
+func Notifiers() []string {
+	if globalDispatcher == nil {
+		return nil
+	}
+	names := make([]string, 0, len(globalDispatcher.notifiers))
+	for _, n := range globalDispatcher.notifiers {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+// Test sends a synthetic TestEvent directly through the named notifier, or
+// all notifiers when name is empty, bypassing the queue so every delivery
+// error is reported to the caller
+func Test(ctx context.Context, name string) ([]TestResult, error) {
+	if globalDispatcher == nil {
+		return nil, ErrNoNotifiers
+	}
+
+	now := time.Now()
+	event := AlertEvent{
+		Type:      TestEvent,
+		SiteID:    "sitewatch-test",
+		SiteName:  "SiteWatch Test",
+		Target:    "primary",
+		IP:        "192.0.2.1",
+		Error:     "test notification, no action required",
+		Timestamp: now,
+		Site:      models.Site{ID: "sitewatch-test", Name: "SiteWatch Test", PrimaryIP: "192.0.2.1"},
+		Status:    models.SiteStatus{SiteID: "sitewatch-test"},
+	}
+
+	log := logger.Default().WithComponent("notify")
+	var results []TestResult
+	for _, n := range globalDispatcher.notifiers {
+		if name != "" && n.Name() != name {
+			continue
+		}
+
+		deliveryCtx, cancel := context.WithTimeout(ctx, testTimeout)
+		start := time.Now()
+		err := n.Notify(deliveryCtx, event)
+		cancel()
+
+		result := TestResult{
+			Notifier:   n.Name(),
+			Success:    err == nil,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Error = err.Error()
+			log.Warn("Test notification failed", "notifier", n.Name(), "error", err)
+		} else {
+			log.Info("Test notification delivered", "notifier", n.Name())
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, ErrUnknownNotifier
+	}
+	return results, nil
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/storage"
+)
+
+// webhookRetryBackoff is the wait before the second attempt, doubled for each further one
+const webhookRetryBackoff = 2 * time.Second
+
+// WebhookConfig holds the settings of the webhook notifier
+type WebhookConfig struct {
+	URL         string
+	MaxAttempts int
+}
+
+// WebhookNotifier posts every alert event as JSON to a URL. An event that
+// still fails after MaxAttempts goes to the dead letter queue, from where it
+// can be retried via the admin API.
+type WebhookNotifier struct {
+	cfg    WebhookConfig
+	client *http.Client
+	store  storage.Storage
+}
+
+// NewWebhookNotifier creates a webhook notifier storing undelivered events in store
+func NewWebhookNotifier(cfg WebhookConfig, store storage.Storage) *WebhookNotifier {
+	return &WebhookNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		store:  store,
+	}
+}
+
+// webhookPayload is the posted event, with the latency chart when
+// notify.webhook.attach_chart is set
+type webhookPayload struct {
+	AlertEvent
+	ChartPNG []byte `json:"chart_png,omitempty"` // Base64 in JSON
+}
+
+// Name returns the notifier name
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify posts the event, retrying with backoff. After the last attempt the
+// event is added to the dead letter queue. Test events are posted once and
+// never queued.
+func (n *WebhookNotifier) Notify(ctx context.Context, event AlertEvent) error {
+	payload, err := json.Marshal(webhookPayload{AlertEvent: event, ChartPNG: event.Chart})
+	if err != nil {
+		return fmt.Errorf("encoding alert event: %w", err)
+	}
+	if event.Type == TestEvent {
+		return n.Deliver(ctx, payload)
+	}
+
+	wait := webhookRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err = n.Deliver(ctx, payload)
+		if err == nil {
+			return nil
+		}
+		if attempt >= n.cfg.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName).Warn("Webhook delivery failed, retrying",
+			"attempt", attempt, "wait", wait.String(), "error", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+
+	n.deadLetter(event, payload, err)
+	return fmt.Errorf("giving up after %d attempts: %w", n.cfg.MaxAttempts, err)
+}
+
+// Deliver posts an encoded event once and expects a 2xx response
+func (n *WebhookNotifier) Deliver(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// deadLetter stores an undelivered event for a later retry
+func (n *WebhookNotifier) deadLetter(event AlertEvent, payload []byte, deliveryErr error) {
+	log := logger.Default().WithComponent("notify").WithSite(event.SiteID, event.SiteName)
+
+	entry := models.DeadLetterEntry{
+		Timestamp:    time.Now(),
+		EventJSON:    string(payload),
+		NotifierName: n.Name(),
+		LastError:    deliveryErr.Error(),
+	}
+	if err := n.store.AddWebhookDLQ(entry); err != nil {
+		log.Error("Failed to add undelivered event to the dead letter queue, event lost",
+			"type", event.Type, "target", event.Target, "error", err)
+		return
+	}
+	updateDLQSize(n.store)
+	log.Warn("Webhook event moved to the dead letter queue", "type", event.Type, "target", event.Target)
+}
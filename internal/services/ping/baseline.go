@@ -0,0 +1,121 @@
+package ping
+
+import (
+	"sync"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/format"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/notify"
+)
+
+// deviationState tracks how long a line has been above or back below its latency baseline
+type deviationState struct {
+	active bool      // A deviation alert is open
+	since  time.Time // Start of the open deviation
+	above  int       // Consecutive checks above the threshold
+	below  int       // Consecutive checks within the threshold while active
+}
+
+var (
+	deviationStates   = make(map[string]*deviationState) // site_id/line_type -> deviation state
+	deviationStatesMu sync.Mutex
+)
+
+// checkLatencyDeviation compares the latency of a successful check with the
+// baseline of its line. After latency_baseline.consecutive checks above the
+// threshold LatencyDeviationStarted is dispatched, after as many checks back
+// within it LatencyDeviationResolved. Failed checks and checks while the site
+// is expected offline are left to outage detection and change nothing.
+func checkLatencyDeviation(appState *config.AppState, result models.PingResult, siteName string, expectedOffline bool) {
+	cfg := appState.Config.LatencyBaseline
+	if !cfg.Enabled || !result.Success || result.Latency == nil || expectedOffline {
+		return
+	}
+
+	baseline, exists := appState.GetLatencyBaseline(result.SiteID, result.LineType)
+	if !exists || baseline.Samples < cfg.MinSamples {
+		return
+	}
+	latency := *result.Latency
+	threshold := baseline.Threshold(cfg.Deviation, cfg.MinIncreaseMs)
+
+	key := result.SiteID + "/" + result.LineType
+	deviationStatesMu.Lock()
+	state, seen := deviationStates[key]
+	if !seen {
+		state = &deviationState{}
+		deviationStates[key] = state
+	}
+
+	var eventType notify.EventType
+	var startedAt time.Time
+	if latency > threshold {
+		state.above++
+		state.below = 0
+		if !state.active && state.above >= cfg.Consecutive {
+			state.active = true
+			state.since = result.Timestamp
+			eventType = notify.LatencyDeviationStarted
+		}
+	} else {
+		state.above = 0
+		if state.active {
+			state.below++
+			if state.below >= cfg.Consecutive {
+				state.active = false
+				state.below = 0
+				startedAt = state.since
+				eventType = notify.LatencyDeviationResolved
+			}
+		}
+	}
+	deviationStatesMu.Unlock()
+
+	if eventType == "" {
+		return
+	}
+
+	log := logger.Default().WithComponent("ping").WithSite(result.SiteID, siteName)
+	event := notify.AlertEvent{
+		Type:      eventType,
+		SiteID:    result.SiteID,
+		SiteName:  siteName,
+		Target:    result.LineType,
+		IP:        result.IP,
+		Timestamp: result.Timestamp,
+		Simulated: result.Source == models.ResultSourceSimulation,
+		Latency:   result.Latency,
+		Threshold: threshold,
+		Baseline:  &baseline,
+	}
+
+	if eventType == notify.LatencyDeviationStarted {
+		config.LatencyDeviatingGauge.WithLabelValues(result.SiteID, result.LineType).Set(1)
+		log.Warn("Latency deviates from baseline",
+			"target", result.LineType,
+			"latency_ms", latency,
+			"threshold_ms", threshold,
+			"baseline_mean_ms", baseline.Mean,
+			"baseline_stddev_ms", baseline.StdDev)
+	} else {
+		config.LatencyDeviatingGauge.WithLabelValues(result.SiteID, result.LineType).Set(0)
+		event.StartedAt = &startedAt
+		event.Duration = format.DurationPrecise(i18n.DefaultLocale(), result.Timestamp.Sub(startedAt))
+		log.Info("Latency back within baseline", "target", result.LineType, "latency_ms", latency, "duration", event.Duration)
+	}
+
+	notify.Dispatch(event)
+}
+
+// IsLatencyDeviating reports whether a deviation alert is open for a site line
+func IsLatencyDeviating(siteID, lineType string) bool {
+	deviationStatesMu.Lock()
+	defer deviationStatesMu.Unlock()
+
+	state, exists := deviationStates[siteID+"/"+lineType]
+	return exists && state.active
+}
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 	
+	"sitewatch/internal/clock"
 	"sitewatch/internal/logger"
 )
 
@@ -24,8 +25,12 @@ type CircuitBreaker struct {
 	state          CircuitBreakerState
 	failures       int
 	lastFailTime   time.Time
+	forced         bool // Manually opened, stays open until Reset
+	tripRetention  time.Duration // How long automatic trips are kept, 0 keeps none
+	trips          []time.Time   // Automatic trips within tripRetention, oldest first
 	mu             sync.RWMutex
 	onStateChange  func(name string, from, to CircuitBreakerState)
+	clock          clock.Clock // Time source of the reset timeout and trip history
 }
 
 // NewCircuitBreaker creates a new circuit breaker
@@ -35,9 +40,17 @@ func NewCircuitBreaker(name string, maxFailures int, resetTimeout time.Duration)
 		maxFailures:  maxFailures,
 		resetTimeout: resetTimeout,
 		state:        StateClosed,
+		clock:        clock.Default(),
 	}
 }
 
+// SetClock replaces the time source of the circuit breaker
+func (cb *CircuitBreaker) SetClock(c clock.Clock) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.clock = c
+}
+
 // SetOnStateChange sets a callback function for state changes
 func (cb *CircuitBreaker) SetOnStateChange(fn func(name string, from, to CircuitBreakerState)) {
 	cb.mu.Lock()
@@ -54,8 +67,9 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 			"state", cb.getStateString(),
 			"failures", cb.getFailures())
 		return &CircuitBreakerError{
-			Name:  cb.name,
-			State: cb.getStateString(),
+			Name:   cb.name,
+			State:  cb.getStateString(),
+			Forced: cb.forced,
 		}
 	}
 	
@@ -86,12 +100,16 @@ func (cb *CircuitBreaker) canExecute() bool {
 	case StateClosed:
 		return true
 	case StateOpen:
+		// A manually opened circuit only closes via Reset
+		if cb.forced {
+			return false
+		}
 		// Check if reset timeout has passed
-		if time.Since(cb.lastFailTime) > cb.resetTimeout {
+		if cb.clock.Now().Sub(cb.lastFailTime) > cb.resetTimeout {
 			// Transition to half-open state
 			cb.mu.RUnlock()
 			cb.mu.Lock()
-			if cb.state == StateOpen && time.Since(cb.lastFailTime) > cb.resetTimeout {
+			if cb.state == StateOpen && cb.clock.Now().Sub(cb.lastFailTime) > cb.resetTimeout {
 				cb.setState(StateHalfOpen)
 			}
 			cb.mu.Unlock()
@@ -112,7 +130,7 @@ func (cb *CircuitBreaker) recordFailure() {
 	defer cb.mu.Unlock()
 	
 	cb.failures++
-	cb.lastFailTime = time.Now()
+	cb.lastFailTime = cb.clock.Now()
 	
 	switch cb.state {
 	case StateClosed:
@@ -146,12 +164,42 @@ func (cb *CircuitBreaker) setState(newState CircuitBreakerState) {
 	oldState := cb.state
 	cb.state = newState
 	
+	// Manual opens are not trips, they say nothing about the thresholds
+	if newState == StateOpen && oldState != StateOpen && !cb.forced && cb.tripRetention > 0 {
+		now := cb.clock.Now()
+		cb.trips = append(cb.pruneTrips(now), now)
+	}
+	
 	if cb.onStateChange != nil && oldState != newState {
 		// Call callback without holding lock to prevent deadlocks
 		go cb.onStateChange(cb.name, oldState, newState)
 	}
 }
 
+// ForceOpen opens the circuit until Reset is called, e.g. for link maintenance
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.forced = true
+	cb.setState(StateOpen)
+}
+
+// Reset closes the circuit and clears the failure count and any manual trip
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.forced = false
+	cb.failures = 0
+	cb.setState(StateClosed)
+}
+
+// IsForcedOpen reports whether the circuit was opened manually
+func (cb *CircuitBreaker) IsForcedOpen() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.forced
+}
+
 // GetState returns the current state (thread-safe)
 func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	cb.mu.RLock()
@@ -159,6 +207,47 @@ func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	return cb.state
 }
 
+// OpenUntil returns when an open circuit lets the next call through
+func (cb *CircuitBreaker) OpenUntil() (time.Time, bool) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	if cb.state != StateOpen {
+		return time.Time{}, false
+	}
+	return cb.lastFailTime.Add(cb.resetTimeout), true
+}
+
+// SetTripRetention sets how long automatic trips are kept for GetTrips
+func (cb *CircuitBreaker) SetTripRetention(retention time.Duration) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.tripRetention = retention
+	cb.trips = cb.pruneTrips(cb.clock.Now())
+}
+
+// GetTrips returns the automatic trips within the trip retention, oldest
+// first, and nil when no trip history is kept
+func (cb *CircuitBreaker) GetTrips() []time.Time {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	if cb.tripRetention <= 0 {
+		return nil
+	}
+	return cb.pruneTrips(cb.clock.Now())
+}
+
+// pruneTrips returns the trips not older than the retention (must hold lock)
+func (cb *CircuitBreaker) pruneTrips(now time.Time) []time.Time {
+	cutoff := now.Add(-cb.tripRetention)
+	kept := make([]time.Time, 0, len(cb.trips))
+	for _, trip := range cb.trips {
+		if trip.After(cutoff) {
+			kept = append(kept, trip)
+		}
+	}
+	return kept
+}
+
 // GetFailures returns the current failure count (thread-safe)
 func (cb *CircuitBreaker) GetFailures() int {
 	cb.mu.RLock()
@@ -187,10 +276,14 @@ func (cb *CircuitBreaker) getFailures() int {
 
 // CircuitBreakerError represents an error when circuit breaker is open
 type CircuitBreakerError struct {
-	Name  string
-	State string
+	Name   string
+	State  string
+	Forced bool // Opened manually
 }
 
 func (e *CircuitBreakerError) Error() string {
+	if e.Forced {
+		return "circuit breaker '" + e.Name + "' is manually opened"
+	}
 	return "circuit breaker '" + e.Name + "' is " + e.State
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 	
+	"sitewatch/internal/clock"
 	"sitewatch/internal/config"
 	"sitewatch/internal/logger"
 )
@@ -15,6 +16,7 @@ type CircuitBreakerManager struct {
 	mu         sync.RWMutex
 	maxFailures int
 	resetTimeout time.Duration
+	tripRetention time.Duration
 }
 
 // NewCircuitBreakerManager creates a new circuit breaker manager
@@ -51,6 +53,7 @@ func (cbm *CircuitBreakerManager) GetBreaker(siteID, lineType string) *CircuitBr
 	
 	name := fmt.Sprintf("%s/%s", siteID, lineType)
 	breaker = NewCircuitBreaker(name, cbm.maxFailures, cbm.resetTimeout)
+	breaker.SetTripRetention(cbm.tripRetention)
 	
 	// Set state change callback for metrics
 	breaker.SetOnStateChange(func(name string, from, to CircuitBreakerState) {
@@ -84,6 +87,52 @@ func (cbm *CircuitBreakerManager) GetBreaker(siteID, lineType string) *CircuitBr
 	return breaker
 }
 
+// SetTripRetention sets how long the trips of every circuit breaker are kept,
+// 0 disables the trip history
+func (cbm *CircuitBreakerManager) SetTripRetention(retention time.Duration) {
+	cbm.mu.Lock()
+	defer cbm.mu.Unlock()
+	cbm.tripRetention = retention
+	for _, breaker := range cbm.breakers {
+		breaker.SetTripRetention(retention)
+	}
+}
+
+// TripRetention returns how long the trips of every circuit breaker are kept
+func (cbm *CircuitBreakerManager) TripRetention() time.Duration {
+	cbm.mu.RLock()
+	defer cbm.mu.RUnlock()
+	return cbm.tripRetention
+}
+
+// FindBreaker returns the circuit breaker of a site line if one was created
+func (cbm *CircuitBreakerManager) FindBreaker(siteID, lineType string) (*CircuitBreaker, bool) {
+	cbm.mu.RLock()
+	defer cbm.mu.RUnlock()
+	breaker, exists := cbm.breakers[fmt.Sprintf("%s-%s", siteID, lineType)]
+	return breaker, exists
+}
+
+// ForceOpen manually opens the circuit breaker of a site line
+func (cbm *CircuitBreakerManager) ForceOpen(siteID, lineType string) CircuitBreakerStats {
+	breaker := cbm.GetBreaker(siteID, lineType)
+	breaker.ForceOpen()
+	
+	log := logger.Default().WithComponent("circuit-breaker")
+	log.Warn("Circuit breaker manually opened", "name", breaker.name)
+	return breakerStats(breaker)
+}
+
+// Reset closes the circuit breaker of a site line, ending a manual trip
+func (cbm *CircuitBreakerManager) Reset(siteID, lineType string) CircuitBreakerStats {
+	breaker := cbm.GetBreaker(siteID, lineType)
+	breaker.Reset()
+	
+	log := logger.Default().WithComponent("circuit-breaker")
+	log.Info("Circuit breaker manually reset", "name", breaker.name)
+	return breakerStats(breaker)
+}
+
 // GetStats returns statistics for all circuit breakers
 func (cbm *CircuitBreakerManager) GetStats() map[string]CircuitBreakerStats {
 	cbm.mu.RLock()
@@ -91,21 +140,53 @@ func (cbm *CircuitBreakerManager) GetStats() map[string]CircuitBreakerStats {
 	
 	stats := make(map[string]CircuitBreakerStats, len(cbm.breakers))
 	for key, breaker := range cbm.breakers {
-		stats[key] = CircuitBreakerStats{
-			Name:     breaker.name,
-			State:    breaker.GetState(),
-			Failures: breaker.GetFailures(),
-		}
+		stats[key] = breakerStats(breaker)
+	}
+	
+	return stats
+}
+
+// breakerStats returns the statistics of a single circuit breaker
+func breakerStats(breaker *CircuitBreaker) CircuitBreakerStats {
+	state := breaker.GetState()
+	stats := CircuitBreakerStats{
+		Name:      breaker.name,
+		State:     state,
+		StateName: stateToString(state),
+		Failures:  breaker.GetFailures(),
+		Forced:    breaker.IsForcedOpen(),
 	}
 	
+	if trips := breaker.GetTrips(); trips != nil {
+		stats.Trips = trips
+		lastHour := CountTripsSince(trips, clock.Default().Now().Add(-time.Hour))
+		stats.TripsLastHour = &lastHour
+	}
 	return stats
 }
 
+// CountTripsSince counts the trips after since
+func CountTripsSince(trips []time.Time, since time.Time) int {
+	count := 0
+	for _, trip := range trips {
+		if trip.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
 // CircuitBreakerStats holds statistics for a circuit breaker
 type CircuitBreakerStats struct {
-	Name     string               `json:"name"`
-	State    CircuitBreakerState  `json:"state"`
-	Failures int                  `json:"failures"`
+	Name      string               `json:"name"`
+	State     CircuitBreakerState  `json:"state"`
+	StateName string               `json:"state_name"`
+	Failures  int                  `json:"failures"`
+	Forced    bool                 `json:"forced"` // Manually opened for maintenance
+	
+	// Trip history, only with ping.circuit_breaker_trip_retention
+	Trips         []time.Time `json:"trips,omitempty"` // Automatic trips, oldest first
+	TripsLastHour *int        `json:"trips_last_hour,omitempty"`
 }
 
 // stateToString converts circuit breaker state to string
@@ -0,0 +1,107 @@
+package ping
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sitewatch/internal/clock"
+)
+
+var errProbe = errors.New("no packets received")
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	clk := clock.NewManual(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	cb := NewCircuitBreaker("site-cb/primary", 3, time.Minute)
+	cb.SetClock(clk)
+	cb.SetTripRetention(time.Hour)
+
+	calls := 0
+	failing := func() error { calls++; return errProbe }
+
+	for i := 0; i < 2; i++ {
+		if err := cb.Call(failing); err != errProbe {
+			t.Fatalf("call %d returned %v, want the probe error", i+1, err)
+		}
+	}
+	if cb.GetState() != StateClosed {
+		t.Fatalf("state %v after 2 failures, want closed", cb.GetState())
+	}
+
+	cb.Call(failing)
+	if cb.GetState() != StateOpen {
+		t.Fatalf("state %v after 3 failures, want open", cb.GetState())
+	}
+	if trips := cb.GetTrips(); len(trips) != 1 || !trips[0].Equal(clk.Now()) {
+		t.Errorf("trips %v, want one at %v", trips, clk.Now())
+	}
+
+	// Blocked without calling the probe until the reset timeout passed
+	err := cb.Call(failing)
+	var cbErr *CircuitBreakerError
+	if !errors.As(err, &cbErr) || cbErr.Forced {
+		t.Fatalf("call while open returned %v, want an automatic circuit breaker error", err)
+	}
+	if calls != 3 {
+		t.Fatalf("probe called %d times while open, want 3", calls)
+	}
+	if until, open := cb.OpenUntil(); !open || !until.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("OpenUntil = %v, %v, want %v", until, open, clk.Now().Add(time.Minute))
+	}
+
+	// A failing probe in half-open state opens the circuit again
+	clk.Advance(time.Minute + time.Second)
+	cb.Call(failing)
+	if calls != 4 || cb.GetState() != StateOpen {
+		t.Fatalf("after a failed half-open probe: calls %d, state %v, want 4 and open", calls, cb.GetState())
+	}
+
+	// A successful one closes it and clears the failures
+	clk.Advance(time.Minute + time.Second)
+	if err := cb.Call(func() error { return nil }); err != nil {
+		t.Fatalf("half-open probe returned %v", err)
+	}
+	if cb.GetState() != StateClosed || cb.GetFailures() != 0 {
+		t.Errorf("state %v with %d failures after a successful probe, want closed with 0", cb.GetState(), cb.GetFailures())
+	}
+	if trips := cb.GetTrips(); len(trips) != 2 {
+		t.Errorf("%d trips recorded, want 2", len(trips))
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	cb := NewCircuitBreaker("site-cb-reset/primary", 3, time.Minute)
+
+	cb.Call(func() error { return errProbe })
+	cb.Call(func() error { return errProbe })
+	cb.Call(func() error { return nil })
+	cb.Call(func() error { return errProbe })
+	cb.Call(func() error { return errProbe })
+
+	if cb.GetState() != StateClosed {
+		t.Errorf("state %v, want closed: failures are only counted in a row", cb.GetState())
+	}
+}
+
+func TestCircuitBreakerForceOpen(t *testing.T) {
+	clk := clock.NewManual(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	cb := NewCircuitBreaker("site-cb-forced/primary", 3, time.Minute)
+	cb.SetClock(clk)
+	cb.SetTripRetention(time.Hour)
+
+	cb.ForceOpen()
+	clk.Advance(time.Hour)
+
+	var cbErr *CircuitBreakerError
+	if err := cb.Call(func() error { return nil }); !errors.As(err, &cbErr) || !cbErr.Forced {
+		t.Fatalf("call returned %v, want a forced circuit breaker error past the reset timeout", err)
+	}
+	if trips := cb.GetTrips(); len(trips) != 0 {
+		t.Errorf("manual open recorded as trip: %v", trips)
+	}
+
+	cb.Reset()
+	if err := cb.Call(func() error { return nil }); err != nil {
+		t.Errorf("call after Reset returned %v", err)
+	}
+}
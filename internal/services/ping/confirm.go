@@ -0,0 +1,38 @@
+package ping
+
+import (
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// confirmStateChange probes a line once more when result would flip it up or
+// down. A contradicted result is marked unconfirmed so it is logged without
+// changing the line state. Returns the follow-up result to send after result,
+// or nil when no confirmation was needed.
+func confirmStateChange(appState *config.AppState, result *models.PingResult) *models.PingResult {
+	if !appState.Config.Ping.ConfirmStateChanges || result.Success != isLineDown(result.SiteID, result.LineType) {
+		return nil
+	}
+
+	confirmation := models.PingResult{
+		SiteID:    result.SiteID,
+		IP:        result.IP,
+		LineType:  result.LineType,
+		Timestamp: clock.Default().Now(),
+	}
+	// The first probe already went through the circuit breaker, the follow-up
+	// must not count twice towards opening it
+	executePing(appState, &confirmation)
+	applySimulation(appState, &confirmation)
+
+	log := logger.Default().WithPing(result.SiteID, result.IP, result.LineType)
+	if confirmation.Success != result.Success {
+		result.Unconfirmed = true
+		log.Info("State change not confirmed", "success", result.Success, "error", result.Error)
+	} else {
+		log.Debug("State change confirmed", "success", result.Success)
+	}
+	return &confirmation
+}
@@ -0,0 +1,239 @@
+package ping
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// watchdogCheckInterval controls how often the watchdog refreshes health metrics
+const watchdogCheckInterval = 5 * time.Second
+
+var (
+	lastConsumed   atomic.Int64 // Unix nanoseconds of the last result taken from ResultChan
+	pendingSince   atomic.Int64 // Unix nanoseconds of the last result sent into an empty ResultChan
+	droppedResults atomic.Int64
+
+	processorCancel context.CancelFunc // Stops the running result processor
+	processorDone   chan struct{}      // Closed once the running result processor returned
+	processorMu     sync.Mutex
+
+	heartbeats   = make(map[string]time.Time) // site_id -> last worker tick
+	heartbeatsMu sync.RWMutex
+)
+
+// ResultPipelineHealth describes the state of the result channel and its consumer
+type ResultPipelineHealth struct {
+	ChannelDepth        int                `json:"channel_depth"`
+	ChannelCapacity     int                `json:"channel_capacity"`
+	DroppedResults      int64              `json:"dropped_results"`
+	LastConsumedSeconds float64            `json:"last_consumed_seconds_ago"`
+	ProcessorStalled    bool               `json:"processor_stalled"`
+	WorkerHeartbeats    map[string]float64 `json:"worker_heartbeats_seconds_ago"`
+}
+
+// SendResult hands a result to the processor. When the channel is full the
+// result waits up to ping.result_send_timeout for room, then it is dropped
+// and counted instead of wedging the ping goroutine. Once ctx ends, i.e. on
+// shutdown when the processor no longer drains the channel, the result is
+// discarded. Returns false if the result was dropped or discarded.
+func SendResult(ctx context.Context, appState *config.AppState, result models.PingResult) bool {
+	if ctx.Err() != nil {
+		discardResult(result)
+		return false
+	}
+	if len(appState.ResultChan) == 0 {
+		pendingSince.Store(time.Now().UnixNano())
+	}
+
+	select {
+	case appState.ResultChan <- result:
+		return true
+	default:
+	}
+
+	if timeout := appState.Config.Ping.ResultSendTimeout; timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case appState.ResultChan <- result:
+			return true
+		case <-ctx.Done():
+			discardResult(result)
+			return false
+		case <-timer.C:
+		}
+	}
+
+	droppedResults.Add(1)
+	config.ResultsDroppedTotal.Inc()
+
+	log := logger.Default().WithPing(result.SiteID, result.IP, result.LineType)
+	log.Warn("Result channel full, dropping ping result",
+		"capacity", cap(appState.ResultChan))
+	return false
+}
+
+// discardResult counts a result of a ping still in flight at shutdown
+func discardResult(result models.PingResult) {
+	config.ResultsDiscardedShutdownTotal.Inc()
+
+	log := logger.Default().WithPing(result.SiteID, result.IP, result.LineType)
+	log.Debug("Shutting down, discarding ping result")
+}
+
+// recordHeartbeat notes that the worker of a site is still ticking
+func recordHeartbeat(siteID string) {
+	heartbeatsMu.Lock()
+	heartbeats[siteID] = time.Now()
+	heartbeatsMu.Unlock()
+}
+
+// markConsumed notes that the processor took a result from the channel
+func markConsumed() {
+	lastConsumed.Store(time.Now().UnixNano())
+}
+
+// GetResultPipelineHealth returns a snapshot of the result pipeline health
+func GetResultPipelineHealth(appState *config.AppState) ResultPipelineHealth {
+	now := time.Now()
+
+	health := ResultPipelineHealth{
+		ChannelDepth:        len(appState.ResultChan),
+		ChannelCapacity:     cap(appState.ResultChan),
+		DroppedResults:      droppedResults.Load(),
+		LastConsumedSeconds: now.Sub(time.Unix(0, lastConsumed.Load())).Seconds(),
+		WorkerHeartbeats:    make(map[string]float64),
+	}
+	health.ProcessorStalled = isProcessorStalled(appState, now)
+
+	heartbeatsMu.RLock()
+	for siteID, beat := range heartbeats {
+		health.WorkerHeartbeats[siteID] = now.Sub(beat).Seconds()
+	}
+	heartbeatsMu.RUnlock()
+
+	return health
+}
+
+// stallThreshold returns how long the processor may go without consuming a
+// result, based on the shortest interval of the enabled sites right now
+func stallThreshold(appState *config.AppState) time.Duration {
+	now := clock.Default().Now()
+	var shortest time.Duration
+	for _, site := range appState.GetSitesSnapshot() {
+		if !site.Enabled {
+			continue
+		}
+		if interval := IntervalAt(appState, site, now); shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+	}
+	if shortest == 0 {
+		shortest = appState.Config.Ping.DefaultInterval
+	}
+
+	multiplier := appState.Config.Watchdog.StallMultiplier
+	if multiplier <= 0 {
+		multiplier = 3
+	}
+	return shortest * time.Duration(multiplier)
+}
+
+// isProcessorStalled reports whether results are waiting in the channel and
+// none has been consumed for too long. An idle pipeline, with nothing to
+// consume, is not stalled however long ago the last result was.
+func isProcessorStalled(appState *config.AppState, now time.Time) bool {
+	if len(appState.ResultChan) == 0 {
+		return false
+	}
+
+	// Progress counts from the last consumed result, or from when results
+	// started queueing again after the channel ran empty
+	since := max(lastConsumed.Load(), pendingSince.Load())
+	if since == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(0, since)) > stallThreshold(appState)
+}
+
+// startResultProcessor starts a result processor, cancelling the one started
+// before, so a single processor takes results from the channel at a time. A
+// cancelled processor wedged in a result returns once that result is done.
+func startResultProcessor(ctx context.Context, appState *config.AppState) {
+	processorMu.Lock()
+	defer processorMu.Unlock()
+
+	if processorCancel != nil {
+		processorCancel()
+	}
+	processorCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	processorCancel, processorDone = cancel, done
+
+	go func() {
+		defer close(done)
+		ProcessResults(processorCtx, appState)
+	}()
+}
+
+// StartWatchdog periodically publishes pipeline health metrics and detects a
+// wedged result processor, optionally starting a replacement
+func StartWatchdog(ctx context.Context, appState *config.AppState) {
+	log := logger.Default().WithComponent("watchdog")
+	log.Info("Starting result pipeline watchdog",
+		"stall_threshold", stallThreshold(appState).String(),
+		"restart_processor", appState.Config.Watchdog.RestartProcessor)
+
+	go func() {
+		ticker := time.NewTicker(watchdogCheckInterval)
+		defer ticker.Stop()
+		reported := false
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("Stopping result pipeline watchdog")
+				return
+			case now := <-ticker.C:
+				heartbeatsMu.RLock()
+				for siteID, beat := range heartbeats {
+					config.WorkerHeartbeatAgeGauge.WithLabelValues(siteID).Set(now.Sub(beat).Seconds())
+				}
+				heartbeatsMu.RUnlock()
+
+				if !isProcessorStalled(appState, now) {
+					if reported {
+						log.Info("Result processor recovered")
+						reported = false
+					}
+					continue
+				}
+
+				if !reported {
+					log.Error("Result processor stalled, no results consumed",
+						"since", time.Unix(0, lastConsumed.Load()),
+						"channel_depth", len(appState.ResultChan),
+						"channel_capacity", cap(appState.ResultChan),
+						"dropped_results", droppedResults.Load())
+					reported = true
+				}
+
+				if appState.Config.Watchdog.RestartProcessor {
+					log.Warn("Cancelling stalled result processor, starting replacement")
+					config.ResultProcessorRestartsTotal.Inc()
+					// Give the replacement a full threshold before judging it
+					markConsumed()
+					reported = false
+					startResultProcessor(ctx, appState)
+				}
+			}
+		}
+	}()
+}
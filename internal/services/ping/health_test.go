@@ -0,0 +1,117 @@
+package ping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// newPipelineState returns an app state with an empty result channel and a
+// 10s default interval, i.e. a 30s stall threshold, and resets the pipeline
+// health of earlier tests
+func newPipelineState(t *testing.T) *config.AppState {
+	t.Helper()
+
+	lastConsumed.Store(0)
+	pendingSince.Store(0)
+	t.Cleanup(func() {
+		lastConsumed.Store(0)
+		pendingSince.Store(0)
+	})
+
+	appState := &config.AppState{
+		SiteStatus: make(map[string]*models.SiteStatus),
+		ResultChan: make(chan models.PingResult, 4),
+	}
+	appState.Config.Ping.DefaultInterval = 10 * time.Second
+	appState.Config.Watchdog.StallMultiplier = 3
+	return appState
+}
+
+func TestIsProcessorStalled(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name         string
+		pending      int
+		lastConsumed time.Duration // Before now, 0 for never
+		pendingSince time.Duration // Before now, 0 for never
+		want         bool
+	}{
+		{"idle since long ago", 0, time.Hour, time.Hour, false},
+		{"idle, never consumed", 0, 0, 0, false},
+		{"queued, consumed recently", 2, 5 * time.Second, time.Hour, false},
+		{"queued after idle, not yet consumed", 1, time.Hour, time.Second, false},
+		{"queued, no progress past threshold", 3, time.Minute, time.Minute, true},
+		{"queued, no progress below threshold", 3, 29 * time.Second, 29 * time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appState := newPipelineState(t)
+			for i := 0; i < tt.pending; i++ {
+				appState.ResultChan <- models.PingResult{SiteID: "site-001"}
+			}
+			if tt.lastConsumed > 0 {
+				lastConsumed.Store(now.Add(-tt.lastConsumed).UnixNano())
+			}
+			if tt.pendingSince > 0 {
+				pendingSince.Store(now.Add(-tt.pendingSince).UnixNano())
+			}
+
+			if got := isProcessorStalled(appState, now); got != tt.want {
+				t.Errorf("isProcessorStalled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendResultMarksPendingSince(t *testing.T) {
+	appState := newPipelineState(t)
+	lastConsumed.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	if !SendResult(context.Background(), appState, models.PingResult{SiteID: "site-001"}) {
+		t.Fatal("SendResult dropped a result with room in the channel")
+	}
+	if isProcessorStalled(appState, time.Now()) {
+		t.Error("result queued after an idle hour reported as stalled")
+	}
+	if !isProcessorStalled(appState, time.Now().Add(time.Minute)) {
+		t.Error("result left in the channel past the threshold not reported as stalled")
+	}
+}
+
+func TestStartResultProcessorCancelsPrevious(t *testing.T) {
+	appState := newPipelineState(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startResultProcessor(ctx, appState)
+	processorMu.Lock()
+	first := processorDone
+	processorMu.Unlock()
+
+	startResultProcessor(ctx, appState)
+	processorMu.Lock()
+	second := processorDone
+	processorMu.Unlock()
+
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("replaced result processor still running")
+	}
+	select {
+	case <-second:
+		t.Fatal("replacement result processor stopped")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-second:
+	case <-time.After(time.Second):
+		t.Fatal("result processor still running after shutdown")
+	}
+}
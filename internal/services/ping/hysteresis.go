@@ -0,0 +1,86 @@
+package ping
+
+import (
+	"sync"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// lineStreak is the confirmed state of a line and the results in a row that
+// contradict it
+type lineStreak struct {
+	online      bool
+	streak      int
+	streakStart time.Time // Timestamp of the first result of the streak
+}
+
+// lineConfirmation is the confirmed state of a line after a result
+type lineConfirmation struct {
+	online  bool
+	pending int       // Results in a row contradicting online
+	since   time.Time // Start of the streak that changed the state, else the result time
+	changed bool
+}
+
+var (
+	lineStreaks   = make(map[string]*lineStreak) // site_id/line_type -> confirmed state
+	lineStreaksMu sync.Mutex
+)
+
+// statusThresholds returns how many failed results in a row mark a line down
+// and how many successful ones mark it up again, per site or ping defaults
+func statusThresholds(appState *config.AppState, siteID string) (failures, successes int) {
+	failures = appState.Config.Ping.FailuresBeforeDown
+	successes = appState.Config.Ping.SuccessesBeforeUp
+	if site, exists := appState.FindSite(siteID); exists {
+		if site.FailuresBeforeDown > 0 {
+			failures = site.FailuresBeforeDown
+		}
+		if site.SuccessesBeforeUp > 0 {
+			successes = site.SuccessesBeforeUp
+		}
+	}
+	return max(failures, 1), max(successes, 1)
+}
+
+// confirmLineState applies result to the streak of its line and returns the
+// confirmed state. The state only flips once the streak reaches the
+// threshold, a result agreeing with the confirmed state resets the streak.
+// Circuit breaker results count as failures. Lines without a result yet count
+// as up, matching isLineDown.
+func confirmLineState(appState *config.AppState, result models.PingResult) lineConfirmation {
+	failures, successes := statusThresholds(appState, result.SiteID)
+	key := result.SiteID + "/" + result.LineType
+
+	lineStreaksMu.Lock()
+	defer lineStreaksMu.Unlock()
+
+	line, exists := lineStreaks[key]
+	if !exists {
+		line = &lineStreak{online: true}
+		lineStreaks[key] = line
+	}
+
+	if result.Success == line.online {
+		line.streak = 0
+		return lineConfirmation{online: line.online, since: result.Timestamp}
+	}
+
+	if line.streak == 0 {
+		line.streakStart = result.Timestamp
+	}
+	line.streak++
+	needed := failures
+	if !line.online {
+		needed = successes
+	}
+	if line.streak < needed {
+		return lineConfirmation{online: line.online, pending: line.streak, since: result.Timestamp}
+	}
+
+	line.online = result.Success
+	line.streak = 0
+	return lineConfirmation{online: line.online, since: line.streakStart, changed: true}
+}
@@ -0,0 +1,163 @@
+package ping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+func TestConfirmLineStateStreaks(t *testing.T) {
+	site := models.Site{ID: "site-streak", Name: "Streak", PrimaryIP: "192.0.2.60", Enabled: true}
+	appState := newTestAppState(t, &FakeProber{}, site)
+	appState.Config.Ping.FailuresBeforeDown = 3
+	appState.Config.Ping.SuccessesBeforeUp = 2
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	steps := []struct {
+		success bool
+		online  bool
+		pending int
+		changed bool
+		since   int // Step whose timestamp the confirmation reports
+	}{
+		{false, true, 1, false, 0},
+		{false, true, 2, false, 1},
+		{true, true, 0, false, 2}, // A success resets the failure streak
+		{false, true, 1, false, 3},
+		{false, true, 2, false, 4},
+		{false, false, 0, true, 3}, // Down since the first failure of the streak
+		{false, false, 0, false, 6},
+		{true, false, 1, false, 7},
+		{false, false, 0, false, 8}, // A failure resets the success streak
+		{true, false, 1, false, 9},
+		{true, true, 0, true, 9},
+	}
+	for i, step := range steps {
+		got := confirmLineState(appState, models.PingResult{
+			SiteID: site.ID, LineType: "primary", Success: step.success, Timestamp: start.Add(time.Duration(i) * time.Minute),
+		})
+		want := lineConfirmation{online: step.online, pending: step.pending, changed: step.changed,
+			since: start.Add(time.Duration(step.since) * time.Minute)}
+		if got != want {
+			t.Errorf("step %d (success %v): %+v, want %+v", i, step.success, got, want)
+		}
+	}
+
+	// The streaks are kept per line
+	secondary := confirmLineState(appState, models.PingResult{SiteID: site.ID, LineType: "secondary", Timestamp: start})
+	if !secondary.online || secondary.pending != 1 {
+		t.Errorf("first secondary failure: %+v, want online with 1 pending", secondary)
+	}
+}
+
+func TestStatusThresholds(t *testing.T) {
+	sites := []models.Site{
+		{ID: "site-default", PrimaryIP: "192.0.2.61"},
+		{ID: "site-override", PrimaryIP: "192.0.2.62", FailuresBeforeDown: 1, SuccessesBeforeUp: 4},
+	}
+	appState := newTestAppState(t, &FakeProber{}, sites...)
+	appState.Config.Ping.FailuresBeforeDown = 3
+	appState.Config.Ping.SuccessesBeforeUp = 2
+
+	tests := []struct {
+		siteID              string
+		failures, successes int
+	}{
+		{"site-default", 3, 2},
+		{"site-override", 1, 4},
+		{"site-unknown", 3, 2},
+	}
+	for _, tt := range tests {
+		if failures, successes := statusThresholds(appState, tt.siteID); failures != tt.failures || successes != tt.successes {
+			t.Errorf("%s: thresholds %d/%d, want %d/%d", tt.siteID, failures, successes, tt.failures, tt.successes)
+		}
+	}
+
+	// Unset thresholds change the state with every result
+	appState.Config.Ping.FailuresBeforeDown = 0
+	appState.Config.Ping.SuccessesBeforeUp = 0
+	if failures, successes := statusThresholds(appState, "site-default"); failures != 1 || successes != 1 {
+		t.Errorf("unset thresholds %d/%d, want 1/1", failures, successes)
+	}
+}
+
+// Checks blocked by an open circuit breaker count towards the failure streak,
+// while every raw result is logged unchanged
+func TestHysteresisWithCircuitBreaker(t *testing.T) {
+	clk := testutil.UseManualClock(t, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	site := models.Site{ID: "site-hysteresis", Name: "Hysteresis", PrimaryIP: "192.0.2.63", Enabled: true}
+	answer := lostReplies
+	prober := &FakeProber{Respond: func(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+		return answer(target, opts)
+	}}
+	appState := newTestAppState(t, prober, site)
+	appState.Config.Ping.FailuresBeforeDown = 5
+	appState.Config.Ping.SuccessesBeforeUp = 2
+
+	// check probes the primary line, processes and returns the result and
+	// moves the clock on to the next check
+	check := func() models.PingResult {
+		t.Helper()
+		PingIP(context.Background(), appState, site.ID, site.PrimaryIP, "primary", 0)
+		result := <-appState.ResultChan
+		HandlePingResult(appState, result)
+		clk.Advance(10 * time.Second)
+		return result
+	}
+	expectLine := func(step string, online bool, pending int) {
+		t.Helper()
+		status, _ := appState.GetSiteStatus(site.ID)
+		if status.PrimaryOnline != online || status.PrimaryPending != pending {
+			t.Errorf("%s: online %v with %d pending, want %v with %d", step, status.PrimaryOnline, status.PrimaryPending, online, pending)
+		}
+	}
+
+	// The breaker opens after three failed probes, the line is still up
+	for i := 1; i <= 3; i++ {
+		if result := check(); result.CircuitOpen {
+			t.Fatalf("check %d blocked before the breaker opened", i)
+		}
+		expectLine("probed failure", true, i)
+	}
+	breaker := GetGlobalCircuitBreakerManager().GetBreaker(site.ID, "primary")
+	if breaker.GetState() != StateOpen {
+		t.Fatalf("breaker %v after 3 failures, want open", breaker.GetState())
+	}
+
+	// Blocked checks complete the streak
+	if result := check(); !result.CircuitOpen {
+		t.Fatalf("check 4 probed with the breaker open: %+v", result)
+	}
+	expectLine("first blocked check", true, 4)
+	check()
+	expectLine("second blocked check", false, 0)
+
+	// Once the breaker lets a probe through again the line needs two successes
+	answer = func(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+		return models.ProbeStats{PacketsSent: opts.Count, PacketsRecv: opts.Count, AvgRtt: time.Millisecond}, nil
+	}
+	clk.Advance(time.Minute)
+	if result := check(); !result.Success || breaker.GetState() != StateClosed {
+		t.Fatalf("half-open probe %+v, breaker %v, want a success closing the breaker", result, breaker.GetState())
+	}
+	expectLine("first success", false, 1)
+	check()
+	expectLine("second success", true, 0)
+
+	logs, _ := appState.Storage.GetAllLogs()
+	circuitOpen, failed := 0, 0
+	for _, log := range logs {
+		if log.CircuitOpen {
+			circuitOpen++
+		}
+		if !log.Success {
+			failed++
+		}
+	}
+	if len(logs) != 7 || failed != 5 || circuitOpen != 2 {
+		t.Errorf("logged %d results, %d failed and %d circuit open, want 7, 5 and 2", len(logs), failed, circuitOpen)
+	}
+}
@@ -0,0 +1,31 @@
+package ping
+
+import (
+	"sync"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+var (
+	latencyWindows   = make(map[string]*rollingWindow) // site_id/line_type -> recent latencies
+	latencyWindowsMu sync.Mutex
+)
+
+// updateCurrentLatencyGauge sets ping_latency_current_ms of the line of a
+// successful check to the mean latency of its last Metrics.CurrentLatencyWindow
+// successful checks. The gauge has no series unless Metrics.CurrentLatency is
+// set, a line adds one series per site and line.
+func updateCurrentLatencyGauge(appState *config.AppState, result models.PingResult) {
+	if !appState.Config.Metrics.CurrentLatency || !result.Success || result.Latency == nil {
+		return
+	}
+
+	latency := *result.Latency
+	if size := appState.Config.Metrics.CurrentLatencyWindow; size > 1 {
+		latencyWindowsMu.Lock()
+		latency = rollingMean(latencyWindows, result.SiteID+"/"+result.LineType, latency, size)
+		latencyWindowsMu.Unlock()
+	}
+	config.PingLatencyCurrentGauge.WithLabelValues(result.SiteID, result.LineType).Set(latency)
+}
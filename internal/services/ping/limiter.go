@@ -0,0 +1,138 @@
+package ping
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+
+	"sitewatch/internal/config"
+)
+
+// PriorityLimiter bounds the number of concurrent ping operations. When all
+// slots are taken, waiting checks are granted slots by site priority (higher
+// first) and in arrival order within the same priority.
+type PriorityLimiter struct {
+	capacity int
+	inUse    int
+	seq      uint64
+	waiters  waiterQueue
+	mu       sync.Mutex
+}
+
+// waiter is a check waiting for a free slot
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// NewPriorityLimiter creates a limiter allowing capacity concurrent operations.
+// A capacity <= 0 disables limiting.
+func NewPriorityLimiter(capacity int) *PriorityLimiter {
+	return &PriorityLimiter{capacity: capacity}
+}
+
+// Acquire blocks until a slot is available or ctx is done
+func (l *PriorityLimiter) Acquire(ctx context.Context, priority int) error {
+	if l.capacity <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.inUse < l.capacity && len(l.waiters) == 0 {
+		l.inUse++
+		l.mu.Unlock()
+		return nil
+	}
+
+	l.seq++
+	w := &waiter{priority: priority, seq: l.seq, ready: make(chan struct{})}
+	heap.Push(&l.waiters, w)
+	config.PingChecksWaitingGauge.Set(float64(len(l.waiters)))
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Slot was granted concurrently, hand it on
+			l.releaseLocked()
+		default:
+			heap.Remove(&l.waiters, w.index)
+			config.PingChecksWaitingGauge.Set(float64(len(l.waiters)))
+		}
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot, granting it to the highest-priority waiter if any
+func (l *PriorityLimiter) Release() {
+	if l.capacity <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+// releaseLocked hands the slot to the next waiter or frees it (must hold lock)
+func (l *PriorityLimiter) releaseLocked() {
+	if len(l.waiters) > 0 {
+		w := heap.Pop(&l.waiters).(*waiter)
+		config.PingChecksWaitingGauge.Set(float64(len(l.waiters)))
+		close(w.ready)
+		return
+	}
+	l.inUse--
+}
+
+// waiterQueue is a max-heap on priority, FIFO within equal priority
+type waiterQueue []*waiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return w
+}
+
+// Global ping limiter instance
+var globalPingLimiter *PriorityLimiter
+var pingLimiterOnce sync.Once
+
+// GetGlobalPingLimiter returns the global ping limiter sized from Ping.MaxConcurrent
+func GetGlobalPingLimiter(appState *config.AppState) *PriorityLimiter {
+	pingLimiterOnce.Do(func() {
+		globalPingLimiter = NewPriorityLimiter(appState.Config.Ping.MaxConcurrent)
+	})
+	return globalPingLimiter
+}
@@ -0,0 +1,59 @@
+package ping
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m, filepath.Join("..", "..", ".."))
+}
+
+// newTestAppState returns an app state probing the given sites with prober,
+// see testutil.NewAppState. The circuit breakers and line streaks of the
+// sites start afresh.
+func newTestAppState(t *testing.T, prober models.Prober, sites ...models.Site) *config.AppState {
+	t.Helper()
+
+	for _, site := range sites {
+		forgetLines(site.ID)
+	}
+	return testutil.NewAppState(t, testutil.Options{Sites: sites, Prober: prober})
+}
+
+// forgetLines drops the circuit breakers and line streaks of a site, they
+// are kept by site ID across tests
+func forgetLines(siteID string) {
+	cbm := GetGlobalCircuitBreakerManager()
+	cbm.mu.Lock()
+	lineStreaksMu.Lock()
+	for _, lineType := range []string{"primary", "secondary"} {
+		delete(cbm.breakers, siteID+"-"+lineType)
+		delete(lineStreaks, siteID+"/"+lineType)
+	}
+	lineStreaksMu.Unlock()
+	cbm.mu.Unlock()
+}
+
+// lostReplies answers every probe without a reply
+func lostReplies(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+	return models.ProbeStats{PacketsSent: opts.Count, PacketLoss: 100}, nil
+}
+
+// waitFor polls cond until it holds or a second passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
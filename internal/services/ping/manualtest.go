@@ -0,0 +1,122 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// ErrTestAllRunning is returned by TestAllSites while another bulk test runs
+var ErrTestAllRunning = errors.New("a test of all sites is already running")
+
+// ErrTestAllCooldown is returned by TestAllSites within ping.test_all_cooldown
+// of the previous bulk test
+var ErrTestAllCooldown = errors.New("all sites were tested recently")
+
+var (
+	testAllMu      sync.Mutex
+	testAllRunning bool
+	testAllLast    time.Time
+)
+
+// TestSite pings the lines of a site now, concurrently within
+// ping.test_timeout. A line still running at the deadline is stopped with
+// the replies it received and marked timed out.
+func TestSite(ctx context.Context, appState *config.AppState, site models.Site) models.SiteTestResult {
+	ctx, cancel := context.WithTimeout(ctx, appState.Config.Ping.TestTimeout)
+	defer cancel()
+
+	now := time.Now()
+	testLine := func(ip, lineType string) *models.LineTestResult {
+		success, latency, errorMsg, timedOut := PingIPSync(ctx, appState, site.ID, ip, lineType)
+		result := &models.LineTestResult{
+			IP:        ip,
+			Success:   success,
+			TimedOut:  timedOut,
+			Timestamp: now,
+		}
+		if !success {
+			result.Error = errorMsg
+		} else if latency != nil {
+			result.Latency = latency
+		}
+		return result
+	}
+
+	result := models.SiteTestResult{SiteID: site.ID, SiteName: site.Name}
+	var wg sync.WaitGroup
+	if site.PrimaryIP != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Primary = testLine(site.PrimaryIP, "primary")
+		}()
+	}
+	if site.SecondaryIP != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Secondary = testLine(site.SecondaryIP, "secondary")
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// TestAllSites tests every enabled site now, at most
+// ping.test_all_concurrency sites at a time, each holding a slot of the ping
+// limiter like a scheduled check. Only one bulk test runs at a time and none
+// within ping.test_all_cooldown of the previous one; retryAfter tells when
+// the next one is allowed. Results are in site order.
+func TestAllSites(ctx context.Context, appState *config.AppState) (results []models.SiteTestResult, retryAfter time.Duration, err error) {
+	testAllMu.Lock()
+	if testAllRunning {
+		testAllMu.Unlock()
+		return nil, 0, ErrTestAllRunning
+	}
+	if wait := time.Until(testAllLast.Add(appState.Config.Ping.TestAllCooldown)); !testAllLast.IsZero() && wait > 0 {
+		testAllMu.Unlock()
+		return nil, wait, ErrTestAllCooldown
+	}
+	testAllRunning = true
+	testAllLast = time.Now()
+	testAllMu.Unlock()
+	defer func() {
+		testAllMu.Lock()
+		testAllRunning = false
+		testAllMu.Unlock()
+	}()
+
+	var sites []models.Site
+	for _, site := range appState.GetSitesSnapshot() {
+		if site.Enabled {
+			sites = append(sites, site)
+		}
+	}
+
+	results = make([]models.SiteTestResult, len(sites))
+	limiter := GetGlobalPingLimiter(appState)
+	slots := make(chan struct{}, max(appState.Config.Ping.TestAllConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if err := limiter.Acquire(ctx, site.Priority); err != nil {
+				results[i] = models.SiteTestResult{SiteID: site.ID, SiteName: site.Name, Error: "test cancelled"}
+				return
+			}
+			defer limiter.Release()
+			results[i] = TestSite(ctx, appState, site)
+		}()
+	}
+	wg.Wait()
+	return results, 0, nil
+}
@@ -0,0 +1,51 @@
+package ping
+
+import (
+	"errors"
+	"fmt"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+// errNetworkNamespace marks failures to switch namespaces, as opposed to ping failures
+var errNetworkNamespace = errors.New("network namespace error")
+
+// siteNamespace returns the network namespace pings of a site run in, or ""
+// for the default namespace or when namespace support is disabled
+func siteNamespace(appState *config.AppState, siteID string) string {
+	if !appState.Config.Ping.EnableNetworkNamespaces {
+		return ""
+	}
+	site, exists := appState.FindSite(siteID)
+	if !exists {
+		return ""
+	}
+	return site.NetworkNamespace
+}
+
+// ValidateNetworkNamespaces checks that the network namespaces referenced by
+// enabled sites exist. Sites with a namespace are only warned about when
+// namespace support is disabled or unavailable on this platform.
+func ValidateNetworkNamespaces(appState *config.AppState) error {
+	log := logger.Default().WithComponent("ping")
+
+	for _, site := range appState.GetSitesSnapshot() {
+		if site.NetworkNamespace == "" || !site.Enabled {
+			continue
+		}
+
+		if !appState.Config.Ping.EnableNetworkNamespaces {
+			log.Warn("Site has a network namespace but namespace support is disabled, ignoring",
+				"site_id", site.ID,
+				"network_namespace", site.NetworkNamespace,
+				"hint", "set SITEWATCH_ENABLE_NETWORK_NAMESPACES=true")
+			continue
+		}
+
+		if err := checkNamespace(site.NetworkNamespace); err != nil {
+			return fmt.Errorf("site %s: network namespace %q: %w", site.ID, site.NetworkNamespace, err)
+		}
+	}
+	return nil
+}
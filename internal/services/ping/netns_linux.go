@@ -0,0 +1,64 @@
+//go:build linux
+
+package ping
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/vishvananda/netns"
+	"sitewatch/internal/logger"
+)
+
+// runInNamespace runs fn with the calling OS thread switched into the named
+// network namespace. Sockets opened by fn stay in that namespace after the
+// thread is switched back.
+func runInNamespace(namespace string, fn func() error) error {
+	if namespace == "" {
+		return fn()
+	}
+
+	// Namespaces are per thread - keep this goroutine on one thread
+	runtime.LockOSThread()
+
+	original, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("%w: getting current namespace: %v", errNetworkNamespace, err)
+	}
+	defer original.Close()
+
+	target, err := netns.GetFromName(namespace)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("%w: opening %q: %v", errNetworkNamespace, namespace, err)
+	}
+	defer target.Close()
+
+	if err := netns.Set(target); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("%w: entering %q: %v", errNetworkNamespace, namespace, err)
+	}
+
+	defer func() {
+		if err := netns.Set(original); err != nil {
+			// Leave the thread locked so the runtime discards it with this goroutine
+			log := logger.Default().WithComponent("ping")
+			log.Error("Failed to restore network namespace, discarding thread",
+				"network_namespace", namespace, "error", err)
+			return
+		}
+		runtime.UnlockOSThread()
+	}()
+
+	return fn()
+}
+
+// checkNamespace verifies that a named network namespace exists
+func checkNamespace(namespace string) error {
+	handle, err := netns.GetFromName(namespace)
+	if err != nil {
+		return err
+	}
+	return handle.Close()
+}
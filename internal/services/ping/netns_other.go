@@ -0,0 +1,28 @@
+//go:build !linux
+
+package ping
+
+import (
+	"sync"
+
+	"sitewatch/internal/logger"
+)
+
+var namespaceWarning sync.Once
+
+// runInNamespace ignores the namespace - network namespaces only exist on Linux
+func runInNamespace(namespace string, fn func() error) error {
+	if namespace != "" {
+		namespaceWarning.Do(func() {
+			log := logger.Default().WithComponent("ping")
+			log.Warn("Network namespaces are only supported on Linux, pinging from the default namespace",
+				"network_namespace", namespace)
+		})
+	}
+	return fn()
+}
+
+// checkNamespace accepts any namespace, runInNamespace warns when it is used
+func checkNamespace(namespace string) error {
+	return nil
+}
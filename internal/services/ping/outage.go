@@ -0,0 +1,77 @@
+package ping
+
+import (
+	"sync"
+	"time"
+
+	"sitewatch/internal/format"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/notify"
+)
+
+// lineState tracks whether a line is currently down and since when
+type lineState struct {
+	down       bool
+	since      time.Time
+	suppressed bool // Went down while the site was expected offline, no alert was sent
+}
+
+var (
+	lineStates   = make(map[string]lineState) // site_id/line_type -> current state
+	lineStatesMu sync.Mutex
+)
+
+// checkOutageTransition dispatches OutageStarted when a line fails and
+// OutageResolved when it answers again. A first result that fails counts as
+// the start of an outage; a first successful result raises nothing.
+// Failures while the site is expected offline raise nothing either; if the
+// line is still down once the schedule ends, the outage starts then.
+func checkOutageTransition(result models.PingResult, siteName string, expectedOffline bool) {
+	key := result.SiteID + "/" + result.LineType
+
+	lineStatesMu.Lock()
+	previous, seen := lineStates[key]
+	stillDown := seen && previous.down && !result.Success
+	if seen && previous.down == !result.Success && !(stillDown && previous.suppressed && !expectedOffline) {
+		lineStatesMu.Unlock()
+		return
+	}
+	lineStates[key] = lineState{down: !result.Success, since: result.Timestamp, suppressed: !result.Success && expectedOffline}
+	lineStatesMu.Unlock()
+
+	event := notify.AlertEvent{
+		SiteID:    result.SiteID,
+		SiteName:  siteName,
+		Target:    result.LineType,
+		IP:        result.IP,
+		Timestamp: result.Timestamp,
+		Simulated: result.Source == models.ResultSourceSimulation,
+	}
+
+	switch {
+	case !result.Success && expectedOffline:
+		return
+	case !result.Success:
+		event.Type = notify.OutageStarted
+		event.Error = result.Error
+	case seen && !previous.suppressed:
+		event.Type = notify.OutageResolved
+		startedAt := previous.since
+		event.StartedAt = &startedAt
+		event.Duration = format.DurationPrecise(i18n.DefaultLocale(), result.Timestamp.Sub(startedAt))
+	default:
+		return
+	}
+
+	notify.Dispatch(event)
+}
+
+// isLineDown reports whether a line is currently recorded as down. Lines
+// without a result yet count as up, matching checkOutageTransition.
+func isLineDown(siteID, lineType string) bool {
+	lineStatesMu.Lock()
+	defer lineStatesMu.Unlock()
+
+	return lineStates[siteID+"/"+lineType].down
+}
@@ -0,0 +1,80 @@
+package ping
+
+import (
+	"sync"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+const (
+	// PacketLossModeInstant exports the loss of the last check as ping_packet_loss_percentage
+	PacketLossModeInstant = "instant"
+
+	// PacketLossModeWindowed exports the mean loss of the last Metrics.PacketLossWindow checks
+	PacketLossModeWindowed = "windowed"
+)
+
+// rollingWindow holds the values of the most recent checks of a line
+type rollingWindow struct {
+	values []float64
+	next   int
+	filled bool
+}
+
+var (
+	lossWindows   = make(map[string]*rollingWindow) // site_id/line_type -> recent losses
+	lossWindowsMu sync.Mutex
+)
+
+// updatePacketLossGauges records the loss of a check. The raw value always goes
+// to ping_packet_loss_last_check_percentage; ping_packet_loss_percentage
+// follows the configured mode.
+func updatePacketLossGauges(appState *config.AppState, result models.PingResult) {
+	if result.PacketLoss == nil {
+		return
+	}
+	loss := *result.PacketLoss
+
+	config.PacketLossLastCheckGauge.WithLabelValues(result.SiteID, result.LineType).Set(loss)
+
+	if appState.Config.Metrics.PacketLossMode == PacketLossModeWindowed {
+		loss = windowedPacketLoss(result.SiteID+"/"+result.LineType, loss, appState.Config.Metrics.PacketLossWindow)
+	}
+	config.PacketLossGauge.WithLabelValues(result.SiteID, result.LineType).Set(loss)
+}
+
+// windowedPacketLoss adds loss to the window of key and returns the mean over
+// the last size checks (fewer until the window has filled up)
+func windowedPacketLoss(key string, loss float64, size int) float64 {
+	lossWindowsMu.Lock()
+	defer lossWindowsMu.Unlock()
+	return rollingMean(lossWindows, key, loss, size)
+}
+
+// rollingMean adds value to the window of key in windows and returns the mean
+// over the last size values, the caller must hold the lock of windows
+func rollingMean(windows map[string]*rollingWindow, key string, value float64, size int) float64 {
+	window, exists := windows[key]
+	if !exists || len(window.values) != size {
+		window = &rollingWindow{values: make([]float64, size)}
+		windows[key] = window
+	}
+
+	window.values[window.next] = value
+	window.next = (window.next + 1) % size
+	if window.next == 0 {
+		window.filled = true
+	}
+
+	count := window.next
+	if window.filled {
+		count = size
+	}
+
+	var sum float64
+	for _, v := range window.values[:count] {
+		sum += v
+	}
+	return sum / float64(count)
+}
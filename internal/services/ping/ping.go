@@ -1,36 +1,67 @@
 package ping
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
-	"github.com/go-ping/ping"
+	"sitewatch/internal/clock"
 	"sitewatch/internal/config"
 	"sitewatch/internal/logger"
 	"sitewatch/internal/models"
 )
 
+// icmpUnavailableError is reported when a ping failure points at the local ICMP
+// setup (blocked ICMP, missing unprivileged ping support) rather than the target
+const icmpUnavailableError = "ICMP may be blocked or unprivileged ping unsupported"
+
+// icmpUnavailableHint explains how to fix the most common unprivileged ping setup issues
+const icmpUnavailableHint = "on Linux allow unprivileged ping via sysctl net.ipv4.ping_group_range, or grant CAP_NET_RAW"
+
+// isICMPPermissionError checks whether err indicates the ICMP socket could not be used
+func isICMPPermissionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "permission denied") || strings.Contains(msg, "operation not permitted")
+}
+
 // PingSite pings both IPs of a site
-func PingSite(appState *config.AppState, site models.Site) {
+func PingSite(ctx context.Context, appState *config.AppState, site models.Site) {
 	// Ping primary IP
-	go PingIP(appState, site.ID, site.PrimaryIP, "primary")
+	go PingIP(ctx, appState, site.ID, site.PrimaryIP, "primary", site.Priority)
 	
 	// Ping secondary IP only if site has dual-line configuration
 	if site.IsDualLine() {
-		go PingIP(appState, site.ID, site.SecondaryIP, "secondary")
+		go PingIP(ctx, appState, site.ID, site.SecondaryIP, "secondary", site.Priority)
 	}
 }
 
-// PingIP pings a specific IP address
-func PingIP(appState *config.AppState, siteID, ip, lineType string) {
+// PingIP pings a specific IP address once a concurrency slot is available
+func PingIP(ctx context.Context, appState *config.AppState, siteID, ip, lineType string, priority int) {
 	log := logger.Default().WithPing(siteID, ip, lineType)
 	
+	// Wait for a slot - higher priority sites are served first when contended
+	limiter := GetGlobalPingLimiter(appState)
+	if err := limiter.Acquire(ctx, priority); err != nil {
+		log.Debug("Ping skipped, shutting down", "error", err)
+		return
+	}
+	defer limiter.Release()
+	
 	result := models.PingResult{
 		SiteID:    siteID,
 		IP:        ip,
 		LineType:  lineType,
-		Timestamp: time.Now(),
+		Timestamp: clock.Default().Now(),
 	}
 	
 	log.Debug("Starting ping operation")
@@ -47,7 +78,13 @@ func PingIP(appState *config.AppState, siteID, ip, lineType string) {
 	if err != nil {
 		// Check if it's a circuit breaker error
 		if cbErr, ok := err.(*CircuitBreakerError); ok {
+			// Manually suppressed lines are not checked and produce no result
+			if cbErr.Forced {
+				log.Debug("Ping suppressed, circuit breaker manually opened")
+				return
+			}
 			result.Success = false
+			result.CircuitOpen = true
 			result.Error = fmt.Sprintf("circuit breaker open: %s", cbErr.Error())
 			log.Warn("Ping blocked by circuit breaker", "error", cbErr.Error())
 		} else {
@@ -56,52 +93,100 @@ func PingIP(appState *config.AppState, siteID, ip, lineType string) {
 		}
 	}
 	
-	// Send result to processor
-	appState.ResultChan <- result
-}
-
-// executePing performs the actual ping operation
-func executePing(appState *config.AppState, result *models.PingResult) error {
-	log := logger.Default().WithPing(result.SiteID, result.IP, result.LineType)
+	// Outage simulations replace the measured values before processing
+	applySimulation(appState, &result)
 	
-	// Create pinger
-	pinger, err := ping.NewPinger(result.IP)
-	if err != nil {
-		result.Success = false
-		result.Error = fmt.Sprintf("failed to create pinger: %v", err)
-		log.Error("Failed to create pinger", "error", err)
-		return err
+	// Probe once more before a line flips state, circuit breaker results are final
+	var confirmation *models.PingResult
+	if _, blocked := err.(*CircuitBreakerError); !blocked {
+		confirmation = confirmStateChange(appState, &result)
+	}
+	
+	// Send result to processor
+	SendResult(ctx, appState, result)
+	if confirmation != nil {
+		SendResult(ctx, appState, *confirmation)
 	}
 	
-	// Configure pinger
+	// Sweep the payload sizes of the site after a probed check the line answered
+	if site, exists := appState.FindSite(siteID); exists && len(site.SizeSweep) > 0 && result.Success && result.Source == "" {
+		runSizeSweep(ctx, appState, *site, ip, lineType)
+	}
+}
+
+// probeOptions returns the probe settings of a site line from the ping configuration
+func probeOptions(appState *config.AppState, siteID, lineType string) models.ProbeOptions {
 	packetCount := appState.Config.Ping.PacketCount
 	if packetCount <= 0 {
 		packetCount = 3 // Default to 3 packets for better statistics
 	}
-	pinger.Count = packetCount
-	pinger.Timeout = appState.Config.Ping.Timeout
-	pinger.SetPrivileged(false) // Use unprivileged mode
-	
-	// Set packet size if configured
-	if appState.Config.Ping.PacketSize > 0 {
-		pinger.Size = appState.Config.Ping.PacketSize
+	sourceIP, sourceInterface := lineSource(appState, siteID, lineType)
+	return models.ProbeOptions{
+		Count:           packetCount,
+		Timeout:         appState.Config.Ping.Timeout,
+		Size:            appState.Config.Ping.PacketSize,
+		Source:          sourceIP,
+		SourceInterface: sourceInterface,
+		Namespace:       siteNamespace(appState, siteID),
+		VerifySize:      appState.Config.Ping.VerifyReplySize,
 	}
+}
+
+// executePing performs the actual ping operation
+func executePing(appState *config.AppState, result *models.PingResult) error {
+	log := logger.Default().WithPing(result.SiteID, result.IP, result.LineType)
 	
-	// Run ping
-	err = pinger.Run()
+	opts := probeOptions(appState, result.SiteID, result.LineType)
+	
+	stats, err := proberOf(appState).Probe(context.Background(), result.IP, opts)
 	if err != nil {
 		result.Success = false
-		result.Error = fmt.Sprintf("ping failed: %v", err)
-		log.Error("Ping execution failed", "error", err)
+		if errors.Is(err, errCreatePinger) {
+			// A bad address is a configuration error, not an outage of the line
+			result.Error = err.Error()
+			result.ConfigError = true
+			log.Error("Failed to create pinger, check the site address", "error", err)
+		} else if errors.Is(err, errSourceAddress) {
+			// Not sent via the default route instead, that would probe another uplink
+			result.Error = err.Error()
+			result.ConfigError = true
+			log.Error("Ping not sent - source unavailable, check source_ip or source_interface", "error", err)
+		} else if errors.Is(err, errNetworkNamespace) {
+			result.Error = err.Error()
+			log.Error("Ping execution failed - network namespace unusable", "error", err)
+		} else if isICMPPermissionError(err) {
+			result.Error = fmt.Sprintf("%s: %v", icmpUnavailableError, err)
+			log.Error("Ping execution failed - ICMP socket unusable", "error", err, "hint", icmpUnavailableHint)
+		} else {
+			result.Error = fmt.Sprintf("ping failed: %v", err)
+			log.Error("Ping execution failed", "error", err)
+		}
 		return err
 	}
 	
-	stats := pinger.Statistics()
+	if stats.Source != "" {
+		result.SourceIP = stats.Source
+		log.Debug("Sent from source IP", "source_ip", stats.Source)
+	}
 	
 	// Always capture packet statistics
 	result.PacketsSent = stats.PacketsSent
 	result.PacketsRecv = stats.PacketsRecv
-	result.PacketsDuplicates = stats.PacketsRecvDuplicates
+	result.PacketsDuplicates = stats.PacketsDuplicates
+	result.PacketsCorrupted = stats.PacketsCorrupted
+	
+	// Replies of the wrong size pass as working pings while real traffic of
+	// that size breaks, too many of them degrade the check
+	if stats.PacketsCorrupted > 0 {
+		corruptedPercent := float64(stats.PacketsCorrupted) / float64(stats.PacketsRecv) * 100
+		if corruptedPercent > appState.Config.Ping.CorruptedDegradedPercent {
+			result.Degraded = true
+			log.Warn("Echo replies with a wrong payload size, check degraded",
+				"packets_corrupted", stats.PacketsCorrupted,
+				"packets_recv", stats.PacketsRecv,
+				"packet_size", opts.Size)
+		}
+	}
 	
 	// Calculate packet loss percentage
 	if stats.PacketsSent > 0 {
@@ -125,6 +210,11 @@ func executePing(appState *config.AppState, result *models.PingResult) error {
 		result.MaxLatency = &maxLatencyMs
 		result.Jitter = &jitterMs
 		
+		if stats.TTL > 0 {
+			ttl := stats.TTL
+			result.TTL = &ttl
+		}
+		
 		log.Debug("Ping successful", 
 			"latency_avg_ms", latencyMs,
 			"latency_min_ms", minLatencyMs,
@@ -133,7 +223,16 @@ func executePing(appState *config.AppState, result *models.PingResult) error {
 			"packets_sent", stats.PacketsSent,
 			"packets_recv", stats.PacketsRecv,
 			"packet_loss_pct", stats.PacketLoss,
-			"duplicates", stats.PacketsRecvDuplicates)
+			"duplicates", stats.PacketsDuplicates,
+			"ttl", stats.TTL)
+	} else if stats.PacketsSent == 0 {
+		// Run returned without error but nothing went out - the host was never actually probed
+		result.Success = false
+		result.Error = icmpUnavailableError
+		log.Warn("Ping failed - no packets could be sent", 
+			"packets_sent", stats.PacketsSent,
+			"hint", icmpUnavailableHint)
+		return errors.New(icmpUnavailableError)
 	} else {
 		result.Success = false
 		result.Error = "no packets received"
@@ -146,43 +245,59 @@ func executePing(appState *config.AppState, result *models.PingResult) error {
 	return nil
 }
 
-// PingIPSync performs a synchronous ping for testing purposes
-func PingIPSync(appState *config.AppState, ip string) (success bool, latency *float64, errorMsg string) {
-	// Create pinger
-	pinger, err := ping.NewPinger(ip)
+// PingIPSync performs a synchronous ping of a site IP for testing purposes.
+// When ctx ends first the ping is stopped, the replies received until then
+// still count and timedOut is set.
+func PingIPSync(ctx context.Context, appState *config.AppState, siteID, ip, lineType string) (success bool, latency *float64, errorMsg string, timedOut bool) {
+	stats, err := proberOf(appState).Probe(ctx, ip, probeOptions(appState, siteID, lineType))
 	if err != nil {
-		return false, nil, fmt.Sprintf("failed to create pinger: %v", err)
-	}
-	
-	// Configure pinger
-	packetCount := appState.Config.Ping.PacketCount
-	if packetCount <= 0 {
-		packetCount = 3 // Default to 3 packets for better statistics
-	}
-	pinger.Count = packetCount
-	pinger.Timeout = appState.Config.Ping.Timeout
-	pinger.SetPrivileged(false) // Use unprivileged mode
-	
-	// Set packet size if configured
-	if appState.Config.Ping.PacketSize > 0 {
-		pinger.Size = appState.Config.Ping.PacketSize
-	}
-	
-	// Run ping
-	err = pinger.Run()
-	if err != nil {
-		return false, nil, fmt.Sprintf("ping failed: %v", err)
+		if errors.Is(err, errCreatePinger) || errors.Is(err, errNetworkNamespace) || errors.Is(err, errSourceAddress) {
+			return false, nil, err.Error(), false
+		}
+		if isICMPPermissionError(err) {
+			return false, nil, fmt.Sprintf("%s: %v", icmpUnavailableError, err), false
+		}
+		return false, nil, fmt.Sprintf("ping failed: %v", err), false
 	}
 	
-	stats := pinger.Statistics()
+	timedOut = ctx.Err() != nil
 	if stats.PacketsRecv > 0 {
 		latencyMs := float64(stats.AvgRtt.Nanoseconds()) / 1000000.0 // Convert to milliseconds
-		return true, &latencyMs, ""
+		return true, &latencyMs, "", timedOut
+	} else if timedOut {
+		return false, nil, "test timed out", true
+	} else if stats.PacketsSent == 0 {
+		return false, nil, icmpUnavailableError, false
 	} else {
-		return false, nil, "no packets received"
+		return false, nil, "no packets received", false
 	}
 }
 
+// PingIPFull sends a single echo request to an address that is not a site,
+// e.g. during subnet discovery, without circuit breaker, simulation, logging
+// or result processing. ok reports whether a reply arrived, err is only set
+// when the probe could not be sent at all.
+func PingIPFull(appState *config.AppState, ip string, timeout time.Duration) (latencyMs float64, ok bool, err error) {
+	stats, err := proberOf(appState).Probe(context.Background(), ip, models.ProbeOptions{Count: 1, Timeout: timeout})
+	if err != nil {
+		if errors.Is(err, errCreatePinger) {
+			return 0, false, err
+		}
+		if isICMPPermissionError(err) {
+			return 0, false, fmt.Errorf("%s: %w", icmpUnavailableError, err)
+		}
+		return 0, false, fmt.Errorf("ping failed: %w", err)
+	}
+
+	if stats.PacketsSent == 0 {
+		return 0, false, errors.New(icmpUnavailableError)
+	}
+	if stats.PacketsRecv == 0 {
+		return 0, false, nil
+	}
+	return float64(stats.AvgRtt.Nanoseconds()) / 1000000.0, true, nil
+}
+
 // HandlePingResult handles a single ping result
 func HandlePingResult(appState *config.AppState, result models.PingResult) {
 	atomic.AddInt64(&appState.TotalChecks, 1)
@@ -193,45 +308,79 @@ func HandlePingResult(appState *config.AppState, result models.PingResult) {
 		successLabel = "true"
 	}
 	
-	config.PingChecksTotal.WithLabelValues(result.SiteID, result.LineType, successLabel).Inc()
+	config.PingChecksTotal.WithLabelValues(result.SiteID, result.LineType, successLabel, result.SourceIP).Inc()
 	
 	// Update extended packet metrics
 	config.PacketsSentCounter.WithLabelValues(result.SiteID, result.LineType).Add(float64(result.PacketsSent))
 	config.PacketsReceivedCounter.WithLabelValues(result.SiteID, result.LineType).Add(float64(result.PacketsRecv))
 	config.PacketsDuplicatesCounter.WithLabelValues(result.SiteID, result.LineType).Add(float64(result.PacketsDuplicates))
 	
-	// Update packet loss gauge
-	if result.PacketLoss != nil {
-		config.PacketLossGauge.WithLabelValues(result.SiteID, result.LineType).Set(*result.PacketLoss)
+	// Update packet loss gauges (raw and per configured mode)
+	updatePacketLossGauges(appState, result)
+	
+	if result.ConfigError {
+		config.PingConfigErrorsTotal.WithLabelValues(result.SiteID, result.LineType).Inc()
+	}
+	if result.PacketsCorrupted > 0 {
+		config.PingPacketsCorruptedTotal.WithLabelValues(result.SiteID, result.LineType).Add(float64(result.PacketsCorrupted))
 	}
 	
 	if result.Success {
 		latencySeconds := *result.Latency / 1000.0 // Convert ms to seconds
 		config.PingLatencyHistogram.WithLabelValues(result.SiteID, result.LineType).Observe(latencySeconds)
-		config.SiteStatusGauge.WithLabelValues(result.SiteID, result.LineType).Set(1)
+		updateCurrentLatencyGauge(appState, result)
 		
 		// Update jitter histogram
 		if result.Jitter != nil {
 			jitterSeconds := *result.Jitter / 1000.0 // Convert ms to seconds
 			config.JitterHistogram.WithLabelValues(result.SiteID, result.LineType).Observe(jitterSeconds)
 		}
-	} else {
-		config.SiteStatusGauge.WithLabelValues(result.SiteID, result.LineType).Set(0)
 	}
 	
 	// Add to ping logs
 	var siteName string
+	var expectedOffline bool
 	for _, site := range appState.Sites {
 		if site.ID == result.SiteID {
 			siteName = site.Name
+			expectedOffline = site.ExpectedOffline.Contains(result.Timestamp) || appState.InMaintenance(site.ID, result.Timestamp)
 			break
 		}
 	}
 	
+	// A state change contradicted by its confirmation probe is only logged
+	if result.Unconfirmed {
+		AddPingLogToStorage(appState, result, siteName)
+		return
+	}
+	
+	if result.Success {
+		appState.RecordFirstSuccess(result.SiteID, result.Timestamp)
+	}
+	
+	// The line status, its gauge and the outage events follow the confirmed
+	// state, the raw result is logged unchanged
+	line := confirmLineState(appState, result)
+	lineStatus := float64(0)
+	if line.online {
+		lineStatus = 1
+	}
+	config.SiteStatusGauge.WithLabelValues(result.SiteID, result.LineType).Set(lineStatus)
+	
+	transition := result
+	transition.Success = line.online
+	if line.changed {
+		transition.Timestamp = line.since // The outage starts (or ends) with the streak
+	}
+	
+	checkTTLChange(appState, result, siteName)
+	checkOutageTransition(transition, siteName, expectedOffline)
+	checkLatencyDeviation(appState, result, siteName, expectedOffline)
+	
 	AddPingLogToStorage(appState, result, siteName)
 	
 	// Update site status in memory
-	UpdateSiteStatus(appState, result)
+	UpdateSiteStatus(appState, result, line)
 }
 
 // AddPingLogToStorage adds a ping log entry to the configured storage backend
@@ -256,6 +405,13 @@ func AddPingLogToStorage(appState *config.AppState, result models.PingResult, si
 		MinLatency:       result.MinLatency,
 		MaxLatency:       result.MaxLatency,
 		Jitter:           result.Jitter,
+		TTL:              result.TTL,
+		Source:           result.Source,
+		SourceIP:         result.SourceIP,
+		PacketsCorrupted: result.PacketsCorrupted,
+		Degraded:         result.Degraded,
+		CircuitOpen:      result.CircuitOpen,
+		ConfigError:      result.ConfigError,
 	}
 	
 	// Add to storage backend
@@ -268,12 +424,12 @@ func AddPingLogToStorage(appState *config.AppState, result models.PingResult, si
 	}
 }
 
-// GetFilteredLogs returns filtered ping logs from storage
-func GetFilteredLogs(appState *config.AppState, siteID string, success *bool, limit int) ([]models.PingLog, error) {
-	log := logger.Default().WithComponent("storage").WithSite(siteID, "")
+// GetFilteredLogs returns filtered ping logs of the given sites (nil = all) from storage
+func GetFilteredLogs(appState *config.AppState, siteIDs []string, success *bool, limit int) ([]models.PingLog, error) {
+	log := logger.Default().WithComponent("storage").With("site_ids", siteIDs)
 	
 	// Get logs from storage backend
-	logs, err := appState.Storage.GetFilteredLogs(siteID, success, limit)
+	logs, err := appState.Storage.GetFilteredLogs(siteIDs, success, limit)
 	if err != nil {
 		log.Error("Failed to get logs from storage", "error", err, "limit", limit, "success_filter", success)
 		return nil, err
@@ -283,8 +439,23 @@ func GetFilteredLogs(appState *config.AppState, siteID string, success *bool, li
 	return logs, nil
 }
 
-// UpdateSiteStatus updates site status in memory
-func UpdateSiteStatus(appState *config.AppState, result models.PingResult) {
+// GetFilteredLogsPage returns one page of filtered ping logs and the total number of matches
+func GetFilteredLogsPage(appState *config.AppState, siteIDs []string, success *bool, limit, offset int) ([]models.PingLog, int, error) {
+	log := logger.Default().WithComponent("storage").With("site_ids", siteIDs)
+	
+	logs, total, err := appState.Storage.GetFilteredLogsPage(siteIDs, success, limit, offset)
+	if err != nil {
+		log.Error("Failed to get log page from storage", "error", err, "limit", limit, "offset", offset, "success_filter", success)
+		return nil, 0, err
+	}
+	
+	log.Debug("Retrieved filtered log page", "count", len(logs), "total", total, "limit", limit, "offset", offset, "success_filter", success)
+	return logs, total, nil
+}
+
+// UpdateSiteStatus updates site status in memory. The online state of the
+// line is the confirmed one, latency and error are those of the result.
+func UpdateSiteStatus(appState *config.AppState, result models.PingResult, line lineConfirmation) {
 	appState.Mu.Lock()
 	defer appState.Mu.Unlock()
 	
@@ -296,7 +467,15 @@ func UpdateSiteStatus(appState *config.AppState, result models.PingResult) {
 	// Update based on line type
 	switch result.LineType {
 	case "primary":
-		status.PrimaryOnline = result.Success
+		status.PrimaryOnline = line.online
+		status.PrimaryPending = line.pending
+		if !result.CircuitOpen {
+			status.PrimaryConfigError = result.ConfigError // Kept while the breaker blocks the probes
+			status.PrimaryDegraded = result.Degraded
+			if result.Source == "" {
+				status.PrimarySourceIP = result.SourceIP
+			}
+		}
 		if result.Success {
 			status.PrimaryLatency = result.Latency
 			status.PrimaryError = ""
@@ -305,7 +484,15 @@ func UpdateSiteStatus(appState *config.AppState, result models.PingResult) {
 			status.PrimaryError = result.Error
 		}
 	case "secondary":
-		status.SecondaryOnline = result.Success
+		status.SecondaryOnline = line.online
+		status.SecondaryPending = line.pending
+		if !result.CircuitOpen {
+			status.SecondaryConfigError = result.ConfigError // Kept while the breaker blocks the probes
+			status.SecondaryDegraded = result.Degraded
+			if result.Source == "" {
+				status.SecondarySourceIP = result.SourceIP
+			}
+		}
 		if result.Success {
 			status.SecondaryLatency = result.Latency
 			status.SecondaryError = ""
@@ -0,0 +1,184 @@
+package ping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+// Results of probes pass through the result channel and the processor into
+// the site status and the storage
+func TestResultPipelineWithFakeProber(t *testing.T) {
+	site := models.Site{ID: "site-pipeline", Name: "Pipeline", PrimaryIP: "192.0.2.10", SecondaryIP: "192.0.2.11", Enabled: true}
+	prober := &FakeProber{Respond: func(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+		if target == site.SecondaryIP {
+			return lostReplies(target, opts)
+		}
+		return models.ProbeStats{PacketsSent: opts.Count, PacketsRecv: opts.Count, AvgRtt: 12 * time.Millisecond, TTL: 57}, nil
+	}}
+	appState := newTestAppState(t, prober, site)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startResultProcessor(ctx, appState)
+
+	PingSite(ctx, appState, site)
+
+	waitFor(t, "both results stored", func() bool {
+		logs, _ := appState.Storage.GetAllLogs()
+		return len(logs) == 2
+	})
+	logs, _ := appState.Storage.GetAllLogs()
+	for _, log := range logs {
+		switch log.Target {
+		case "primary":
+			if !log.Success || log.Latency == nil || *log.Latency != 12 || log.TTL == nil || *log.TTL != 57 || log.SiteName != "Pipeline" {
+				t.Errorf("unexpected primary log %+v", log)
+			}
+		case "secondary":
+			if log.Success || log.Error != "no packets received" || log.PacketsSent != 3 {
+				t.Errorf("unexpected secondary log %+v", log)
+			}
+		}
+	}
+
+	waitFor(t, "site status updated", func() bool {
+		status, _ := appState.GetSiteStatus(site.ID)
+		return !status.LastCheck.IsZero() && status.PrimaryLatency != nil && status.SecondaryError != ""
+	})
+	status, _ := appState.GetSiteStatus(site.ID)
+	if !status.PrimaryOnline || status.SecondaryOnline || status.BothOnline {
+		t.Errorf("status primary %v, secondary %v, both %v, want true, false, false",
+			status.PrimaryOnline, status.SecondaryOnline, status.BothOnline)
+	}
+	if got := prober.Probes(); len(got) != 2 {
+		t.Errorf("probed %v, want both lines once", got)
+	}
+}
+
+// Failing probes open the circuit breaker of the line, its checks are then
+// reported as circuit open without probing
+func TestPingIPTripsCircuitBreaker(t *testing.T) {
+	site := models.Site{ID: "site-trip", Name: "Trip", PrimaryIP: "192.0.2.20", Enabled: true}
+	prober := &FakeProber{Respond: lostReplies}
+	appState := newTestAppState(t, prober, site)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		PingIP(ctx, appState, site.ID, site.PrimaryIP, "primary", 0)
+	}
+
+	if got := len(prober.Probes()); got != 3 {
+		t.Errorf("probed %d times, want 3 before the breaker opened", got)
+	}
+	if state := GetGlobalCircuitBreakerManager().GetBreaker(site.ID, "primary").GetState(); state != StateOpen {
+		t.Errorf("breaker state %v, want open", state)
+	}
+
+	var results []models.PingResult
+	for len(appState.ResultChan) > 0 {
+		results = append(results, <-appState.ResultChan)
+	}
+	if len(results) != 4 {
+		t.Fatalf("%d results sent, want 4", len(results))
+	}
+	for i, result := range results[:3] {
+		if result.Success || result.CircuitOpen || result.Error != "no packets received" {
+			t.Errorf("result %d: %+v, want a probed failure", i+1, result)
+		}
+	}
+	if blocked := results[3]; blocked.Success || !blocked.CircuitOpen || blocked.PacketsSent != 0 {
+		t.Errorf("result 4: %+v, want blocked by the circuit breaker", blocked)
+	}
+}
+
+func TestPingIPSyncWithFakeProber(t *testing.T) {
+	site := models.Site{ID: "site-sync", Name: "Sync", PrimaryIP: "192.0.2.30", SecondaryIP: "192.0.2.31", Enabled: true}
+	prober := &FakeProber{Respond: func(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+		if target == site.SecondaryIP {
+			return lostReplies(target, opts)
+		}
+		return models.ProbeStats{PacketsSent: opts.Count, PacketsRecv: opts.Count, AvgRtt: 5 * time.Millisecond}, nil
+	}}
+	appState := newTestAppState(t, prober, site)
+
+	success, latency, errorMsg, timedOut := PingIPSync(context.Background(), appState, site.ID, site.PrimaryIP, "primary")
+	if !success || latency == nil || *latency != 5 || errorMsg != "" || timedOut {
+		t.Errorf("primary: %v, %v, %q, %v", success, latency, errorMsg, timedOut)
+	}
+	success, _, errorMsg, _ = PingIPSync(context.Background(), appState, site.ID, site.SecondaryIP, "secondary")
+	if success || errorMsg != "no packets received" {
+		t.Errorf("secondary: %v, %q, want a failure", success, errorMsg)
+	}
+	if len(appState.ResultChan) != 0 {
+		t.Error("synchronous test pings sent results to the processor")
+	}
+}
+
+// The circuit breaker of a line lets a probe through once its reset timeout
+// passed on the clock of the application, results carry that clock's time
+func TestPingIPBreakerResetTiming(t *testing.T) {
+	clk := testutil.UseManualClock(t, time.Date(2024, 3, 31, 0, 59, 0, 0, time.UTC))
+	site := models.Site{ID: "site-reset", Name: "Reset", PrimaryIP: "192.0.2.50", Enabled: true}
+	answer := lostReplies
+	prober := &FakeProber{Respond: func(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+		return answer(target, opts)
+	}}
+	appState := newTestAppState(t, prober, site)
+	ctx := context.Background()
+
+	check := func() models.PingResult {
+		t.Helper()
+		PingIP(ctx, appState, site.ID, site.PrimaryIP, "primary", 0)
+		result := <-appState.ResultChan
+		if !result.Timestamp.Equal(clk.Now()) {
+			t.Errorf("result at %v, want the clock time %v", result.Timestamp, clk.Now())
+		}
+		return result
+	}
+
+	for i := 0; i < 3; i++ {
+		check()
+	}
+	breaker := GetGlobalCircuitBreakerManager().GetBreaker(site.ID, "primary")
+	if breaker.GetState() != StateOpen {
+		t.Fatalf("breaker %v after 3 failures, want open", breaker.GetState())
+	}
+
+	// Still blocked when exactly the reset timeout passed
+	clk.Advance(time.Minute)
+	if result := check(); !result.CircuitOpen || len(prober.Probes()) != 3 {
+		t.Fatalf("check at the reset timeout probed (%d probes, %+v)", len(prober.Probes()), result)
+	}
+
+	// Probed half-open past it, the failure opens the breaker again
+	clk.Advance(time.Second)
+	if result := check(); result.CircuitOpen || len(prober.Probes()) != 4 {
+		t.Fatalf("check past the reset timeout not probed (%d probes, %+v)", len(prober.Probes()), result)
+	}
+	if breaker.GetState() != StateOpen {
+		t.Fatalf("breaker %v after a failed half-open probe, want open", breaker.GetState())
+	}
+	if until, _ := breaker.OpenUntil(); !until.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("open until %v, want a minute after the failed probe", until)
+	}
+
+	// The reset timeout restarts with the failed probe
+	answer = func(target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+		return models.ProbeStats{PacketsSent: opts.Count, PacketsRecv: opts.Count, AvgRtt: time.Millisecond}, nil
+	}
+	clk.Advance(30 * time.Second)
+	if result := check(); !result.CircuitOpen {
+		t.Fatalf("check 30s after the failed half-open probe not blocked: %+v", result)
+	}
+	clk.Advance(31 * time.Second)
+	if result := check(); !result.Success {
+		t.Fatalf("check after the second reset timeout failed: %+v", result)
+	}
+	if breaker.GetState() != StateClosed {
+		t.Errorf("breaker %v after a successful half-open probe, want closed", breaker.GetState())
+	}
+}
@@ -0,0 +1,147 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-ping/ping"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// errCreatePinger marks probes that could not be set up, e.g. an address that
+// does not parse or resolve, as opposed to probes that ran and failed
+var errCreatePinger = errors.New("failed to create pinger")
+
+// go-ping puts a timestamp and a tracking UUID into every payload, which is
+// never shorter than that. Replies carry the ICMP echo header on top.
+const (
+	minEchoPayload   = 24
+	echoHeaderLength = 8
+)
+
+// ICMPProber probes targets with unprivileged ICMP echo requests via go-ping
+type ICMPProber struct{}
+
+// Probe implements models.Prober, the ping is stopped when ctx ends
+func (ICMPProber) Probe(ctx context.Context, target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+	pinger, err := ping.NewPinger(target)
+	if err != nil {
+		return models.ProbeStats{}, fmt.Errorf("%w: %v", errCreatePinger, err)
+	}
+	pinger.Count = opts.Count
+	pinger.Timeout = opts.Timeout
+	pinger.SetPrivileged(false) // Use unprivileged mode
+	if opts.Size > 0 {
+		pinger.Size = opts.Size
+	}
+
+	// Count the reply TTLs, the most common one is recorded to detect route
+	// changes. Unprivileged sockets may not report it (0), it stays unset then.
+	// With VerifySize, replies truncated or padded on the way, e.g. by
+	// middleboxes mangling large payloads, are counted as corrupted.
+	ttlCounts := make(map[int]int)
+	var lastTTL, corrupted int
+	expectedBytes := echoHeaderLength + max(pinger.Size, minEchoPayload)
+	pinger.OnRecv = func(pkt *ping.Packet) {
+		if pkt.Ttl > 0 {
+			ttlCounts[pkt.Ttl]++
+			lastTTL = pkt.Ttl
+		}
+		if opts.VerifySize && pkt.Nbytes != expectedBytes {
+			corrupted++
+		}
+	}
+
+	// Stop the ping at the deadline of the caller
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pinger.Stop()
+		case <-done:
+		}
+	}()
+
+	// Run ping, from the network namespace if set. The source is resolved in
+	// there as well, the addresses of the host differ between namespaces.
+	err = runInNamespace(opts.Namespace, func() error {
+		source, err := resolveSource(opts.Source, opts.SourceInterface, pinger.IPAddr().IP)
+		if err != nil {
+			return err
+		}
+		pinger.Source = source
+		return pinger.Run()
+	})
+	if err != nil {
+		return models.ProbeStats{}, err
+	}
+
+	stats := pinger.Statistics()
+	return models.ProbeStats{
+		PacketsSent:       stats.PacketsSent,
+		PacketsRecv:       stats.PacketsRecv,
+		PacketsDuplicates: stats.PacketsRecvDuplicates,
+		PacketLoss:        stats.PacketLoss,
+		AvgRtt:            stats.AvgRtt,
+		MinRtt:            stats.MinRtt,
+		MaxRtt:            stats.MaxRtt,
+		StdDevRtt:         stats.StdDevRtt,
+		TTL:               modalTTL(ttlCounts, lastTTL),
+		Source:            pinger.Source,
+		PacketsCorrupted:  corrupted,
+	}, nil
+}
+
+// FakeProber answers probes from canned responses instead of the network, for
+// tests of the ping workers, circuit breakers and result pipeline
+type FakeProber struct {
+	// Respond returns the outcome of a probe, nil answers every probe with
+	// all packets received after 1ms
+	Respond func(target string, opts models.ProbeOptions) (models.ProbeStats, error)
+
+	mu     sync.Mutex
+	probes []string
+}
+
+// Probe implements models.Prober and records the probed target
+func (f *FakeProber) Probe(ctx context.Context, target string, opts models.ProbeOptions) (models.ProbeStats, error) {
+	f.mu.Lock()
+	f.probes = append(f.probes, target)
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return models.ProbeStats{}, nil
+	}
+	if f.Respond != nil {
+		return f.Respond(target, opts)
+	}
+	return models.ProbeStats{
+		PacketsSent: opts.Count,
+		PacketsRecv: opts.Count,
+		AvgRtt:      time.Millisecond,
+		MinRtt:      time.Millisecond,
+		MaxRtt:      time.Millisecond,
+	}, nil
+}
+
+// Probes returns the probed targets in order
+func (f *FakeProber) Probes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.probes...)
+}
+
+// proberOf returns the prober pings of appState are sent with, ICMPProber
+// unless main or a test set AppState.Prober
+func proberOf(appState *config.AppState) models.Prober {
+	if appState.Prober != nil {
+		return appState.Prober
+	}
+	return ICMPProber{}
+}
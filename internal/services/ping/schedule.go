@@ -0,0 +1,83 @@
+package ping
+
+import (
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// Reasons reported instead of a next check time
+const (
+	ScheduleReasonPaused          = "paused"           // Site is disabled
+	ScheduleReasonProbingDisabled = "probing_disabled" // Ingest-only mode
+	ScheduleReasonCircuitOpen     = "circuit_open"     // Next check is blocked by the circuit breaker
+	ScheduleReasonSuppressed      = "suppressed"       // Circuit breaker manually opened
+	ScheduleReasonNotScheduled    = "not_scheduled"    // Worker has not armed its ticker yet
+)
+
+// EffectiveInterval returns the check interval of a site: its own interval
+// if set, otherwise the configured default
+func EffectiveInterval(appState *config.AppState, site models.Site) time.Duration {
+	if site.Interval > 0 {
+		return time.Duration(site.Interval) * time.Second
+	}
+	return appState.Config.Ping.DefaultInterval
+}
+
+// IntervalAt returns the check interval of a site at t: the interval of the
+// first interval_schedule window containing t, otherwise EffectiveInterval
+func IntervalAt(appState *config.AppState, site models.Site, t time.Time) time.Duration {
+	for _, window := range site.IntervalSchedule {
+		if window.Interval > 0 && window.Contains(t) {
+			return time.Duration(window.Interval) * time.Second
+		}
+	}
+	return EffectiveInterval(appState, site)
+}
+
+// GetCheckSchedule returns when each line of a site is checked next
+func GetCheckSchedule(appState *config.AppState, site models.Site) models.CheckSchedule {
+	now := clock.Default().Now()
+	schedule := models.CheckSchedule{
+		IntervalSeconds: IntervalAt(appState, site, now).Seconds(),
+	}
+
+	next, scheduled := appState.GetNextCheck(site.ID)
+
+	lineSchedule := func(lineType string) models.LineSchedule {
+		switch {
+		case !appState.Config.IsPingEnabled():
+			return models.LineSchedule{Reason: ScheduleReasonProbingDisabled}
+		case !site.Enabled:
+			return models.LineSchedule{Reason: ScheduleReasonPaused}
+		case !scheduled:
+			return models.LineSchedule{Reason: ScheduleReasonNotScheduled}
+		}
+
+		// An open circuit blocks the check unless it has reset by then
+		if breaker, exists := GetGlobalCircuitBreakerManager().FindBreaker(site.ID, lineType); exists {
+			if breaker.IsForcedOpen() {
+				return models.LineSchedule{Reason: ScheduleReasonSuppressed}
+			}
+			if openUntil, open := breaker.OpenUntil(); open && !next.After(openUntil) {
+				return models.LineSchedule{Reason: ScheduleReasonCircuitOpen}
+			}
+		}
+
+		nextCheck := next
+		remaining := nextCheck.Sub(now).Seconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		return models.LineSchedule{NextCheckAt: &nextCheck, SecondsRemaining: &remaining}
+	}
+
+	schedule.Primary = lineSchedule("primary")
+	if site.IsDualLine() {
+		secondary := lineSchedule("secondary")
+		schedule.Secondary = &secondary
+	}
+	return schedule
+}
@@ -0,0 +1,199 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+// scheduleSiteID is the site of the check schedule tests
+const scheduleSiteID = "site-schedule"
+
+// tripBreaker opens the circuit breaker of a line with failed probes
+func tripBreaker(siteID, lineType string) {
+	breaker := GetGlobalCircuitBreakerManager().GetBreaker(siteID, lineType)
+	for breaker.GetState() != StateOpen {
+		breaker.Call(func() error { return errProbe })
+	}
+}
+
+// scheduleWindow returns an interval_schedule window in UTC
+func scheduleWindow(start, end string, interval int) models.IntervalWindow {
+	return models.IntervalWindow{OfflineWindow: models.OfflineWindow{Start: start, End: end, Timezone: "UTC"}, Interval: interval}
+}
+
+func TestIntervalAt(t *testing.T) {
+	appState := newTestAppState(t, &FakeProber{})
+	appState.Config.Ping.DefaultInterval = 30 * time.Second
+
+	business := scheduleWindow("08:00", "18:00", 10)
+	night := scheduleWindow("22:00", "06:00", 300)
+	day := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		site models.Site
+		at   time.Time
+		want time.Duration
+	}{
+		{"default", models.Site{}, day, 30 * time.Second},
+		{"site override", models.Site{Interval: 60}, day, time.Minute},
+		{"inside a window", models.Site{Interval: 60, IntervalSchedule: []models.IntervalWindow{business}}, day, 10 * time.Second},
+		{"outside the windows", models.Site{Interval: 60, IntervalSchedule: []models.IntervalWindow{business, night}}, day.Add(-5 * time.Hour), time.Minute},
+		{"window across midnight", models.Site{IntervalSchedule: []models.IntervalWindow{business, night}}, day.Add(11 * time.Hour), 5 * time.Minute},
+		{"window ends", models.Site{IntervalSchedule: []models.IntervalWindow{business}}, day.Add(6 * time.Hour), 30 * time.Second},
+		{"first window wins", models.Site{IntervalSchedule: []models.IntervalWindow{business, scheduleWindow("00:00", "23:59", 120)}}, day, 10 * time.Second},
+		{"window without interval", models.Site{Interval: 60, IntervalSchedule: []models.IntervalWindow{scheduleWindow("08:00", "18:00", 0)}}, day, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IntervalAt(appState, tt.site, tt.at); got != tt.want {
+				t.Errorf("IntervalAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCheckSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	disabled := false
+
+	tests := []struct {
+		name     string
+		site     models.Site
+		paused   bool
+		setup    func(appState *config.AppState)
+		interval float64
+		reason   string  // Of the primary line, empty when it has a next check
+		remain   float64 // Seconds to the primary check
+	}{
+		{
+			name:     "scheduled",
+			site:     models.Site{Interval: 60},
+			setup:    func(s *config.AppState) { s.SetNextCheck(scheduleSiteID, now.Add(45*time.Second)) },
+			interval: 60,
+			remain:   45,
+		},
+		{
+			name:     "overdue",
+			site:     models.Site{},
+			setup:    func(s *config.AppState) { s.SetNextCheck(scheduleSiteID, now.Add(-5*time.Second)) },
+			interval: 30,
+			remain:   0,
+		},
+		{
+			name:     "inside an interval window",
+			site:     models.Site{Interval: 60, IntervalSchedule: []models.IntervalWindow{scheduleWindow("11:00", "13:00", 10)}},
+			setup:    func(s *config.AppState) { s.SetNextCheck(scheduleSiteID, now.Add(10*time.Second)) },
+			interval: 10,
+			remain:   10,
+		},
+		{
+			name:     "not scheduled yet",
+			site:     models.Site{},
+			interval: 30,
+			reason:   ScheduleReasonNotScheduled,
+		},
+		{
+			name:     "paused",
+			site:     models.Site{},
+			paused:   true,
+			setup:    func(s *config.AppState) { s.SetNextCheck(scheduleSiteID, now.Add(time.Second)) },
+			interval: 30,
+			reason:   ScheduleReasonPaused,
+		},
+		{
+			name: "probing disabled",
+			site: models.Site{},
+			setup: func(s *config.AppState) {
+				s.Config.Ping.Enabled = &disabled
+				s.SetNextCheck(scheduleSiteID, now.Add(time.Second))
+			},
+			interval: 30,
+			reason:   ScheduleReasonProbingDisabled,
+		},
+		{
+			name: "circuit open",
+			site: models.Site{},
+			setup: func(s *config.AppState) {
+				tripBreaker(scheduleSiteID, "primary")
+				s.SetNextCheck(scheduleSiteID, now.Add(time.Minute)) // Exactly the reset timeout
+			},
+			interval: 30,
+			reason:   ScheduleReasonCircuitOpen,
+		},
+		{
+			name: "circuit resets before the check",
+			site: models.Site{},
+			setup: func(s *config.AppState) {
+				tripBreaker(scheduleSiteID, "primary")
+				s.SetNextCheck(scheduleSiteID, now.Add(time.Minute+time.Second))
+			},
+			interval: 30,
+			remain:   61,
+		},
+		{
+			name: "suppressed",
+			site: models.Site{},
+			setup: func(s *config.AppState) {
+				GetGlobalCircuitBreakerManager().ForceOpen(scheduleSiteID, "primary")
+				s.SetNextCheck(scheduleSiteID, now.Add(time.Second))
+			},
+			interval: 30,
+			reason:   ScheduleReasonSuppressed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.UseManualClock(t, now)
+			site := tt.site
+			site.ID, site.Name, site.PrimaryIP, site.Enabled = scheduleSiteID, "Schedule", "192.0.2.70", !tt.paused
+			appState := newTestAppState(t, &FakeProber{}, site)
+			if tt.setup != nil {
+				tt.setup(appState)
+			}
+
+			schedule := GetCheckSchedule(appState, site)
+			if schedule.IntervalSeconds != tt.interval {
+				t.Errorf("interval %vs, want %vs", schedule.IntervalSeconds, tt.interval)
+			}
+			if schedule.Secondary != nil {
+				t.Errorf("single-line site has a secondary schedule %+v", schedule.Secondary)
+			}
+			primary := schedule.Primary
+			if primary.Reason != tt.reason {
+				t.Fatalf("reason %q, want %q", primary.Reason, tt.reason)
+			}
+			if tt.reason != "" {
+				if primary.NextCheckAt != nil || primary.SecondsRemaining != nil {
+					t.Errorf("next check %v reported with reason %s", primary.NextCheckAt, tt.reason)
+				}
+				return
+			}
+			if primary.SecondsRemaining == nil || *primary.SecondsRemaining != tt.remain {
+				t.Errorf("seconds remaining %v, want %v", primary.SecondsRemaining, tt.remain)
+			}
+		})
+	}
+}
+
+// Each line of a dual-line site reports its own circuit breaker
+func TestGetCheckScheduleDualLine(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	testutil.UseManualClock(t, now)
+	site := models.Site{ID: scheduleSiteID, Name: "Schedule", PrimaryIP: "192.0.2.70", SecondaryIP: "192.0.2.71", Enabled: true}
+	appState := newTestAppState(t, &FakeProber{}, site)
+	appState.SetNextCheck(site.ID, now.Add(20*time.Second))
+	tripBreaker(site.ID, "secondary")
+
+	schedule := GetCheckSchedule(appState, site)
+	if schedule.Primary.NextCheckAt == nil || !schedule.Primary.NextCheckAt.Equal(now.Add(20*time.Second)) {
+		t.Errorf("primary next check %v, want %v", schedule.Primary.NextCheckAt, now.Add(20*time.Second))
+	}
+	if schedule.Secondary == nil || schedule.Secondary.Reason != ScheduleReasonCircuitOpen {
+		t.Errorf("secondary schedule %+v, want circuit open", schedule.Secondary)
+	}
+}
@@ -0,0 +1,188 @@
+package ping
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// MaxSimulationDuration bounds a single outage simulation
+const MaxSimulationDuration = 24 * time.Hour
+
+var (
+	simulations   = make(map[string]models.Simulation) // site_id/line_type -> active simulation
+	simulationsMu sync.Mutex
+)
+
+// StartSimulation validates and activates a simulation, replacing any running
+// one on the same line
+func StartSimulation(siteID, target, mode string, value float64, duration time.Duration) (models.Simulation, error) {
+	switch mode {
+	case models.SimulationModeDown:
+	case models.SimulationModeLatency:
+		if value <= 0 {
+			return models.Simulation{}, fmt.Errorf("latency simulation needs a positive value (ms)")
+		}
+	case models.SimulationModeLoss:
+		if value <= 0 || value > 100 {
+			return models.Simulation{}, fmt.Errorf("loss simulation needs a value between 0 and 100 (percent)")
+		}
+	default:
+		return models.Simulation{}, fmt.Errorf("invalid mode %q, must be down, latency or loss", mode)
+	}
+	if duration <= 0 || duration > MaxSimulationDuration {
+		return models.Simulation{}, fmt.Errorf("duration must be between 0 and %s", MaxSimulationDuration)
+	}
+
+	now := time.Now()
+	simulation := models.Simulation{
+		SiteID:    siteID,
+		Target:    target,
+		Mode:      mode,
+		Value:     value,
+		StartedAt: now,
+		ExpiresAt: now.Add(duration),
+	}
+
+	simulationsMu.Lock()
+	simulations[siteID+"/"+target] = simulation
+	simulationsMu.Unlock()
+
+	logger.Default().WithComponent("ping").WithSite(siteID, "").Warn("Outage simulation started",
+		"source", models.ResultSourceSimulation,
+		"target", target,
+		"mode", mode,
+		"value", value,
+		"expires_at", simulation.ExpiresAt)
+	return simulation, nil
+}
+
+// CancelSimulation ends the simulations of a site, of one line when target is
+// set, and returns the cancelled ones
+func CancelSimulation(siteID, target string) []models.Simulation {
+	simulationsMu.Lock()
+	defer simulationsMu.Unlock()
+
+	var cancelled []models.Simulation
+	for key, simulation := range simulations {
+		if simulation.SiteID == siteID && (target == "" || simulation.Target == target) {
+			delete(simulations, key)
+			cancelled = append(cancelled, simulation)
+		}
+	}
+
+	for _, simulation := range cancelled {
+		logger.Default().WithComponent("ping").WithSite(siteID, "").Info("Outage simulation cancelled",
+			"source", models.ResultSourceSimulation, "target", simulation.Target, "mode", simulation.Mode)
+	}
+	return cancelled
+}
+
+// ActiveSimulations returns the running simulations, of one site when siteID
+// is set, ordered by site and line
+func ActiveSimulations(siteID string) []models.Simulation {
+	simulationsMu.Lock()
+	defer simulationsMu.Unlock()
+
+	expireSimulationsLocked(time.Now())
+	active := []models.Simulation{}
+	for _, simulation := range simulations {
+		if siteID == "" || simulation.SiteID == siteID {
+			active = append(active, simulation)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].SiteID != active[j].SiteID {
+			return active[i].SiteID < active[j].SiteID
+		}
+		return active[i].Target < active[j].Target
+	})
+	return active
+}
+
+// expireSimulationsLocked removes simulations past their expiry, the caller holds simulationsMu
+func expireSimulationsLocked(now time.Time) {
+	for key, simulation := range simulations {
+		if !now.Before(simulation.ExpiresAt) {
+			delete(simulations, key)
+			logger.Default().WithComponent("ping").WithSite(simulation.SiteID, "").Info("Outage simulation expired",
+				"source", models.ResultSourceSimulation, "target", simulation.Target, "mode", simulation.Mode)
+		}
+	}
+}
+
+// applySimulation overrides a probe result with the synthetic values of the
+// simulation running on its line and marks it with the simulation source
+func applySimulation(appState *config.AppState, result *models.PingResult) {
+	simulationsMu.Lock()
+	expireSimulationsLocked(result.Timestamp)
+	simulation, active := simulations[result.SiteID+"/"+result.LineType]
+	simulationsMu.Unlock()
+	if !active {
+		return
+	}
+
+	sent := result.PacketsSent
+	if sent <= 0 {
+		sent = appState.Config.Ping.PacketCount
+	}
+	result.Source = models.ResultSourceSimulation
+	result.PacketsSent = sent
+	result.PacketsDuplicates = 0
+
+	switch simulation.Mode {
+	case models.SimulationModeDown:
+		simulateFailure(result, "simulated outage")
+	case models.SimulationModeLatency:
+		latency := simulation.Value
+		result.Success = true
+		result.Error = ""
+		result.Latency = &latency
+		result.MinLatency = &latency
+		result.MaxLatency = &latency
+		jitter := 0.0
+		result.Jitter = &jitter
+		result.PacketsRecv = sent
+		loss := 0.0
+		result.PacketLoss = &loss
+	case models.SimulationModeLoss:
+		// Partial loss keeps the measured latency, a failed probe stays failed
+		received := int(math.Round(float64(sent) * (100 - simulation.Value) / 100))
+		if received == 0 {
+			simulateFailure(result, fmt.Sprintf("simulated packet loss %.0f%%", simulation.Value))
+			break
+		}
+		if !result.Success {
+			break
+		}
+		result.PacketsRecv = received
+		loss := float64(sent-received) / float64(sent) * 100
+		result.PacketLoss = &loss
+	}
+
+	logger.Default().WithPing(result.SiteID, result.IP, result.LineType).Info("Simulated result injected",
+		"source", result.Source,
+		"mode", simulation.Mode,
+		"success", result.Success,
+		"expires_at", simulation.ExpiresAt)
+}
+
+// simulateFailure turns a result into a failed check losing all packets
+func simulateFailure(result *models.PingResult, reason string) {
+	loss := 100.0
+	result.Success = false
+	result.Error = reason
+	result.Latency = nil
+	result.MinLatency = nil
+	result.MaxLatency = nil
+	result.Jitter = nil
+	result.TTL = nil
+	result.PacketsRecv = 0
+	result.PacketLoss = &loss
+}
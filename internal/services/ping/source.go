@@ -0,0 +1,123 @@
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+// errSourceAddress marks probes that were not sent because the configured
+// source address or interface is not available on this host. They are not
+// sent via the default route instead.
+var errSourceAddress = errors.New("source address unavailable")
+
+// lineSource returns the source address and interface configured for a line
+// of a site, both "" to let the kernel choose
+func lineSource(appState *config.AppState, siteID, lineType string) (ip, iface string) {
+	site, exists := appState.FindSite(siteID)
+	if !exists {
+		return "", ""
+	}
+	return site.LineSource(lineType)
+}
+
+// resolveSource returns the local address to probe target from: the source
+// address once it is assigned to an interface, or the first address of the
+// source interface in the family of target (IPv4 first when target is nil).
+// Both empty returns "" to let the kernel choose.
+func resolveSource(ip, iface string, target net.IP) (string, error) {
+	if ip == "" && iface == "" {
+		return "", nil
+	}
+	wantIPv4 := target == nil || target.To4() != nil
+
+	if ip != "" {
+		source := net.ParseIP(ip)
+		if source == nil {
+			return "", fmt.Errorf("%w: invalid IP address %q", errSourceAddress, ip)
+		}
+		if target != nil && (source.To4() != nil) != wantIPv4 {
+			return "", fmt.Errorf("%w: %s and target %s are of different address families", errSourceAddress, ip, target)
+		}
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return "", fmt.Errorf("%w: listing local addresses: %v", errSourceAddress, err)
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(source) {
+				return ip, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s is not assigned to an interface of this host", errSourceAddress, ip)
+	}
+
+	netIface, err := net.InterfaceByName(iface)
+	if err != nil {
+		return "", fmt.Errorf("%w: interface %s not found", errSourceAddress, iface)
+	}
+	if netIface.Flags&net.FlagUp == 0 {
+		return "", fmt.Errorf("%w: interface %s is down", errSourceAddress, iface)
+	}
+	addrs, err := netIface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("%w: listing addresses of interface %s: %v", errSourceAddress, iface, err)
+	}
+	var fallback string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue // Link-local IPv6 sources would need a zone
+		}
+		if (ipNet.IP.To4() != nil) == wantIPv4 {
+			return ipNet.IP.String(), nil
+		}
+		if target == nil && fallback == "" {
+			fallback = ipNet.IP.String()
+		}
+	}
+	if fallback != "" {
+		return fallback, nil
+	}
+	family := "IPv4"
+	if !wantIPv4 {
+		family = "IPv6"
+	}
+	return "", fmt.Errorf("%w: interface %s has no %s address", errSourceAddress, iface, family)
+}
+
+// CheckPingSources logs the lines of enabled sites whose source address or
+// interface is not available on this host. They are not stopped: their
+// checks fail with a configuration error until the source appears, e.g.
+// once a VPN interface is up.
+func CheckPingSources(appState *config.AppState) {
+	log := logger.Default().WithComponent("ping")
+
+	for _, site := range appState.GetSitesSnapshot() {
+		if !site.Enabled {
+			continue
+		}
+		lines := map[string]string{"primary": site.PrimaryIP, "secondary": site.SecondaryIP}
+		for _, lineType := range []string{"primary", "secondary"} {
+			ip, iface := site.LineSource(lineType)
+			if lines[lineType] == "" || (ip == "" && iface == "") {
+				continue
+			}
+			target := net.ParseIP(lines[lineType]) // nil for host names, any family then
+			err := runInNamespace(siteNamespace(appState, site.ID), func() error {
+				_, err := resolveSource(ip, iface, target)
+				return err
+			})
+			if err != nil {
+				log.Error("Ping source unavailable, checks of the line fail until it is",
+					"site_id", site.ID,
+					"line_type", lineType,
+					"source_ip", ip,
+					"source_interface", iface,
+					"error", err)
+			}
+		}
+	}
+}
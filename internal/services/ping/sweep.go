@@ -0,0 +1,131 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/jobs"
+)
+
+// sizeSweepPurgeInterval is how often expired size sweep results are removed
+const sizeSweepPurgeInterval = time.Hour
+
+// SizeSweepSummary is the latest size sweep of a site line
+type SizeSweepSummary struct {
+	Timestamp         time.Time                `json:"timestamp"`
+	Results           []models.SizeSweepResult `json:"results"`             // One per size, in size_sweep order
+	LargestIntactSize int                      `json:"largest_intact_size"` // Largest size all of whose replies came back complete, 0 for none
+	FailingSizes      []int                    `json:"failing_sizes"`       // Sizes with lost or corrupted replies
+}
+
+// runSizeSweep probes a line once with each payload size of the site's
+// size_sweep, verifying the size of every reply, and stores the results.
+// The sizes are probed one after another within the slot of the check, the
+// results share the time the sweep started.
+func runSizeSweep(ctx context.Context, appState *config.AppState, site models.Site, ip, lineType string) {
+	log := logger.Default().WithPing(site.ID, ip, lineType)
+
+	started := clock.Default().Now()
+	results := make([]models.SizeSweepResult, 0, len(site.SizeSweep))
+	for _, size := range site.SizeSweep {
+		if ctx.Err() != nil {
+			return // Shutting down, an incomplete sweep is not stored
+		}
+
+		opts := probeOptions(appState, site.ID, lineType)
+		opts.Size = size
+		opts.VerifySize = true
+
+		result := models.SizeSweepResult{
+			Timestamp: started,
+			SiteID:    site.ID,
+			Target:    lineType,
+			Size:      size,
+		}
+		stats, err := proberOf(appState).Probe(ctx, ip, opts)
+		switch {
+		case err != nil && (errors.Is(err, errCreatePinger) || errors.Is(err, errNetworkNamespace) || errors.Is(err, errSourceAddress)):
+			result.Error = err.Error()
+		case err != nil:
+			result.Error = fmt.Sprintf("ping failed: %v", err)
+		case stats.PacketsRecv == 0:
+			result.PacketsSent = stats.PacketsSent
+			result.Error = "no packets received"
+		default:
+			result.PacketsSent = stats.PacketsSent
+			result.PacketsRecv = stats.PacketsRecv
+			result.PacketsCorrupted = stats.PacketsCorrupted
+			latencyMs := float64(stats.AvgRtt.Nanoseconds()) / 1000000.0
+			result.Latency = &latencyMs
+		}
+		if !result.Intact() {
+			log.Debug("Size sweep: replies lost or corrupted",
+				"size", size,
+				"packets_sent", result.PacketsSent,
+				"packets_recv", result.PacketsRecv,
+				"packets_corrupted", result.PacketsCorrupted,
+				"error", result.Error)
+		}
+		results = append(results, result)
+	}
+
+	if err := appState.Storage.AddSizeSweep(results); err != nil {
+		log.Error("Failed to store size sweep", "error", err)
+	}
+}
+
+// SummarizeSizeSweeps returns the latest sweep of each line among results,
+// keyed by line type
+func SummarizeSizeSweeps(results []models.SizeSweepResult) map[string]SizeSweepSummary {
+	// The results of a sweep share its timestamp
+	latest := make(map[string][]models.SizeSweepResult)
+	for _, result := range results {
+		sweep := latest[result.Target]
+		if len(sweep) > 0 && result.Timestamp.After(sweep[0].Timestamp) {
+			sweep = nil // A later sweep of the line
+		}
+		if len(sweep) == 0 || result.Timestamp.Equal(sweep[0].Timestamp) {
+			latest[result.Target] = append(sweep, result)
+		}
+	}
+
+	summaries := make(map[string]SizeSweepSummary, len(latest))
+	for lineType, sweep := range latest {
+		summary := SizeSweepSummary{Timestamp: sweep[0].Timestamp, Results: sweep, FailingSizes: []int{}}
+		for _, result := range sweep {
+			if !result.Intact() {
+				summary.FailingSizes = append(summary.FailingSizes, result.Size)
+			} else if result.Size > summary.LargestIntactSize {
+				summary.LargestIntactSize = result.Size
+			}
+		}
+		summaries[lineType] = summary
+	}
+	return summaries
+}
+
+// RegisterSizeSweepPurge registers the removal of size sweep results older
+// than ping.size_sweep_retention_days
+func RegisterSizeSweepPurge(appState *config.AppState) error {
+	return jobs.Register(jobs.Job{
+		Name:     "size-sweep-purge",
+		Interval: sizeSweepPurgeInterval,
+		Run: func(ctx context.Context) error {
+			retention := time.Duration(appState.Config.Ping.SizeSweepRetentionDays) * 24 * time.Hour
+			purged, err := appState.Storage.PurgeSizeSweeps(clock.Default().Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			if purged > 0 {
+				logger.Default().WithComponent("ping").Debug("Expired size sweep results purged", "count", purged)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,73 @@
+package ping
+
+import (
+	"sync"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// DefaultTTLChangeThreshold is the TTL shift treated as a route change when not configured
+const DefaultTTLChangeThreshold = 2
+
+var (
+	lastTTLs   = make(map[string]int) // site_id/line_type -> last observed TTL
+	lastTTLsMu sync.Mutex
+)
+
+// checkTTLChange compares the reply TTL with the previous one for the same line
+// and reports shifts beyond the configured threshold as possible reroutes
+func checkTTLChange(appState *config.AppState, result models.PingResult, siteName string) {
+	if result.TTL == nil {
+		return
+	}
+	ttl := *result.TTL
+
+	config.PingTTLGauge.WithLabelValues(result.SiteID, result.LineType).Set(float64(ttl))
+
+	key := result.SiteID + "/" + result.LineType
+	lastTTLsMu.Lock()
+	previous, seen := lastTTLs[key]
+	lastTTLs[key] = ttl
+	lastTTLsMu.Unlock()
+
+	if !seen {
+		return
+	}
+
+	if IsTTLChange(previous, ttl, appState.Config.Ping.TTLChangeThreshold) {
+		config.PingTTLChangesTotal.WithLabelValues(result.SiteID, result.LineType).Inc()
+
+		log := logger.Default().WithComponent("ping").WithSite(result.SiteID, siteName)
+		log.Warn("TTL change detected, path may have been rerouted",
+			"target", result.LineType,
+			"ip", result.IP,
+			"previous_ttl", previous,
+			"ttl", ttl)
+	}
+}
+
+// IsTTLChange reports whether two TTL observations differ by at least threshold hops
+func IsTTLChange(previous, current, threshold int) bool {
+	if threshold <= 0 {
+		threshold = DefaultTTLChangeThreshold
+	}
+	diff := current - previous
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff >= threshold
+}
+
+// modalTTL returns the most common reply TTL of a check, on a tie the one seen
+// last, and 0 without replies
+func modalTTL(counts map[int]int, last int) int {
+	modal, best := last, counts[last]
+	for ttl, count := range counts {
+		if count > best {
+			modal, best = ttl, count
+		}
+	}
+	return modal
+}
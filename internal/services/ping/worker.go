@@ -2,20 +2,40 @@ package ping
 
 import (
 	"context"
-	"time"
+	"sync"
 
+	"sitewatch/internal/clock"
 	"sitewatch/internal/config"
 	"sitewatch/internal/logger"
 	"sitewatch/internal/models"
 )
 
+var (
+	workersCtx   context.Context // Context of the running workers, used for sites added at runtime
+	workersCtxMu sync.Mutex
+)
+
 // StartPingWorkers starts ping workers for all enabled sites
 func StartPingWorkers(ctx context.Context, appState *config.AppState) {
 	log := logger.Default().WithComponent("ping-workers")
 	log.Info("Starting ping workers")
 	
-	// Start result processor
-	go ProcessResults(ctx, appState)
+	workersCtxMu.Lock()
+	workersCtx = ctx
+	workersCtxMu.Unlock()
+	
+	GetGlobalCircuitBreakerManager().SetTripRetention(appState.Config.Ping.CircuitBreakerTripRetention)
+	
+	// Start result processor and the watchdog supervising it
+	markConsumed()
+	startResultProcessor(ctx, appState)
+	StartWatchdog(ctx, appState)
+	
+	// In ingest-only mode results arrive through the API, no probes are started
+	if !appState.Config.IsPingEnabled() {
+		log.Info("Active probing disabled, skipping ping workers")
+		return
+	}
 	
 	// Start ping workers for each site
 	enabledCount := 0
@@ -25,38 +45,62 @@ func StartPingWorkers(ctx context.Context, appState *config.AppState) {
 			continue
 		}
 		
-		log.Info("Starting ping worker for site", "site_id", site.ID, "site_name", site.Name)
+		log.Info("Starting ping worker for site", "site_id", site.ID, "site_name", site.Name, "priority", site.Priority)
 		go PingWorker(ctx, appState, site)
 		enabledCount++
 	}
 	
-	log.Info("All ping workers started", "enabled_sites", enabledCount, "total_sites", len(appState.Sites))
+	log.Info("All ping workers started", "enabled_sites", enabledCount, "total_sites", len(appState.Sites),
+		"max_concurrent", appState.Config.Ping.MaxConcurrent)
+}
+
+// StartSiteWorker starts the ping worker of a site added at runtime. Nothing
+// is started for disabled sites, in ingest-only mode or before StartPingWorkers.
+func StartSiteWorker(appState *config.AppState, site models.Site) {
+	workersCtxMu.Lock()
+	ctx := workersCtx
+	workersCtxMu.Unlock()
+	
+	if ctx == nil || !site.Enabled || !appState.Config.IsPingEnabled() {
+		return
+	}
+	logger.Default().WithComponent("ping-workers").Info("Starting ping worker for site", "site_id", site.ID, "site_name", site.Name, "priority", site.Priority)
+	go PingWorker(ctx, appState, site)
 }
 
 // PingWorker handles pinging for a specific site
 func PingWorker(ctx context.Context, appState *config.AppState, site models.Site) {
 	log := logger.Default().WithSite(site.ID, site.Name)
 	
-	interval := time.Duration(site.Interval) * time.Second
-	if interval == 0 {
-		interval = appState.Config.Ping.DefaultInterval
-	}
+	clk := clock.Default()
+	interval := IntervalAt(appState, site, clk.Now())
 	
 	log.Debug("Ping worker initialized", "interval", interval.String())
 	
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	// A timer rearmed on every tick, so interval_schedule windows take effect
+	// from the first tick inside them
+	timer := clk.NewTimer(interval)
+	defer timer.Stop()
+	appState.SetNextCheck(site.ID, clk.Now().Add(interval))
 	
 	// Immediate first ping
-	PingSite(appState, site)
+	recordHeartbeat(site.ID)
+	PingSite(ctx, appState, site)
 	
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info("Stopping ping worker")
 			return
-		case <-ticker.C:
-			PingSite(appState, site)
+		case now := <-timer.C():
+			if next := IntervalAt(appState, site, now); next != interval {
+				log.Debug("Check interval changed by schedule", "interval", next.String(), "previous", interval.String())
+				interval = next
+			}
+			timer.Reset(interval)
+			appState.SetNextCheck(site.ID, now.Add(interval))
+			recordHeartbeat(site.ID)
+			PingSite(ctx, appState, site)
 		}
 	}
 }
@@ -72,8 +116,27 @@ func ProcessResults(ctx context.Context, appState *config.AppState) {
 			log.Info("Stopping result processor")
 			return
 		case result := <-appState.ResultChan:
+			if ctx.Err() != nil {
+				// Replaced or stopped while taking the result, hand it back
+				// to the processor replacing this one
+				requeueResult(appState, result)
+				log.Info("Stopping result processor")
+				return
+			}
+			markConsumed()
 			log.Debug("Processing ping result", "site_id", result.SiteID, "line_type", result.LineType, "success", result.Success)
 			HandlePingResult(appState, result)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// requeueResult returns a result to the channel, counting it as dropped when
+// the channel filled up in the meantime
+func requeueResult(appState *config.AppState, result models.PingResult) {
+	select {
+	case appState.ResultChan <- result:
+	default:
+		droppedResults.Add(1)
+		config.ResultsDroppedTotal.Inc()
+	}
+}
@@ -0,0 +1,107 @@
+package ping
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+func TestPingWorkerInterval(t *testing.T) {
+	start := time.Date(2024, 1, 15, 9, 59, 40, 0, time.UTC)
+	clk := testutil.UseManualClock(t, start)
+
+	site := models.Site{ID: "site-worker", Name: "Worker", PrimaryIP: "192.0.2.40", Interval: 10, Enabled: true,
+		// From 10:00, i.e. 20s after the start, the site is checked every 5s
+		IntervalSchedule: []models.IntervalWindow{{OfflineWindow: models.OfflineWindow{Start: "10:00", End: "11:00", Timezone: "UTC"}, Interval: 5}},
+	}
+	prober := &FakeProber{}
+	appState := newTestAppState(t, prober, site)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go PingWorker(ctx, appState, site)
+
+	// expectCheck waits until the worker checked n times and armed the
+	// timer of the next check at next
+	expectCheck := func(n int, next time.Time) {
+		t.Helper()
+		waitFor(t, "next check scheduled", func() bool {
+			scheduled, ok := appState.GetNextCheck(site.ID)
+			return ok && scheduled.Equal(next) && len(prober.Probes()) == n
+		})
+	}
+
+	expectCheck(1, start.Add(10*time.Second)) // Immediate first check
+
+	clk.Advance(9 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if got := len(prober.Probes()); got != 1 {
+		t.Fatalf("checked %d times before the interval passed, want 1", got)
+	}
+
+	clk.Advance(time.Second)
+	expectCheck(2, start.Add(20*time.Second))
+
+	// The schedule window shortens the interval from the tick inside it
+	clk.Advance(10 * time.Second)
+	expectCheck(3, start.Add(25*time.Second))
+	schedule := GetCheckSchedule(appState, site)
+	if schedule.IntervalSeconds != 5 || schedule.Primary.SecondsRemaining == nil || *schedule.Primary.SecondsRemaining != 5 {
+		t.Errorf("schedule inside the window: interval %vs, remaining %v, want 5s and 5", schedule.IntervalSeconds, schedule.Primary.SecondsRemaining)
+	}
+	clk.Advance(5 * time.Second)
+	expectCheck(4, start.Add(30*time.Second))
+
+	cancel()
+}
+
+// waitForGoroutines waits up to a second for the number of goroutines to
+// drop back to baseline
+func waitForGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after shutdown, %d before start", runtime.NumGoroutine(), baseline)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStartPingWorkersStopsOnCancel(t *testing.T) {
+	sites := []models.Site{
+		{ID: "site-stop-1", Name: "Stop 1", PrimaryIP: "192.0.2.51", Enabled: true},
+		{ID: "site-stop-2", Name: "Stop 2", PrimaryIP: "192.0.2.52", SecondaryIP: "192.0.2.53", Enabled: true},
+		{ID: "site-stop-3", Name: "Stop 3", PrimaryIP: "192.0.2.54"},
+	}
+	prober := &FakeProber{}
+	appState := newTestAppState(t, prober, sites...)
+	appState.Config.Ping.CircuitBreakerTripRetention = GetGlobalCircuitBreakerManager().TripRetention()
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	StartPingWorkers(ctx, appState)
+
+	// Both lines of the second site and the first site are checked at start
+	waitFor(t, "first checks processed", func() bool {
+		logs, _ := appState.Storage.GetAllLogs()
+		return len(prober.Probes()) == 3 && len(logs) == 3
+	})
+
+	cancel()
+	processorMu.Lock()
+	done := processorDone
+	processorMu.Unlock()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("result processor still running after shutdown")
+	}
+	waitForGoroutines(t, baseline)
+}
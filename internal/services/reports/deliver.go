@@ -0,0 +1,165 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// httpClient sends the Slack and webhook requests
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// deliver sends the summary through every channel of the schedule and
+// returns the names of the channels that succeeded and failed
+func deliver(ctx context.Context, app *config.AppState, schedule models.ReportSchedule, summary models.AvailabilitySummary) (delivered, failed []string) {
+	log := logger.Default().WithComponent("reports")
+
+	send := func(channel string, fn func() error) {
+		if err := fn(); err != nil {
+			log.Error("Report delivery failed", "schedule", schedule.Name, "channel", channel, "error", err)
+			failed = append(failed, channel)
+			return
+		}
+		delivered = append(delivered, channel)
+	}
+
+	if len(schedule.Email) > 0 {
+		send("email", func() error { return sendEmail(app, schedule, summary) })
+	}
+	if schedule.SlackWebhookURL != "" {
+		send("slack", func() error {
+			return postJSON(ctx, schedule.SlackWebhookURL, map[string]string{"text": RenderText(summary)})
+		})
+	}
+	if schedule.WebhookURL != "" {
+		send("webhook", func() error { return postJSON(ctx, schedule.WebhookURL, summary) })
+	}
+	return delivered, failed
+}
+
+// postJSON posts body as JSON and expects a 2xx response
+func postJSON(ctx context.Context, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// sendEmail mails the text summary with the HTML or CSV table attached
+func sendEmail(app *config.AppState, schedule models.ReportSchedule, summary models.AvailabilitySummary) error {
+	cfg := app.Config.Notify.Email
+	var (
+		attachment  []byte
+		filename    string
+		contentType string
+		err         error
+	)
+	if schedule.Attachment == "csv" {
+		attachment, err = RenderCSV(summary)
+		filename, contentType = "summary.csv", "text/csv; charset=utf-8"
+	} else {
+		attachment, err = RenderHTML(summary)
+		filename, contentType = "summary.html", "text/html; charset=utf-8"
+	}
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	textPart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return fmt.Errorf("creating message: %w", err)
+	}
+	qp := quotedprintable.NewWriter(textPart)
+	if _, err := io.WriteString(qp, RenderText(summary)); err != nil {
+		return fmt.Errorf("creating message: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("creating message: %w", err)
+	}
+
+	filePart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+	})
+	if err != nil {
+		return fmt.Errorf("creating message: %w", err)
+	}
+	if err := writeBase64(filePart, attachment); err != nil {
+		return fmt.Errorf("creating message: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("creating message: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(schedule.Email, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", Subject(summary)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+	}
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))
+	if err := smtp.SendMail(addr, auth, cfg.From, schedule.Email, msg.Bytes()); err != nil {
+		return fmt.Errorf("sending mail via %s: %w", addr, err)
+	}
+	return nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
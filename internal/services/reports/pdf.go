@@ -0,0 +1,281 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"sitewatch/internal/config"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/stats"
+)
+
+// MaxPDFMonths is the longest period of a monthly PDF report
+const MaxPDFMonths = 12
+
+// maxPDFIncidents caps the incident rows per site and month, the rest is counted
+const maxPDFIncidents = 50
+
+// ErrPDFRangeTooLong is returned for PDF reports spanning more than MaxPDFMonths
+var ErrPDFRangeTooLong = fmt.Errorf("period is longer than %d months", MaxPDFMonths)
+
+// pdfTimeLayout formats incident times in PDF reports
+const pdfTimeLayout = "2006-01-02 15:04"
+
+// MonthlyPDF is a rendered monthly PDF report
+type MonthlyPDF struct {
+	pdf *fpdf.Fpdf
+}
+
+// Write serializes the document to w
+func (m *MonthlyPDF) Write(w io.Writer) error {
+	return m.pdf.Output(w)
+}
+
+// pdfWriter renders report sections into a document
+type pdfWriter struct {
+	pdf    *fpdf.Fpdf
+	tr     func(string) string // UTF-8 to the code page of the core fonts
+	locale string
+	width  float64 // Usable page width
+	charts int
+}
+
+// BuildMonthlyPDF renders the SLA reports of all enabled sites for the months
+// (UTC) from first to last, inclusive. The logs are read and rendered one
+// month at a time, so only a single month of logs is held in memory. The
+// generation stops with the error of ctx once it is done.
+func BuildMonthlyPDF(ctx context.Context, app *config.AppState, first, last time.Time, locale string) (*MonthlyPDF, error) {
+	months := (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+	if months < 1 {
+		return nil, errors.New("period ends before it starts")
+	}
+	if months > MaxPDFMonths {
+		return nil, ErrPDFRangeTooLong
+	}
+
+	var sites []models.Site
+	for _, site := range app.GetSitesSnapshot() {
+		if site.Enabled {
+			sites = append(sites, site)
+		}
+	}
+
+	layout := app.Config.Reports.PDF
+	title := layout.Title
+	if title == "" {
+		title = i18n.T(locale, "report.monthly_title")
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("SiteWatch "+config.Version, true)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.AliasNbPages("")
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	w := &pdfWriter{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor(""), locale: locale, width: pageWidth - left - right}
+
+	logo := w.registerLogo(layout.LogoPath)
+	pdf.SetHeaderFuncMode(func() {
+		if logo != "" {
+			pdf.ImageOptions(logo, left, 8, 0, 12, false, fpdf.ImageOptions{}, 0, "")
+		}
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.SetY(10)
+		pdf.CellFormat(0, 8, w.tr(title), "", 1, "R", false, 0, "")
+		pdf.Ln(6)
+	}, true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-14)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.CellFormat(w.width*0.75, 6, w.tr(layout.Footer), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, w.tr(i18n.T(locale, "report.page", pdf.PageNo(), "{nb}")), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		monthly, err := stats.GenerateMonthlyReport(app, sites, month, locale)
+		if err != nil {
+			return nil, err
+		}
+
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.CellFormat(0, 9, w.tr(fmt.Sprintf("%s %s", i18n.T(locale, "report.period"), monthly.Month)), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, w.tr(fmt.Sprintf("%s - %s UTC", monthly.PeriodStart.Format(pdfTimeLayout), monthly.PeriodEnd.Format(pdfTimeLayout))), "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 5, w.tr(i18n.T(locale, "report.generated", monthly.GeneratedAt.Format(pdfTimeLayout+" UTC"))), "", 1, "L", false, 0, "")
+		pdf.Ln(4)
+
+		for _, site := range monthly.Sites {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := w.site(site); err != nil {
+				return nil, err
+			}
+		}
+		if err := pdf.Error(); err != nil {
+			return nil, fmt.Errorf("rendering PDF: %w", err)
+		}
+	}
+	return &MonthlyPDF{pdf: pdf}, nil
+}
+
+// registerLogo adds the configured logo to the document and returns its
+// name, "" when unset or unreadable
+func (w *pdfWriter) registerLogo(path string) string {
+	if path == "" {
+		return ""
+	}
+	log := logger.Default().WithComponent("reports")
+	imageType := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if imageType != "png" && imageType != "jpg" && imageType != "jpeg" {
+		log.Warn("Unsupported PDF logo format, expected PNG or JPEG", "path", path)
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Warn("Failed to read PDF logo", "path", path, "error", err)
+		return ""
+	}
+	w.pdf.RegisterImageOptionsReader("logo", fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if w.pdf.Err() {
+		log.Warn("Failed to load PDF logo", "path", path, "error", w.pdf.Error())
+		w.pdf.ClearError()
+		return ""
+	}
+	return "logo"
+}
+
+// site renders the report of one site: uptime against the SLA, latency,
+// daily uptime chart and incidents
+func (w *pdfWriter) site(site models.MonthlyReportSite) error {
+	pdf, report := w.pdf, site.Report
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY() > pageHeight-110 {
+		pdf.AddPage()
+	}
+
+	pdf.SetFont("Helvetica", "B", 12)
+	heading := fmt.Sprintf("%s (%s)", report.Site.Name, report.Site.ID)
+	if report.Site.Location != "" {
+		heading += " - " + report.Site.Location
+	}
+	pdf.CellFormat(0, 7, w.tr(heading), "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	t := func(key string) string { return i18n.T(w.locale, key) }
+	w.table(
+		[]string{t("report.line"), t("report.target"), t("report.actual"), t("report.checks"), t("report.downtime"), t("report.verdict")},
+		[]float64{0.2, 0.12, 0.12, 0.12, 0.28, 0.16},
+		func(row func(cells ...string)) {
+			for _, line := range report.Lines {
+				verdict := t("report.pass")
+				if !line.Met {
+					verdict = t("report.fail")
+				}
+				row(t("line."+line.Line), fmt.Sprintf("%.2f%%", line.TargetUptime), fmt.Sprintf("%.3f%%", line.ActualUptime),
+					fmt.Sprintf("%d", line.TotalChecks), line.Downtime, verdict)
+			}
+		})
+
+	ms := func(v float64) string { return fmt.Sprintf("%.2f ms", v) }
+	w.table(
+		[]string{t("report.line"), t("report.latency_mean"), t("report.latency_p50"), t("report.latency_p95"),
+			t("report.latency_p99"), t("report.latency_min"), t("report.latency_max"), t("report.jitter")},
+		[]float64{0.16, 0.12, 0.12, 0.12, 0.12, 0.12, 0.12, 0.12},
+		func(row func(cells ...string)) {
+			for _, latency := range report.Latency {
+				row(t("line."+latency.Line), ms(latency.Mean), ms(latency.P50), ms(latency.P95), ms(latency.P99),
+					ms(latency.Min), ms(latency.Max), ms(latency.Jitter))
+			}
+		})
+
+	if err := w.uptimeChart(site.DailyUptime, report.Site.GetCombinedSLAUptime()); err != nil {
+		return err
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 6, w.tr(t("report.incidents")), "", 1, "L", false, 0, "")
+	if len(report.Incidents) == 0 {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, w.tr(t("report.no_incidents")), "", 1, "L", false, 0, "")
+	} else {
+		shown := report.Incidents[:min(len(report.Incidents), maxPDFIncidents)]
+		w.table(
+			[]string{t("report.line"), t("report.start"), t("report.end"), t("report.duration")},
+			[]float64{0.2, 0.25, 0.25, 0.3},
+			func(row func(cells ...string)) {
+				for _, incident := range shown {
+					duration := incident.Duration
+					if incident.Ongoing {
+						duration += " (" + t("report.ongoing") + ")"
+					}
+					row(t("line."+incident.Target), incident.Start.Format(pdfTimeLayout), incident.End.Format(pdfTimeLayout), duration)
+				}
+			})
+		if hidden := len(report.Incidents) - len(shown); hidden > 0 {
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.CellFormat(0, 5, w.tr(i18n.T(w.locale, "report.more_incidents", hidden)), "", 1, "L", false, 0, "")
+		}
+	}
+	pdf.Ln(6)
+	return nil
+}
+
+// table renders a header row and the rows added by fill, widths are shares
+// of the page width
+func (w *pdfWriter) table(header []string, widths []float64, fill func(row func(cells ...string))) {
+	pdf := w.pdf
+	cells := func(style string, background bool, values []string) {
+		pdf.SetFont("Helvetica", style, 8)
+		for i, value := range values {
+			pdf.CellFormat(widths[i]*w.width, 5.5, w.tr(value), "1", 0, "L", background, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetFillColor(243, 244, 246)
+	cells("B", true, header)
+	fill(func(values ...string) { cells("", false, values) })
+	pdf.Ln(3)
+}
+
+// uptimeChart embeds the daily uptime chart with its caption
+func (w *pdfWriter) uptimeChart(daily models.ChartSeries, target float64) error {
+	if len(daily) == 0 {
+		return nil
+	}
+	floor := uptimeChartFloor(daily, target)
+	chart, err := renderUptimeChart(daily, target, floor)
+	if err != nil {
+		return fmt.Errorf("rendering uptime chart: %w", err)
+	}
+
+	w.charts++
+	name := fmt.Sprintf("uptime-%d", w.charts)
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	w.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(chart))
+
+	height := w.width * uptimeChartHeight / uptimeChartWidth
+	w.pdf.SetFont("Helvetica", "B", 10)
+	w.pdf.CellFormat(0, 6, w.tr(i18n.T(w.locale, "report.daily_uptime", math.Round(floor), target)), "", 1, "L", false, 0, "")
+	w.pdf.ImageOptions(name, -1, -1, w.width, height, true, options, 0, "")
+	w.pdf.Ln(3)
+	return nil
+}
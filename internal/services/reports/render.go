@@ -0,0 +1,118 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"sitewatch/internal/models"
+)
+
+// periodLayout formats the period bounds in subjects, messages and tables
+const periodLayout = "2006-01-02 15:04"
+
+// Subject returns the title of a summary, used as email subject
+func Subject(summary models.AvailabilitySummary) string {
+	return fmt.Sprintf("SiteWatch availability summary %s: %s - %s", summary.Name,
+		summary.PeriodStart.Format("2006-01-02"), summary.PeriodEnd.Format("2006-01-02"))
+}
+
+// RenderText returns the summary as plain text, the email body and Slack message
+func RenderText(summary models.AvailabilitySummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", Subject(summary))
+	fmt.Fprintf(&b, "Period: %s - %s (%s)\n", summary.PeriodStart.Format(periodLayout),
+		summary.PeriodEnd.Format(periodLayout), summary.PeriodEnd.Location())
+	fmt.Fprintf(&b, "Mean uptime %.2f%%, %d/%d sites met their SLA, %d incidents, %d checks\n\n",
+		summary.MeanUptime, summary.SitesMetSLA, len(summary.Sites), summary.Incidents, summary.TotalChecks)
+
+	for _, site := range summary.Sites {
+		if site.TotalChecks == 0 {
+			fmt.Fprintf(&b, "NO DATA  %s (%s): no checks\n", site.SiteName, site.SiteID)
+			continue
+		}
+		state := "OK     "
+		if !site.Met {
+			state = "MISSED "
+		}
+		fmt.Fprintf(&b, "%s  %s (%s): %.2f%% of %.2f%% target, %.2f ms, %d incidents\n",
+			state, site.SiteName, site.SiteID, site.Uptime, site.TargetUptime, site.MeanLatency, site.Incidents)
+	}
+	return b.String()
+}
+
+// RenderCSV returns one row per site
+func RenderCSV(summary models.AvailabilitySummary) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{"site_id", "site_name", "uptime", "target_uptime", "met", "mean_latency_ms", "total_checks", "incidents"}}
+	for _, site := range summary.Sites {
+		rows = append(rows, []string{
+			site.SiteID,
+			site.SiteName,
+			strconv.FormatFloat(site.Uptime, 'f', 2, 64),
+			strconv.FormatFloat(site.TargetUptime, 'f', 2, 64),
+			strconv.FormatBool(site.Met),
+			strconv.FormatFloat(site.MeanLatency, 'f', 2, 64),
+			strconv.Itoa(site.TotalChecks),
+			strconv.Itoa(site.Incidents),
+		})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("writing csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+var summaryTemplate = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"period": func(summary models.AvailabilitySummary) string {
+		return fmt.Sprintf("%s - %s (%s)", summary.PeriodStart.Format(periodLayout),
+			summary.PeriodEnd.Format(periodLayout), summary.PeriodEnd.Location())
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; color: #1f2937; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d1d5db; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.missed { color: #b91c1c; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{period .Summary}}</p>
+<p>Mean uptime {{printf "%.2f" .Summary.MeanUptime}}%, {{.Summary.SitesMetSLA}}/{{len .Summary.Sites}} sites met their SLA, {{.Summary.Incidents}} incidents, {{.Summary.TotalChecks}} checks</p>
+<table>
+<tr><th>Site</th><th>Uptime</th><th>Target</th><th>Mean latency</th><th>Checks</th><th>Incidents</th></tr>
+{{range .Summary.Sites}}<tr{{if not .Met}} class="missed"{{end}}>
+<td>{{.SiteName}} ({{.SiteID}})</td>
+{{if .TotalChecks}}<td>{{printf "%.2f" .Uptime}}%</td>{{else}}<td>-</td>{{end}}
+<td>{{printf "%.2f" .TargetUptime}}%</td>
+<td>{{printf "%.2f" .MeanLatency}} ms</td>
+<td>{{.TotalChecks}}</td>
+<td>{{.Incidents}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// RenderHTML returns the summary as an HTML table
+func RenderHTML(summary models.AvailabilitySummary) ([]byte, error) {
+	var buf bytes.Buffer
+	data := struct {
+		Title   string
+		Summary models.AvailabilitySummary
+	}{Subject(summary), summary}
+	if err := summaryTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering html: %w", err)
+	}
+	return buf.Bytes(), nil
+}
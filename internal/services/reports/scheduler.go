@@ -0,0 +1,115 @@
+package reports
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/stats"
+)
+
+// checkInterval controls how often the schedules are checked for a due run
+const checkInterval = time.Minute
+
+// StartReportScheduler checks the report schedules every minute and sends the
+// summaries that are due
+func StartReportScheduler(ctx context.Context, app *config.AppState) {
+	log := logger.Default().WithComponent("reports")
+	location, err := app.Config.ReportsLocation()
+	if err != nil {
+		log.Error("Invalid reports timezone, report scheduler not started", "timezone", app.Config.Reports.Timezone, "error", err)
+		return
+	}
+	log.Info("Starting report scheduler", "schedules", len(app.Config.Reports.Schedules), "timezone", location.String())
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			RunDueReports(ctx, app, time.Now().In(location))
+			select {
+			case <-ctx.Done():
+				log.Info("Stopping report scheduler")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunDueReports runs every schedule whose last occurrence up to now has not
+// been run yet. The last run is persisted, so a restart neither repeats a
+// sent report nor skips one that fell into the downtime; of several missed
+// occurrences only the latest is sent. A schedule seen for the first time
+// starts with its next occurrence instead of reporting the past.
+func RunDueReports(ctx context.Context, app *config.AppState, now time.Time) {
+	log := logger.Default().WithComponent("reports")
+
+	for _, schedule := range app.Config.Reports.Schedules {
+		due := schedule.LastOccurrence(now)
+		lastRun, err := app.Storage.GetReportLastRun(schedule.Name)
+		if err != nil {
+			log.Error("Failed to read last report run", "schedule", schedule.Name, "error", err)
+			continue
+		}
+
+		if lastRun.IsZero() {
+			if err := app.Storage.SetReportLastRun(schedule.Name, due); err != nil {
+				log.Error("Failed to record report schedule", "schedule", schedule.Name, "error", err)
+			}
+			continue
+		}
+		if due.After(lastRun) {
+			runReport(ctx, app, schedule, due)
+		}
+	}
+}
+
+// runReport generates the summary of the period ending at occurrence, delivers
+// it and records the run
+func runReport(ctx context.Context, app *config.AppState, schedule models.ReportSchedule, occurrence time.Time) {
+	log := logger.Default().WithComponent("reports")
+	start := schedule.PeriodStart(occurrence)
+
+	// Not recorded, so the run is retried on the next check
+	summary, err := stats.GenerateAvailabilitySummary(app, schedule.Name, schedule.Sites, start, occurrence)
+	if err != nil {
+		config.ReportsGeneratedTotal.WithLabelValues(schedule.Name, "error").Inc()
+		log.Error("Report generation failed", "schedule", schedule.Name, "error", err)
+		return
+	}
+
+	delivered, failed := deliver(ctx, app, schedule, summary)
+
+	// Recorded even when a channel failed, so the others do not get the report twice
+	if err := app.Storage.SetReportLastRun(schedule.Name, occurrence); err != nil {
+		log.Error("Failed to record report run, it may be sent again", "schedule", schedule.Name, "error", err)
+	}
+
+	status := "success"
+	switch {
+	case len(delivered) == 0:
+		status = "error"
+	case len(failed) > 0:
+		status = "partial"
+	}
+	config.ReportsGeneratedTotal.WithLabelValues(schedule.Name, status).Inc()
+
+	attrs := []any{
+		"schedule", schedule.Name,
+		"status", status,
+		"period_start", start,
+		"period_end", occurrence,
+		"sites", len(summary.Sites),
+		"delivered", strings.Join(delivered, ","),
+	}
+	if len(failed) > 0 {
+		log.Warn("Scheduled report sent with failures", append(attrs, "failed", strings.Join(failed, ","))...)
+		return
+	}
+	log.Info("Scheduled report sent", attrs...)
+}
@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"sitewatch/internal/models"
+)
+
+// Size of the daily uptime chart in pixels
+const (
+	uptimeChartWidth  = 900
+	uptimeChartHeight = 180
+)
+
+var (
+	chartMet     = color.RGBA{34, 197, 94, 255}   // Days at or above the target
+	chartMissed  = color.RGBA{239, 68, 68, 255}   // Days below the target
+	chartNoData  = color.RGBA{209, 213, 219, 255} // Days without checks
+	chartTarget  = color.RGBA{37, 99, 235, 255}
+	chartGridTop = color.RGBA{229, 231, 235, 255}
+)
+
+// uptimeChartFloor returns the lower bound of the uptime axis: 90% unless a
+// day or the target is lower, then the next 10% below it
+func uptimeChartFloor(daily models.ChartSeries, target float64) float64 {
+	low := math.Min(90, target)
+	for _, uptime := range daily {
+		if !math.IsNaN(uptime) && uptime < low {
+			low = uptime
+		}
+	}
+	return math.Max(0, math.Floor(low/10)*10)
+}
+
+// renderUptimeChart draws the daily uptime as bars against the SLA target
+// line and returns it as PNG. The axis runs from floor to 100%.
+func renderUptimeChart(daily models.ChartSeries, target, floor float64) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, uptimeChartWidth, uptimeChartHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	y := func(uptime float64) int {
+		share := (uptime - floor) / (100 - floor)
+		return uptimeChartHeight - 1 - int(math.Round(math.Max(0, math.Min(1, share))*float64(uptimeChartHeight-1)))
+	}
+	horizontal := func(row int, c color.Color, dashed bool) {
+		for x := 0; x < uptimeChartWidth; x++ {
+			if !dashed || (x/8)%2 == 0 {
+				img.Set(x, row, c)
+			}
+		}
+	}
+	horizontal(0, chartGridTop, false)
+	horizontal(uptimeChartHeight-1, chartGridTop, false)
+
+	if len(daily) > 0 {
+		slot := float64(uptimeChartWidth) / float64(len(daily))
+		gap := int(math.Max(1, slot*0.15))
+		for i, uptime := range daily {
+			left := int(float64(i) * slot)
+			right := int(float64(i+1)*slot) - gap
+			top, fill := y(uptime), chartMet
+			switch {
+			case math.IsNaN(uptime):
+				top, fill = uptimeChartHeight-4, chartNoData
+			case uptime < target:
+				fill = chartMissed
+			}
+			draw.Draw(img, image.Rect(left, top, right, uptimeChartHeight), &image.Uniform{fill}, image.Point{}, draw.Src)
+		}
+	}
+
+	if target > floor {
+		horizontal(y(target), chartTarget, true)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+//go:build linux
+
+package selfmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readHostCounters reads the CPU times, load averages, memory and network
+// error counters of the host from /proc
+func readHostCounters() (hostCounters, error) {
+	var counters hostCounters
+
+	stat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return counters, err
+	}
+	// cpu  user nice system idle iowait irq softirq steal guest guest_nice
+	fields := strings.Fields(strings.SplitN(string(stat), "\n", 2)[0])
+	if len(fields) < 9 || fields[0] != "cpu" {
+		return counters, fmt.Errorf("unexpected /proc/stat format")
+	}
+	for i, field := range fields[1:9] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return counters, fmt.Errorf("parsing /proc/stat: %w", err)
+		}
+		counters.cpuTotal += value
+		if i == 3 || i == 4 { // idle and iowait
+			counters.cpuIdle += value
+		}
+	}
+
+	loadavg, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return counters, err
+	}
+	if _, err := fmt.Sscanf(string(loadavg), "%f %f %f", &counters.load1, &counters.load5, &counters.load15); err != nil {
+		return counters, fmt.Errorf("parsing /proc/loadavg: %w", err)
+	}
+
+	meminfo, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return counters, err
+	}
+	for _, line := range strings.Split(string(meminfo), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			counters.memoryTotal = kb * 1024
+		case "MemAvailable:":
+			counters.memoryAvailable = kb * 1024
+		}
+	}
+
+	netdev, err := os.Open("/proc/net/dev")
+	if err != nil {
+		return counters, err
+	}
+	defer netdev.Close()
+	scanner := bufio.NewScanner(netdev)
+	for scanner.Scan() {
+		name, stats, found := strings.Cut(scanner.Text(), ":")
+		if !found || strings.TrimSpace(name) == "lo" {
+			continue
+		}
+		// Receive: bytes packets errs drop ..., transmit from the 9th field on
+		fields := strings.Fields(stats)
+		if len(fields) < 12 {
+			continue
+		}
+		for _, i := range []int{2, 3, 10, 11} {
+			value, err := strconv.ParseInt(fields[i], 10, 64)
+			if err == nil {
+				counters.networkErrors += value
+			}
+		}
+	}
+	return counters, scanner.Err()
+}
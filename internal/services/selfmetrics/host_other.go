@@ -0,0 +1,10 @@
+//go:build !linux
+
+package selfmetrics
+
+import "errors"
+
+// readHostCounters is only implemented for Linux, which exposes the counters in /proc
+func readHostCounters() (hostCounters, error) {
+	return hostCounters{}, errors.New("host metrics are only supported on Linux")
+}
@@ -0,0 +1,83 @@
+package selfmetrics
+
+import (
+	"context"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/jobs"
+)
+
+// hostCounters are the raw host figures, CPU times and network errors are
+// counters since boot
+type hostCounters struct {
+	cpuTotal, cpuIdle    uint64
+	load1, load5, load15 float64
+	memoryTotal          int64
+	memoryAvailable      int64
+	networkErrors        int64
+}
+
+// RegisterSampler registers the job that samples the load of the monitoring
+// host every self_metrics.interval and removes samples older than
+// self_metrics.retention_days
+func RegisterSampler(app *config.AppState) error {
+	if !app.Config.SelfMetrics.Enabled {
+		return nil
+	}
+
+	var previous *hostCounters
+	return jobs.Register(jobs.Job{
+		Name:     "self-metrics",
+		Interval: app.Config.SelfMetrics.Interval,
+		Run: func(ctx context.Context) error {
+			counters, err := readHostCounters()
+			if err != nil {
+				return err
+			}
+			if err := app.Storage.AddHostSample(newHostSample(time.Now(), counters, previous)); err != nil {
+				return err
+			}
+			previous = &counters
+
+			retention := time.Duration(app.Config.SelfMetrics.RetentionDays) * 24 * time.Hour
+			purged, err := app.Storage.PurgeHostSamples(time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			if purged > 0 {
+				logger.Default().WithComponent("self-metrics").Debug("Expired host samples purged", "count", purged)
+			}
+			return nil
+		},
+	})
+}
+
+// newHostSample converts the counters to a sample. CPU usage and network
+// errors are the changes since the previous counters, the first sample
+// reports the CPU average since boot and no network errors.
+func newHostSample(now time.Time, counters hostCounters, previous *hostCounters) models.HostSample {
+	sample := models.HostSample{
+		Timestamp:            now,
+		Load1:                counters.load1,
+		Load5:                counters.load5,
+		Load15:               counters.load15,
+		MemoryAvailableBytes: counters.memoryAvailable,
+	}
+	if counters.memoryTotal > 0 {
+		sample.MemoryUsedPercent = float64(counters.memoryTotal-counters.memoryAvailable) / float64(counters.memoryTotal) * 100
+	}
+
+	total, idle := counters.cpuTotal, counters.cpuIdle
+	if previous != nil {
+		total -= previous.cpuTotal
+		idle -= previous.cpuIdle
+		sample.NetworkErrors = max(counters.networkErrors-previous.networkErrors, 0)
+	}
+	if total > 0 && idle <= total {
+		sample.CPUPercent = float64(total-idle) / float64(total) * 100
+	}
+	return sample
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// LatencySampleSize is the number of latencies kept per line for percentiles.
+// Up to this many checks percentiles are exact, beyond it they are estimated
+// from a uniform random sample of this size, so statistics over long ranges
+// use constant memory.
+const LatencySampleSize = 4096
+
+// SampleStats aggregates a series of values without storing them
+type SampleStats struct {
+	Count int
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Add adds a value to the aggregate
+func (s *SampleStats) Add(v float64) {
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count++
+	s.Sum += v
+}
+
+// Mean returns the mean of the values, 0 without values
+func (s *SampleStats) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// latencySample keeps a uniform random sample of at most LatencySampleSize
+// values of a series (reservoir sampling)
+type latencySample struct {
+	values []float64
+	seen   int
+	rng    *rand.Rand // Created once the sample is full
+}
+
+// add offers a value to the sample
+func (s *latencySample) add(v float64) {
+	s.seen++
+	if len(s.values) < LatencySampleSize {
+		s.values = append(s.values, v)
+		return
+	}
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(int64(s.seen)))
+	}
+	if i := s.rng.Intn(s.seen); i < LatencySampleSize {
+		s.values[i] = v
+	}
+}
+
+// percentile returns the value below which p percent of the sampled values
+// fall, using the nearest-rank method, and 0 without values
+func (s *latencySample) percentile(p float64) float64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), s.values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
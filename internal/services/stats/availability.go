@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+)
+
+const (
+	// AvailabilityMatrixCacheTTL controls how long computed matrices are reused
+	AvailabilityMatrixCacheTTL = 5 * time.Minute
+
+	// MaxAvailabilitySlots caps the number of rows in a single matrix
+	MaxAvailabilitySlots = 2000
+)
+
+// AvailabilityMatrixColumns names the columns of each matrix row
+var AvailabilityMatrixColumns = []string{"primary_uptime_pct", "secondary_uptime_pct", "combined_uptime_pct"}
+
+// AvailabilityMatrix holds uptime percentages per time slot
+type AvailabilityMatrix struct {
+	Labels []string     `json:"labels"`
+	Rows   [][3]float64 `json:"matrix"`
+}
+
+type availabilityCacheEntry struct {
+	matrices  map[string]AvailabilityMatrix
+	expiresAt time.Time
+}
+
+var (
+	availabilityCache   = make(map[string]availabilityCacheEntry)
+	availabilityCacheMu sync.Mutex
+)
+
+// ParsePeriod parses durations like "30m", "24h" and the day-based forms "7d"/"30d"
+func ParsePeriod(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid period %q", value)
+		}
+		return time.Duration(days) * HoursPerDay * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid period %q", value)
+	}
+	return d, nil
+}
+
+// ValidateAvailabilityRange checks that period/resolution produce a usable matrix
+func ValidateAvailabilityRange(period, resolution time.Duration) error {
+	if resolution < time.Minute {
+		return fmt.Errorf("resolution must be at least 1m")
+	}
+	if period < resolution {
+		return fmt.Errorf("period must be at least one resolution step")
+	}
+	if slots := int(period / resolution); slots > MaxAvailabilitySlots {
+		return fmt.Errorf("period/resolution yields %d slots, maximum is %d", slots, MaxAvailabilitySlots)
+	}
+	return nil
+}
+
+// GenerateAvailabilityMatrix returns primary/secondary/combined uptime per time slot for one site
+func GenerateAvailabilityMatrix(app *config.AppState, siteID string, period, resolution time.Duration) (AvailabilityMatrix, error) {
+	matrices, err := generateAvailabilityMatrices(app, []string{siteID}, period, resolution)
+	if err != nil {
+		return AvailabilityMatrix{}, err
+	}
+	return matrices[siteID], nil
+}
+
+// GenerateAvailabilityMatrices returns availability matrices for all configured sites
+func GenerateAvailabilityMatrices(app *config.AppState, period, resolution time.Duration) (map[string]AvailabilityMatrix, error) {
+	sites := app.GetSitesSnapshot()
+	siteIDs := make([]string, 0, len(sites))
+	for _, site := range sites {
+		siteIDs = append(siteIDs, site.ID)
+	}
+	return generateAvailabilityMatrices(app, siteIDs, period, resolution)
+}
+
+// generateAvailabilityMatrices computes (or returns cached) matrices for the given sites
+func generateAvailabilityMatrices(app *config.AppState, siteIDs []string, period, resolution time.Duration) (map[string]AvailabilityMatrix, error) {
+	cacheKey := fmt.Sprintf("%s|%s|%s", strings.Join(siteIDs, ","), period, resolution)
+
+	availabilityCacheMu.Lock()
+	if entry, ok := availabilityCache[cacheKey]; ok && clock.Default().Now().Before(entry.expiresAt) {
+		availabilityCacheMu.Unlock()
+		return entry.matrices, nil
+	}
+	availabilityCacheMu.Unlock()
+
+	// Align slots to resolution boundaries, the last slot contains now
+	now := clock.Default().Now().UTC()
+	end := now.Truncate(resolution).Add(resolution)
+	start := end.Add(-period)
+	slots := int(period / resolution)
+
+	logs, err := app.Storage.GetLogsInRange(start, end)
+	if err != nil {
+		log := logger.Default().WithComponent("stats-availability")
+		log.Error("Failed to get logs for availability matrix", "error", err)
+		return nil, err
+	}
+
+	labelFormat := "Jan 2 15:04"
+	if resolution >= HoursPerDay*time.Hour {
+		labelFormat = "Jan 2"
+	}
+	labels := make([]string, slots)
+	for i := range labels {
+		labels[i] = start.Add(time.Duration(i) * resolution).Format(labelFormat)
+	}
+
+	// Bucket logs per site and slot in a single pass
+	wanted := make(map[string][]*TimeframeStats, len(siteIDs))
+	for _, siteID := range siteIDs {
+		buckets := make([]*TimeframeStats, slots)
+		for i := range buckets {
+			buckets[i] = NewTimeframeStats()
+		}
+		wanted[siteID] = buckets
+	}
+
+	for _, pingLog := range logs {
+		buckets, ok := wanted[pingLog.SiteID]
+		if !ok {
+			continue
+		}
+		idx := int(pingLog.Timestamp.Sub(start) / resolution)
+		if idx < 0 || idx >= slots {
+			continue
+		}
+		buckets[idx].AddLog(pingLog)
+	}
+
+	matrices := make(map[string]AvailabilityMatrix, len(siteIDs))
+	for siteID, buckets := range wanted {
+		rows := make([][3]float64, slots)
+		for i, bucket := range buckets {
+			rows[i] = [3]float64{
+				bucket.GetProviderUptime("primary"),
+				bucket.GetProviderUptime("secondary"),
+				bucket.GetUptimePercentage(),
+			}
+		}
+		matrices[siteID] = AvailabilityMatrix{
+			Labels: labels,
+			Rows:   rows,
+		}
+	}
+
+	availabilityCacheMu.Lock()
+	// Drop expired entries so the cache stays bounded by the set of active queries
+	for key, entry := range availabilityCache {
+		if now.After(entry.expiresAt) {
+			delete(availabilityCache, key)
+		}
+	}
+	availabilityCache[cacheKey] = availabilityCacheEntry{
+		matrices:  matrices,
+		expiresAt: clock.Default().Now().Add(AvailabilityMatrixCacheTTL),
+	}
+	availabilityCacheMu.Unlock()
+
+	return matrices, nil
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// LatencyBaselineUpdateInterval controls how often latency baselines are recalculated
+const LatencyBaselineUpdateInterval = 5 * time.Minute
+
+// StartLatencyBaselineUpdater periodically recalculates the latency baseline of
+// every site line over the configured trailing window
+func StartLatencyBaselineUpdater(ctx context.Context, app *config.AppState) {
+	log := logger.Default().WithComponent("stats-baseline")
+	log.Info("Starting latency baseline updater",
+		"interval", LatencyBaselineUpdateInterval,
+		"window", app.Config.LatencyBaseline.Window)
+
+	go func() {
+		ticker := time.NewTicker(LatencyBaselineUpdateInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := UpdateLatencyBaselines(app); err != nil {
+				log.Error("Failed to update latency baselines", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				log.Info("Stopping latency baseline updater")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// UpdateLatencyBaselines recalculates all baselines in one pass over the logs
+// of the window and stores them in the application state
+func UpdateLatencyBaselines(app *config.AppState) error {
+	now := clock.Default().Now()
+	since := now.Add(-app.Config.LatencyBaseline.Window)
+
+	logs, err := app.Storage.GetLogsInRange(since, now)
+	if err != nil {
+		return err
+	}
+
+	sites := app.GetSitesSnapshot()
+	schedules := make(map[string]models.OfflineSchedule, len(sites))
+	for _, site := range sites {
+		schedules[site.ID] = site.ExpectedOffline
+	}
+
+	latencies := make(map[string][]float64)
+	for _, pingLog := range logs {
+		schedule, exists := schedules[pingLog.SiteID]
+		if !exists || !pingLog.Success || pingLog.Latency == nil || schedule.Contains(pingLog.Timestamp) {
+			continue
+		}
+		key := pingLog.SiteID + "/" + pingLog.Target
+		latencies[key] = append(latencies[key], *pingLog.Latency)
+	}
+
+	baselines := make(map[string]models.LatencyBaseline, len(latencies))
+	for _, site := range sites {
+		for _, line := range []string{"primary", "secondary"} {
+			values := latencies[site.ID+"/"+line]
+			if len(values) == 0 {
+				continue
+			}
+			mean, stdDev := meanAndStdDev(values)
+			baselines[site.ID+"/"+line] = models.LatencyBaseline{
+				SiteID:    site.ID,
+				Line:      line,
+				Mean:      roundToDecimalPlaces(mean, LatencyPrecision),
+				StdDev:    roundToDecimalPlaces(stdDev, LatencyPrecision),
+				Samples:   len(values),
+				Since:     since,
+				UpdatedAt: now,
+			}
+			config.LatencyBaselineMeanGauge.WithLabelValues(site.ID, line).Set(mean)
+			config.LatencyBaselineStdDevGauge.WithLabelValues(site.ID, line).Set(stdDev)
+		}
+	}
+
+	app.SetLatencyBaselines(baselines)
+	logger.Default().WithComponent("stats-baseline").Debug("Latency baselines updated", "lines", len(baselines))
+	return nil
+}
+
+// meanAndStdDev returns the mean and population standard deviation of values
+func meanAndStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
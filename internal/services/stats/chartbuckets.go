@@ -0,0 +1,269 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// chartBucketSizes are the bucket sizes the resolver chooses from, smallest first.
+// Longer spans fall back to multiples of a day.
+var chartBucketSizes = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	HoursPerDay * time.Hour,
+}
+
+// ChartWindow is the bucket layout of a chart: Points buckets of size Bucket from Start
+type ChartWindow struct {
+	Start  time.Time
+	Bucket time.Duration
+	Points int
+}
+
+// ResolveChartWindow picks the smallest bucket size of at least requested
+// that covers from..to in at most maxPoints buckets aligned to the bucket size
+func ResolveChartWindow(from, to time.Time, requested time.Duration, maxPoints int) ChartWindow {
+	if maxPoints <= 0 {
+		maxPoints = MaxChartDataPoints
+	}
+
+	for _, size := range chartBucketSizes {
+		if size < requested {
+			continue
+		}
+		if window := alignChartWindow(from, to, size); window.Points <= maxPoints {
+			return window
+		}
+	}
+
+	// Multi-day buckets: start from the estimate and grow until the aligned window fits
+	day := HoursPerDay * time.Hour
+	days := int(to.Sub(from)/(day*time.Duration(maxPoints))) + 1
+	if requestedDays := int((requested + day - 1) / day); requestedDays > days {
+		days = requestedDays
+	}
+	for {
+		if window := alignChartWindow(from, to, time.Duration(days)*day); window.Points <= maxPoints {
+			return window
+		}
+		days++
+	}
+}
+
+// alignChartWindow lays out buckets of size covering from..to, starting at the bucket containing from
+func alignChartWindow(from, to time.Time, size time.Duration) ChartWindow {
+	start := from.Truncate(size)
+	points := int((to.Sub(start) + size - 1) / size)
+	if points < 1 {
+		points = 1
+	}
+	return ChartWindow{Start: start, Bucket: size, Points: points}
+}
+
+// chartLabelFormat returns the time format for bucket labels of a window
+func chartLabelFormat(window ChartWindow) string {
+	switch {
+	case window.Bucket >= HoursPerDay*time.Hour:
+		return "Jan 2"
+	case time.Duration(window.Points-1)*window.Bucket > HoursPerDay*time.Hour:
+		return "Jan 2 15:04"
+	default:
+		return "15:04"
+	}
+}
+
+// GenerateChartDataForWindow generates a time series chart for a custom from..to
+// range. resolution is the requested bucket size, raised as needed to stay
+// within the configured point cap.
+func GenerateChartDataForWindow(app *config.AppState, siteID, chartType string, from, to time.Time, resolution time.Duration) (interface{}, error) {
+	if !isTimeSeriesChart(chartType) {
+		return nil, fmt.Errorf("chart type %q does not support custom ranges (expected one of %s)", chartType, joinTimeSeriesCharts())
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	window := ResolveChartWindow(from.UTC(), to.UTC(), resolution, app.Config.Display.MaxChartPoints)
+	return generateWindowChart(app, siteID, chartType, window), nil
+}
+
+// generateWindowChart buckets the logs of a site over window and aggregates them for chartType
+func generateWindowChart(app *config.AppState, siteID, chartType string, window ChartWindow) interface{} {
+	end := window.Start.Add(time.Duration(window.Points) * window.Bucket)
+	logs, err := app.Storage.GetLogsInRange(window.Start, end)
+	if err != nil {
+		log := logger.Default().WithComponent("stats-chart")
+		log.Error("Failed to get logs for chart", "site_id", siteID, "chart", chartType, "error", err)
+	}
+
+	buckets := make([][]models.PingLog, window.Points)
+	for _, pingLog := range logs {
+		if pingLog.SiteID != siteID || pingLog.Timestamp.Before(window.Start) || !pingLog.Timestamp.Before(end) {
+			continue
+		}
+		idx := int(pingLog.Timestamp.Sub(window.Start) / window.Bucket)
+		buckets[idx] = append(buckets[idx], pingLog)
+	}
+
+	labelFormat := chartLabelFormat(window)
+	labels := make([]string, window.Points)
+	for i := range labels {
+		labels[i] = window.Start.Add(time.Duration(i) * window.Bucket).Format(labelFormat)
+	}
+	bucketSeconds := int64(window.Bucket / time.Second)
+
+	// Single-line sites get a null secondary series
+	site, exists := app.FindSite(siteID)
+	dualLine := exists && site.IsDualLine()
+
+	switch chartType {
+	case "uptime":
+		result := ChartDataResult{Labels: labels, BucketSeconds: bucketSeconds}
+		for _, bucket := range buckets {
+			stats := NewTimeframeStats()
+			for _, pingLog := range bucket {
+				stats.AddLog(pingLog)
+			}
+			result.CombinedData = append(result.CombinedData, stats.GetUptimePercentage())
+			result.PrimaryData = append(result.PrimaryData, stats.GetProviderUptime("primary"))
+			if dualLine {
+				result.SecondaryData = append(result.SecondaryData, stats.GetProviderUptime("secondary"))
+			}
+		}
+		return result
+	case "latency_minmax":
+		minResult := ChartDataResult{Labels: labels, BucketSeconds: bucketSeconds}
+		maxResult := ChartDataResult{Labels: labels, BucketSeconds: bucketSeconds}
+		for _, bucket := range buckets {
+			minResult.PrimaryData = append(minResult.PrimaryData, bucketExtreme(bucket, "primary", true))
+			maxResult.PrimaryData = append(maxResult.PrimaryData, bucketExtreme(bucket, "primary", false))
+			if dualLine {
+				minResult.SecondaryData = append(minResult.SecondaryData, bucketExtreme(bucket, "secondary", true))
+				maxResult.SecondaryData = append(maxResult.SecondaryData, bucketExtreme(bucket, "secondary", false))
+			}
+		}
+		return fiber.Map{
+			"min": minResult,
+			"max": maxResult,
+		}
+	}
+
+	var primaryData, secondaryData, combinedData []float64
+	for _, bucket := range buckets {
+		primary, secondary := bucketValue(bucket, "primary", chartType), bucketValue(bucket, "secondary", chartType)
+		primaryData = append(primaryData, primary)
+		secondaryData = append(secondaryData, secondary)
+		if chartType == "latency" {
+			combinedData = append(combinedData, combineLatency(primary, secondary, app.Config.Display.CombinedLatency))
+		}
+	}
+	// A missing TTL is unknown rather than 0, so TTL buckets without data are always null
+	gaps := app.Config.Display.ChartGaps
+	if chartType == "ttl" {
+		gaps = ChartGapsNull
+	}
+	result := filterEmptyBucketsCombined(labels, primaryData, secondaryData, combinedData, gaps)
+	result.BucketSeconds = bucketSeconds
+	if !dualLine {
+		result.SecondaryData = nil
+	}
+	return result
+}
+
+// bucketValue aggregates the logs of one line in a bucket: mean latency, mean
+// jitter, the packet delivery rate or the most common reply TTL, NaN when the
+// bucket has no data
+func bucketValue(bucket []models.PingLog, target, chartType string) float64 {
+	if chartType == "ttl" {
+		return bucketTTL(bucket, target)
+	}
+	var sum float64
+	var count, sent, received int
+	for _, pingLog := range bucket {
+		if pingLog.Target != target {
+			continue
+		}
+		switch chartType {
+		case "latency":
+			if pingLog.Success && pingLog.Latency != nil {
+				sum += *pingLog.Latency
+				count++
+			}
+		case "jitter":
+			if pingLog.Jitter != nil {
+				sum += *pingLog.Jitter
+				count++
+			}
+		case "packet_transmission":
+			sent += pingLog.PacketsSent
+			received += pingLog.PacketsRecv
+		}
+	}
+
+	if chartType == "packet_transmission" {
+		if sent == 0 {
+			return math.NaN()
+		}
+		return float64(received) / float64(sent) * 100
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// bucketTTL returns the most common reply TTL of one line in a bucket, on a
+// tie the later one, NaN when no check recorded a TTL
+func bucketTTL(bucket []models.PingLog, target string) float64 {
+	counts := make(map[int]int)
+	modal, best := 0, 0
+	for _, pingLog := range bucket {
+		if pingLog.Target != target || pingLog.TTL == nil {
+			continue
+		}
+		ttl := *pingLog.TTL
+		counts[ttl]++
+		if counts[ttl] >= best {
+			modal, best = ttl, counts[ttl]
+		}
+	}
+	if best == 0 {
+		return math.NaN()
+	}
+	return float64(modal)
+}
+
+// bucketExtreme returns the lowest minimum or highest maximum latency of one line in a bucket
+func bucketExtreme(bucket []models.PingLog, target string, lowest bool) float64 {
+	var extreme float64
+	var set bool
+	for _, pingLog := range bucket {
+		if pingLog.Target != target {
+			continue
+		}
+		value := pingLog.MaxLatency
+		if lowest {
+			value = pingLog.MinLatency
+		}
+		if value == nil {
+			continue
+		}
+		if !set || (lowest && *value < extreme) || (!lowest && *value > extreme) {
+			extreme = *value
+			set = true
+		}
+	}
+	return extreme
+}
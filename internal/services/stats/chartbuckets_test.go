@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+// Each bucket size holds up to maxPoints buckets, one nanosecond more
+// switches to the next size
+func TestResolveChartWindowBoundaries(t *testing.T) {
+	const maxPoints = 100
+	midnight := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	day := HoursPerDay * time.Hour
+
+	for i, size := range chartBucketSizes {
+		next := 2 * day
+		if i+1 < len(chartBucketSizes) {
+			next = chartBucketSizes[i+1]
+		}
+		span := size * maxPoints
+
+		t.Run(size.String(), func(t *testing.T) {
+			if window := ResolveChartWindow(midnight, midnight.Add(span), 0, maxPoints); window.Bucket != size || window.Points != maxPoints {
+				t.Errorf("span %v: %v buckets of %v, want %d of %v", span, window.Points, window.Bucket, maxPoints, size)
+			}
+			if window := ResolveChartWindow(midnight, midnight.Add(span+1), 0, maxPoints); window.Bucket != next {
+				t.Errorf("span %v + 1ns: buckets of %v, want %v", span, window.Bucket, next)
+			}
+		})
+	}
+}
+
+func TestResolveChartWindow(t *testing.T) {
+	midnight := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	day := HoursPerDay * time.Hour
+
+	tests := []struct {
+		name      string
+		from      time.Time
+		span      time.Duration
+		requested time.Duration
+		maxPoints int
+		bucket    time.Duration
+		points    int
+	}{
+		// A start inside a bucket adds a partial bucket at the front
+		{"aligned", midnight, 100 * time.Minute, time.Minute, 100, time.Minute, 100},
+		{"unaligned start", midnight.Add(30 * time.Second), 100 * time.Minute, time.Minute, 100, 5 * time.Minute, 21},
+		{"unaligned start within the cap", midnight.Add(30 * time.Second), 99 * time.Minute, time.Minute, 100, time.Minute, 100},
+
+		// The requested resolution is a lower bound
+		{"requested between sizes", midnight, time.Hour, 10 * time.Minute, 100, 15 * time.Minute, 4},
+		{"requested size", midnight, time.Hour, 15 * time.Minute, 100, 15 * time.Minute, 4},
+		{"requested days", midnight, 30 * day, 3 * day, 100, 3 * day, 11},
+		{"requested part of a day", midnight, 7 * day, 25 * time.Hour, 100, 2 * day, 4},
+
+		// Points never drop below one and an unset cap is MaxChartDataPoints
+		{"empty range", midnight, 0, time.Minute, 100, time.Minute, 1},
+		{"default cap", midnight, time.Duration(MaxChartDataPoints) * time.Minute, time.Minute, 0, time.Minute, MaxChartDataPoints},
+		{"default cap exceeded", midnight, time.Duration(MaxChartDataPoints+1) * time.Minute, time.Minute, 0, 5 * time.Minute, (MaxChartDataPoints + 5) / 5},
+
+		// Past a day the resolver counts in whole days, aligned to the zero
+		// time rather than the range, so 30 days of 3 day buckets are 11
+		{"a year", midnight, 365 * day, 0, 100, 4 * day, 92},
+		{"small cap", midnight, 30 * day, 0, 10, 4 * day, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := tt.from.Add(tt.span)
+			window := ResolveChartWindow(tt.from, to, tt.requested, tt.maxPoints)
+			if window.Bucket != tt.bucket || window.Points != tt.points {
+				t.Errorf("%d buckets of %v, want %d of %v", window.Points, window.Bucket, tt.points, tt.bucket)
+			}
+			if end := window.Start.Add(time.Duration(window.Points) * window.Bucket); window.Start.After(tt.from) || end.Before(to) {
+				t.Errorf("window %v..%v does not cover %v..%v", window.Start, end, tt.from, to)
+			}
+		})
+	}
+}
+
+// A 12h range at the requested 5 minutes would be 144 points, the chart
+// reports the 15 minute buckets it was raised to
+func TestGenerateChartDataForWindowBucketSize(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	appState := testutil.NewAppState(t, testutil.Options{Logs: []models.PingLog{checkLog(now.Add(-time.Hour), true)}, Now: now, Clock: true})
+	appState.Config.Display.MaxChartPoints = 100
+
+	chart, err := GenerateChartDataForWindow(appState, "site-001", "uptime", now.Add(-12*time.Hour), now, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := chart.(ChartDataResult)
+	if result.BucketSeconds != 900 || len(result.Labels) != 48 {
+		t.Errorf("%d labels of %ds buckets, want 48 of 900s", len(result.Labels), result.BucketSeconds)
+	}
+}
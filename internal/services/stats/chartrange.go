@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// chartTypes lists the chart types served by GenerateChartDataForRange, in display order
+var chartTypes = []string{"latency", "uptime", "packet_transmission", "jitter", "latency_minmax", "ttl", "yearly", "distribution"}
+
+// chartRanges is the supported time range matrix per chart type
+var chartRanges = map[string][]string{
+	"latency":             {"1h", "3h", "12h", "24h", "7d", "30d"},
+	"uptime":              {"1h", "3h", "12h", "24h", "7d", "30d"},
+	"packet_transmission": {"1h", "3h", "12h", "24h", "7d", "30d"},
+	"jitter":              {"1h", "3h", "12h", "24h", "7d", "30d"},
+	"latency_minmax":      {"1h", "3h", "12h", "24h", "7d", "30d"},
+	"ttl":                 {"1h", "3h", "12h", "24h", "7d", "30d"},
+	"yearly":              {"12m"},
+	"distribution":        {"24h"},
+}
+
+// timeSeriesCharts are the chart types bucketed over time, which support custom ranges
+var timeSeriesCharts = []string{"latency", "uptime", "packet_transmission", "jitter", "latency_minmax", "ttl"}
+
+// chartRangePresets maps the preset ranges of time series charts to their
+// span and requested bucket size. The bucket size is raised as needed to
+// stay within the point cap.
+var chartRangePresets = map[string]struct {
+	span       time.Duration
+	resolution time.Duration
+}{
+	"1h":  {time.Hour, time.Minute},
+	"3h":  {3 * time.Hour, 5 * time.Minute},
+	"12h": {12 * time.Hour, 5 * time.Minute},
+	"24h": {HoursPerDay * time.Hour, time.Hour},
+	"7d":  {DaysPerWeek * HoursPerDay * time.Hour, HoursPerDay * time.Hour},
+	"30d": {30 * HoursPerDay * time.Hour, HoursPerDay * time.Hour},
+}
+
+// isTimeSeriesChart reports whether chartType is bucketed over time
+func isTimeSeriesChart(chartType string) bool {
+	for _, t := range timeSeriesCharts {
+		if t == chartType {
+			return true
+		}
+	}
+	return false
+}
+
+// joinTimeSeriesCharts lists the time series chart types for error messages
+func joinTimeSeriesCharts() string {
+	return strings.Join(timeSeriesCharts, ", ")
+}
+
+// ChartTypes returns the supported chart types
+func ChartTypes() []string {
+	return append([]string(nil), chartTypes...)
+}
+
+// ChartRanges returns the supported time ranges of a chart type, nil for unknown types
+func ChartRanges(chartType string) []string {
+	ranges, exists := chartRanges[chartType]
+	if !exists {
+		return nil
+	}
+	return append([]string(nil), ranges...)
+}
+
+// ValidateChartRange checks a chart type and time range against the supported matrix
+func ValidateChartRange(chartType, timeRange string) error {
+	ranges, exists := chartRanges[chartType]
+	if !exists {
+		return fmt.Errorf("unsupported chart type %q (expected one of %s)", chartType, strings.Join(chartTypes, ", "))
+	}
+	for _, r := range ranges {
+		if r == timeRange {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported range %q for %s chart (expected one of %s)", timeRange, chartType, strings.Join(ranges, ", "))
+}
+
+// bundleCharts are the charts of the GenerateChartData bundle
+var bundleCharts = []string{"latency", "uptime", "sla", "distribution", "yearly", "packet_transmission", "jitter", "latency_minmax", "incidents"}
+
+// ChartSelection is the set of bundle charts to compute, nil selects all
+type ChartSelection map[string]bool
+
+// Has reports whether chart is selected
+func (s ChartSelection) Has(chart string) bool {
+	return s == nil || s[chart]
+}
+
+// ParseChartSelection parses a comma separated list of bundle charts, e.g.
+// "latency,uptime". An empty list selects all charts.
+func ParseChartSelection(list string) (ChartSelection, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	selection := make(ChartSelection)
+	for _, chart := range strings.Split(list, ",") {
+		chart = strings.TrimSpace(chart)
+		if !isBundleChart(chart) {
+			return nil, fmt.Errorf("unsupported chart %q (expected one of %s)", chart, strings.Join(bundleCharts, ", "))
+		}
+		selection[chart] = true
+	}
+	return selection, nil
+}
+
+// BundleCharts returns the charts selectable in the chart bundle
+func BundleCharts() []string {
+	return append([]string(nil), bundleCharts...)
+}
+
+// isBundleChart reports whether chart is part of the chart bundle
+func isBundleChart(chart string) bool {
+	for _, c := range bundleCharts {
+		if c == chart {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"math"
+
+	"sitewatch/internal/models"
+)
+
+// ConfidenceLevel is the confidence of the reported uptime and packet loss
+// intervals (percent) and ConfidenceZ the matching standard normal quantile
+const (
+	ConfidenceLevel = 95
+	ConfidenceZ     = 1.959964
+)
+
+// WilsonInterval returns the Wilson score interval of the proportion of
+// successes in trials at the standard normal quantile z, as fractions.
+// Unlike the normal approximation it stays within 0..1 and is not zero
+// width at 0% or 100%, so 3 of 3 successful checks give 0.44-1 while
+// 1000 of 1000 give 0.996-1.
+func WilsonInterval(successes, trials int, z float64) (lower, upper float64) {
+	if trials <= 0 {
+		return 0, 1
+	}
+	n := float64(trials)
+	p := float64(successes) / n
+	z2 := z * z
+
+	denominator := 1 + z2/n
+	center := (p + z2/(2*n)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denominator
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// percentInterval returns the 95% Wilson interval of successes in trials in
+// percent, nil without trials
+func percentInterval(successes, trials int) *models.ConfidenceInterval {
+	if trials <= 0 {
+		return nil
+	}
+	lower, upper := WilsonInterval(successes, trials, ConfidenceZ)
+	return &models.ConfidenceInterval{
+		Lower:      roundToDecimalPlaces(lower*100, UptimePrecision),
+		Upper:      roundToDecimalPlaces(upper*100, UptimePrecision),
+		Samples:    trials,
+		Confidence: ConfidenceLevel,
+	}
+}
+
+// GetUptimeInterval returns the confidence interval of the uptime of this timeframe
+func (ts *TimeframeStats) GetUptimeInterval() *models.ConfidenceInterval {
+	return percentInterval(ts.SuccessChecks, ts.TotalChecks)
+}
+
+// GetProviderUptimeInterval returns the confidence interval of the uptime of a specific provider
+func (ts *TimeframeStats) GetProviderUptimeInterval(provider string) *models.ConfidenceInterval {
+	switch provider {
+	case "primary":
+		return percentInterval(ts.PrimarySuccess, ts.PrimaryTotal)
+	case "secondary":
+		return percentInterval(ts.SecondarySuccess, ts.SecondaryTotal)
+	}
+	return nil
+}
+
+// GetProviderPacketLossInterval returns the confidence interval of the packet
+// loss of a specific provider, with every sent echo request as a sample
+func (ts *TimeframeStats) GetProviderPacketLossInterval(provider string) *models.ConfidenceInterval {
+	var sent, received int
+	switch provider {
+	case "primary":
+		sent, received = ts.PrimaryPacketsSent, ts.PrimaryPacketsReceived
+	case "secondary":
+		sent, received = ts.SecondaryPacketsSent, ts.SecondaryPacketsReceived
+	default:
+		return nil
+	}
+	return percentInterval(max(sent-received, 0), sent)
+}
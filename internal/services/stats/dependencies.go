@@ -0,0 +1,157 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// DependencyEdgeType is the type of every edge of the dependency graph
+const DependencyEdgeType = "depends_on"
+
+// BuildDependencyGraph returns all sites as nodes with their current status,
+// an edge per depends_on entry and a warning for every dependency cycle
+func BuildDependencyGraph(app *config.AppState) models.DependencyGraph {
+	sites := app.GetSitesSnapshot()
+	statuses := app.GetSiteStatusSnapshot()
+
+	graph := models.DependencyGraph{
+		Nodes:    make([]models.DependencyNode, 0, len(sites)),
+		Edges:    []models.DependencyEdge{},
+		Warnings: []string{},
+	}
+	for _, site := range sites {
+		graph.Nodes = append(graph.Nodes, models.DependencyNode{
+			ID:     site.ID,
+			Name:   site.Name,
+			Status: SiteStatusLabel(site, statuses[site.ID]),
+		})
+		for _, dependency := range site.DependsOn {
+			graph.Edges = append(graph.Edges, models.DependencyEdge{From: site.ID, To: dependency, Type: DependencyEdgeType})
+		}
+	}
+
+	for _, cycle := range FindDependencyCycles(sites) {
+		graph.Warnings = append(graph.Warnings, fmt.Sprintf("dependency cycle: %s", strings.Join(cycle, " -> ")))
+	}
+	return graph
+}
+
+// SiteStatusLabel summarizes the status of a site: online when all lines
+// answer, degraded when only one line of a dual-line site does, offline when
+// none does and unknown without a status
+func SiteStatusLabel(site models.Site, status *models.SiteStatus) string {
+	switch {
+	case status == nil:
+		return "unknown"
+	case !site.IsDualLine() && status.PrimaryOnline, site.IsDualLine() && status.BothOnline:
+		return "online"
+	case status.PrimaryOnline || status.SecondaryOnline:
+		return "degraded"
+	default:
+		return "offline"
+	}
+}
+
+// FindDependencyCycles returns every dependency cycle once, as a path of site
+// IDs starting and ending at the smallest ID of the cycle
+func FindDependencyCycles(sites []models.Site) [][]string {
+	dependsOn := make(map[string][]string, len(sites))
+	ids := make([]string, 0, len(sites))
+	for _, site := range sites {
+		dependsOn[site.ID] = site.DependsOn
+		ids = append(ids, site.ID)
+	}
+	sort.Strings(ids)
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(ids))
+	seen := make(map[string]bool)
+	var cycles [][]string
+	var path []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = inProgress
+		path = append(path, id)
+		for _, dependency := range dependsOn[id] {
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case inProgress:
+				start := 0
+				for path[start] != dependency {
+					start++
+				}
+				cycle := canonicalCycle(path[start:])
+				if key := strings.Join(cycle, "\x00"); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// canonicalCycle rotates a cycle to start at its smallest ID and closes it
+func canonicalCycle(members []string) []string {
+	smallest := 0
+	for i, id := range members {
+		if id < members[smallest] {
+			smallest = i
+		}
+	}
+	cycle := make([]string, 0, len(members)+1)
+	cycle = append(cycle, members[smallest:]...)
+	cycle = append(cycle, members[:smallest]...)
+	return append(cycle, cycle[0])
+}
+
+// AffectedSites returns the sites that depend on siteID directly or
+// transitively, i.e. everything impacted when siteID is degraded, in
+// configuration order
+func AffectedSites(sites []models.Site, siteID string) []models.Site {
+	dependents := make(map[string][]string)
+	for _, site := range sites {
+		for _, dependency := range site.DependsOn {
+			dependents[dependency] = append(dependents[dependency], site.ID)
+		}
+	}
+
+	affected := make(map[string]bool)
+	queue := []string{siteID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if !affected[dependent] && dependent != siteID {
+				affected[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	result := []models.Site{}
+	for _, site := range sites {
+		if affected[site.ID] {
+			result = append(result, site)
+		}
+	}
+	return result
+}
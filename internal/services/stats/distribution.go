@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// MaxLatencyBuckets limits the bucket bounds of a distribution query
+const MaxLatencyBuckets = 50
+
+// ErrInvalidRange is returned for a distribution range that does not end after it starts
+var ErrInvalidRange = errors.New("to must be after from")
+
+// LatencyDistribution is the latency histogram of a site over a time range
+type LatencyDistribution struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Bounds  []float64       `json:"bounds"`  // Upper bucket bounds in ms, the last bucket is open-ended
+	Samples int             `json:"samples"` // Successful checks with a latency in the range
+	Chart   ChartDataResult `json:"chart"`   // Counts per bucket, combined and per line
+}
+
+// ParseLatencyBuckets parses comma separated ascending bucket bounds in
+// milliseconds, e.g. "10,50,100". An empty value selects the default buckets.
+func ParseLatencyBuckets(value string) ([]float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultLatencyBuckets, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > MaxLatencyBuckets {
+		return nil, fmt.Errorf("too many buckets: %d (maximum %d)", len(parts), MaxLatencyBuckets)
+	}
+	bounds := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || bound <= 0 || math.IsInf(bound, 0) {
+			return nil, fmt.Errorf("invalid bucket bound %q (expected a positive number of milliseconds)", part)
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("bucket bounds must be ascending, %v follows %v", bound, bounds[len(bounds)-1])
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+// LatencyBucketLabels returns the labels of the buckets defined by bounds, e.g. "10-50ms" and "500ms+"
+func LatencyBucketLabels(bounds []float64) []string {
+	labels := make([]string, 0, len(bounds)+1)
+	lower := "0"
+	for _, bound := range bounds {
+		upper := strconv.FormatFloat(bound, 'f', -1, 64)
+		labels = append(labels, lower+"-"+upper+"ms")
+		lower = upper
+	}
+	return append(labels, lower+"ms+")
+}
+
+// GenerateLatencyDistribution counts the latencies of the successful checks of
+// a site from..to into the buckets defined by bounds
+func GenerateLatencyDistribution(app *config.AppState, siteID string, from, to time.Time, bounds []float64) (LatencyDistribution, error) {
+	if !to.After(from) {
+		return LatencyDistribution{}, ErrInvalidRange
+	}
+
+	logs, err := app.Storage.GetLogsInRange(from, to)
+	if err != nil {
+		return LatencyDistribution{}, fmt.Errorf("reading logs: %w", err)
+	}
+
+	var siteLogs []models.PingLog
+	for _, pingLog := range logs {
+		if pingLog.SiteID == siteID && !pingLog.Timestamp.Before(from) && pingLog.Timestamp.Before(to) {
+			siteLogs = append(siteLogs, pingLog)
+		}
+	}
+
+	combined, primary, secondary := latencyStatsByLine(siteLogs, siteID, from, bounds)
+	return LatencyDistribution{
+		From:    from,
+		To:      to,
+		Bounds:  bounds,
+		Samples: combined.Latency.Count,
+		Chart: ChartDataResult{
+			Labels:        LatencyBucketLabels(bounds),
+			CombinedData:  combined.GetLatencyDistribution(),
+			PrimaryData:   primary.GetLatencyDistribution(),
+			SecondaryData: secondary.GetLatencyDistribution(),
+		},
+	}, nil
+}
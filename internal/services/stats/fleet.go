@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/jobs"
+)
+
+// RegisterFleetSnapshots registers the job that stores the site counts of
+// the overview every fleet_history.interval and removes snapshots older than
+// fleet_history.retention_days
+func RegisterFleetSnapshots(app *config.AppState) error {
+	if !app.Config.FleetHistory.Enabled {
+		return nil
+	}
+
+	return jobs.Register(jobs.Job{
+		Name:     "fleet-snapshots",
+		Interval: app.Config.FleetHistory.Interval,
+		Run: func(ctx context.Context) error {
+			snapshot := TakeFleetSnapshot(app)
+			if err := app.Storage.AddFleetSnapshot(snapshot); err != nil {
+				return err
+			}
+
+			retention := time.Duration(app.Config.FleetHistory.RetentionDays) * 24 * time.Hour
+			purged, err := app.Storage.PurgeFleetSnapshots(snapshot.Timestamp.Add(-retention))
+			if err != nil {
+				return err
+			}
+			if purged > 0 {
+				logger.Default().WithComponent("fleet-history").Debug("Expired fleet snapshots purged", "count", purged)
+			}
+			return nil
+		},
+	})
+}
+
+// countSiteStatuses counts the enabled sites by their current status. Sites
+// without a status yet count as offline, degraded dual-line sites as online.
+// The caller must hold app.Mu.
+func countSiteStatuses(app *config.AppState) models.FleetSnapshot {
+	counts := models.FleetSnapshot{TotalSites: len(app.Sites)}
+	for _, site := range app.Sites {
+		if !site.Enabled {
+			continue
+		}
+
+		status, exists := app.SiteStatus[site.ID]
+		if !exists {
+			counts.OfflineSites++
+			continue
+		}
+
+		if site.IsDualLine() {
+			// Dual-line site
+			if status.PrimaryOnline && status.SecondaryOnline {
+				counts.OnlineSites++
+			} else if status.PrimaryOnline || status.SecondaryOnline {
+				// Count degraded sites as online (since at least one line works)
+				counts.OnlineSites++
+				counts.DegradedSites++
+			} else {
+				counts.OfflineSites++
+			}
+		} else {
+			// Single-line site
+			if status.PrimaryOnline {
+				counts.OnlineSites++
+			} else {
+				counts.OfflineSites++
+			}
+		}
+	}
+	return counts
+}
+
+// TakeFleetSnapshot returns the current site counts of the overview, without
+// reading the logs
+func TakeFleetSnapshot(app *config.AppState) models.FleetSnapshot {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+
+	snapshot := countSiteStatuses(app)
+	snapshot.Timestamp = clock.Default().Now()
+	return snapshot
+}
+
+// FleetChartResult is the number of online, offline and degraded sites over a
+// time range, bucketed like the time series charts of the sites. Each bucket
+// shows its worst snapshot: the fewest online and the most offline and
+// degraded sites, so short incidents stay visible in long ranges.
+type FleetChartResult struct {
+	Labels        []string
+	TotalSites    models.ChartSeries
+	OnlineSites   models.ChartSeries
+	OfflineSites  models.ChartSeries
+	DegradedSites models.ChartSeries
+	BucketSeconds int64 `json:",omitempty"`
+}
+
+// GenerateFleetChart generates the fleet availability chart for a preset
+// range of the time series charts, e.g. "24h"
+func GenerateFleetChart(app *config.AppState, timeRange string) (FleetChartResult, error) {
+	preset, ok := chartRangePresets[timeRange]
+	if !ok {
+		return FleetChartResult{}, fmt.Errorf("unsupported range %q for fleet chart (expected one of %s)", timeRange, strings.Join(chartRanges["latency"], ", "))
+	}
+
+	now := clock.Default().Now().UTC()
+	window := ResolveChartWindow(now.Add(-preset.span), now, preset.resolution, app.Config.Display.MaxChartPoints)
+	end := window.Start.Add(time.Duration(window.Points) * window.Bucket)
+	snapshots, err := app.Storage.GetFleetSnapshots(window.Start, end)
+	if err != nil {
+		return FleetChartResult{}, err
+	}
+
+	buckets := make([][]models.FleetSnapshot, window.Points)
+	for _, snapshot := range snapshots {
+		if snapshot.Timestamp.Before(window.Start) || !snapshot.Timestamp.Before(end) {
+			continue
+		}
+		idx := int(snapshot.Timestamp.Sub(window.Start) / window.Bucket)
+		buckets[idx] = append(buckets[idx], snapshot)
+	}
+
+	labelFormat := chartLabelFormat(window)
+	result := FleetChartResult{BucketSeconds: int64(window.Bucket / time.Second)}
+	for i, bucket := range buckets {
+		result.Labels = append(result.Labels, window.Start.Add(time.Duration(i)*window.Bucket).Format(labelFormat))
+		if len(bucket) == 0 {
+			result.TotalSites = append(result.TotalSites, math.NaN())
+			result.OnlineSites = append(result.OnlineSites, math.NaN())
+			result.OfflineSites = append(result.OfflineSites, math.NaN())
+			result.DegradedSites = append(result.DegradedSites, math.NaN())
+			continue
+		}
+
+		worst := bucket[0]
+		for _, snapshot := range bucket[1:] {
+			worst.TotalSites = max(worst.TotalSites, snapshot.TotalSites)
+			worst.OnlineSites = min(worst.OnlineSites, snapshot.OnlineSites)
+			worst.OfflineSites = max(worst.OfflineSites, snapshot.OfflineSites)
+			worst.DegradedSites = max(worst.DegradedSites, snapshot.DegradedSites)
+		}
+		result.TotalSites = append(result.TotalSites, float64(worst.TotalSites))
+		result.OnlineSites = append(result.OnlineSites, float64(worst.OnlineSites))
+		result.OfflineSites = append(result.OfflineSites, float64(worst.OfflineSites))
+		result.DegradedSites = append(result.DegradedSites, float64(worst.DegradedSites))
+	}
+	return result, nil
+}
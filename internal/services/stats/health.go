@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"math"
+
+	"sitewatch/internal/models"
+)
+
+// Health score component limits. Each component scores 100 when healthy and
+// falls linearly to 0 at its limit.
+const (
+	// HealthDowntimeLimitFactor: uptime scores 0 at this multiple of the SLA's allowed downtime
+	HealthDowntimeLimitFactor = 10
+
+	// HealthDefaultLatencyTarget is used when the site has no max_latency SLA (ms)
+	HealthDefaultLatencyTarget = 100
+
+	// HealthPacketLossLimit is the packet loss (%) scoring 0
+	HealthPacketLossLimit = 10
+
+	// HealthJitterLimit is the jitter (ms) scoring 0
+	HealthJitterLimit = 30
+)
+
+// CalculateHealthScore combines the uptime, latency, packet loss and jitter of
+// a timeframe into a weighted 0-100 score:
+//
+//	score = Σ(weight × component) / Σ(weight)
+//
+//	uptime      100 while downtime is within the SLA allowance, 0 at
+//	            HealthDowntimeLimitFactor × the allowance
+//	latency     100 up to the max_latency SLA (or HealthDefaultLatencyTarget),
+//	            0 at twice that
+//	packet_loss 100 at 0%, 0 at HealthPacketLossLimit
+//	jitter      100 at 0 ms, 0 at HealthJitterLimit
+//
+// Latency and jitter are left out when the timeframe has no measurements, so
+// a site that is completely down is judged on uptime and packet loss alone.
+// Returns 0 when the timeframe has no checks.
+func CalculateHealthScore(site models.Site, ts *TimeframeStats, weights models.HealthScoreWeights) float64 {
+	if ts.TotalChecks == 0 {
+		return 0
+	}
+
+	var weighted, totalWeight float64
+	add := func(weight, score float64) {
+		weighted += weight * math.Max(0, math.Min(100, score))
+		totalWeight += weight
+	}
+
+	// Uptime relative to the SLA allowance
+	target := site.GetCombinedSLAUptime()
+	uptime := ts.GetUptimePercentage()
+	allowed := 100 - target
+	if allowed <= 0 {
+		add(weights.Uptime, uptime)
+	} else if downtime := 100 - uptime; downtime <= allowed {
+		add(weights.Uptime, 100)
+	} else {
+		limit := allowed * HealthDowntimeLimitFactor
+		add(weights.Uptime, 100*(limit-downtime)/(limit-allowed))
+	}
+
+	// Latency relative to the latency SLA
+	if ts.Latency.Count > 0 {
+		latencyTarget := float64(HealthDefaultLatencyTarget)
+		if maxLatency := site.GetPrimaryMaxLatency(); maxLatency != nil && *maxLatency > 0 {
+			latencyTarget = float64(*maxLatency)
+		}
+		if mean := ts.GetMeanLatency(); mean <= latencyTarget {
+			add(weights.Latency, 100)
+		} else {
+			add(weights.Latency, 100*(2*latencyTarget-mean)/latencyTarget)
+		}
+	}
+
+	add(weights.PacketLoss, 100*(1-ts.GetMeanPacketLoss()/HealthPacketLossLimit))
+
+	if ts.Jitter.Count > 0 {
+		add(weights.Jitter, 100*(1-ts.GetMeanJitter()/HealthJitterLimit))
+	}
+
+	if totalWeight == 0 {
+		return 0
+	}
+	return roundToDecimalPlaces(weighted/totalWeight, UptimePrecision)
+}
@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// HostChartResult is the load of the monitoring host over a time range,
+// bucketed like the time series charts of the sites so both can be overlaid.
+// CPU, load and memory are bucket means, network errors the bucket sum.
+type HostChartResult struct {
+	Labels            []string
+	CPUPercent        models.ChartSeries
+	Load1             models.ChartSeries
+	MemoryUsedPercent models.ChartSeries
+	NetworkErrors     models.ChartSeries
+	BucketSeconds     int64 `json:",omitempty"`
+}
+
+// GenerateHostChart generates the monitoring host chart for a preset range of
+// the time series charts, e.g. "24h"
+func GenerateHostChart(app *config.AppState, timeRange string) (HostChartResult, error) {
+	preset, ok := chartRangePresets[timeRange]
+	if !ok {
+		return HostChartResult{}, fmt.Errorf("unsupported range %q for host chart (expected one of %s)", timeRange, strings.Join(chartRanges["latency"], ", "))
+	}
+
+	now := clock.Default().Now().UTC()
+	window := ResolveChartWindow(now.Add(-preset.span), now, preset.resolution, app.Config.Display.MaxChartPoints)
+	end := window.Start.Add(time.Duration(window.Points) * window.Bucket)
+	samples, err := app.Storage.GetHostSamples(window.Start, end)
+	if err != nil {
+		return HostChartResult{}, err
+	}
+
+	buckets := make([][]models.HostSample, window.Points)
+	for _, sample := range samples {
+		if sample.Timestamp.Before(window.Start) || !sample.Timestamp.Before(end) {
+			continue
+		}
+		idx := int(sample.Timestamp.Sub(window.Start) / window.Bucket)
+		buckets[idx] = append(buckets[idx], sample)
+	}
+
+	labelFormat := chartLabelFormat(window)
+	result := HostChartResult{BucketSeconds: int64(window.Bucket / time.Second)}
+	for i, bucket := range buckets {
+		result.Labels = append(result.Labels, window.Start.Add(time.Duration(i)*window.Bucket).Format(labelFormat))
+		if len(bucket) == 0 {
+			result.CPUPercent = append(result.CPUPercent, math.NaN())
+			result.Load1 = append(result.Load1, math.NaN())
+			result.MemoryUsedPercent = append(result.MemoryUsedPercent, math.NaN())
+			result.NetworkErrors = append(result.NetworkErrors, math.NaN())
+			continue
+		}
+
+		var cpu, load, memory float64
+		var networkErrors int64
+		for _, sample := range bucket {
+			cpu += sample.CPUPercent
+			load += sample.Load1
+			memory += sample.MemoryUsedPercent
+			networkErrors += sample.NetworkErrors
+		}
+		count := float64(len(bucket))
+		result.CPUPercent = append(result.CPUPercent, cpu/count)
+		result.Load1 = append(result.Load1, load/count)
+		result.MemoryUsedPercent = append(result.MemoryUsedPercent, memory/count)
+		result.NetworkErrors = append(result.NetworkErrors, float64(networkErrors))
+	}
+	return result, nil
+}
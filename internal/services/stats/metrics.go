@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"strings"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/services/ping"
+	"sitewatch/internal/storage"
+)
+
+// UpdateDerivedMetrics recalculates the gauges that are not set by events:
+// 24h health score and uptime per site, stale sites, circuit breaker counts
+// and trip rates and storage size and buffer. Called by the metrics updater so scrapes only
+// read the last values.
+func UpdateDerivedMetrics(app *config.AppState) {
+	log := logger.Default().WithComponent("metrics")
+
+	if err := updateSiteMetrics(app); err != nil {
+		// Keep the previous values rather than publishing ones computed without data
+		log.Error("Failed to get logs for site metrics", "error", err)
+	}
+	config.StaleSitesGauge.Set(float64(countStaleSites(app, clock.Default().Now())))
+
+	counts := map[string]int{"closed": 0, "half-open": 0, "open": 0}
+	for _, breaker := range ping.GetGlobalCircuitBreakerManager().GetStats() {
+		counts[breaker.StateName]++
+		if breaker.TripsLastHour != nil {
+			// Breakers are named site/line, the line never contains a slash
+			sep := strings.LastIndex(breaker.Name, "/")
+			config.CircuitBreakerTripsPerHourGauge.WithLabelValues(breaker.Name[:sep], breaker.Name[sep+1:]).Set(float64(*breaker.TripsLastHour))
+		}
+	}
+	for state, count := range counts {
+		config.CircuitBreakersGauge.WithLabelValues(state).Set(float64(count))
+	}
+
+	config.StorageBufferedLogsGauge.Set(float64(app.StorageBufferedCount()))
+	if size, err := storage.SQLiteFileSize(app.Config.Storage.SQLitePath); err == nil {
+		config.StorageSizeBytesGauge.Set(float64(size))
+	} else {
+		log.Debug("Failed to get storage size", "path", app.Config.Storage.SQLitePath, "error", err)
+	}
+}
+
+// updateSiteMetrics recalculates the health score and line uptimes of every
+// site in one pass over the last 24 hours of logs
+func updateSiteMetrics(app *config.AppState) error {
+	sites := app.GetSitesSnapshot()
+	now := clock.Default().Now()
+	since := now.Add(-HoursPerDay * time.Hour)
+
+	logs, err := app.Storage.GetLogsInRange(since, now)
+	if err != nil {
+		return err
+	}
+
+	perSite := make(map[string]*TimeframeStats, len(sites))
+	for _, site := range sites {
+		perSite[site.ID] = NewTimeframeStatsExcluding(site.ExpectedOffline, checkExclusions(app))
+	}
+	for _, pingLog := range logs {
+		ts, exists := perSite[pingLog.SiteID]
+		if !exists || !pingLog.Timestamp.After(since) || validateLogData(pingLog) != nil {
+			continue
+		}
+		ts.AddLog(pingLog)
+	}
+
+	for _, site := range sites {
+		ts := perSite[site.ID]
+		config.SiteHealthScoreGauge.WithLabelValues(site.ID).Set(CalculateHealthScore(site, ts, app.Config.HealthScore.Weights))
+
+		// Lines without checks in the window have no uptime rather than 0%
+		for line, total := range map[string]int{"primary": ts.PrimaryTotal, "secondary": ts.SecondaryTotal} {
+			if total == 0 {
+				config.SiteUptime24hGauge.DeleteLabelValues(site.ID, line)
+				continue
+			}
+			config.SiteUptime24hGauge.WithLabelValues(site.ID, line).Set(ts.GetProviderUptime(line))
+		}
+	}
+	return nil
+}
+
+// countStaleSites returns the number of enabled sites whose last check is
+// older than watchdog.stall_multiplier of their intervals
+func countStaleSites(app *config.AppState, now time.Time) int {
+	multiplier := app.Config.Watchdog.StallMultiplier
+	if multiplier <= 0 {
+		multiplier = 3
+	}
+
+	statuses := app.GetSiteStatusSnapshot()
+	stale := 0
+	for _, site := range app.GetSitesSnapshot() {
+		status, exists := statuses[site.ID]
+		if !site.Enabled || !exists || status.LastCheck.IsZero() { // Not checked yet
+			continue
+		}
+		if now.Sub(status.LastCheck) > ping.IntervalAt(app, site, status.LastCheck)*time.Duration(multiplier) {
+			stale++
+		}
+	}
+	return stale
+}
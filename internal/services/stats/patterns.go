@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/services/jobs"
+)
+
+const (
+	// FailurePatternWindowDays is how far back recurring failures are searched
+	FailurePatternWindowDays = 30
+
+	// FailurePatternUpdateInterval controls how often failure patterns are recalculated
+	FailurePatternUpdateInterval = time.Hour
+)
+
+// lineOutage is a run of consecutive failed checks of one line
+type lineOutage struct {
+	start time.Time
+	end   time.Time // First successful check, or the last failure while ongoing
+}
+
+// patternSlot collects the outages starting in one hour of the day or week
+type patternSlot struct {
+	periods map[string]bool // Days (weekly: ISO weeks) with an outage in the slot
+	outages []lineOutage
+}
+
+// RegisterFailurePatternUpdater registers the job that recalculates the
+// recurring failure patterns of every site line, so statistics requests
+// only read the last result
+func RegisterFailurePatternUpdater(app *config.AppState) error {
+	return jobs.Register(jobs.Job{
+		Name:     "failure-patterns",
+		Interval: FailurePatternUpdateInterval,
+		Run: func(ctx context.Context) error {
+			return UpdateFailurePatterns(app)
+		},
+	})
+}
+
+// UpdateFailurePatterns finds the hours of the day, or of the week, in which
+// a line failed on at least failure_patterns.min_occurrences days (weeks)
+// over the last FailurePatternWindowDays, in one pass over the logs, and
+// stores them in the application state
+func UpdateFailurePatterns(app *config.AppState) error {
+	now := clock.Default().Now()
+	since := now.AddDate(0, 0, -FailurePatternWindowDays)
+
+	logs, err := app.Storage.GetLogsInRange(since, now)
+	if err != nil {
+		return err
+	}
+
+	sites := app.GetSitesSnapshot()
+	schedules := make(map[string]models.OfflineSchedule, len(sites))
+	for _, site := range sites {
+		schedules[site.ID] = site.ExpectedOffline
+	}
+
+	// Logs are in timestamp order, so outages are built in a single pass
+	outages := make(map[string][]lineOutage)
+	failures := make(map[string][]time.Time)
+	open := make(map[string]*lineOutage)
+	for _, pingLog := range logs {
+		schedule, exists := schedules[pingLog.SiteID]
+		if !exists || schedule.Contains(pingLog.Timestamp) {
+			continue
+		}
+		key := pingLog.SiteID + "/" + pingLog.Target
+		if pingLog.Success {
+			if outage := open[key]; outage != nil {
+				outage.end = pingLog.Timestamp
+				outages[key] = append(outages[key], *outage)
+				delete(open, key)
+			}
+			continue
+		}
+
+		failures[key] = append(failures[key], pingLog.Timestamp)
+		if outage := open[key]; outage != nil {
+			outage.end = pingLog.Timestamp
+		} else {
+			open[key] = &lineOutage{start: pingLog.Timestamp, end: pingLog.Timestamp}
+		}
+	}
+	for key, outage := range open {
+		outages[key] = append(outages[key], *outage)
+	}
+
+	minOccurrences := app.Config.FailurePatterns.MinOccurrences
+	patterns := make(map[string][]models.FailurePattern, len(sites))
+	count := 0
+	for _, site := range sites {
+		lines := []string{"primary"}
+		if site.IsDualLine() {
+			lines = append(lines, "secondary")
+		}
+
+		sitePatterns := []models.FailurePattern{}
+		for _, line := range lines {
+			other := "secondary"
+			if line == "secondary" {
+				other = "primary"
+			}
+			sitePatterns = append(sitePatterns, detectFailurePatterns(line, outages[site.ID+"/"+line],
+				site.IsDualLine(), failures[site.ID+"/"+other], minOccurrences, now.Location())...)
+		}
+		patterns[site.ID] = sitePatterns
+		count += len(sitePatterns)
+	}
+
+	app.SetFailurePatterns(patterns)
+	logger.Default().WithComponent("stats-patterns").Debug("Failure patterns updated", "patterns", count)
+	return nil
+}
+
+// sitePatterns returns the last failure patterns of a site, app.Mu must be held
+func sitePatterns(app *config.AppState, siteID string) []models.FailurePattern {
+	if patterns, exists := app.FailurePatterns[siteID]; exists {
+		return patterns
+	}
+	return []models.FailurePattern{}
+}
+
+// detectFailurePatterns groups the outages of a line by the hour of the day
+// and of the week they started in. An hour failing on minOccurrences days
+// is a daily pattern, else a weekday hour failing in minOccurrences weeks is
+// a weekly one. otherFailures are the failed checks of the other line of a
+// dual-line site.
+func detectFailurePatterns(line string, outages []lineOutage, dualLine bool, otherFailures []time.Time, minOccurrences int, loc *time.Location) []models.FailurePattern {
+	daily := make(map[int]*patternSlot)
+	weekly := make(map[[2]int]*patternSlot)
+	for _, outage := range outages {
+		start := outage.start.In(loc)
+		year, week := start.ISOWeek()
+
+		addToSlot(daily, start.Hour(), start.Format(time.DateOnly), outage)
+		addToSlot(weekly, [2]int{int(start.Weekday()), start.Hour()}, fmt.Sprintf("%d-W%02d", year, week), outage)
+	}
+
+	patterns := []models.FailurePattern{}
+	for hour := 0; hour < HoursPerDay; hour++ {
+		if slot := daily[hour]; slot != nil && len(slot.periods) >= minOccurrences {
+			patterns = append(patterns, failurePattern(line, "daily", "", hour, slot, dualLine, otherFailures))
+		}
+	}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		for hour := 0; hour < HoursPerDay; hour++ {
+			// A daily pattern already covers every weekday of its hour
+			if slot := daily[hour]; slot != nil && len(slot.periods) >= minOccurrences {
+				continue
+			}
+			if slot := weekly[[2]int{int(weekday), hour}]; slot != nil && len(slot.periods) >= minOccurrences {
+				patterns = append(patterns, failurePattern(line, "weekly", weekday.String(), hour, slot, dualLine, otherFailures))
+			}
+		}
+	}
+	return patterns
+}
+
+// addToSlot records an outage in the slot of key for period
+func addToSlot[K comparable](slots map[K]*patternSlot, key K, period string, outage lineOutage) {
+	slot := slots[key]
+	if slot == nil {
+		slot = &patternSlot{periods: make(map[string]bool)}
+		slots[key] = slot
+	}
+	slot.periods[period] = true
+	slot.outages = append(slot.outages, outage)
+}
+
+// failurePattern summarizes the outages of a slot. The pattern of a dual-line
+// site is asymmetric when the other line had no failed check during most of them.
+func failurePattern(line, recurrence, weekday string, hour int, slot *patternSlot, dualLine bool, otherFailures []time.Time) models.FailurePattern {
+	var total time.Duration
+	var lastSeen time.Time
+	overlapping := 0
+	for _, outage := range slot.outages {
+		total += outage.end.Sub(outage.start)
+		if outage.start.After(lastSeen) {
+			lastSeen = outage.start
+		}
+		// otherFailures is sorted, find the first failure not before the start
+		i := sort.Search(len(otherFailures), func(i int) bool { return !otherFailures[i].Before(outage.start) })
+		if i < len(otherFailures) && !otherFailures[i].After(outage.end) {
+			overlapping++
+		}
+	}
+
+	return models.FailurePattern{
+		Line:               line,
+		Recurrence:         recurrence,
+		Weekday:            weekday,
+		Window:             fmt.Sprintf("%02d:00-%02d:00", hour, (hour+1)%HoursPerDay),
+		Occurrences:        len(slot.periods),
+		AvgDurationSeconds: roundToDecimalPlaces((total / time.Duration(len(slot.outages))).Seconds(), 1),
+		LastSeen:           lastSeen,
+		Asymmetric:         dualLine && overlapping*2 < len(slot.outages),
+	}
+}
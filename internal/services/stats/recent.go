@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"strings"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/format"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+const (
+	// DefaultRecentChecksPerLine is the number of checks shown per line in the details view
+	DefaultRecentChecksPerLine = 20
+
+	// MaxRecentChecksPerLine caps the per_line query parameter
+	MaxRecentChecksPerLine = 200
+)
+
+// RecentChecks holds the most recent checks of a site split by line
+type RecentChecks struct {
+	Primary   []models.RecentCheck `json:"primary"`
+	Secondary []models.RecentCheck `json:"secondary"`
+}
+
+// GetRecentChecks returns the newest perLine checks for each line of a site
+func GetRecentChecks(app *config.AppState, siteID string, perLine int) RecentChecks {
+	result := RecentChecks{
+		Primary:   []models.RecentCheck{},
+		Secondary: []models.RecentCheck{},
+	}
+
+	logs, err := app.Storage.GetRecentLogsPerTarget(siteID, perLine)
+	if err != nil {
+		log := logger.Default().WithComponent("stats-recent").WithSite(siteID, "")
+		log.Error("Failed to get recent checks", "error", err)
+		return result
+	}
+
+	now := clock.Default().Now()
+	for _, pingLog := range logs {
+		check := models.RecentCheck{
+			Timestamp:  pingLog.Timestamp,
+			TimeAgo:    FormatTimeAgo(now.Sub(pingLog.Timestamp)),
+			Target:     pingLog.Target,
+			Success:    pingLog.Success,
+			Latency:    pingLog.Latency,
+			LatencyStr: "-",
+			PacketLoss: pingLog.PacketLoss,
+			LossStr:    "-",
+			ErrorCode:  ErrorCode(pingLog),
+			Error:      pingLog.Error,
+		}
+		if pingLog.Latency != nil {
+			check.LatencyStr = format.Latency(*pingLog.Latency)
+		}
+		if pingLog.PacketLoss != nil {
+			check.LossStr = format.Percent(*pingLog.PacketLoss)
+		}
+
+		if pingLog.Target == "secondary" {
+			result.Secondary = append(result.Secondary, check)
+		} else {
+			result.Primary = append(result.Primary, check)
+		}
+	}
+
+	return result
+}
+
+// ErrorCode maps a failed check's error message to a short, stable code
+func ErrorCode(pingLog models.PingLog) string {
+	if pingLog.Success {
+		return ""
+	}
+
+	msg := strings.ToLower(pingLog.Error)
+	switch {
+	case strings.HasPrefix(msg, "circuit breaker open"):
+		return "CIRCUIT_OPEN"
+	case strings.Contains(msg, "icmp may be blocked"):
+		return "ICMP_UNAVAILABLE"
+	case strings.HasPrefix(msg, "failed to create pinger"):
+		return "RESOLVE_FAILED"
+	case strings.HasPrefix(msg, "no packets received"):
+		return "TIMEOUT"
+	case strings.HasPrefix(msg, "ping failed"):
+		return "PING_ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// FormatTimeAgo formats an elapsed duration as a relative timestamp
+func FormatTimeAgo(d time.Duration) string {
+	return format.TimeAgo(i18n.DefaultLocale(), d)
+}
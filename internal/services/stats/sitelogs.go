@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"time"
+
+	"sitewatch/internal/models"
+)
+
+// siteLogs holds the logs of one site, filtered and bucketed by time in a
+// single pass over all logs. The statistics and every chart of the bundle are
+// computed from it instead of scanning all logs once per chart and bucket.
+type siteLogs struct {
+	siteID string
+	now    time.Time
+	logs   []models.PingLog // All logs of the site, in storage order
+
+	hourStarts  []time.Time        // DefaultChartDataPoints hours, oldest first
+	hourly      [][]models.PingLog // Logs per hour of hourStarts
+	dayStarts   []time.Time        // DaysPerWeek days, oldest first
+	daily       [][]models.PingLog // Logs per day of dayStarts
+	monthStarts []time.Time        // MonthsPerYear calendar months, oldest first
+	monthly     [][]models.PingLog // Logs per month of monthStarts
+}
+
+// newSiteLogs collects the logs of siteID and buckets them into the hours,
+// days and months before now that the charts show
+func newSiteLogs(allLogs []models.PingLog, siteID string, now time.Time) *siteLogs {
+	sl := &siteLogs{
+		siteID:      siteID,
+		now:         now,
+		hourStarts:  make([]time.Time, DefaultChartDataPoints),
+		hourly:      make([][]models.PingLog, DefaultChartDataPoints),
+		dayStarts:   make([]time.Time, DaysPerWeek),
+		daily:       make([][]models.PingLog, DaysPerWeek),
+		monthStarts: make([]time.Time, MonthsPerYear),
+		monthly:     make([][]models.PingLog, MonthsPerYear),
+	}
+
+	hourEnd := now.Truncate(time.Hour).Add(time.Hour)
+	for i := range sl.hourStarts {
+		sl.hourStarts[i] = hourEnd.Add(time.Duration(i-DefaultChartDataPoints) * time.Hour)
+	}
+	dayEnd := now.Truncate(HoursPerDay * time.Hour).Add(HoursPerDay * time.Hour)
+	for i := range sl.dayStarts {
+		sl.dayStarts[i] = dayEnd.Add(time.Duration(i-DaysPerWeek) * HoursPerDay * time.Hour)
+	}
+
+	// Month starts are derived like the charts always did, which can name a
+	// month twice at the end of long months, so a month maps to a list of buckets
+	monthBuckets := make(map[int][]int, MonthsPerYear)
+	for i := range sl.monthStarts {
+		monthStart := now.AddDate(0, i+1-MonthsPerYear, 0).Truncate(HoursPerDay * time.Hour)
+		monthStart = time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, monthStart.Location())
+		sl.monthStarts[i] = monthStart
+		key := monthKey(monthStart)
+		monthBuckets[key] = append(monthBuckets[key], i)
+	}
+
+	for _, pingLog := range allLogs {
+		if pingLog.SiteID != siteID {
+			continue
+		}
+		sl.logs = append(sl.logs, pingLog)
+
+		if idx, ok := bucketIndex(pingLog.Timestamp, sl.hourStarts[0], time.Hour, DefaultChartDataPoints); ok {
+			sl.hourly[idx] = append(sl.hourly[idx], pingLog)
+		}
+		if idx, ok := bucketIndex(pingLog.Timestamp, sl.dayStarts[0], HoursPerDay*time.Hour, DaysPerWeek); ok {
+			sl.daily[idx] = append(sl.daily[idx], pingLog)
+		}
+		for _, idx := range monthBuckets[monthKey(pingLog.Timestamp.In(now.Location()))] {
+			sl.monthly[idx] = append(sl.monthly[idx], pingLog)
+		}
+	}
+	return sl
+}
+
+// bucketIndex returns the bucket of t among count buckets of size width from start
+func bucketIndex(t, start time.Time, width time.Duration, count int) (int, bool) {
+	if t.Before(start) {
+		return 0, false
+	}
+	idx := int(t.Sub(start) / width)
+	return idx, idx < count
+}
+
+// monthKey identifies the calendar month of t
+func monthKey(t time.Time) int {
+	return t.Year()*12 + int(t.Month())
+}
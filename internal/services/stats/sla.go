@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/models"
+)
+
+const (
+	// SLAWindowHours is the rolling window SLA targets are evaluated against (30 days)
+	SLAWindowHours = 30 * HoursPerDay
+
+	// SLABurnRateWindow is the recent window used to measure the current burn rate
+	SLABurnRateWindow = HoursPerDay * time.Hour
+
+	// SLABreachImminentHours raises an SLABreachImminent event below this many hours
+	SLABreachImminentHours = 24
+
+	// EventSLABreachImminent is the RecentEvent status for predicted SLA breaches
+	EventSLABreachImminent = "sla_breach_imminent"
+)
+
+// SLABreachPrediction estimates when an SLA target will be missed at the current burn rate
+type SLABreachPrediction = models.SLABreachPrediction
+
+// PredictSLABreach extrapolates linearly from the current burn rate how long the
+// remaining error budget lasts. Returns nil when the burn rate is below 1, i.e.
+// the budget is being consumed within its allowance.
+func PredictSLABreach(actualUptime, targetUptime, burnRate float64, remainingBudgetHours float64) *SLABreachPrediction {
+	if burnRate < 1 {
+		return nil
+	}
+
+	allowedDowntime := (100 - targetUptime) / 100
+	if allowedDowntime <= 0 {
+		return nil
+	}
+
+	now := clock.Default().Now()
+
+	// Budget already exhausted - the breach is happening now
+	if remainingBudgetHours <= 0 || actualUptime < targetUptime {
+		return &SLABreachPrediction{
+			PredictedBreachAt: now,
+			HoursUntilBreach:  0,
+			Confidence:        1,
+		}
+	}
+
+	// At burn rate b, downtime accrues at b * allowedDowntime hours per hour
+	hoursUntilBreach := remainingBudgetHours / (burnRate * allowedDowntime)
+
+	return &SLABreachPrediction{
+		PredictedBreachAt: now.Add(time.Duration(hoursUntilBreach * float64(time.Hour))),
+		HoursUntilBreach:  roundToDecimalPlaces(hoursUntilBreach, UptimePrecision),
+		Confidence:        roundToDecimalPlaces(1-1/burnRate, UptimePrecision),
+	}
+}
+
+// calculateSLABreaches evaluates the error budget of each SLA target of a site
+func calculateSLABreaches(site models.Site, window, recent *TimeframeStats) []models.SLABreachStatus {
+	type slaLine struct {
+		name   string
+		target float64
+		actual float64
+		burn   float64
+		checks int
+	}
+
+	lines := []slaLine{
+		{"primary", site.GetPrimarySLAUptime(), window.GetProviderUptime("primary"), recent.GetProviderUptime("primary"), window.PrimaryTotal},
+	}
+	if site.IsDualLine() {
+		lines = append(lines,
+			slaLine{"secondary", site.GetSecondarySLAUptime(), window.GetProviderUptime("secondary"), recent.GetProviderUptime("secondary"), window.SecondaryTotal},
+			slaLine{"combined", site.GetCombinedSLAUptime(), window.GetUptimePercentage(), recent.GetUptimePercentage(), window.TotalChecks},
+		)
+	}
+
+	breaches := []models.SLABreachStatus{}
+	for _, line := range lines {
+		allowedDowntime := 100 - line.target
+		if line.checks == 0 || allowedDowntime <= 0 {
+			continue
+		}
+
+		budgetHours := allowedDowntime / 100 * SLAWindowHours
+		usedHours := (100 - line.actual) / 100 * SLAWindowHours
+		burnRate := (100 - line.burn) / allowedDowntime
+
+		status := models.SLABreachStatus{
+			Line:                 line.name,
+			TargetUptime:         line.target,
+			ActualUptime:         line.actual,
+			BurnRate:             roundToDecimalPlaces(burnRate, UptimePrecision),
+			RemainingBudgetHours: roundToDecimalPlaces(budgetHours-usedHours, UptimePrecision),
+			Breached:             line.actual < line.target,
+		}
+		status.SLABreachPrediction = PredictSLABreach(line.actual, line.target, burnRate, budgetHours-usedHours)
+
+		breaches = append(breaches, status)
+	}
+
+	return breaches
+}
+
+// SLABreachEvents returns SLABreachImminent events for targets predicted to
+// breach within SLABreachImminentHours, with messages localized for locale
+func SLABreachEvents(siteID string, breaches []models.SLABreachStatus, locale string) []models.RecentEvent {
+	var events []models.RecentEvent
+	for _, breach := range breaches {
+		prediction := breach.SLABreachPrediction
+		if prediction == nil || prediction.HoursUntilBreach >= SLABreachImminentHours {
+			continue
+		}
+
+		message := i18n.T(locale, "event.sla_breach_predicted",
+			lineLabel(locale, breach.Line), breach.TargetUptime,
+			FormatDurationIn(locale, time.Duration(prediction.HoursUntilBreach*float64(time.Hour))))
+		if breach.Breached {
+			message = i18n.T(locale, "event.sla_breached",
+				lineLabel(locale, breach.Line), breach.TargetUptime, breach.ActualUptime)
+		}
+
+		events = append(events, models.RecentEvent{
+			Timestamp: clock.Default().Now(),
+			Status:    EventSLABreachImminent,
+			Message:   message,
+			SiteID:    siteID,
+			Target:    breach.Line,
+			IsOutage:  false,
+		})
+	}
+	return events
+}
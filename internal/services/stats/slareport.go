@@ -0,0 +1,232 @@
+package stats
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/models"
+)
+
+// SLAReportMonthFormat is the layout of the month parameter of SLA reports
+const SLAReportMonthFormat = "2006-01"
+
+// ParseSLAReportMonth parses a YYYY-MM month, rejecting months that have not started yet
+func ParseSLAReportMonth(month string, now time.Time) (time.Time, error) {
+	start, err := time.ParseInLocation(SLAReportMonthFormat, month, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q (expected YYYY-MM)", month)
+	}
+	if start.After(now) {
+		return time.Time{}, fmt.Errorf("month %s has not started yet", month)
+	}
+	return start, nil
+}
+
+// GenerateSLAReport builds the SLA compliance report of a site for the
+// calendar month (UTC) starting at monthStart. Checks inside the expected
+// offline schedule of the site are excluded like in the live statistics.
+func GenerateSLAReport(app *config.AppState, site models.Site, monthStart time.Time, locale string) (models.SLAReport, error) {
+	now := clock.Default().Now().UTC()
+	periodEnd := slaReportPeriodEnd(monthStart, now)
+	logs, err := app.Storage.GetLogsInRange(monthStart, periodEnd)
+	if err != nil {
+		return models.SLAReport{}, fmt.Errorf("reading logs: %w", err)
+	}
+	report, _ := buildSLAReport(app, site, monthStart, periodEnd, now, logs, locale)
+	return report, nil
+}
+
+// GenerateMonthlyReport builds the SLA reports of the given sites for the
+// calendar month (UTC) starting at monthStart from a single read of the
+// logs, with the daily uptime of each site
+func GenerateMonthlyReport(app *config.AppState, sites []models.Site, monthStart time.Time, locale string) (models.MonthlyReport, error) {
+	now := clock.Default().Now().UTC()
+	periodEnd := slaReportPeriodEnd(monthStart, now)
+	logs, err := app.Storage.GetLogsInRange(monthStart, periodEnd)
+	if err != nil {
+		return models.MonthlyReport{}, fmt.Errorf("reading logs: %w", err)
+	}
+
+	monthly := models.MonthlyReport{
+		Month:       monthStart.Format(SLAReportMonthFormat),
+		PeriodStart: monthStart,
+		PeriodEnd:   periodEnd,
+		GeneratedAt: now,
+	}
+	for _, site := range sites {
+		report, siteLogs := buildSLAReport(app, site, monthStart, periodEnd, now, logs, locale)
+		monthly.Sites = append(monthly.Sites, models.MonthlyReportSite{
+			Report:      report,
+			DailyUptime: dailyUptime(app, site, monthStart, periodEnd, siteLogs),
+		})
+	}
+	return monthly, nil
+}
+
+// slaReportPeriodEnd returns the exclusive end of the report month, capped at now
+func slaReportPeriodEnd(monthStart, now time.Time) time.Time {
+	periodEnd := monthStart.AddDate(0, 1, 0)
+	if periodEnd.After(now) {
+		periodEnd = now
+	}
+	return periodEnd
+}
+
+// dailyUptime returns the uptime of a site for each day of the report
+// period, NaN for days without checks. siteLogs must be sorted by time.
+func dailyUptime(app *config.AppState, site models.Site, periodStart, periodEnd time.Time, siteLogs []models.PingLog) models.ChartSeries {
+	var series models.ChartSeries
+	i := 0
+	for day := periodStart; day.Before(periodEnd); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		ts := NewTimeframeStatsExcluding(site.ExpectedOffline, checkExclusions(app))
+		for ; i < len(siteLogs) && siteLogs[i].Timestamp.Before(next); i++ {
+			ts.AddLog(siteLogs[i])
+		}
+		if ts.TotalChecks == 0 {
+			series = append(series, math.NaN())
+			continue
+		}
+		series = append(series, ts.GetUptimePercentage())
+	}
+	return series
+}
+
+// buildSLAReport builds the report of a site from the logs of the report
+// period, which may hold other sites as well. Returns the logs of the site
+// sorted by time.
+func buildSLAReport(app *config.AppState, site models.Site, monthStart, periodEnd, now time.Time, logs []models.PingLog, locale string) (models.SLAReport, []models.PingLog) {
+	var siteLogs []models.PingLog
+	for _, pingLog := range logs {
+		if pingLog.SiteID == site.ID && !pingLog.Timestamp.Before(monthStart) && pingLog.Timestamp.Before(periodEnd) {
+			siteLogs = append(siteLogs, pingLog)
+		}
+	}
+	sort.Slice(siteLogs, func(i, j int) bool {
+		return siteLogs[i].Timestamp.Before(siteLogs[j].Timestamp)
+	})
+
+	ts := NewTimeframeStatsExcluding(site.ExpectedOffline, checkExclusions(app))
+	for _, pingLog := range siteLogs {
+		ts.AddLog(pingLog)
+	}
+
+	report := models.SLAReport{
+		Site:        site,
+		Month:       monthStart.Format(SLAReportMonthFormat),
+		PeriodStart: monthStart,
+		PeriodEnd:   periodEnd,
+		Incidents:   slaReportIncidents(siteLogs, site.ExpectedOffline, locale),
+		GeneratedAt: now,
+	}
+
+	span := periodEnd.Sub(monthStart)
+	addLine := func(line string, target, actual float64, checks int) {
+		downtime := time.Duration((100 - actual) / 100 * float64(span)).Round(time.Minute)
+		report.Lines = append(report.Lines, models.SLAReportLine{
+			Line:            line,
+			TargetUptime:    target,
+			ActualUptime:    actual,
+			TotalChecks:     checks,
+			Downtime:        FormatDurationIn(locale, downtime),
+			DowntimeMinutes: int(downtime / time.Minute),
+			Met:             checks > 0 && actual >= target,
+		})
+	}
+	addLine("primary", site.GetPrimarySLAUptime(), ts.GetProviderUptime("primary"), ts.PrimaryTotal)
+	report.Latency = append(report.Latency, slaReportLatency(ts, "primary"))
+	if site.IsDualLine() {
+		addLine("secondary", site.GetSecondarySLAUptime(), ts.GetProviderUptime("secondary"), ts.SecondaryTotal)
+		addLine("combined", site.GetCombinedSLAUptime(), ts.GetUptimePercentage(), ts.TotalChecks)
+		report.Latency = append(report.Latency, slaReportLatency(ts, "secondary"))
+	}
+
+	signSLAReport(&report, app.Config.Reports.SigningKey)
+	return report, siteLogs
+}
+
+// slaReportLatency summarizes the latency of one line for the report
+func slaReportLatency(ts *TimeframeStats, line string) models.SLAReportLatency {
+	latency := providerSample(line, &ts.PrimaryLatency, &ts.SecondaryLatency)
+	return models.SLAReportLatency{
+		Line:   line,
+		Mean:   roundToDecimalPlaces(latency.Mean(), LatencyPrecision),
+		P50:    ts.GetProviderLatencyPercentile(line, 50),
+		P95:    ts.GetProviderLatencyPercentile(line, 95),
+		P99:    ts.GetProviderLatencyPercentile(line, 99),
+		Min:    roundToDecimalPlaces(latency.Min, LatencyPrecision),
+		Max:    roundToDecimalPlaces(latency.Max, LatencyPrecision),
+		Jitter: ts.GetProviderMeanJitter(line),
+	}
+}
+
+// slaReportIncidents groups consecutive failed checks of each line into
+// incidents. logs must be sorted by time.
+func slaReportIncidents(logs []models.PingLog, schedule models.OfflineSchedule, locale string) []models.SLAReportIncident {
+	incidents := []models.SLAReportIncident{}
+	open := make(map[string]*models.SLAReportIncident)
+
+	closeIncident := func(target string, end time.Time, ongoing bool) {
+		incident := open[target]
+		incident.End = end
+		incident.Ongoing = ongoing
+		incident.Duration = FormatDurationIn(locale, end.Sub(incident.Start))
+		incidents = append(incidents, *incident)
+		delete(open, target)
+	}
+
+	lastFailure := make(map[string]time.Time)
+	for _, pingLog := range logs {
+		if schedule.Contains(pingLog.Timestamp) {
+			continue
+		}
+		_, down := open[pingLog.Target]
+		switch {
+		case !pingLog.Success && !down:
+			open[pingLog.Target] = &models.SLAReportIncident{Target: pingLog.Target, Start: pingLog.Timestamp}
+			lastFailure[pingLog.Target] = pingLog.Timestamp
+		case !pingLog.Success:
+			lastFailure[pingLog.Target] = pingLog.Timestamp
+		case down:
+			closeIncident(pingLog.Target, pingLog.Timestamp, false)
+		}
+	}
+	for target := range open {
+		closeIncident(target, lastFailure[target], true)
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].Start.Before(incidents[j].Start)
+	})
+	return incidents
+}
+
+// signSLAReport stores a digest of the report content and generation time:
+// an HMAC-SHA256 when a signing key is configured, else a plain SHA-256
+func signSLAReport(report *models.SLAReport, key string) {
+	report.Signature = ""
+	report.SignatureAlgorithm = ""
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+
+	if key != "" {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(payload)
+		report.Signature = hex.EncodeToString(mac.Sum(nil))
+		report.SignatureAlgorithm = "HMAC-SHA256"
+		return
+	}
+	sum := sha256.Sum256(payload)
+	report.Signature = hex.EncodeToString(sum[:])
+	report.SignatureAlgorithm = "SHA-256"
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Europe/Berlin for the DST test
+
+	"sitewatch/internal/models"
+	"sitewatch/internal/testutil"
+)
+
+// Checks around midnight of a month boundary belong to the month they fall
+// into, and the report of the running month ends at the clock time
+func TestSLAReportMonthBoundary(t *testing.T) {
+	site := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true}
+	boundary := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	logs := []models.PingLog{
+		checkLog(boundary.Add(-20*time.Minute), true),
+		checkLog(boundary.Add(-10*time.Minute), false),
+		checkLog(boundary.Add(-time.Nanosecond), false),
+		checkLog(boundary, true),
+		checkLog(boundary.Add(10*time.Minute), true),
+		checkLog(boundary.Add(20*time.Minute), true),
+		checkLog(boundary.Add(30*time.Minute), false),
+	}
+	now := boundary.Add(time.Hour)
+	appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{site}, Logs: logs, Now: now, Clock: true})
+
+	tests := []struct {
+		month     string
+		periodEnd time.Time
+		checks    int
+		uptime    float64
+		dailyDays int
+	}{
+		{"2024-01", boundary, 3, roundToDecimalPlaces(100.0/3, UptimePrecision), 31},
+		{"2024-02", now, 4, 75, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.month, func(t *testing.T) {
+			monthStart, err := ParseSLAReportMonth(tt.month, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := GenerateSLAReport(appState, site, monthStart, "en")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !report.PeriodEnd.Equal(tt.periodEnd) {
+				t.Errorf("period end %v, want %v", report.PeriodEnd, tt.periodEnd)
+			}
+			if !report.GeneratedAt.Equal(now) {
+				t.Errorf("generated at %v, want the clock time %v", report.GeneratedAt, now)
+			}
+			line := report.Lines[0]
+			if line.TotalChecks != tt.checks || line.ActualUptime != tt.uptime {
+				t.Errorf("%d checks with %.2f%% uptime, want %d with %.2f%%", line.TotalChecks, line.ActualUptime, tt.checks, tt.uptime)
+			}
+
+			monthly, err := GenerateMonthlyReport(appState, []models.Site{site}, monthStart, "en")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if days := len(monthly.Sites[0].DailyUptime); days != tt.dailyDays {
+				t.Errorf("%d days of daily uptime, want %d", days, tt.dailyDays)
+			}
+		})
+	}
+
+	if _, err := ParseSLAReportMonth("2024-03", now); err == nil {
+		t.Error("report of a month that has not started accepted")
+	}
+}
+
+// An expected offline window in a zone with daylight saving time follows the
+// local time across the switch, so the excluded UTC hour moves
+func TestSLAReportExpectedOfflineAcrossDST(t *testing.T) {
+	site := models.Site{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true,
+		ExpectedOffline: models.OfflineSchedule{{Start: "01:00", End: "02:00", Timezone: "Europe/Berlin"}},
+	}
+	// Berlin switches from CET (UTC+1) to CEST (UTC+2) at 2024-03-31 01:00 UTC
+	logs := []models.PingLog{
+		checkLog(time.Date(2024, 3, 30, 0, 30, 0, 0, time.UTC), false),  // 01:30 CET, offline window
+		checkLog(time.Date(2024, 3, 30, 23, 30, 0, 0, time.UTC), false), // 00:30 CET, counted
+		checkLog(time.Date(2024, 3, 31, 0, 30, 0, 0, time.UTC), false),  // 01:30 CET, offline window
+		checkLog(time.Date(2024, 3, 31, 1, 30, 0, 0, time.UTC), true),   // 03:30 CEST, counted
+		checkLog(time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC), false), // 01:30 CEST, offline window
+	}
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	appState := testutil.NewAppState(t, testutil.Options{Sites: []models.Site{site}, Logs: logs, Now: now, Clock: true})
+
+	report, err := GenerateSLAReport(appState, site, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := report.Lines[0]; line.TotalChecks != 2 || line.ActualUptime != 50 {
+		t.Errorf("%d checks with %.2f%% uptime, want 2 with 50%%", line.TotalChecks, line.ActualUptime)
+	}
+}
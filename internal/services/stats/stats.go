@@ -3,14 +3,19 @@ package stats
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
 	
 	"github.com/gofiber/fiber/v2"
+	"sitewatch/internal/clock"
 	"sitewatch/internal/config"
+	"sitewatch/internal/format"
+	"sitewatch/internal/i18n"
 	"sitewatch/internal/logger"
 	"sitewatch/internal/models"
+	"sitewatch/internal/services/ping"
 )
 
 // Constants for better maintainability
@@ -25,6 +30,15 @@ const (
 	DefaultChartDataPoints = 24
 	MaxChartDataPoints     = 100
 	
+	// Chart gap modes for buckets without data
+	ChartGapsDrop = "drop"
+	ChartGapsNull = "null"
+	
+	// Combined latency modes of dual-line sites
+	CombinedLatencyMin           = "min"
+	CombinedLatencyMean          = "mean"
+	CombinedLatencyBestAvailable = "best_available"
+	
 	// Latency distribution buckets in milliseconds
 	LatencyBucket1  = 10
 	LatencyBucket2  = 50
@@ -55,7 +69,9 @@ func validateLogData(pingLog models.PingLog) error {
 	return nil
 }
 
-// TimeframeStats holds statistics for a specific timeframe
+// TimeframeStats holds statistics for a specific timeframe. Values are
+// aggregated as logs are added instead of being stored, so the memory use
+// does not grow with the length of the timeframe.
 type TimeframeStats struct {
 	TotalChecks     int
 	SuccessChecks   int
@@ -65,46 +81,104 @@ type TimeframeStats struct {
 	SecondarySuccess int
 	
 	// Latency statistics
-	Latencies       []float64
-	MinLatency      float64
-	MaxLatency      float64
-	SumLatency      float64
-	JitterValues    []float64    // Jitter (standard deviation) values
+	Latency         SampleStats
+	Jitter          SampleStats  // Jitter (standard deviation) values
+	latencyBounds   []float64    // Upper bounds of the distribution buckets
+	latencyBuckets  []float64    // Latencies per distribution bucket
 	
 	// Provider-specific extended statistics
-	PrimaryMinLatencies []float64
-	PrimaryMaxLatencies []float64 
-	PrimaryJitterValues []float64
-	SecondaryMinLatencies []float64
-	SecondaryMaxLatencies []float64
-	SecondaryJitterValues []float64
+	PrimaryLatency      SampleStats
+	SecondaryLatency    SampleStats
+	PrimaryMinLatency   SampleStats // Per check minimum latencies
+	PrimaryMaxLatency   SampleStats // Per check maximum latencies
+	PrimaryJitter       SampleStats
+	SecondaryMinLatency SampleStats
+	SecondaryMaxLatency SampleStats
+	SecondaryJitter     SampleStats
+	primaryLatencySample   latencySample // For percentiles
+	secondaryLatencySample latencySample
 	
 	// Packet statistics  
 	TotalPacketsSent      int
 	TotalPacketsReceived  int
 	TotalPacketsDuplicates int
-	PacketLossValues      []float64
+	PacketLoss            SampleStats
 	
 	// Provider-specific packet stats
 	PrimaryPacketsSent       int
 	PrimaryPacketsReceived   int
 	PrimaryPacketsDuplicates int
-	PrimaryPacketLossValues  []float64
+	PrimaryPacketLoss        SampleStats
 	SecondaryPacketsSent     int
 	SecondaryPacketsReceived int
 	SecondaryPacketsDuplicates int
-	SecondaryPacketLossValues []float64
+	SecondaryPacketLoss      SampleStats
+	
+	// Checks skipped because the site was expected to be offline
+	ExpectedOfflineChecks int
+	expectedOffline       models.OfflineSchedule
+	
+	// Checks skipped because the circuit breaker blocked the probe or the
+	// address did not parse or resolve
+	CircuitOpenChecks int
+	ConfigErrorChecks int
+	exclude           CheckExclusions
+}
+
+// CheckExclusions are the checks left out of uptime besides the expected
+// offline schedule of a site
+type CheckExclusions struct {
+	CircuitOpen bool // ping.exclude_circuit_open_checks
+	ConfigError bool // ping.exclude_config_error_checks
+}
+
+// checkExclusions returns the check exclusions of the ping configuration
+func checkExclusions(app *config.AppState) CheckExclusions {
+	return CheckExclusions{
+		CircuitOpen: app.Config.Ping.ExcludeCircuitOpenChecks,
+		ConfigError: app.Config.Ping.ExcludeConfigErrorChecks,
+	}
 }
 
-// NewTimeframeStats creates a new TimeframeStats instance
+// NewTimeframeStats creates a new TimeframeStats instance with the default latency distribution buckets
 func NewTimeframeStats() *TimeframeStats {
+	return newTimeframeStatsBuckets(DefaultLatencyBuckets)
+}
+
+// newTimeframeStatsBuckets creates a TimeframeStats instance that counts the
+// latency distribution into the buckets defined by the ascending bounds
+func newTimeframeStatsBuckets(bounds []float64) *TimeframeStats {
 	return &TimeframeStats{
-		MinLatency: math.MaxFloat64,
+		latencyBounds:  bounds,
+		latencyBuckets: make([]float64, len(bounds)+1),
 	}
 }
 
+// NewTimeframeStatsExcluding creates a TimeframeStats instance that ignores
+// logs inside the expected offline schedule of a site and the checks that
+// were not probed as selected by exclude
+func NewTimeframeStatsExcluding(schedule models.OfflineSchedule, exclude CheckExclusions) *TimeframeStats {
+	ts := NewTimeframeStats()
+	ts.expectedOffline = schedule
+	ts.exclude = exclude
+	return ts
+}
+
 // AddLog processes a log entry for this timeframe
 func (ts *TimeframeStats) AddLog(log models.PingLog) {
+	if ts.expectedOffline.Contains(log.Timestamp) {
+		ts.ExpectedOfflineChecks++
+		return
+	}
+	if ts.exclude.CircuitOpen && log.CircuitOpen {
+		ts.CircuitOpenChecks++
+		return
+	}
+	if ts.exclude.ConfigError && log.ConfigError {
+		ts.ConfigErrorChecks++
+		return
+	}
+	
 	ts.TotalChecks++
 	
 	// Packet statistics (always collected)
@@ -112,7 +186,7 @@ func (ts *TimeframeStats) AddLog(log models.PingLog) {
 	ts.TotalPacketsReceived += log.PacketsRecv
 	ts.TotalPacketsDuplicates += log.PacketsDuplicates
 	if log.PacketLoss != nil {
-		ts.PacketLossValues = append(ts.PacketLossValues, *log.PacketLoss)
+		ts.PacketLoss.Add(*log.PacketLoss)
 	}
 	
 	if log.Success {
@@ -120,21 +194,13 @@ func (ts *TimeframeStats) AddLog(log models.PingLog) {
 		
 		// Add latency data if available
 		if log.Latency != nil {
-			latency := *log.Latency
-			ts.Latencies = append(ts.Latencies, latency)
-			ts.SumLatency += latency
-			
-			if latency < ts.MinLatency {
-				ts.MinLatency = latency
-			}
-			if latency > ts.MaxLatency {
-				ts.MaxLatency = latency
-			}
+			ts.Latency.Add(*log.Latency)
+			ts.latencyBuckets[sort.SearchFloat64s(ts.latencyBounds, *log.Latency)]++
 		}
 		
 		// Extended latency statistics
 		if log.Jitter != nil {
-			ts.JitterValues = append(ts.JitterValues, *log.Jitter)
+			ts.Jitter.Add(*log.Jitter)
 		}
 	}
 	
@@ -145,20 +211,24 @@ func (ts *TimeframeStats) AddLog(log models.PingLog) {
 		ts.PrimaryPacketsReceived += log.PacketsRecv
 		ts.PrimaryPacketsDuplicates += log.PacketsDuplicates
 		if log.PacketLoss != nil {
-			ts.PrimaryPacketLossValues = append(ts.PrimaryPacketLossValues, *log.PacketLoss)
+			ts.PrimaryPacketLoss.Add(*log.PacketLoss)
 		}
 		
 		if log.Success {
 			ts.PrimarySuccess++
 			// Provider-specific extended latency stats
+			if log.Latency != nil {
+				ts.PrimaryLatency.Add(*log.Latency)
+				ts.primaryLatencySample.add(*log.Latency)
+			}
 			if log.MinLatency != nil {
-				ts.PrimaryMinLatencies = append(ts.PrimaryMinLatencies, *log.MinLatency)
+				ts.PrimaryMinLatency.Add(*log.MinLatency)
 			}
 			if log.MaxLatency != nil {
-				ts.PrimaryMaxLatencies = append(ts.PrimaryMaxLatencies, *log.MaxLatency)
+				ts.PrimaryMaxLatency.Add(*log.MaxLatency)
 			}
 			if log.Jitter != nil {
-				ts.PrimaryJitterValues = append(ts.PrimaryJitterValues, *log.Jitter)
+				ts.PrimaryJitter.Add(*log.Jitter)
 			}
 		}
 	} else if log.Target == "secondary" {
@@ -167,20 +237,24 @@ func (ts *TimeframeStats) AddLog(log models.PingLog) {
 		ts.SecondaryPacketsReceived += log.PacketsRecv
 		ts.SecondaryPacketsDuplicates += log.PacketsDuplicates
 		if log.PacketLoss != nil {
-			ts.SecondaryPacketLossValues = append(ts.SecondaryPacketLossValues, *log.PacketLoss)
+			ts.SecondaryPacketLoss.Add(*log.PacketLoss)
 		}
 		
 		if log.Success {
 			ts.SecondarySuccess++
 			// Provider-specific extended latency stats
+			if log.Latency != nil {
+				ts.SecondaryLatency.Add(*log.Latency)
+				ts.secondaryLatencySample.add(*log.Latency)
+			}
 			if log.MinLatency != nil {
-				ts.SecondaryMinLatencies = append(ts.SecondaryMinLatencies, *log.MinLatency)
+				ts.SecondaryMinLatency.Add(*log.MinLatency)
 			}
 			if log.MaxLatency != nil {
-				ts.SecondaryMaxLatencies = append(ts.SecondaryMaxLatencies, *log.MaxLatency)
+				ts.SecondaryMaxLatency.Add(*log.MaxLatency)
 			}
 			if log.Jitter != nil {
-				ts.SecondaryJitterValues = append(ts.SecondaryJitterValues, *log.Jitter)
+				ts.SecondaryJitter.Add(*log.Jitter)
 			}
 		}
 	}
@@ -196,10 +270,7 @@ func (ts *TimeframeStats) GetUptimePercentage() float64 {
 
 // GetMeanLatency calculates mean latency for this timeframe
 func (ts *TimeframeStats) GetMeanLatency() float64 {
-	if len(ts.Latencies) == 0 {
-		return 0
-	}
-	return roundToDecimalPlaces(ts.SumLatency/float64(len(ts.Latencies)), LatencyPrecision)
+	return roundToDecimalPlaces(ts.Latency.Mean(), LatencyPrecision)
 }
 
 // GetProviderUptime calculates uptime percentage for a specific provider
@@ -221,212 +292,223 @@ func (ts *TimeframeStats) GetProviderUptime(provider string) float64 {
 }
 
 // GetProviderMeanLatency calculates mean latency for a specific provider
-func (ts *TimeframeStats) GetProviderMeanLatency(provider string, allLogs []models.PingLog, siteID string) float64 {
-	var sum float64
-	var count int
-	
-	for _, log := range allLogs {
-		if log.SiteID != siteID || !log.Success || log.Latency == nil || log.Target != provider {
-			continue
-		}
-		sum += *log.Latency
-		count++
-	}
-	
-	if count == 0 {
+func (ts *TimeframeStats) GetProviderMeanLatency(provider string) float64 {
+	values := providerSample(provider, &ts.PrimaryLatency, &ts.SecondaryLatency)
+	if values == nil {
 		return 0
 	}
-	return roundToDecimalPlaces(sum/float64(count), LatencyPrecision)
+	return roundToDecimalPlaces(values.Mean(), LatencyPrecision)
 }
 
 // GetMeanJitter calculates mean jitter across all measurements
 func (ts *TimeframeStats) GetMeanJitter() float64 {
-	if len(ts.JitterValues) == 0 {
-		return 0
-	}
-	sum := 0.0
-	for _, jitter := range ts.JitterValues {
-		sum += jitter
-	}
-	return roundToDecimalPlaces(sum/float64(len(ts.JitterValues)), LatencyPrecision)
+	return roundToDecimalPlaces(ts.Jitter.Mean(), LatencyPrecision)
 }
 
 // GetMeanPacketLoss calculates mean packet loss percentage
 func (ts *TimeframeStats) GetMeanPacketLoss() float64 {
-	if len(ts.PacketLossValues) == 0 {
-		return 0
-	}
-	sum := 0.0
-	for _, loss := range ts.PacketLossValues {
-		sum += loss
-	}
-	return roundToDecimalPlaces(sum/float64(len(ts.PacketLossValues)), UptimePrecision)
+	return roundToDecimalPlaces(ts.PacketLoss.Mean(), UptimePrecision)
 }
 
-// GetProviderMeanJitter calculates mean jitter for a specific provider
-func (ts *TimeframeStats) GetProviderMeanJitter(provider string) float64 {
-	var values []float64
+// providerSample returns the primary or secondary aggregate for provider, nil for an unknown provider
+func providerSample(provider string, primary, secondary *SampleStats) *SampleStats {
 	switch provider {
 	case "primary":
-		values = ts.PrimaryJitterValues
+		return primary
 	case "secondary":
-		values = ts.SecondaryJitterValues
-	default:
-		return 0
+		return secondary
 	}
-	
-	if len(values) == 0 {
+	return nil
+}
+
+// GetProviderMeanJitter calculates mean jitter for a specific provider
+func (ts *TimeframeStats) GetProviderMeanJitter(provider string) float64 {
+	values := providerSample(provider, &ts.PrimaryJitter, &ts.SecondaryJitter)
+	if values == nil {
 		return 0
 	}
-	sum := 0.0
-	for _, jitter := range values {
-		sum += jitter
-	}
-	return roundToDecimalPlaces(sum/float64(len(values)), LatencyPrecision)
+	return roundToDecimalPlaces(values.Mean(), LatencyPrecision)
 }
 
 // GetProviderMeanPacketLoss calculates mean packet loss for a specific provider
 func (ts *TimeframeStats) GetProviderMeanPacketLoss(provider string) float64 {
-	var values []float64
-	switch provider {
-	case "primary":
-		values = ts.PrimaryPacketLossValues
-	case "secondary":
-		values = ts.SecondaryPacketLossValues
-	default:
-		return 0
-	}
-	
-	if len(values) == 0 {
+	values := providerSample(provider, &ts.PrimaryPacketLoss, &ts.SecondaryPacketLoss)
+	if values == nil {
 		return 0
 	}
-	sum := 0.0
-	for _, loss := range values {
-		sum += loss
-	}
-	return roundToDecimalPlaces(sum/float64(len(values)), UptimePrecision)
+	return roundToDecimalPlaces(values.Mean(), UptimePrecision)
 }
 
-// GetProviderMinLatency calculates minimum latency for a specific provider
-func (ts *TimeframeStats) GetProviderMinLatency(provider string) float64 {
-	var values []float64
+// GetProviderPacketsDuplicates returns the duplicate packets received from a specific provider
+func (ts *TimeframeStats) GetProviderPacketsDuplicates(provider string) int {
 	switch provider {
 	case "primary":
-		values = ts.PrimaryMinLatencies
+		return ts.PrimaryPacketsDuplicates
 	case "secondary":
-		values = ts.SecondaryMinLatencies
-	default:
-		return 0
+		return ts.SecondaryPacketsDuplicates
 	}
-	
-	if len(values) == 0 {
+	return 0
+}
+
+// GetProviderMinLatency calculates minimum latency for a specific provider
+func (ts *TimeframeStats) GetProviderMinLatency(provider string) float64 {
+	values := providerSample(provider, &ts.PrimaryMinLatency, &ts.SecondaryMinLatency)
+	if values == nil || values.Count == 0 {
 		return 0
 	}
-	
-	min := values[0]
-	for _, latency := range values[1:] {
-		if latency < min {
-			min = latency
-		}
-	}
-	return roundToDecimalPlaces(min, LatencyPrecision)
+	return roundToDecimalPlaces(values.Min, LatencyPrecision)
 }
 
 // GetProviderMaxLatency calculates maximum latency for a specific provider
 func (ts *TimeframeStats) GetProviderMaxLatency(provider string) float64 {
-	var values []float64
+	values := providerSample(provider, &ts.PrimaryMaxLatency, &ts.SecondaryMaxLatency)
+	if values == nil || values.Count == 0 {
+		return 0
+	}
+	return roundToDecimalPlaces(values.Max, LatencyPrecision)
+}
+
+// GetProviderLatencyPercentile returns the latency below which p percent of
+// the checks of a provider fall, using the nearest-rank method. It is exact
+// up to LatencySampleSize checks and estimated from a sample beyond.
+func (ts *TimeframeStats) GetProviderLatencyPercentile(provider string, p float64) float64 {
 	switch provider {
 	case "primary":
-		values = ts.PrimaryMaxLatencies
+		return roundToDecimalPlaces(ts.primaryLatencySample.percentile(p), LatencyPrecision)
 	case "secondary":
-		values = ts.SecondaryMaxLatencies
-	default:
-		return 0
-	}
-	
-	if len(values) == 0 {
-		return 0
+		return roundToDecimalPlaces(ts.secondaryLatencySample.percentile(p), LatencyPrecision)
 	}
-	
-	max := values[0]
-	for _, latency := range values[1:] {
-		if latency > max {
-			max = latency
-		}
-	}
-	return roundToDecimalPlaces(max, LatencyPrecision)
+	return 0
 }
 
-// GetLatencyDistribution calculates latency distribution in predefined buckets
+// DefaultLatencyBuckets are the upper bounds of the predefined distribution buckets in milliseconds
+var DefaultLatencyBuckets = []float64{LatencyBucket1, LatencyBucket2, LatencyBucket3, LatencyBucket4, LatencyBucket5}
+
+// GetLatencyDistribution returns the latencies counted per bucket: one bucket
+// up to each of the ascending bounds (inclusive) and one above the last bound.
+// The default buckets are 0-10, 10-50, 50-100, 100-200, 200-500 and 500+.
 func (ts *TimeframeStats) GetLatencyDistribution() []float64 {
-	distribution := make([]float64, 6) // 6 buckets: 0-10, 10-50, 50-100, 100-200, 200-500, 500+
-	
-	for _, latency := range ts.Latencies {
-		var bucketIndex int
-		if latency <= LatencyBucket1 {
-			bucketIndex = 0
-		} else if latency <= LatencyBucket2 {
-			bucketIndex = 1
-		} else if latency <= LatencyBucket3 {
-			bucketIndex = 2
-		} else if latency <= LatencyBucket4 {
-			bucketIndex = 3
-		} else if latency <= LatencyBucket5 {
-			bucketIndex = 4
-		} else {
-			bucketIndex = 5
+	return append([]float64(nil), ts.latencyBuckets...)
+}
+
+// siteOfflineSchedule returns the expected offline schedule of a site.
+// Callers must hold app.Mu.
+func siteOfflineSchedule(app *config.AppState, siteID string) models.OfflineSchedule {
+	for _, site := range app.Sites {
+		if site.ID == siteID {
+			return site.ExpectedOffline
 		}
-		distribution[bucketIndex]++
 	}
-	
-	return distribution
+	return nil
 }
 
-// GetAllLogs returns all ping logs from storage
-func GetAllLogs(app *config.AppState) []models.PingLog {
-	if storageImpl, ok := app.Storage.(interface{ GetAllLogs() ([]models.PingLog, error) }); ok {
-		logs, err := storageImpl.GetAllLogs()
-		if err != nil {
-			log := logger.Default().WithComponent("stats-storage")
-			log.Error("Failed to get all logs from storage", "error", err)
-			return []models.PingLog{}
+// siteDualLine reports whether a site has a secondary line, app.Mu must be held
+func siteDualLine(app *config.AppState, siteID string) bool {
+	for _, site := range app.Sites {
+		if site.ID == siteID {
+			return site.IsDualLine()
 		}
-		return logs
 	}
-	return []models.PingLog{}
+	return false
+}
+
+// dropSecondarySeries removes the secondary series of a single-line site,
+// they are encoded as null instead of a line that is always down
+func dropSecondarySeries(chartData *models.ChartData) {
+	chartData.LatencyChartDataSecondary = nil
+	chartData.UptimeChartDataSecondary = nil
+	chartData.SLAChartDataSecondary = nil
+	chartData.DistributionSecondaryData = nil
+	chartData.YearlyUptimeDataSecondary = nil
+	chartData.PacketLossChartDataSecondary = nil
+	chartData.JitterChartDataSecondary = nil
+	chartData.LatencyMinChartDataSecondary = nil
+	chartData.LatencyMaxChartDataSecondary = nil
+}
+
+// GetAllLogs returns all ping logs from storage
+func GetAllLogs(app *config.AppState) ([]models.PingLog, error) {
+	logs, err := app.Storage.GetAllLogs()
+	if err != nil {
+		log := logger.Default().WithComponent("stats-storage")
+		log.Error("Failed to get all logs from storage", "error", err)
+		return nil, err
+	}
+	return logs, nil
 }
 
 // CalculateSiteStatistics calculates comprehensive statistics for a site
 func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteStatistics {
+	// Use UTC time to avoid timezone issues
+	return CalculateSiteStatisticsAt(app, siteID, clock.Default().Now().UTC())
+}
+
+// CalculateSiteStatisticsAt calculates the statistics of a site as of now,
+// the time windows (last hour, day, ...) end at now
+func CalculateSiteStatisticsAt(app *config.AppState, siteID string, now time.Time) models.SiteStatistics {
 	app.Mu.RLock()
 	defer app.Mu.RUnlock()
 	
-	// Use UTC time to avoid timezone issues
-	now := time.Now().UTC()
+	// Get all logs from storage, without them no statistic is meaningful
+	allLogs, err := GetAllLogs(app)
+	if err != nil {
+		return unavailableStatistics()
+	}
+	
+	return calculateSiteStatistics(app, newSiteLogs(allLogs, siteID, now))
+}
+
+// CalculateSiteDetails calculates the statistics and the selected charts of a
+// site from a single read of the logs, for pages that show both
+func CalculateSiteDetails(app *config.AppState, siteID string, charts ChartSelection) (models.SiteStatistics, models.ChartData) {
+	app.Mu.RLock()
+	defer app.Mu.RUnlock()
+	
+	allLogs, err := GetAllLogs(app)
+	if err != nil {
+		logNoChartLogs()
+		return unavailableStatistics(), models.ChartData{}
+	}
+	
+	sl := newSiteLogs(allLogs, siteID, clock.Default().Now().UTC())
+	statistics := calculateSiteStatistics(app, sl)
+	if len(allLogs) == 0 {
+		logNoChartLogs()
+		return statistics, models.ChartData{}
+	}
+	return statistics, generateChartData(app, sl, charts)
+}
+
+// unavailableStatistics marks the statistics of a site as unavailable
+func unavailableStatistics() models.SiteStatistics {
+	return models.SiteStatistics{Unavailable: true, SLABreaches: []models.SLABreachStatus{}, Patterns: []models.FailurePattern{}}
+}
+
+// calculateSiteStatistics aggregates the logs of a site, app.Mu must be held
+func calculateSiteStatistics(app *config.AppState, sl *siteLogs) models.SiteStatistics {
+	siteID := sl.siteID
+	now := sl.now
 	day24h := now.Add(-HoursPerDay * time.Hour)
 	day7d := now.Add(-DaysPerWeek * HoursPerDay * time.Hour)
 	month12 := now.AddDate(-1, 0, 0) // 12 months ago
+	slaWindow := now.Add(-SLAWindowHours * time.Hour)
 	
-	// Initialize timeframe statistics
+	// Initialize timeframe statistics, skipping the planned offline periods of the site
+	schedule := siteOfflineSchedule(app, siteID)
+	exclude := checkExclusions(app)
 	stats := map[string]*TimeframeStats{
-		"all": NewTimeframeStats(),
-		"24h": NewTimeframeStats(),
-		"7d":  NewTimeframeStats(),
-		"12m": NewTimeframeStats(),
+		"all": NewTimeframeStatsExcluding(schedule, exclude),
+		"24h": NewTimeframeStatsExcluding(schedule, exclude),
+		"7d":  NewTimeframeStatsExcluding(schedule, exclude),
+		"30d": NewTimeframeStatsExcluding(schedule, exclude),
+		"12m": NewTimeframeStatsExcluding(schedule, exclude),
 	}
 	
 	var lastIncidentTime time.Time
 	var lastIncidentDuration string
 	
-	// Get all logs from storage
-	allLogs := GetAllLogs(app)
-	
 	// Analyze ping logs in a single pass
-	for _, pingLog := range allLogs {
-		if pingLog.SiteID != siteID {
-			continue
-		}
-		
+	for _, pingLog := range sl.logs {
 		// Validate log data
 		if err := validateLogData(pingLog); err != nil {
 			log := logger.Default().WithComponent("stats").WithSite(siteID, "")
@@ -438,7 +520,7 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 		stats["all"].AddLog(pingLog)
 		
 		// Track failures for incident detection
-		if !pingLog.Success && pingLog.Timestamp.After(lastIncidentTime) {
+		if !pingLog.Success && pingLog.Timestamp.After(lastIncidentTime) && !schedule.Contains(pingLog.Timestamp) {
 			lastIncidentTime = pingLog.Timestamp
 		}
 		
@@ -450,6 +532,9 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 		if logTime.After(day7d) {
 			stats["7d"].AddLog(pingLog)
 		}
+		if logTime.After(slaWindow) {
+			stats["30d"].AddLog(pingLog)
+		}
 		if logTime.After(month12) {
 			stats["12m"].AddLog(pingLog)
 		}
@@ -464,10 +549,10 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 	// Calculate latency statistics
 	var avgLatency, minLatencyResult, maxLatencyResult float64
 	
-	if len(allStats.Latencies) > 0 {
+	if allStats.Latency.Count > 0 {
 		avgLatency = allStats.GetMeanLatency()
-		minLatencyResult = roundToDecimalPlaces(allStats.MinLatency, LatencyPrecision)
-		maxLatencyResult = roundToDecimalPlaces(allStats.MaxLatency, LatencyPrecision)
+		minLatencyResult = roundToDecimalPlaces(allStats.Latency.Min, LatencyPrecision)
+		maxLatencyResult = roundToDecimalPlaces(allStats.Latency.Max, LatencyPrecision)
 	} else {
 		minLatencyResult = 0
 		maxLatencyResult = 0
@@ -481,15 +566,10 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 	
 	// Format last incident
 	var lastIncident string
+	var lastIncidentAt *time.Time
 	if !lastIncidentTime.IsZero() {
-		diff := now.Sub(lastIncidentTime)
-		if diff < time.Hour {
-			lastIncident = fmt.Sprintf("%dm ago", int(diff.Minutes()))
-		} else if diff < HoursPerDay*time.Hour {
-			lastIncident = fmt.Sprintf("%dh ago", int(diff.Hours()))
-		} else {
-			lastIncident = fmt.Sprintf("%dd ago", int(diff.Hours()/HoursPerDay))
-		}
+		lastIncidentAt = &lastIncidentTime
+		lastIncident = FormatTimeAgo(now.Sub(lastIncidentTime))
 		// TODO: Implement proper incident duration tracking
 		lastIncidentDuration = "~5min" 
 	} else {
@@ -505,13 +585,66 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 	}
 	
 	// Calculate provider-specific mean latencies
-	meanLatencyPrimary := stats["all"].GetProviderMeanLatency("primary", allLogs, siteID)
-	meanLatencySecondary := stats["all"].GetProviderMeanLatency("secondary", allLogs, siteID)
+	meanLatencyPrimary := stats["all"].GetProviderMeanLatency("primary")
+	meanLatencySecondary := stats["all"].GetProviderMeanLatency("secondary")
+	
+	// Evaluate SLA error budgets against the configured targets
+	// and combine the last 24h into the health score
+	slaBreaches := []models.SLABreachStatus{}
+	var healthScore float64
+	lines := []string{"primary", "secondary"}
+	dualLine := false
+	for _, site := range app.Sites {
+		if site.ID == siteID {
+			dualLine = site.IsDualLine()
+			if !dualLine {
+				lines = lines[:1]
+			}
+			slaBreaches = calculateSLABreaches(site, stats["30d"], stats24h)
+			healthScore = CalculateHealthScore(site, stats24h, app.Config.HealthScore.Weights)
+			config.SiteHealthScoreGauge.WithLabelValues(siteID).Set(healthScore)
+			break
+		}
+	}
+	
+	// Summarize each line of the site
+	providerStats := make(map[string]models.ProviderSummary, len(lines))
+	for _, line := range lines {
+		providerStats[line] = models.ProviderSummary{
+			Uptime24h:        stats24h.GetProviderUptime(line),
+			Uptime7d:         stats7d.GetProviderUptime(line),
+			Uptime12m:        stats12m.GetProviderUptime(line),
+			MeanLatency:      allStats.GetProviderMeanLatency(line),
+			P95Latency:       allStats.GetProviderLatencyPercentile(line, 95),
+			MinLatency:       allStats.GetProviderMinLatency(line),
+			MaxLatency:       allStats.GetProviderMaxLatency(line),
+			Jitter:           allStats.GetProviderMeanJitter(line),
+			PacketLoss:       allStats.GetProviderMeanPacketLoss(line),
+			DuplicatePackets: float64(allStats.GetProviderPacketsDuplicates(line)),
+			Uptime24hInterval:  stats24h.GetProviderUptimeInterval(line),
+			Uptime7dInterval:   stats7d.GetProviderUptimeInterval(line),
+			Uptime12mInterval:  stats12m.GetProviderUptimeInterval(line),
+			PacketLossInterval: allStats.GetProviderPacketLossInterval(line),
+		}
+	}
+	
+	// Annotate the windows that reach back before monitoring started
+	var monitoredSince, firstSuccess *time.Time
+	var uptime7dNote, uptime12mNote string
+	if monitoring, exists := app.SiteMonitoring[siteID]; exists {
+		firstSeen := monitoring.FirstSeen
+		monitoredSince = &firstSeen
+		firstSuccess = monitoring.FirstSuccess
+		uptime7dNote = coverageNote(firstSeen, day7d, now)
+		uptime12mNote = coverageNote(firstSeen, month12, now)
+	}
 	
 	return models.SiteStatistics{
 		// Current latencies
 		CurrentLatencyPrimary:    currentLatencyPrimary,
 		CurrentLatencySecondary:  currentLatencySecondary,
+		DualLine:                 dualLine,
+		ProviderStats:            providerStats,
 		MeanLatencyPrimary:       meanLatencyPrimary,
 		MeanLatencySecondary:     meanLatencySecondary,
 		
@@ -537,6 +670,15 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 		Uptime24h:                stats24h.GetUptimePercentage(),
 		Uptime7d:                 stats7d.GetUptimePercentage(),
 		Uptime12m:                stats12m.GetUptimePercentage(),
+		Uptime7dNote:             uptime7dNote,
+		Uptime12mNote:            uptime12mNote,
+		Uptime24hInterval:        stats24h.GetUptimeInterval(),
+		Uptime7dInterval:         stats7d.GetUptimeInterval(),
+		Uptime12mInterval:        stats12m.GetUptimeInterval(),
+		
+		// Monitoring coverage
+		MonitoredSince:           monitoredSince,
+		FirstSuccess:             firstSuccess,
 		
 		// Provider-specific uptime (24h)
 		UptimePrimary:            stats24h.GetProviderUptime("primary"),
@@ -562,119 +704,169 @@ func CalculateSiteStatistics(app *config.AppState, siteID string) models.SiteSta
 		// Incident tracking
 		LastIncident:             lastIncident,
 		LastIncidentDuration:     lastIncidentDuration,
+		LastIncidentAt:           lastIncidentAt,
+		
+		// SLA error budgets
+		SLABreaches:              slaBreaches,
+		Patterns:                 sitePatterns(app, siteID),
+		
+		// Composite health
+		HealthScore:              healthScore,
 	}
 }
 
-// GenerateChartData generates chart data for a site with improved structure and error handling
-func GenerateChartData(app *config.AppState, siteID string) models.ChartData {
+// coverageNote describes how much data an uptime window is based on when
+// monitoring started inside it, e.g. "based on 3 days of data", and is empty
+// for a fully covered window
+func coverageNote(monitoredSince, windowStart, now time.Time) string {
+	if !monitoredSince.After(windowStart) {
+		return ""
+	}
+	covered := now.Sub(monitoredSince)
+	if days := int(covered.Hours() / HoursPerDay); days >= 1 {
+		return fmt.Sprintf("based on %d %s of data", days, plural(days, "day", "days"))
+	}
+	hours := int(covered.Hours())
+	if hours < 1 {
+		return "based on less than an hour of data"
+	}
+	return fmt.Sprintf("based on %d %s of data", hours, plural(hours, "hour", "hours"))
+}
+
+// plural returns one for n == 1 and many otherwise
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// GenerateChartData generates chart data for a site with improved structure and error handling.
+// charts selects the charts to compute, nil computes all of them.
+func GenerateChartData(app *config.AppState, siteID string, charts ChartSelection) models.ChartData {
 	app.Mu.RLock()
 	defer app.Mu.RUnlock()
 	
-	now := time.Now().UTC()
-	day24h := now.Add(-HoursPerDay * time.Hour)
-	
 	// Get all logs from storage
-	allLogs := GetAllLogs(app)
-	if len(allLogs) == 0 {
-		log := logger.Default().WithComponent("stats-chart")
-		log.Warn("No logs available for chart generation")
+	allLogs, err := GetAllLogs(app)
+	if err != nil || len(allLogs) == 0 {
+		logNoChartLogs()
 		return models.ChartData{}
 	}
+	return generateChartData(app, newSiteLogs(allLogs, siteID, clock.Default().Now().UTC()), charts)
+}
+
+// logNoChartLogs reports that the charts are empty for lack of logs
+func logNoChartLogs() {
+	log := logger.Default().WithComponent("stats-chart")
+	log.Warn("No logs available for chart generation")
+}
+
+// generateChartData computes the selected charts from the bucketed logs of a
+// site, app.Mu must be held
+func generateChartData(app *config.AppState, sl *siteLogs, charts ChartSelection) models.ChartData {
+	siteID := sl.siteID
+	day24h := sl.now.Add(-HoursPerDay * time.Hour)
+	gaps := app.Config.Display.ChartGaps
+	var chartData models.ChartData
+	
+	// Latency timeline (last 24h, hourly buckets)
+	if charts.Has("latency") {
+		latencyData := generateLatencyChart(sl, gaps, app.Config.Display.CombinedLatency)
+		chartData.LatencyChartLabels = latencyData.Labels
+		chartData.LatencyChartDataPrimary = latencyData.PrimaryData
+		chartData.LatencyChartDataSecondary = latencyData.SecondaryData
+		chartData.LatencyChartDataCombined = latencyData.CombinedData
+	}
+	
+	// Uptime overview (last 7 days, daily buckets)
+	if charts.Has("uptime") {
+		uptimeData := generateUptimeChart(sl)
+		chartData.UptimeChartLabels = uptimeData.Labels
+		chartData.UptimeChartData = uptimeData.CombinedData
+		chartData.UptimeChartDataPrimary = uptimeData.PrimaryData
+		chartData.UptimeChartDataSecondary = uptimeData.SecondaryData
+	}
 	
-	// Generate latency timeline (last 24h, hourly buckets)
-	latencyData := generateLatencyChart(allLogs, siteID, now, DefaultChartDataPoints)
+	// SLA comparison (last 12 months, monthly buckets)
+	if charts.Has("sla") {
+		slaData := generateSLAChart(sl, siteOfflineSchedule(app, siteID), checkExclusions(app))
+		chartData.SLAChartLabels = slaData.Labels
+		chartData.SLAChartDataPrimary = slaData.PrimaryData
+		chartData.SLAChartDataSecondary = slaData.SecondaryData
+	}
 	
-	// Generate uptime overview (last 7 days, daily buckets)
-	uptimeData := generateUptimeChart(allLogs, siteID, now, DaysPerWeek)
+	// Response time distribution (last 24h)
+	if charts.Has("distribution") {
+		distributionData := generateDistributionChart(sl, day24h)
+		chartData.DistributionChartLabels = distributionData.Labels
+		chartData.DistributionChartData = distributionData.CombinedData
+		chartData.DistributionPrimaryData = distributionData.PrimaryData
+		chartData.DistributionSecondaryData = distributionData.SecondaryData
+	}
 	
-	// Generate SLA comparison (last 12 months, monthly buckets)
-	slaData := generateSLAChart(allLogs, siteID, now, MonthsPerYear)
+	// Yearly uptime chart (last 12 months for SLA tracking)
+	if charts.Has("yearly") {
+		yearlyData := generateYearlyChart(sl)
+		chartData.YearlyUptimeLabels = yearlyData.Labels
+		chartData.YearlyUptimeData = yearlyData.CombinedData
+		chartData.YearlyUptimeDataPrimary = yearlyData.PrimaryData
+		chartData.YearlyUptimeDataSecondary = yearlyData.SecondaryData
+	}
 	
-	// Generate response time distribution (last 24h)
-	distributionData := generateDistributionChart(allLogs, siteID, day24h)
+	// Extended ping data charts (24h) - Packet Transmission Success Rate
+	if charts.Has("packet_transmission") {
+		packetTransmissionData := generatePacketTransmissionChart(sl, gaps)
+		chartData.PacketLossChartLabels = packetTransmissionData.Labels
+		chartData.PacketLossChartDataPrimary = packetTransmissionData.PrimaryData
+		chartData.PacketLossChartDataSecondary = packetTransmissionData.SecondaryData
+	}
 	
-	// Generate yearly uptime chart (last 12 months for SLA tracking)
-	yearlyData := generateYearlyChart(allLogs, siteID, now, MonthsPerYear)
+	if charts.Has("jitter") {
+		jitterData := generateJitterChart(sl, gaps)
+		chartData.JitterChartLabels = jitterData.Labels
+		chartData.JitterChartDataPrimary = jitterData.PrimaryData
+		chartData.JitterChartDataSecondary = jitterData.SecondaryData
+	}
 	
-	// Generate extended ping data charts
-	packetTransmissionData := generatePacketTransmissionChart(allLogs, siteID, now, DefaultChartDataPoints)
-	jitterData := generateJitterChart(allLogs, siteID, now, DefaultChartDataPoints)
-	minLatencyData, maxLatencyData := generateLatencyMinMaxChart(allLogs, siteID, now, DefaultChartDataPoints)
+	if charts.Has("latency_minmax") {
+		minLatencyData, maxLatencyData := generateLatencyMinMaxChart(sl)
+		chartData.LatencyMinMaxChartLabels = minLatencyData.Labels
+		chartData.LatencyMinChartDataPrimary = minLatencyData.PrimaryData
+		chartData.LatencyMinChartDataSecondary = minLatencyData.SecondaryData
+		chartData.LatencyMaxChartDataPrimary = maxLatencyData.PrimaryData
+		chartData.LatencyMaxChartDataSecondary = maxLatencyData.SecondaryData
+	}
 	
-	return models.ChartData{
-		// Latency timeline (24h)
-		LatencyChartLabels:        latencyData.Labels,
-		LatencyChartDataPrimary:   latencyData.PrimaryData,
-		LatencyChartDataSecondary: latencyData.SecondaryData,
-
-		// Uptime overview (7d)
-		UptimeChartLabels:        uptimeData.Labels,
-		UptimeChartData:          uptimeData.CombinedData,
-		UptimeChartDataPrimary:   uptimeData.PrimaryData,
-		UptimeChartDataSecondary: uptimeData.SecondaryData,
-
-		// SLA comparison (12m)
-		SLAChartLabels:        slaData.Labels,
-		SLAChartDataPrimary:   slaData.PrimaryData,
-		SLAChartDataSecondary: slaData.SecondaryData,
-
-		// Response time distribution (24h)
-		DistributionChartLabels:   distributionData.Labels,
-		DistributionChartData:     distributionData.CombinedData,
-		DistributionPrimaryData:   distributionData.PrimaryData,
-		DistributionSecondaryData: distributionData.SecondaryData,
-
-		// Yearly SLA tracking (365d)
-		YearlyUptimeLabels:        yearlyData.Labels,
-		YearlyUptimeData:          yearlyData.CombinedData,
-		YearlyUptimeDataPrimary:   yearlyData.PrimaryData,
-		YearlyUptimeDataSecondary: yearlyData.SecondaryData,
-		
-		// Extended ping data charts (24h) - Packet Transmission Success Rate
-		PacketLossChartLabels:        packetTransmissionData.Labels,
-		PacketLossChartDataPrimary:   packetTransmissionData.PrimaryData,
-		PacketLossChartDataSecondary: packetTransmissionData.SecondaryData,
-		
-		JitterChartLabels:        jitterData.Labels,
-		JitterChartDataPrimary:   jitterData.PrimaryData,
-		JitterChartDataSecondary: jitterData.SecondaryData,
-		
-		LatencyMinMaxChartLabels:        minLatencyData.Labels,
-		LatencyMinChartDataPrimary:      minLatencyData.PrimaryData,
-		LatencyMinChartDataSecondary:    minLatencyData.SecondaryData,
-		LatencyMaxChartDataPrimary:      maxLatencyData.PrimaryData,
-		LatencyMaxChartDataSecondary:    maxLatencyData.SecondaryData,
+	// Incident boundaries for the 24h charts
+	if charts.Has("incidents") {
+		chartData.IncidentMarkers = generateIncidentMarkers(sl, day24h, app.Config.Ping.TTLChangeThreshold)
+	}
+	
+	if !siteDualLine(app, siteID) {
+		dropSecondarySeries(&chartData)
 	}
+	
+	return chartData
 }
 
 // ChartDataResult represents structured chart data
 type ChartDataResult struct {
 	Labels        []string
-	CombinedData  []float64
-	PrimaryData   []float64
-	SecondaryData []float64
+	CombinedData  models.ChartSeries
+	PrimaryData   models.ChartSeries
+	SecondaryData models.ChartSeries
+	BucketSeconds int64 `json:",omitempty"` // Bucket size of time series charts
 }
 
 // generateLatencyChart generates latency chart data (hourly)
-func generateLatencyChart(allLogs []models.PingLog, siteID string, now time.Time, hours int) ChartDataResult {
+func generateLatencyChart(sl *siteLogs, gaps, combinedMode string) ChartDataResult {
 	var labels []string
-	var primaryLatencies, secondaryLatencies []float64
+	var primaryLatencies, secondaryLatencies, combinedLatencies []float64
 	
-	for i := hours - 1; i >= 0; i-- {
-		hourStart := now.Add(time.Duration(-i) * time.Hour).Truncate(time.Hour)
-		hourEnd := hourStart.Add(time.Hour)
-		
+	for i, hourStart := range sl.hourStarts {
 		labels = append(labels, hourStart.Format("15:04"))
-		
-		// Filter logs for this specific hour
-		var hourLogs []models.PingLog
-		for _, log := range allLogs {
-			if log.SiteID == siteID && !log.Timestamp.Before(hourStart) && log.Timestamp.Before(hourEnd) {
-				hourLogs = append(hourLogs, log)
-			}
-		}
-		
-
+		hourLogs := sl.hourly[i]
 		
 		// Calculate mean latencies for this hour only
 		var primarySum, secondarySum float64
@@ -692,7 +884,7 @@ func generateLatencyChart(allLogs []models.PingLog, siteID string, now time.Time
 			}
 		}
 		
-		var primaryMean, secondaryMean float64
+		primaryMean, secondaryMean := math.NaN(), math.NaN()
 		if primaryCount > 0 {
 			primaryMean = primarySum / float64(primaryCount)
 		}
@@ -702,6 +894,7 @@ func generateLatencyChart(allLogs []models.PingLog, siteID string, now time.Time
 		
 		primaryLatencies = append(primaryLatencies, primaryMean)
 		secondaryLatencies = append(secondaryLatencies, secondaryMean)
+		combinedLatencies = append(combinedLatencies, combineLatency(primaryMean, secondaryMean, combinedMode))
 	}
 	
 	// Add detailed debugging output
@@ -724,41 +917,42 @@ func generateLatencyChart(allLogs []models.PingLog, siteID string, now time.Time
 	// Get sample of actual logs for debugging
 	sampleLogCount := 0
 	var sampleLogTimes []string
-	for _, log := range allLogs {
-		if log.SiteID == siteID && sampleLogCount < 5 {
-			sampleLogTimes = append(sampleLogTimes, log.Timestamp.Format("2006-01-02 15:04:05 UTC"))
-			sampleLogCount++
+	for _, log := range sl.logs {
+		if sampleLogCount >= 5 {
+			break
 		}
+		sampleLogTimes = append(sampleLogTimes, log.Timestamp.Format("2006-01-02 15:04:05 UTC"))
+		sampleLogCount++
 	}
 	
 	log.Info("Generated hourly latency chart data", 
-		"site_id", siteID, 
-		"hours", hours,
-		"total_logs", len(allLogs),
+		"site_id", sl.siteID, 
+		"hours", len(sl.hourStarts),
+		"site_logs", len(sl.logs),
 		"labels_count", len(labels),
 		"primary_count", len(primaryLatencies),
 		"secondary_count", len(secondaryLatencies),
 		"non_zero_primary", nonZeroPrimary,
 		"non_zero_secondary", nonZeroSecondary,
-		"sample_primary_first", func() []float64 { 
+		"sample_primary_first", func() models.ChartSeries { 
 			if len(primaryLatencies) >= 3 { 
 				return primaryLatencies[:3] 
 			} 
 			return primaryLatencies 
 		}(),
-		"sample_primary_last", func() []float64 { 
+		"sample_primary_last", func() models.ChartSeries { 
 			if len(primaryLatencies) >= 3 { 
 				return primaryLatencies[len(primaryLatencies)-3:] 
 			} 
 			return primaryLatencies 
 		}(),
-		"sample_secondary_first", func() []float64 { 
+		"sample_secondary_first", func() models.ChartSeries { 
 			if len(secondaryLatencies) >= 3 { 
 				return secondaryLatencies[:3] 
 			} 
 			return secondaryLatencies 
 		}(),
-		"sample_secondary_last", func() []float64 { 
+		"sample_secondary_last", func() models.ChartSeries { 
 			if len(secondaryLatencies) >= 3 { 
 				return secondaryLatencies[len(secondaryLatencies)-3:] 
 			} 
@@ -771,134 +965,122 @@ func generateLatencyChart(allLogs []models.PingLog, siteID string, now time.Time
 			return labels 
 		}(),
 		"sample_log_times", sampleLogTimes,
-		"now_utc", now.Format("2006-01-02 15:04:05 UTC"))
+		"now_utc", sl.now.Format("2006-01-02 15:04:05 UTC"))
 	
 	// Filter out empty buckets to show only periods with real data
-	filteredResult := filterEmptyBuckets(labels, primaryLatencies, secondaryLatencies)
+	filteredResult := filterEmptyBucketsCombined(labels, primaryLatencies, secondaryLatencies, combinedLatencies, gaps)
 	
 	return filteredResult
 }
 
-// generateLatencyChartMinutely generates latency chart data with minute-level granularity
-func generateLatencyChartMinutely(allLogs []models.PingLog, siteID string, now time.Time, minutes int) ChartDataResult {
-	var labels []string
-	var primaryLatencies, secondaryLatencies []float64
-	
-	for i := minutes - 1; i >= 0; i-- {
-		minuteStart := now.Add(time.Duration(-i) * time.Minute).Truncate(time.Minute)
-		minuteEnd := minuteStart.Add(time.Minute)
-		
-		labels = append(labels, minuteStart.Format("15:04"))
-		
-		// Filter logs for this specific minute
-		var minuteLogs []models.PingLog
-		for _, log := range allLogs {
-			if log.SiteID == siteID && !log.Timestamp.Before(minuteStart) && log.Timestamp.Before(minuteEnd) {
-				minuteLogs = append(minuteLogs, log)
-			}
-		}
-		
-		// Calculate mean latencies for this minute
-		var primarySum, secondarySum float64
-		var primaryCount, secondaryCount int
-		
-		for _, log := range minuteLogs {
-			if log.Success && log.Latency != nil {
-				if log.Target == "primary" {
-					primarySum += *log.Latency
-					primaryCount++
-				} else if log.Target == "secondary" {
-					secondarySum += *log.Latency
-					secondaryCount++
-				}
-			}
-		}
-		
-		var primaryMean, secondaryMean float64
-		if primaryCount > 0 {
-			primaryMean = primarySum / float64(primaryCount)
-		}
-		if secondaryCount > 0 {
-			secondaryMean = secondarySum / float64(secondaryCount)
-		}
-		
-		primaryLatencies = append(primaryLatencies, primaryMean)
-		secondaryLatencies = append(secondaryLatencies, secondaryMean)
+// combineLatency merges the mean latencies of both lines in a bucket into the
+// latency users experience on a redundant link, NaN when neither line has
+// data: "min" takes the faster line, "mean" averages the lines with data and
+// "best_available" takes the primary line, or the secondary while the primary
+// has no data (active/backup failover)
+func combineLatency(primary, secondary float64, mode string) float64 {
+	switch {
+	case math.IsNaN(primary):
+		return secondary
+	case math.IsNaN(secondary):
+		return primary
+	}
+	
+	switch mode {
+	case CombinedLatencyMean:
+		return (primary + secondary) / 2
+	case CombinedLatencyBestAvailable:
+		return primary
+	default:
+		return math.Min(primary, secondary)
 	}
-	
-	// Filter out empty buckets to show only periods with real data
-	return filterEmptyBuckets(labels, primaryLatencies, secondaryLatencies)
 }
 
-// generateLatencyChart5Minutes generates latency chart data with 5-minute buckets
-func generateLatencyChart5Minutes(allLogs []models.PingLog, siteID string, now time.Time, periods int) ChartDataResult {
-	var labels []string
-	var primaryLatencies, secondaryLatencies []float64
-	
-	for i := periods - 1; i >= 0; i-- {
-		periodStart := now.Add(time.Duration(-i*5) * time.Minute).Truncate(5 * time.Minute)
-		periodEnd := periodStart.Add(5 * time.Minute)
-		
-		labels = append(labels, periodStart.Format("15:04"))
-		
-		// Filter logs for this 5-minute period
-		var periodLogs []models.PingLog
-		for _, log := range allLogs {
-			if log.SiteID == siteID && !log.Timestamp.Before(periodStart) && log.Timestamp.Before(periodEnd) {
-				periodLogs = append(periodLogs, log)
+// filterEmptyBuckets applies the chart gap mode to buckets without data, marked
+// as NaN. "drop" removes buckets that are empty on both lines and reports a
+// line without data as 0, "null" keeps every bucket so NaN is encoded as null
+// and charts show a break on the true time axis.
+// NOTE: 0 values are valid data (e.g. 0% packet loss), only NaN counts as empty
+func filterEmptyBuckets(labels []string, primaryData, secondaryData []float64, gaps string) ChartDataResult {
+	return filterEmptyBucketsCombined(labels, primaryData, secondaryData, nil, gaps)
+}
+
+// filterEmptyBucketsCombined is filterEmptyBuckets with a combined series,
+// which is kept aligned with the line series (nil for charts without one)
+func filterEmptyBucketsCombined(labels []string, primaryData, secondaryData, combinedData []float64, gaps string) ChartDataResult {
+	valueAt := func(data []float64, i int) float64 {
+		if i < len(data) {
+			return data[i]
+		}
+		return math.NaN()
+	}
+	
+	if gaps == ChartGapsNull {
+		result := ChartDataResult{Labels: labels}
+		for i := range labels {
+			result.PrimaryData = append(result.PrimaryData, valueAt(primaryData, i))
+			result.SecondaryData = append(result.SecondaryData, valueAt(secondaryData, i))
+			if combinedData != nil {
+				result.CombinedData = append(result.CombinedData, valueAt(combinedData, i))
 			}
 		}
-		
-		// Calculate mean latencies for this 5-minute period
-		var primarySum, secondarySum float64
-		var primaryCount, secondaryCount int
-		
-		for _, log := range periodLogs {
-			if log.Success && log.Latency != nil {
-				if log.Target == "primary" {
-					primarySum += *log.Latency
-					primaryCount++
-				} else if log.Target == "secondary" {
-					secondarySum += *log.Latency
-					secondaryCount++
-				}
-			}
+		return result
+	}
+	
+	var filteredLabels []string
+	var filteredPrimary, filteredSecondary, filteredCombined []float64
+	
+	// Keep buckets that have data in at least one line (including 0 values)
+	for i := 0; i < len(labels); i++ {
+		primary, secondary := valueAt(primaryData, i), valueAt(secondaryData, i)
+		if math.IsNaN(primary) && math.IsNaN(secondary) {
+			continue
 		}
-		
-		var primaryMean, secondaryMean float64
-		if primaryCount > 0 {
-			primaryMean = primarySum / float64(primaryCount)
+		filteredLabels = append(filteredLabels, labels[i])
+		filteredPrimary = append(filteredPrimary, zeroIfNaN(primary))
+		filteredSecondary = append(filteredSecondary, zeroIfNaN(secondary))
+		if combinedData != nil {
+			filteredCombined = append(filteredCombined, zeroIfNaN(valueAt(combinedData, i)))
 		}
-		if secondaryCount > 0 {
-			secondaryMean = secondarySum / float64(secondaryCount)
+	}
+	
+	// Fallback: if no data found, keep at least the last bucket to avoid empty charts
+	if len(filteredLabels) == 0 && len(labels) > 0 {
+		filteredLabels = append(filteredLabels, labels[len(labels)-1])
+		filteredPrimary = append(filteredPrimary, 0)
+		filteredSecondary = append(filteredSecondary, 0)
+		if combinedData != nil {
+			filteredCombined = append(filteredCombined, 0)
 		}
-		
-		primaryLatencies = append(primaryLatencies, primaryMean)
-		secondaryLatencies = append(secondaryLatencies, secondaryMean)
 	}
 	
-	// Filter out empty buckets to show only periods with real data
-	return filterEmptyBuckets(labels, primaryLatencies, secondaryLatencies)
+	return ChartDataResult{
+		Labels:        filteredLabels,
+		PrimaryData:   filteredPrimary,
+		SecondaryData: filteredSecondary,
+		CombinedData:  filteredCombined,
+	}
+}
+
+// zeroIfNaN reports a bucket without data as 0
+func zeroIfNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return v
 }
 
-// generatePacketTransmissionChartMinutely generates packet transmission success rate data with minute-level granularity  
-func generatePacketTransmissionChartMinutely(allLogs []models.PingLog, siteID string, now time.Time, minutes int) ChartDataResult {
+// generatePacketTransmissionChart generates packet transmission chart data showing sent vs received packets
+func generatePacketTransmissionChart(sl *siteLogs, gaps string) ChartDataResult {
 	var labels []string
 	var primarySuccess, secondarySuccess []float64
 	
-	for i := minutes - 1; i >= 0; i-- {
-		minuteStart := now.Add(time.Duration(-i) * time.Minute).Truncate(time.Minute)
-		minuteEnd := minuteStart.Add(time.Minute)
-		
-		labels = append(labels, minuteStart.Format("15:04"))
+	for i, hourStart := range sl.hourStarts {
+		labels = append(labels, hourStart.Format("15:04"))
 		
 		var primarySent, primaryReceived, secondarySent, secondaryReceived int
 		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(minuteStart) || !log.Timestamp.Before(minuteEnd) {
-				continue
-			}
-			
+		for _, log := range sl.hourly[i] {
 			if log.Target == "primary" {
 				primarySent += log.PacketsSent
 				primaryReceived += log.PacketsRecv
@@ -909,7 +1091,7 @@ func generatePacketTransmissionChartMinutely(allLogs []models.PingLog, siteID st
 		}
 		
 		// Calculate success rate as percentage of received vs sent packets
-		var primarySuccessRate, secondarySuccessRate float64
+		primarySuccessRate, secondarySuccessRate := math.NaN(), math.NaN()
 		if primarySent > 0 {
 			primarySuccessRate = (float64(primaryReceived) / float64(primarySent)) * 100
 		}
@@ -921,256 +1103,21 @@ func generatePacketTransmissionChartMinutely(allLogs []models.PingLog, siteID st
 		secondarySuccess = append(secondarySuccess, secondarySuccessRate)
 	}
 	
-	return filterEmptyBuckets(labels, primarySuccess, secondarySuccess)
+	return filterEmptyBuckets(labels, primarySuccess, secondarySuccess, gaps)
 }
 
-// generatePacketTransmissionChart5Minutes generates packet transmission success rate data with 5-minute buckets
-func generatePacketTransmissionChart5Minutes(allLogs []models.PingLog, siteID string, now time.Time, periods int) ChartDataResult {
+// generateJitterChart generates jitter chart data
+func generateJitterChart(sl *siteLogs, gaps string) ChartDataResult {
 	var labels []string
-	var primarySuccess, secondarySuccess []float64
+	var primaryJitter, secondaryJitter []float64
 	
-	for i := periods - 1; i >= 0; i-- {
-		periodStart := now.Add(time.Duration(-i*5) * time.Minute).Truncate(5 * time.Minute)
-		periodEnd := periodStart.Add(5 * time.Minute)
-		
-		labels = append(labels, periodStart.Format("15:04"))
-		
-		var primarySent, primaryReceived, secondarySent, secondaryReceived int
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(periodStart) || !log.Timestamp.Before(periodEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" {
-				primarySent += log.PacketsSent
-				primaryReceived += log.PacketsRecv
-			} else if log.Target == "secondary" {
-				secondarySent += log.PacketsSent
-				secondaryReceived += log.PacketsRecv
-			}
-		}
-		
-		// Calculate success rate as percentage of received vs sent packets
-		var primarySuccessRate, secondarySuccessRate float64
-		if primarySent > 0 {
-			primarySuccessRate = (float64(primaryReceived) / float64(primarySent)) * 100
-		}
-		if secondarySent > 0 {
-			secondarySuccessRate = (float64(secondaryReceived) / float64(secondarySent)) * 100
-		}
-		
-		primarySuccess = append(primarySuccess, primarySuccessRate)
-		secondarySuccess = append(secondarySuccess, secondarySuccessRate)
-	}
-	
-	return filterEmptyBuckets(labels, primarySuccess, secondarySuccess)
-}
-
-// generateJitterChartMinutely generates jitter chart data with minute-level granularity
-func generateJitterChartMinutely(allLogs []models.PingLog, siteID string, now time.Time, minutes int) ChartDataResult {
-	var labels []string
-	var primaryJitter, secondaryJitter []float64
-	
-	for i := minutes - 1; i >= 0; i-- {
-		minuteStart := now.Add(time.Duration(-i) * time.Minute).Truncate(time.Minute)
-		minuteEnd := minuteStart.Add(time.Minute)
-		
-		labels = append(labels, minuteStart.Format("15:04"))
-		
-		var primaryJitterSum, secondaryJitterSum float64
-		var primaryCount, secondaryCount int
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(minuteStart) || !log.Timestamp.Before(minuteEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" && log.Jitter != nil {
-				primaryJitterSum += *log.Jitter
-				primaryCount++
-			} else if log.Target == "secondary" && log.Jitter != nil {
-				secondaryJitterSum += *log.Jitter
-				secondaryCount++
-			}
-		}
-		
-		if primaryCount > 0 {
-			primaryJitter = append(primaryJitter, primaryJitterSum/float64(primaryCount))
-		} else {
-			primaryJitter = append(primaryJitter, 0)
-		}
-		
-		if secondaryCount > 0 {
-			secondaryJitter = append(secondaryJitter, secondaryJitterSum/float64(secondaryCount))
-		} else {
-			secondaryJitter = append(secondaryJitter, 0)
-		}
-	}
-	
-	return filterEmptyBuckets(labels, primaryJitter, secondaryJitter)
-}
-
-// generateJitterChart5Minutes generates jitter chart data with 5-minute buckets
-func generateJitterChart5Minutes(allLogs []models.PingLog, siteID string, now time.Time, periods int) ChartDataResult {
-	var labels []string
-	var primaryJitter, secondaryJitter []float64
-	
-	for i := periods - 1; i >= 0; i-- {
-		periodStart := now.Add(time.Duration(-i*5) * time.Minute).Truncate(5 * time.Minute)
-		periodEnd := periodStart.Add(5 * time.Minute)
-		
-		labels = append(labels, periodStart.Format("15:04"))
-		
-		var primaryJitterSum, secondaryJitterSum float64
-		var primaryCount, secondaryCount int
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(periodStart) || !log.Timestamp.Before(periodEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" && log.Jitter != nil {
-				primaryJitterSum += *log.Jitter
-				primaryCount++
-			} else if log.Target == "secondary" && log.Jitter != nil {
-				secondaryJitterSum += *log.Jitter
-				secondaryCount++
-			}
-		}
-		
-		if primaryCount > 0 {
-			primaryJitter = append(primaryJitter, primaryJitterSum/float64(primaryCount))
-		} else {
-			primaryJitter = append(primaryJitter, 0)
-		}
-		
-		if secondaryCount > 0 {
-			secondaryJitter = append(secondaryJitter, secondaryJitterSum/float64(secondaryCount))
-		} else {
-			secondaryJitter = append(secondaryJitter, 0)
-		}
-	}
-	
-	return filterEmptyBuckets(labels, primaryJitter, secondaryJitter)
-}
-
-// filterEmptyBuckets removes time buckets that have no data for any line
-// NOTE: 0 values are considered valid data (e.g. 0% packet loss), only filter truly empty buckets
-func filterEmptyBuckets(labels []string, primaryData, secondaryData []float64) ChartDataResult {
-	var filteredLabels []string
-	var filteredPrimary, filteredSecondary []float64
-	
-	// Keep buckets that have data in at least one line (including 0 values)
-	for i := 0; i < len(labels); i++ {
-		hasPrimaryData := i < len(primaryData)
-		hasSecondaryData := i < len(secondaryData)
-		
-		// Include bucket if we have data for either line (even if value is 0)
-		if hasPrimaryData || hasSecondaryData {
-			filteredLabels = append(filteredLabels, labels[i])
-			
-			if i < len(primaryData) {
-				filteredPrimary = append(filteredPrimary, primaryData[i])
-			} else {
-				filteredPrimary = append(filteredPrimary, 0)
-			}
-			
-			if i < len(secondaryData) {
-				filteredSecondary = append(filteredSecondary, secondaryData[i])
-			} else {
-				filteredSecondary = append(filteredSecondary, 0)
-			}
-		}
-	}
-	
-	// Fallback: if no data found, keep at least the last bucket to avoid empty charts
-	if len(filteredLabels) == 0 && len(labels) > 0 {
-		lastIdx := len(labels) - 1
-		filteredLabels = append(filteredLabels, labels[lastIdx])
-		
-		if lastIdx < len(primaryData) {
-			filteredPrimary = append(filteredPrimary, primaryData[lastIdx])
-		} else {
-			filteredPrimary = append(filteredPrimary, 0)
-		}
-		
-		if lastIdx < len(secondaryData) {
-			filteredSecondary = append(filteredSecondary, secondaryData[lastIdx])
-		} else {
-			filteredSecondary = append(filteredSecondary, 0)
-		}
-	}
-	
-	return ChartDataResult{
-		Labels:        filteredLabels,
-		PrimaryData:   filteredPrimary,
-		SecondaryData: filteredSecondary,
-	}
-}
-
-// generatePacketTransmissionChart generates packet transmission chart data showing sent vs received packets
-func generatePacketTransmissionChart(allLogs []models.PingLog, siteID string, now time.Time, hours int) ChartDataResult {
-	var labels []string
-	var primarySuccess, secondarySuccess []float64
-	
-	for i := hours - 1; i >= 0; i-- {
-		hourStart := now.Add(time.Duration(-i) * time.Hour).Truncate(time.Hour)
-		hourEnd := hourStart.Add(time.Hour)
-		
-		labels = append(labels, hourStart.Format("15:04"))
-		
-		var primarySent, primaryReceived, secondarySent, secondaryReceived int
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(hourStart) || !log.Timestamp.Before(hourEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" {
-				primarySent += log.PacketsSent
-				primaryReceived += log.PacketsRecv
-			} else if log.Target == "secondary" {
-				secondarySent += log.PacketsSent
-				secondaryReceived += log.PacketsRecv
-			}
-		}
-		
-		// Calculate success rate as percentage of received vs sent packets
-		var primarySuccessRate, secondarySuccessRate float64
-		if primarySent > 0 {
-			primarySuccessRate = (float64(primaryReceived) / float64(primarySent)) * 100
-		}
-		if secondarySent > 0 {
-			secondarySuccessRate = (float64(secondaryReceived) / float64(secondarySent)) * 100
-		}
-		
-		primarySuccess = append(primarySuccess, primarySuccessRate)
-		secondarySuccess = append(secondarySuccess, secondarySuccessRate)
-	}
-	
-	return filterEmptyBuckets(labels, primarySuccess, secondarySuccess)
-}
-
-// generateJitterChart generates jitter chart data
-func generateJitterChart(allLogs []models.PingLog, siteID string, now time.Time, hours int) ChartDataResult {
-	var labels []string
-	var primaryJitter, secondaryJitter []float64
-	
-	for i := hours - 1; i >= 0; i-- {
-		hourStart := now.Add(time.Duration(-i) * time.Hour).Truncate(time.Hour)
-		hourEnd := hourStart.Add(time.Hour)
-		
+	for i, hourStart := range sl.hourStarts {
 		labels = append(labels, hourStart.Format("15:04"))
 		
 		var primaryJitterSum, secondaryJitterSum float64
 		var primaryCount, secondaryCount int
 		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(hourStart) || !log.Timestamp.Before(hourEnd) {
-				continue
-			}
-			
+		for _, log := range sl.hourly[i] {
 			if log.Target == "primary" && log.Jitter != nil {
 				primaryJitterSum += *log.Jitter
 				primaryCount++
@@ -1183,39 +1130,32 @@ func generateJitterChart(allLogs []models.PingLog, siteID string, now time.Time,
 		if primaryCount > 0 {
 			primaryJitter = append(primaryJitter, primaryJitterSum/float64(primaryCount))
 		} else {
-			primaryJitter = append(primaryJitter, 0)
+			primaryJitter = append(primaryJitter, math.NaN())
 		}
 		
 		if secondaryCount > 0 {
 			secondaryJitter = append(secondaryJitter, secondaryJitterSum/float64(secondaryCount))
 		} else {
-			secondaryJitter = append(secondaryJitter, 0)
+			secondaryJitter = append(secondaryJitter, math.NaN())
 		}
 	}
 	
 	// Filter out empty buckets to show only periods with real data
-	return filterEmptyBuckets(labels, primaryJitter, secondaryJitter)
+	return filterEmptyBuckets(labels, primaryJitter, secondaryJitter, gaps)
 }
 
 // generateLatencyMinMaxChart generates min/max latency chart data
-func generateLatencyMinMaxChart(allLogs []models.PingLog, siteID string, now time.Time, hours int) (ChartDataResult, ChartDataResult) {
+func generateLatencyMinMaxChart(sl *siteLogs) (ChartDataResult, ChartDataResult) {
 	var labels []string
 	var primaryMin, primaryMax, secondaryMin, secondaryMax []float64
 	
-	for i := hours - 1; i >= 0; i-- {
-		hourStart := now.Add(time.Duration(-i) * time.Hour).Truncate(time.Hour)
-		hourEnd := hourStart.Add(time.Hour)
-		
+	for i, hourStart := range sl.hourStarts {
 		labels = append(labels, hourStart.Format("15:04"))
 		
 		var primaryMinVal, primaryMaxVal, secondaryMinVal, secondaryMaxVal float64
 		var primaryMinSet, primaryMaxSet, secondaryMinSet, secondaryMaxSet bool
 		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(hourStart) || !log.Timestamp.Before(hourEnd) {
-				continue
-			}
-			
+		for _, log := range sl.hourly[i] {
 			if log.Target == "primary" {
 				if log.MinLatency != nil {
 					if !primaryMinSet || *log.MinLatency < primaryMinVal {
@@ -1285,294 +1225,16 @@ func generateLatencyMinMaxChart(allLogs []models.PingLog, siteID string, now tim
 	return minResult, maxResult
 }
 
-// generateLatencyChartDaily generates latency chart data (daily)
-func generateLatencyChartDaily(allLogs []models.PingLog, siteID string, now time.Time, days int) ChartDataResult {
-	var labels []string
-	var primaryLatencies, secondaryLatencies []float64
-	
-	for i := days - 1; i >= 0; i-- {
-		dayStart := now.AddDate(0, 0, -i).Truncate(24 * time.Hour)
-		dayEnd := dayStart.Add(24 * time.Hour)
-		
-		labels = append(labels, dayStart.Format("Jan 2"))
-		
-		// Filter logs for this specific day
-		var dayLogs []models.PingLog
-		for _, log := range allLogs {
-			if log.SiteID == siteID && !log.Timestamp.Before(dayStart) && log.Timestamp.Before(dayEnd) {
-				dayLogs = append(dayLogs, log)
-			}
-		}
-		
-		// Calculate mean latencies for this day only
-		var primarySum, secondarySum float64
-		var primaryCount, secondaryCount int
-		
-		for _, log := range dayLogs {
-			if log.Success && log.Latency != nil {
-				if log.Target == "primary" {
-					primarySum += *log.Latency
-					primaryCount++
-				} else if log.Target == "secondary" {
-					secondarySum += *log.Latency
-					secondaryCount++
-				}
-			}
-		}
-		
-		var primaryMean, secondaryMean float64
-		if primaryCount > 0 {
-			primaryMean = primarySum / float64(primaryCount)
-		}
-		if secondaryCount > 0 {
-			secondaryMean = secondarySum / float64(secondaryCount)
-		}
-		
-		primaryLatencies = append(primaryLatencies, primaryMean)
-		secondaryLatencies = append(secondaryLatencies, secondaryMean)
-	}
-	
-	return ChartDataResult{
-		Labels:        labels,
-		PrimaryData:   primaryLatencies,
-		SecondaryData: secondaryLatencies,
-	}
-}
-
-// generatePacketTransmissionChartDaily generates packet transmission success rate data (daily aggregation)
-func generatePacketTransmissionChartDaily(allLogs []models.PingLog, siteID string, now time.Time, days int) ChartDataResult {
-	var labels []string
-	var primarySuccess, secondarySuccess []float64
-	
-	for i := days - 1; i >= 0; i-- {
-		dayStart := now.AddDate(0, 0, -i).Truncate(24 * time.Hour)
-		dayEnd := dayStart.Add(24 * time.Hour)
-		
-		labels = append(labels, dayStart.Format("Jan 2"))
-		
-		var primarySent, primaryReceived, secondarySent, secondaryReceived int
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(dayStart) || !log.Timestamp.Before(dayEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" {
-				primarySent += log.PacketsSent
-				primaryReceived += log.PacketsRecv
-			} else if log.Target == "secondary" {
-				secondarySent += log.PacketsSent
-				secondaryReceived += log.PacketsRecv
-			}
-		}
-		
-		// Calculate success rate as percentage of received vs sent packets
-		var primarySuccessRate, secondarySuccessRate float64
-		if primarySent > 0 {
-			primarySuccessRate = (float64(primaryReceived) / float64(primarySent)) * 100
-		}
-		if secondarySent > 0 {
-			secondarySuccessRate = (float64(secondaryReceived) / float64(secondarySent)) * 100
-		}
-		
-		primarySuccess = append(primarySuccess, primarySuccessRate)
-		secondarySuccess = append(secondarySuccess, secondarySuccessRate)
-	}
-	
-	return filterEmptyBuckets(labels, primarySuccess, secondarySuccess)
-}
-
-// generateJitterChartDaily generates jitter chart data (daily aggregation)
-func generateJitterChartDaily(allLogs []models.PingLog, siteID string, now time.Time, days int) ChartDataResult {
-	var labels []string
-	var primaryJitter, secondaryJitter []float64
-	
-	for i := days - 1; i >= 0; i-- {
-		dayStart := now.AddDate(0, 0, -i).Truncate(24 * time.Hour)
-		dayEnd := dayStart.Add(24 * time.Hour)
-		
-		labels = append(labels, dayStart.Format("Jan 2"))
-		
-		var primaryJitterSum, secondaryJitterSum float64
-		var primaryCount, secondaryCount int
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(dayStart) || !log.Timestamp.Before(dayEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" && log.Jitter != nil {
-				primaryJitterSum += *log.Jitter
-				primaryCount++
-			} else if log.Target == "secondary" && log.Jitter != nil {
-				secondaryJitterSum += *log.Jitter
-				secondaryCount++
-			}
-		}
-		
-		if primaryCount > 0 {
-			primaryJitter = append(primaryJitter, primaryJitterSum/float64(primaryCount))
-		} else {
-			primaryJitter = append(primaryJitter, 0)
-		}
-		
-		if secondaryCount > 0 {
-			secondaryJitter = append(secondaryJitter, secondaryJitterSum/float64(secondaryCount))
-		} else {
-			secondaryJitter = append(secondaryJitter, 0)
-		}
-	}
-	
-	return ChartDataResult{
-		Labels:        labels,
-		PrimaryData:   primaryJitter,
-		SecondaryData: secondaryJitter,
-	}
-}
-
-// generateLatencyMinMaxChartDaily generates min/max latency chart data (daily aggregation)
-func generateLatencyMinMaxChartDaily(allLogs []models.PingLog, siteID string, now time.Time, days int) (ChartDataResult, ChartDataResult) {
-	var labels []string
-	var primaryMin, primaryMax, secondaryMin, secondaryMax []float64
-	
-	for i := days - 1; i >= 0; i-- {
-		dayStart := now.AddDate(0, 0, -i).Truncate(24 * time.Hour)
-		dayEnd := dayStart.Add(24 * time.Hour)
-		
-		labels = append(labels, dayStart.Format("Jan 2"))
-		
-		var primaryMinVal, primaryMaxVal, secondaryMinVal, secondaryMaxVal float64
-		var primaryMinSet, primaryMaxSet, secondaryMinSet, secondaryMaxSet bool
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(dayStart) || !log.Timestamp.Before(dayEnd) {
-				continue
-			}
-			
-			if log.Target == "primary" {
-				if log.MinLatency != nil {
-					if !primaryMinSet || *log.MinLatency < primaryMinVal {
-						primaryMinVal = *log.MinLatency
-						primaryMinSet = true
-					}
-				}
-				if log.MaxLatency != nil {
-					if !primaryMaxSet || *log.MaxLatency > primaryMaxVal {
-						primaryMaxVal = *log.MaxLatency
-						primaryMaxSet = true
-					}
-				}
-			} else if log.Target == "secondary" {
-				if log.MinLatency != nil {
-					if !secondaryMinSet || *log.MinLatency < secondaryMinVal {
-						secondaryMinVal = *log.MinLatency
-						secondaryMinSet = true
-					}
-				}
-				if log.MaxLatency != nil {
-					if !secondaryMaxSet || *log.MaxLatency > secondaryMaxVal {
-						secondaryMaxVal = *log.MaxLatency
-						secondaryMaxSet = true
-					}
-				}
-			}
-		}
-		
-		if primaryMinSet {
-			primaryMin = append(primaryMin, primaryMinVal)
-		} else {
-			primaryMin = append(primaryMin, 0)
-		}
-		
-		if primaryMaxSet {
-			primaryMax = append(primaryMax, primaryMaxVal)
-		} else {
-			primaryMax = append(primaryMax, 0)
-		}
-		
-		if secondaryMinSet {
-			secondaryMin = append(secondaryMin, secondaryMinVal)
-		} else {
-			secondaryMin = append(secondaryMin, 0)
-		}
-		
-		if secondaryMaxSet {
-			secondaryMax = append(secondaryMax, secondaryMaxVal)
-		} else {
-			secondaryMax = append(secondaryMax, 0)
-		}
-	}
-	
-	minResult := ChartDataResult{
-		Labels:        labels,
-		PrimaryData:   primaryMin,
-		SecondaryData: secondaryMin,
-	}
-	
-	maxResult := ChartDataResult{
-		Labels:        labels,
-		PrimaryData:   primaryMax,
-		SecondaryData: secondaryMax,
-	}
-	
-	return minResult, maxResult
-}
-
-// generateUptimeChartHourly generates uptime chart data (hourly aggregation)
-func generateUptimeChartHourly(allLogs []models.PingLog, siteID string, now time.Time, hours int) ChartDataResult {
-	var labels []string
-	var combinedData, primaryData, secondaryData []float64
-	
-	for i := hours - 1; i >= 0; i-- {
-		hourStart := now.Add(time.Duration(-i) * time.Hour).Truncate(time.Hour)
-		hourEnd := hourStart.Add(time.Hour)
-		
-		labels = append(labels, hourStart.Format("15:04"))
-		
-		stats := NewTimeframeStats()
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(hourStart) || !log.Timestamp.Before(hourEnd) {
-				continue
-			}
-			stats.AddLog(log)
-		}
-		
-		// Calculate uptime percentages for this hour
-		combinedUptime := stats.GetUptimePercentage()
-		primaryUptime := stats.GetProviderUptime("primary")
-		secondaryUptime := stats.GetProviderUptime("secondary")
-		
-		combinedData = append(combinedData, combinedUptime)
-		primaryData = append(primaryData, primaryUptime)
-		secondaryData = append(secondaryData, secondaryUptime)
-	}
-	
-	return ChartDataResult{
-		Labels:        labels,
-		CombinedData:  combinedData,
-		PrimaryData:   primaryData,
-		SecondaryData: secondaryData,
-	}
-}
-
 // generateUptimeChart generates uptime chart data
-func generateUptimeChart(allLogs []models.PingLog, siteID string, now time.Time, days int) ChartDataResult {
+func generateUptimeChart(sl *siteLogs) ChartDataResult {
 	var labels []string
 	var combinedData, primaryData, secondaryData []float64
 	
-	for i := days - 1; i >= 0; i-- {
-		dayStart := now.AddDate(0, 0, -i).Truncate(HoursPerDay * time.Hour)
-		dayEnd := dayStart.Add(HoursPerDay * time.Hour)
-		
+	for i, dayStart := range sl.dayStarts {
 		labels = append(labels, dayStart.Format("Jan 2"))
 		
 		stats := NewTimeframeStats()
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(dayStart) || !log.Timestamp.Before(dayEnd) {
-				continue
-			}
+		for _, log := range sl.daily[i] {
 			stats.AddLog(log)
 		}
 		
@@ -1589,24 +1251,17 @@ func generateUptimeChart(allLogs []models.PingLog, siteID string, now time.Time,
 	}
 }
 
-// generateSLAChart generates SLA comparison chart data
-func generateSLAChart(allLogs []models.PingLog, siteID string, now time.Time, months int) ChartDataResult {
+// generateSLAChart generates SLA comparison chart data, excluding the expected offline schedule
+// and the checks selected by exclude
+func generateSLAChart(sl *siteLogs, schedule models.OfflineSchedule, exclude CheckExclusions) ChartDataResult {
 	var labels []string
 	var primaryData, secondaryData []float64
 	
-	for i := months - 1; i >= 0; i-- {
-		monthStart := now.AddDate(0, -i, 0).Truncate(HoursPerDay * time.Hour)
-		monthStart = time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, monthStart.Location())
-		monthEnd := monthStart.AddDate(0, 1, 0)
-		
+	for i, monthStart := range sl.monthStarts {
 		labels = append(labels, monthStart.Format("Jan 2006"))
 		
-		stats := NewTimeframeStats()
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(monthStart) || !log.Timestamp.Before(monthEnd) {
-				continue
-			}
+		stats := NewTimeframeStatsExcluding(schedule, exclude)
+		for _, log := range sl.monthly[i] {
 			stats.AddLog(log)
 		}
 		
@@ -1622,52 +1277,50 @@ func generateSLAChart(allLogs []models.PingLog, siteID string, now time.Time, mo
 }
 
 // generateDistributionChart generates response time distribution chart data
-func generateDistributionChart(allLogs []models.PingLog, siteID string, since time.Time) ChartDataResult {
-	distributionLabels := []string{"0-10ms", "10-50ms", "50-100ms", "100-200ms", "200-500ms", "500ms+"}
+func generateDistributionChart(sl *siteLogs, since time.Time) ChartDataResult {
+	stats, primaryStats, secondaryStats := latencyStatsByLine(sl.logs, sl.siteID, since, DefaultLatencyBuckets)
 	
-	stats := NewTimeframeStats()
-	primaryStats := NewTimeframeStats()
-	secondaryStats := NewTimeframeStats()
+	return ChartDataResult{
+		Labels:        LatencyBucketLabels(DefaultLatencyBuckets),
+		CombinedData:  stats.GetLatencyDistribution(),
+		PrimaryData:   primaryStats.GetLatencyDistribution(),
+		SecondaryData: secondaryStats.GetLatencyDistribution(),
+	}
+}
+
+// latencyStatsByLine collects the successful checks of a site since the given
+// time with a latency, combined and per line, counting the latency
+// distribution into the buckets defined by bounds
+func latencyStatsByLine(allLogs []models.PingLog, siteID string, since time.Time, bounds []float64) (combined, primary, secondary *TimeframeStats) {
+	combined = newTimeframeStatsBuckets(bounds)
+	primary = newTimeframeStatsBuckets(bounds)
+	secondary = newTimeframeStatsBuckets(bounds)
 	
 	for _, log := range allLogs {
 		if log.SiteID != siteID || log.Timestamp.Before(since) || !log.Success || log.Latency == nil {
 			continue
 		}
 		
-		stats.AddLog(log)
+		combined.AddLog(log)
 		if log.Target == "primary" {
-			primaryStats.AddLog(log)
+			primary.AddLog(log)
 		} else if log.Target == "secondary" {
-			secondaryStats.AddLog(log)
+			secondary.AddLog(log)
 		}
 	}
-	
-	return ChartDataResult{
-		Labels:        distributionLabels,
-		CombinedData:  stats.GetLatencyDistribution(),
-		PrimaryData:   primaryStats.GetLatencyDistribution(),
-		SecondaryData: secondaryStats.GetLatencyDistribution(),
-	}
+	return combined, primary, secondary
 }
 
 // generateYearlyChart generates yearly uptime chart data
-func generateYearlyChart(allLogs []models.PingLog, siteID string, now time.Time, months int) ChartDataResult {
+func generateYearlyChart(sl *siteLogs) ChartDataResult {
 	var labels []string
 	var combinedData, primaryData, secondaryData []float64
 	
-	for i := months - 1; i >= 0; i-- {
-		monthStart := now.AddDate(0, -i, 0).Truncate(HoursPerDay * time.Hour)
-		monthStart = time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, monthStart.Location())
-		monthEnd := monthStart.AddDate(0, 1, 0)
-		
+	for i, monthStart := range sl.monthStarts {
 		labels = append(labels, monthStart.Format("Jan"))
 		
 		stats := NewTimeframeStats()
-		
-		for _, log := range allLogs {
-			if log.SiteID != siteID || log.Timestamp.Before(monthStart) || !log.Timestamp.Before(monthEnd) {
-				continue
-			}
+		for _, log := range sl.monthly[i] {
 			stats.AddLog(log)
 		}
 		
@@ -1684,29 +1337,54 @@ func generateYearlyChart(allLogs []models.PingLog, siteID string, now time.Time,
 	}
 }
 
-// GetRecentEvents returns recent status change events for a site with improved event detection
-func GetRecentEvents(app *config.AppState, siteID string, limit int) []models.RecentEvent {
+// GetRecentEvents returns recent status change events for a site with improved event detection.
+// Messages are localized for locale; Status keeps the machine-readable event code.
+func GetRecentEvents(app *config.AppState, siteID string, limit int, locale string) []models.RecentEvent {
 	app.Mu.RLock()
 	defer app.Mu.RUnlock()
 	
 	// Get all logs from storage
-	allLogs := GetAllLogs(app)
-	if len(allLogs) == 0 {
+	allLogs, err := GetAllLogs(app)
+	if err != nil || len(allLogs) == 0 {
 		log := logger.Default().WithComponent("stats-events")
 		log.Warn("No logs available for event detection")
 		return []models.RecentEvent{}
 	}
 	
+	events := detectEvents(allLogs, siteID, app.Config.Ping.TTLChangeThreshold, locale)
+	
+	// Reverse to get newest events first
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	
+	// Limit to requested number of events
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	
+	return events
+}
+
+// detectEvents finds status changes and TTL shifts for a site, oldest first
+func detectEvents(allLogs []models.PingLog, siteID string, ttlThreshold int, locale string) []models.RecentEvent {
+	// Storage returns newest first - analyze a chronological copy of the site's logs
+	var siteLogs []models.PingLog
+	for _, pingLog := range allLogs {
+		if pingLog.SiteID == siteID {
+			siteLogs = append(siteLogs, pingLog)
+		}
+	}
+	sort.SliceStable(siteLogs, func(i, j int) bool {
+		return siteLogs[i].Timestamp.Before(siteLogs[j].Timestamp)
+	})
+	
 	var events []models.RecentEvent
 	var lastStatus = make(map[string]bool) // target -> success
+	var lastTTL = make(map[string]int)     // target -> reply TTL
 	
 	// Analyze logs in chronological order to detect status changes
-	for i := 0; i < len(allLogs); i++ {
-		pingLog := allLogs[i]
-		if pingLog.SiteID != siteID {
-			continue
-		}
-		
+	for _, pingLog := range siteLogs {
 		// Validate log data before processing
 		if err := validateLogData(pingLog); err != nil {
 			log := logger.Default().WithComponent("stats-events").WithSite(siteID, "")
@@ -1725,11 +1403,11 @@ func GetRecentEvents(app *config.AppState, siteID string, limit int) []models.Re
 			// This log represents the NEW status after the change
 			if pingLog.Success {
 				event.Status = "restored"
-				event.Message = fmt.Sprintf("%s connection restored", strings.Title(pingLog.Target))
+				event.Message = i18n.T(locale, "event.connection_restored", lineLabel(locale, pingLog.Target))
 				event.IsOutage = false
 			} else {
 				event.Status = "failed"
-				event.Message = fmt.Sprintf("%s connection lost", strings.Title(pingLog.Target))
+				event.Message = i18n.T(locale, "event.connection_lost", lineLabel(locale, pingLog.Target))
 				event.IsOutage = true
 			}
 			
@@ -1737,19 +1415,59 @@ func GetRecentEvents(app *config.AppState, siteID string, limit int) []models.Re
 		}
 		
 		lastStatus[pingLog.Target] = pingLog.Success
+		
+		// A shifted reply TTL means a different number of hops - the path changed
+		if pingLog.TTL != nil {
+			if prevTTL, exists := lastTTL[pingLog.Target]; exists && ping.IsTTLChange(prevTTL, *pingLog.TTL, ttlThreshold) {
+				events = append(events, models.RecentEvent{
+					Timestamp: pingLog.Timestamp,
+					SiteID:    pingLog.SiteID,
+					Target:    pingLog.Target,
+					Status:    "rerouted",
+					Message:   i18n.T(locale, "event.rerouted", lineLabel(locale, pingLog.Target), prevTTL, *pingLog.TTL),
+					IsOutage:  false,
+				})
+			}
+			lastTTL[pingLog.Target] = *pingLog.TTL
+		}
 	}
 	
-	// Reverse to get newest events first
-	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
-		events[i], events[j] = events[j], events[i]
+	return events
+}
+
+// lineLabel returns the display name of a line ("Primary") in locale
+func lineLabel(locale, target string) string {
+	key := "line." + target
+	if label := i18n.T(locale, key); label != key {
+		return label
 	}
-	
-	// Limit to requested number of events
-	if len(events) > limit {
-		events = events[:limit]
+	return strings.Title(target)
+}
+
+// generateIncidentMarkers converts events since the given time into chart annotations
+func generateIncidentMarkers(sl *siteLogs, since time.Time, ttlThreshold int) []models.ChartAnnotation {
+	markers := []models.ChartAnnotation{}
+	for _, event := range detectEvents(sl.logs, sl.siteID, ttlThreshold, i18n.DefaultLocale()) {
+		if event.Timestamp.Before(since) {
+			continue
+		}
+		
+		markerType := "outage_end"
+		switch event.Status {
+		case "failed":
+			markerType = "outage_start"
+		case "rerouted":
+			markerType = "reroute"
+		}
+		
+		markers = append(markers, models.ChartAnnotation{
+			Timestamp: event.Timestamp,
+			Type:      markerType,
+			Target:    event.Target,
+			Label:     event.Message,
+		})
 	}
-	
-	return events
+	return markers
 }
 
 // CalculateOverviewData calculates overall system statistics with improved accuracy
@@ -1757,47 +1475,13 @@ func CalculateOverviewData(app *config.AppState) models.OverviewData {
 	app.Mu.RLock()
 	defer app.Mu.RUnlock()
 	
-	// Get all logs from storage
-	allLogs := GetAllLogs(app)
+	// Get all logs from storage, the overall uptime is unknown without them
+	allLogs, err := GetAllLogs(app)
 	
-	totalSites := len(app.Sites)
-	var onlineSites, offlineSites, degradedSites int
+	counts := countSiteStatuses(app)
 	var totalChecks int64
 	var successfulChecks int64
 	
-	// Count site statuses with improved logic
-	for _, site := range app.Sites {
-		if !site.Enabled {
-			continue
-		}
-		
-		status, exists := app.SiteStatus[site.ID]
-		if !exists {
-			offlineSites++
-			continue
-		}
-		
-		if site.IsDualLine() {
-			// Dual-line site
-			if status.PrimaryOnline && status.SecondaryOnline {
-				onlineSites++
-			} else if status.PrimaryOnline || status.SecondaryOnline {
-				// Count degraded sites as online (since at least one line works)
-				onlineSites++
-				degradedSites++
-			} else {
-				offlineSites++
-			}
-		} else {
-			// Single-line site
-			if status.PrimaryOnline {
-				onlineSites++
-			} else {
-				offlineSites++
-			}
-		}
-	}
-	
 	// Calculate overall uptime with improved accuracy
 	totalChecks = atomic.LoadInt64(&app.TotalChecks)
 	
@@ -1814,144 +1498,84 @@ func CalculateOverviewData(app *config.AppState) models.OverviewData {
 	}
 	
 	// Calculate uptime duration
-	uptime := time.Since(app.StartTime)
+	uptime := clock.Default().Now().Sub(app.StartTime)
 	uptimeStr := FormatDuration(uptime)
 	
 	return models.OverviewData{
-		TotalSites:       totalSites,
-		OnlineSites:      onlineSites,
-		OfflineSites:     offlineSites,
-		DegradedSites:    degradedSites,
+		TotalSites:       counts.TotalSites,
+		OnlineSites:      counts.OnlineSites,
+		OfflineSites:     counts.OfflineSites,
+		DegradedSites:    counts.DegradedSites,
 		UptimePercentage: uptimePercentage,
 		TotalChecks:      totalChecks,
 		Uptime:           uptimeStr,
+		StorageDegraded:  app.IsStorageDegraded(),
+		StatisticsUnavailable: err != nil,
 	}
 }
 
-// GenerateChartDataForRange generates chart data for a specific chart type and time range
+// GenerateChartDataForRange generates chart data for a specific chart type and time range.
+// Callers validate the combination with ValidateChartRange first.
 func GenerateChartDataForRange(app *config.AppState, siteID, chartType, timeRange string) interface{} {
+	if preset, ok := chartRangePresets[timeRange]; ok && isTimeSeriesChart(chartType) {
+		now := clock.Default().Now().UTC()
+		window := ResolveChartWindow(now.Add(-preset.span), now, preset.resolution, app.Config.Display.MaxChartPoints)
+		return generateWindowChart(app, siteID, chartType, window)
+	}
+	
 	app.Mu.RLock()
 	defer app.Mu.RUnlock()
 	
-	now := time.Now().UTC()
-	allLogs := GetAllLogs(app)
+	now := clock.Default().Now().UTC()
 	
 	switch chartType {
-	case "latency":
-		switch timeRange {
-		case "1h":
-			return generateLatencyChartMinutely(allLogs, siteID, now, 60) // 60 minute points
-		case "3h":
-			return generateLatencyChart5Minutes(allLogs, siteID, now, 36) // 36 x 5-minute points
-		case "12h":
-			return generateLatencyChart5Minutes(allLogs, siteID, now, 144) // 144 x 5-minute points
-		case "24h":
-			return generateLatencyChart(allLogs, siteID, now, 24) // 24 hourly points
-		case "7d":
-			return generateLatencyChartDaily(allLogs, siteID, now, 7) // 7 daily points
+	case "yearly":
+		// Always return 12 months for SLA tracking, starting with the first month
+		since := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, now.Location())
+		logs, err := app.Storage.GetLogsInRange(since, now)
+		if err != nil {
+			return chartUnavailable(err)
 		}
-	case "uptime":
-		switch timeRange {
-		case "12h":
-			// For sub-day ranges, use hourly aggregation
-			return generateUptimeChartHourly(allLogs, siteID, now, 12) // 12 hourly points
-		case "24h":
-			return generateUptimeChartHourly(allLogs, siteID, now, 24) // 24 hourly points
-		case "7d":
-			return generateUptimeChart(allLogs, siteID, now, 7) // 7 daily points
-		case "30d":
-			return generateUptimeChart(allLogs, siteID, now, 30) // 30 daily points
+		result := generateSLAChart(newSiteLogs(logs, siteID, now), siteOfflineSchedule(app, siteID), checkExclusions(app))
+		if !siteDualLine(app, siteID) {
+			result.SecondaryData = nil
 		}
-	case "yearly":
-		// Always return 12 months for SLA tracking
-		return generateSLAChart(allLogs, siteID, now, 12)
+		return result
 	case "distribution":
 		// Always return last 24 hours distribution
 		since := now.Add(-24 * time.Hour)
-		return generateDistributionChart(allLogs, siteID, since)
-	case "packet_transmission":
-		switch timeRange {
-		case "1h":
-			return generatePacketTransmissionChartMinutely(allLogs, siteID, now, 60) // 60 minute points
-		case "3h":
-			return generatePacketTransmissionChart5Minutes(allLogs, siteID, now, 36) // 36 x 5-minute points
-		case "12h":
-			return generatePacketTransmissionChart5Minutes(allLogs, siteID, now, 144) // 144 x 5-minute points
-		case "24h":
-			return generatePacketTransmissionChart(allLogs, siteID, now, 24) // 24 hourly points
-		case "7d":
-			return generatePacketTransmissionChartDaily(allLogs, siteID, now, 7) // 7 daily points
-		}
-	case "jitter":
-		switch timeRange {
-		case "1h":
-			return generateJitterChartMinutely(allLogs, siteID, now, 60) // 60 minute points
-		case "3h":
-			return generateJitterChart5Minutes(allLogs, siteID, now, 36) // 36 x 5-minute points
-		case "12h":
-			return generateJitterChart5Minutes(allLogs, siteID, now, 144) // 144 x 5-minute points
-		case "24h":
-			return generateJitterChart(allLogs, siteID, now, 24) // 24 hourly points
-		case "7d":
-			return generateJitterChartDaily(allLogs, siteID, now, 7) // 7 daily points
+		logs, err := app.Storage.GetLogsInRange(since, now)
+		if err != nil {
+			return chartUnavailable(err)
 		}
-	case "latency_minmax":
-		switch timeRange {
-		case "1h":
-			minData, maxData := generateLatencyMinMaxChart(allLogs, siteID, now, 1)
-			return fiber.Map{
-				"min": minData,
-				"max": maxData,
-			}
-		case "3h":
-			minData, maxData := generateLatencyMinMaxChart(allLogs, siteID, now, 3)
-			return fiber.Map{
-				"min": minData,
-				"max": maxData,
-			}
-		case "12h":
-			minData, maxData := generateLatencyMinMaxChart(allLogs, siteID, now, 12)
-			return fiber.Map{
-				"min": minData,
-				"max": maxData,
-			}
-		case "24h":
-			minData, maxData := generateLatencyMinMaxChart(allLogs, siteID, now, 24)
-			return fiber.Map{
-				"min": minData,
-				"max": maxData,
-			}
-		case "7d":
-			minData, maxData := generateLatencyMinMaxChartDaily(allLogs, siteID, now, 7)
-			return fiber.Map{
-				"min": minData,
-				"max": maxData,
-			}
+		result := generateDistributionChart(newSiteLogs(logs, siteID, now), since)
+		if !siteDualLine(app, siteID) {
+			result.SecondaryData = nil
 		}
+		return result
 	}
 	
 	return fiber.Map{"error": "Invalid chart type or range"}
 }
 
-// FormatDuration formats a duration in a human-readable way with improved precision
+// chartUnavailable logs a storage error and returns the error marker of a chart
+func chartUnavailable(err error) fiber.Map {
+	logger.Default().WithComponent("stats-chart").Error("Failed to get logs for chart", "error", err)
+	return fiber.Map{"error": "Statistics unavailable"}
+}
+
+// FormatDuration formats a duration in a human-readable way with improved precision,
+// using the server's default locale
 func FormatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	} else if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	} else if d < HoursPerDay*time.Hour {
-		hours := int(d.Hours())
-		minutes := int(d.Minutes()) % 60
-		if minutes == 0 {
-			return fmt.Sprintf("%dh", hours)
-		}
-		return fmt.Sprintf("%dh %dm", hours, minutes)
-	} else {
-		days := int(d.Hours()) / HoursPerDay
-		hours := int(d.Hours()) % HoursPerDay
-		if hours == 0 {
-			return fmt.Sprintf("%dd", days)
-		}
-		return fmt.Sprintf("%dd %dh", days, hours)
-	}
+	return FormatDurationIn(i18n.DefaultLocale(), d)
+}
+
+// FormatDurationIn formats a duration with the unit suffixes of locale
+func FormatDurationIn(locale string, d time.Duration) string {
+	return format.Duration(locale, d)
+}
+
+// FormatDurationPrecise formats a duration with every non-zero unit, for incident durations
+func FormatDurationPrecise(d time.Duration) string {
+	return format.DurationPrecise(i18n.DefaultLocale(), d)
 }
\ No newline at end of file
@@ -0,0 +1,264 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sitewatch/internal/models"
+	"sitewatch/internal/storage"
+	"sitewatch/internal/testutil"
+)
+
+// benchmarkLogs is the size of the busy site of the benchmarks, a year of
+// checks of both lines every minute is just over a million logs
+const benchmarkLogs = 1_000_000
+
+// siteLogsOf returns n checks of site-001 alternating between both lines,
+// with latencies skewed like real ones and 2% failures. The logs are the
+// same for every n and call.
+func siteLogsOf(n int) []models.PingLog {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	logs := make([]models.PingLog, n)
+	for i := range logs {
+		log := models.PingLog{
+			Timestamp:   start.Add(time.Duration(i/2) * time.Minute),
+			SiteID:      "site-001",
+			Target:      "primary",
+			PacketsSent: 3,
+		}
+		if i%2 == 1 {
+			log.Target = "secondary"
+		}
+		if rng.Intn(50) == 0 {
+			loss := 100.0
+			log.PacketLoss = &loss
+		} else {
+			latency := 2 + rng.ExpFloat64()*30
+			jitter := latency / 10
+			minLatency, maxLatency := latency-jitter, latency+jitter
+			loss := 0.0
+			log.Success = true
+			log.PacketsRecv = 3
+			log.Latency, log.Jitter = &latency, &jitter
+			log.MinLatency, log.MaxLatency = &minLatency, &maxLatency
+			log.PacketLoss = &loss
+		}
+		logs[i] = log
+	}
+	return logs
+}
+
+// checkLog returns a check of the primary line of site-001 at t
+func checkLog(t time.Time, success bool) models.PingLog {
+	log := models.PingLog{Timestamp: t, SiteID: "site-001", Target: "primary", IP: "192.0.2.1", Success: success, PacketsSent: 3}
+	if success {
+		latency := 10.0
+		log.Latency = &latency
+		log.PacketsRecv = 3
+	} else {
+		log.Error = "no packets received"
+	}
+	return log
+}
+
+// exactPercentile returns the nearest-rank percentile of all values
+func exactPercentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Statistics over many more checks than LatencySampleSize stay close to the
+// values computed from every latency
+func TestTimeframeStatsWithinTolerance(t *testing.T) {
+	logs := siteLogsOf(200_000)
+
+	ts := NewTimeframeStats()
+	var primary []float64
+	var sum float64
+	buckets := make([]float64, len(DefaultLatencyBuckets)+1)
+	for _, log := range logs {
+		ts.AddLog(log)
+		if !log.Success {
+			continue
+		}
+		sum += *log.Latency
+		buckets[sort.SearchFloat64s(DefaultLatencyBuckets, *log.Latency)]++
+		if log.Target == "primary" {
+			primary = append(primary, *log.Latency)
+		}
+	}
+
+	if ts.TotalChecks != len(logs) || ts.PrimaryTotal != len(logs)/2 {
+		t.Fatalf("counted %d checks, %d primary, want %d and %d", ts.TotalChecks, ts.PrimaryTotal, len(logs), len(logs)/2)
+	}
+	if want := roundToDecimalPlaces(sum/float64(ts.SuccessChecks), LatencyPrecision); ts.GetMeanLatency() != want {
+		t.Errorf("mean latency = %v, want %v", ts.GetMeanLatency(), want)
+	}
+	var primarySum float64
+	for _, latency := range primary {
+		primarySum += latency
+	}
+	if want := roundToDecimalPlaces(primarySum/float64(len(primary)), LatencyPrecision); ts.GetProviderMeanLatency("primary") != want {
+		t.Errorf("primary mean latency = %v, want %v", ts.GetProviderMeanLatency("primary"), want)
+	}
+	if distribution := ts.GetLatencyDistribution(); !equalFloats(distribution, buckets) {
+		t.Errorf("latency distribution = %v, want %v", distribution, buckets)
+	}
+
+	// Percentiles are estimated from a sample, 5% off is well within what
+	// the sample size gives for these tails
+	for _, p := range []float64{50, 95, 99} {
+		got := ts.GetProviderLatencyPercentile("primary", p)
+		want := exactPercentile(primary, p)
+		if math.Abs(got-want) > want*0.05 {
+			t.Errorf("primary P%v = %v, want %v within 5%%", p, got, want)
+		}
+	}
+}
+
+// equalFloats reports whether a and b hold the same values
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkTimeframeStats(b *testing.B) {
+	logs := siteLogsOf(benchmarkLogs)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ts := NewTimeframeStats()
+		for _, log := range logs {
+			ts.AddLog(log)
+		}
+		ts.GetMeanLatency()
+		ts.GetMeanPacketLoss()
+		ts.GetProviderLatencyPercentile("primary", 95)
+		ts.GetProviderLatencyPercentile("secondary", 95)
+	}
+}
+
+// BenchmarkTimeframeStatsStoredValues stores every value like TimeframeStats
+// did before the streaming aggregates, as the baseline they are compared to
+func BenchmarkTimeframeStatsStoredValues(b *testing.B) {
+	logs := siteLogsOf(benchmarkLogs)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var latencies, jitters, packetLoss, primary, secondary []float64
+		for _, log := range logs {
+			if log.PacketLoss != nil {
+				packetLoss = append(packetLoss, *log.PacketLoss)
+			}
+			if !log.Success {
+				continue
+			}
+			latencies = append(latencies, *log.Latency)
+			jitters = append(jitters, *log.Jitter)
+			if log.Target == "primary" {
+				primary = append(primary, *log.Latency)
+			} else {
+				secondary = append(secondary, *log.Latency)
+			}
+		}
+		exactPercentile(primary, 95)
+		exactPercentile(secondary, 95)
+	}
+}
+
+func BenchmarkGetLatencyDistribution(b *testing.B) {
+	logs := siteLogsOf(benchmarkLogs)
+	bounds, err := ParseLatencyBuckets("5,10,20,50,100,200,500,1000")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		combined, primary, secondary := latencyStatsByLine(logs, "site-001", time.Time{}, bounds)
+		combined.GetLatencyDistribution()
+		primary.GetLatencyDistribution()
+		secondary.GetLatencyDistribution()
+	}
+}
+
+var errStorageDown = errors.New("storage unavailable")
+
+// unreadableStorage is a memory storage whose log reads fail
+type unreadableStorage struct {
+	*storage.MemoryStorage
+}
+
+func (s unreadableStorage) GetAllLogs() ([]models.PingLog, error) {
+	return nil, errStorageDown
+}
+
+func (s unreadableStorage) GetLogsInRange(start, end time.Time) ([]models.PingLog, error) {
+	return nil, errStorageDown
+}
+
+// A storage error marks the statistics unavailable instead of reporting a
+// site without checks
+func TestStatisticsUnavailableOnStorageError(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	sites := []models.Site{{ID: "site-001", Name: "Berlin", PrimaryIP: "192.0.2.1", Enabled: true}}
+	appState := testutil.NewAppState(t, testutil.Options{Sites: sites, Logs: []models.PingLog{checkLog(now.Add(-time.Minute), true)}, Now: now, Clock: true})
+	appState.TotalChecks = 1
+	appState.Storage = unreadableStorage{appState.Storage.(*storage.MemoryStorage)}
+
+	statistics := CalculateSiteStatistics(appState, "site-001")
+	if !statistics.Unavailable {
+		t.Errorf("statistics %+v, want them marked unavailable", statistics)
+	}
+	if statistics.SLABreaches == nil || statistics.Patterns == nil {
+		t.Error("unavailable statistics have nil lists, want empty ones")
+	}
+
+	statistics, charts := CalculateSiteDetails(appState, "site-001", nil)
+	if !statistics.Unavailable {
+		t.Errorf("site details statistics %+v, want them marked unavailable", statistics)
+	}
+	if len(charts.LatencyChartLabels) != 0 || len(charts.UptimeChartLabels) != 0 {
+		t.Errorf("site details charts %+v, want none", charts)
+	}
+
+	if overview := CalculateOverviewData(appState); !overview.StatisticsUnavailable || overview.UptimePercentage != 0 {
+		t.Errorf("overview %+v, want statistics unavailable without an uptime", overview)
+	}
+
+	for _, chartType := range []string{"yearly", "distribution"} {
+		chart, ok := GenerateChartDataForRange(appState, "site-001", chartType, "24h").(fiber.Map)
+		if !ok || chart["error"] != "Statistics unavailable" {
+			t.Errorf("%s chart = %v, want the unavailable marker", chartType, chart)
+		}
+	}
+
+	// The same site reads as available once the storage recovers
+	appState.Storage = appState.Storage.(unreadableStorage).MemoryStorage
+	if statistics := CalculateSiteStatistics(appState, "site-001"); statistics.Unavailable {
+		t.Error("statistics unavailable with a readable storage")
+	}
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/models"
+)
+
+// GenerateAvailabilitySummary builds the availability of the given sites (all
+// sites when siteIDs is empty) for start <= t < end. Checks inside the
+// expected offline schedule of a site are excluded like in the SLA reports.
+func GenerateAvailabilitySummary(app *config.AppState, name string, siteIDs []string, start, end time.Time) (models.AvailabilitySummary, error) {
+	logs, err := app.Storage.GetLogsInRange(start, end)
+	if err != nil {
+		return models.AvailabilitySummary{}, fmt.Errorf("reading logs: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(siteIDs))
+	for _, id := range siteIDs {
+		wanted[id] = true
+	}
+	var sites []models.Site
+	for _, site := range app.GetSitesSnapshot() {
+		if len(wanted) == 0 || wanted[site.ID] {
+			sites = append(sites, site)
+		}
+	}
+
+	// GetLogsInRange returns the logs in chronological order, as incident detection needs
+	perSite := make(map[string][]models.PingLog, len(sites))
+	for _, pingLog := range logs {
+		perSite[pingLog.SiteID] = append(perSite[pingLog.SiteID], pingLog)
+	}
+
+	summary := models.AvailabilitySummary{
+		Name:        name,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Sites:       make([]models.AvailabilitySummarySite, 0, len(sites)),
+		GeneratedAt: clock.Default().Now(),
+	}
+	var uptimeSum float64
+	var sitesWithChecks int
+	for _, site := range sites {
+		ts := NewTimeframeStatsExcluding(site.ExpectedOffline, checkExclusions(app))
+		for _, pingLog := range perSite[site.ID] {
+			ts.AddLog(pingLog)
+		}
+
+		entry := models.AvailabilitySummarySite{
+			SiteID:       site.ID,
+			SiteName:     site.Name,
+			Uptime:       ts.GetUptimePercentage(),
+			TargetUptime: site.GetCombinedSLAUptime(),
+			MeanLatency:  ts.GetMeanLatency(),
+			TotalChecks:  ts.TotalChecks,
+			Incidents:    len(slaReportIncidents(perSite[site.ID], site.ExpectedOffline, i18n.DefaultLocale())),
+		}
+		entry.Met = entry.TotalChecks > 0 && entry.Uptime >= entry.TargetUptime
+		summary.Sites = append(summary.Sites, entry)
+
+		summary.TotalChecks += entry.TotalChecks
+		summary.Incidents += entry.Incidents
+		if entry.Met {
+			summary.SitesMetSLA++
+		}
+		if entry.TotalChecks > 0 {
+			uptimeSum += entry.Uptime
+			sitesWithChecks++
+		}
+	}
+	if sitesWithChecks > 0 {
+		summary.MeanUptime = roundToDecimalPlaces(uptimeSum/float64(sitesWithChecks), UptimePrecision)
+	}
+	return summary, nil
+}
@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// FallbackStorage wraps a persistent backend and buffers writes in memory
+// while the backend keeps failing. Buffered logs are flushed back into the
+// backend automatically once writes succeed again.
+type FallbackStorage struct {
+	primary             Storage
+	buffer              *MemoryStorage
+	threshold           int
+	consecutiveFailures int
+	degraded            bool
+	mu                  sync.Mutex
+	onWriteFailure      func(err error)
+	onDegradedChange    func(degraded bool)
+}
+
+// NewFallbackStorage creates a fallback wrapper that switches to degraded mode
+// after threshold consecutive write failures
+func NewFallbackStorage(primary Storage, threshold, bufferSize int) *FallbackStorage {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	return &FallbackStorage{
+		primary:   primary,
+		buffer:    NewMemoryStorage(bufferSize),
+		threshold: threshold,
+	}
+}
+
+// SetOnWriteFailure sets a callback invoked for every failed backend write
+func (f *FallbackStorage) SetOnWriteFailure(fn func(err error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onWriteFailure = fn
+}
+
+// SetOnDegradedChange sets a callback invoked when degraded mode is entered or left
+func (f *FallbackStorage) SetOnDegradedChange(fn func(degraded bool)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onDegradedChange = fn
+}
+
+// IsDegraded reports whether the backend is currently considered unwritable
+func (f *FallbackStorage) IsDegraded() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.degraded
+}
+
+// Backend returns the wrapped storage backend
+func (f *FallbackStorage) Backend() Storage {
+	return f.primary
+}
+
+// BufferedCount returns the number of logs waiting to be flushed
+func (f *FallbackStorage) BufferedCount() int {
+	return f.buffer.Len()
+}
+
+func (f *FallbackStorage) AddPingLog(pingLog models.PingLog) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	log := logger.Default().WithComponent("storage-fallback")
+
+	err := f.primary.AddPingLog(pingLog)
+	if err != nil {
+		f.consecutiveFailures++
+		if f.onWriteFailure != nil {
+			f.onWriteFailure(err)
+		}
+
+		// Keep the entry so it can be persisted after recovery
+		f.buffer.AddPingLog(pingLog)
+
+		if !f.degraded && f.consecutiveFailures >= f.threshold {
+			f.setDegraded(true)
+			log.Error("Storage writes failing persistently, switching to in-memory fallback",
+				"consecutive_failures", f.consecutiveFailures,
+				"error", err)
+		}
+		return err
+	}
+
+	f.consecutiveFailures = 0
+	if f.buffer.Len() > 0 {
+		f.flush()
+	}
+	if f.degraded && f.buffer.Len() == 0 {
+		f.setDegraded(false)
+		log.Info("Storage writes recovered, fallback buffer flushed")
+	}
+
+	return nil
+}
+
+// flush writes buffered logs into the backend (must hold lock)
+func (f *FallbackStorage) flush() {
+	log := logger.Default().WithComponent("storage-fallback")
+
+	pending := f.buffer.Drain()
+	for i, pingLog := range pending {
+		if err := f.primary.AddPingLog(pingLog); err != nil {
+			// Re-buffer whatever could not be written
+			for _, remaining := range pending[i:] {
+				f.buffer.AddPingLog(remaining)
+			}
+			log.Warn("Fallback buffer flush interrupted", "flushed", i, "remaining", len(pending)-i, "error", err)
+			return
+		}
+	}
+
+	log.Info("Fallback buffer flushed", "count", len(pending))
+}
+
+// setDegraded changes the degraded flag and notifies listeners (must hold lock)
+func (f *FallbackStorage) setDegraded(degraded bool) {
+	f.degraded = degraded
+	if f.onDegradedChange != nil {
+		f.onDegradedChange(degraded)
+	}
+}
+
+func (f *FallbackStorage) GetFilteredLogs(siteIDs []string, success *bool, limit int) ([]models.PingLog, error) {
+	logs, err := f.primary.GetFilteredLogs(siteIDs, success, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.buffer.Len() == 0 {
+		return logs, nil
+	}
+
+	// Merge buffered logs so the dashboard does not go stale while degraded
+	buffered, _ := f.buffer.GetFilteredLogs(siteIDs, success, limit)
+	logs = append(logs, buffered...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.After(logs[j].Timestamp)
+	})
+	if limit > 0 && len(logs) > limit {
+		logs = logs[:limit]
+	}
+
+	return logs, nil
+}
+
+func (f *FallbackStorage) GetFilteredLogsPage(siteIDs []string, success *bool, limit, offset int) ([]models.PingLog, int, error) {
+	if f.buffer.Len() == 0 {
+		return f.primary.GetFilteredLogsPage(siteIDs, success, limit, offset)
+	}
+
+	// Buffered logs interleave with stored ones, so page over the merged head
+	logs, total, err := f.primary.GetFilteredLogsPage(siteIDs, success, offset+limit, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	buffered, _ := f.buffer.GetFilteredLogs(siteIDs, success, 0)
+	logs = append(logs, buffered...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.After(logs[j].Timestamp)
+	})
+
+	return pageLogs(logs, limit, offset), total + len(buffered), nil
+}
+
+func (f *FallbackStorage) GetAllLogs() ([]models.PingLog, error) {
+	return f.GetFilteredLogs(nil, nil, 0)
+}
+
+func (f *FallbackStorage) GetLogsInRange(start, end time.Time) ([]models.PingLog, error) {
+	logs, err := f.primary.GetLogsInRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.buffer.Len() == 0 {
+		return logs, nil
+	}
+
+	buffered, _ := f.buffer.GetLogsInRange(start, end)
+	logs = append(logs, buffered...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+
+	return logs, nil
+}
+
+func (f *FallbackStorage) GetRecentLogsPerTarget(siteID string, perTarget int) ([]models.PingLog, error) {
+	logs, err := f.primary.GetRecentLogsPerTarget(siteID, perTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.buffer.Len() == 0 {
+		return logs, nil
+	}
+
+	buffered, _ := f.buffer.GetRecentLogsPerTarget(siteID, perTarget)
+	logs = append(logs, buffered...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.After(logs[j].Timestamp)
+	})
+
+	return limitPerTarget(logs, perTarget), nil
+}
+
+// ReplaceMaintenanceWindows is not buffered while degraded, the windows are
+// imported again on the next refresh
+func (f *FallbackStorage) ReplaceMaintenanceWindows(source string, windows []models.MaintenanceWindow) error {
+	return f.primary.ReplaceMaintenanceWindows(source, windows)
+}
+
+func (f *FallbackStorage) GetMaintenanceWindows(start, end time.Time) ([]models.MaintenanceWindow, error) {
+	return f.primary.GetMaintenanceWindows(start, end)
+}
+
+// UpsertDiscoveryCandidate is not buffered while degraded, the address is
+// recorded again by the next scan
+func (f *FallbackStorage) UpsertDiscoveryCandidate(candidate models.DiscoveryCandidate) error {
+	return f.primary.UpsertDiscoveryCandidate(candidate)
+}
+
+func (f *FallbackStorage) GetDiscoveryCandidates() ([]models.DiscoveryCandidate, error) {
+	return f.primary.GetDiscoveryCandidates()
+}
+
+func (f *FallbackStorage) DeleteDiscoveryCandidate(ip string) error {
+	return f.primary.DeleteDiscoveryCandidate(ip)
+}
+
+func (f *FallbackStorage) GetReportLastRun(name string) (time.Time, error) {
+	return f.primary.GetReportLastRun(name)
+}
+
+// SetReportLastRun is not buffered while degraded, the caller decides how to
+// handle a run that could not be recorded
+func (f *FallbackStorage) SetReportLastRun(name string, lastRun time.Time) error {
+	return f.primary.SetReportLastRun(name, lastRun)
+}
+
+// EnsureSiteFirstSeen and SetSiteFirstSuccess are not buffered while degraded,
+// the caller keeps the dates it could not record and retries them later
+func (f *FallbackStorage) EnsureSiteFirstSeen(siteID string, firstSeen time.Time) error {
+	return f.primary.EnsureSiteFirstSeen(siteID, firstSeen)
+}
+
+func (f *FallbackStorage) SetSiteFirstSuccess(siteID string, firstSuccess time.Time) error {
+	return f.primary.SetSiteFirstSuccess(siteID, firstSuccess)
+}
+
+func (f *FallbackStorage) GetSiteMonitoring() (map[string]models.SiteMonitoring, error) {
+	return f.primary.GetSiteMonitoring()
+}
+
+// The dead letter queue is not buffered while degraded, an entry that cannot
+// be stored is reported to the notifier, which logs the lost event
+func (f *FallbackStorage) AddWebhookDLQ(entry models.DeadLetterEntry) error {
+	return f.primary.AddWebhookDLQ(entry)
+}
+
+func (f *FallbackStorage) GetWebhookDLQ(limit, offset int) ([]models.DeadLetterEntry, int, error) {
+	return f.primary.GetWebhookDLQ(limit, offset)
+}
+
+func (f *FallbackStorage) GetWebhookDLQEntry(id int64) (models.DeadLetterEntry, bool, error) {
+	return f.primary.GetWebhookDLQEntry(id)
+}
+
+func (f *FallbackStorage) UpdateWebhookDLQRetry(id int64, lastError string, retriedAt time.Time) error {
+	return f.primary.UpdateWebhookDLQRetry(id, lastError, retriedAt)
+}
+
+func (f *FallbackStorage) DeleteWebhookDLQ(id int64) error {
+	return f.primary.DeleteWebhookDLQ(id)
+}
+
+func (f *FallbackStorage) PurgeWebhookDLQ(before time.Time) (int, error) {
+	return f.primary.PurgeWebhookDLQ(before)
+}
+
+func (f *FallbackStorage) AddHostSample(sample models.HostSample) error {
+	return f.primary.AddHostSample(sample)
+}
+
+func (f *FallbackStorage) GetHostSamples(start, end time.Time) ([]models.HostSample, error) {
+	return f.primary.GetHostSamples(start, end)
+}
+
+func (f *FallbackStorage) PurgeHostSamples(before time.Time) (int, error) {
+	return f.primary.PurgeHostSamples(before)
+}
+
+func (f *FallbackStorage) AddFleetSnapshot(snapshot models.FleetSnapshot) error {
+	return f.primary.AddFleetSnapshot(snapshot)
+}
+
+func (f *FallbackStorage) GetFleetSnapshots(start, end time.Time) ([]models.FleetSnapshot, error) {
+	return f.primary.GetFleetSnapshots(start, end)
+}
+
+func (f *FallbackStorage) PurgeFleetSnapshots(before time.Time) (int, error) {
+	return f.primary.PurgeFleetSnapshots(before)
+}
+
+func (f *FallbackStorage) AddSizeSweep(results []models.SizeSweepResult) error {
+	return f.primary.AddSizeSweep(results)
+}
+
+func (f *FallbackStorage) GetSizeSweeps(siteID string, start, end time.Time) ([]models.SizeSweepResult, error) {
+	return f.primary.GetSizeSweeps(siteID, start, end)
+}
+
+func (f *FallbackStorage) PurgeSizeSweeps(before time.Time) (int, error) {
+	return f.primary.PurgeSizeSweeps(before)
+}
+
+func (f *FallbackStorage) Optimize(vacuum bool) (models.StorageOptimizeResult, error) {
+	return f.primary.Optimize(vacuum)
+}
+
+func (f *FallbackStorage) Close() error {
+	return f.primary.Close()
+}
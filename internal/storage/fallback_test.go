@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"sitewatch/internal/models"
+)
+
+var errDiskFull = errors.New("database or disk is full")
+
+// faultyStorage is a memory storage whose writes fail while down
+type faultyStorage struct {
+	*MemoryStorage
+	mu   sync.Mutex
+	down bool
+}
+
+func (s *faultyStorage) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *faultyStorage) AddPingLog(log models.PingLog) error {
+	s.mu.Lock()
+	down := s.down
+	s.mu.Unlock()
+	if down {
+		return errDiskFull
+	}
+	return s.MemoryStorage.AddPingLog(log)
+}
+
+// fallbackLog returns a check of site-001 the given seconds after a fixed time
+func fallbackLog(second int) models.PingLog {
+	return models.PingLog{
+		Timestamp: time.Date(2024, 1, 15, 10, 0, second, 0, time.UTC),
+		SiteID:    "site-001",
+		Target:    "primary",
+		Success:   true,
+	}
+}
+
+func TestFallbackStorage(t *testing.T) {
+	backend := &faultyStorage{MemoryStorage: NewMemoryStorage(100)}
+	fallback := NewFallbackStorage(backend, 2, 3)
+
+	var failures int
+	var changes []bool
+	fallback.SetOnWriteFailure(func(err error) { failures++ })
+	fallback.SetOnDegradedChange(func(degraded bool) { changes = append(changes, degraded) })
+
+	if err := fallback.AddPingLog(fallbackLog(0)); err != nil {
+		t.Fatalf("write with the backend up: %v", err)
+	}
+
+	// Buffering while storage is down, degraded from the threshold on
+	backend.setDown(true)
+	if err := fallback.AddPingLog(fallbackLog(1)); !errors.Is(err, errDiskFull) {
+		t.Fatalf("write with the backend down returned %v", err)
+	}
+	if fallback.IsDegraded() {
+		t.Fatal("degraded after one failure, threshold is 2")
+	}
+	fallback.AddPingLog(fallbackLog(2))
+	if !fallback.IsDegraded() {
+		t.Fatal("not degraded after two failures")
+	}
+	if got := fallback.BufferedCount(); got != 2 {
+		t.Fatalf("%d logs buffered, want 2", got)
+	}
+
+	// Buffered logs are read back merged with the stored ones
+	logs, err := fallback.GetAllLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 || !logs[0].Timestamp.Equal(fallbackLog(2).Timestamp) {
+		t.Fatalf("read %d logs newest %v, want 3 newest at second 2", len(logs), logs[0].Timestamp)
+	}
+
+	// Dropping the oldest buffered logs once the buffer is full
+	fallback.AddPingLog(fallbackLog(3))
+	fallback.AddPingLog(fallbackLog(4))
+	if got := fallback.BufferedCount(); got != 3 {
+		t.Fatalf("%d logs buffered, want the buffer size 3", got)
+	}
+
+	// Flushing on recovery with the next successful write
+	backend.setDown(false)
+	if err := fallback.AddPingLog(fallbackLog(5)); err != nil {
+		t.Fatalf("write after recovery: %v", err)
+	}
+	if fallback.IsDegraded() || fallback.BufferedCount() != 0 {
+		t.Fatalf("degraded %v with %d buffered after recovery, want flushed", fallback.IsDegraded(), fallback.BufferedCount())
+	}
+
+	stored, _ := backend.MemoryStorage.GetAllLogs()
+	var seconds []int
+	for _, log := range stored {
+		seconds = append(seconds, log.Timestamp.Second())
+	}
+	// Second 1 was dropped from the full buffer, newest first
+	want := []int{5, 4, 3, 2, 0}
+	if len(seconds) != len(want) {
+		t.Fatalf("stored seconds %v, want %v", seconds, want)
+	}
+	for i := range want {
+		if seconds[i] != want[i] {
+			t.Fatalf("stored seconds %v, want %v", seconds, want)
+		}
+	}
+
+	if failures != 4 {
+		t.Errorf("%d write failures reported, want 4", failures)
+	}
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("degraded changes %v, want [true false]", changes)
+	}
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+)
+
+// InstrumentedStorage wraps a backend and reports the duration and outcome of
+// every storage operation. Calls slower than slowThreshold are logged.
+type InstrumentedStorage struct {
+	backend       Storage
+	slowThreshold time.Duration
+	observer      func(op string, duration time.Duration, err error)
+	mu            sync.RWMutex
+}
+
+// NewInstrumentedStorage creates an instrumentation decorator around backend
+func NewInstrumentedStorage(backend Storage, slowThreshold time.Duration) *InstrumentedStorage {
+	return &InstrumentedStorage{
+		backend:       backend,
+		slowThreshold: slowThreshold,
+	}
+}
+
+// SetObserver sets a callback invoked after every storage operation
+func (s *InstrumentedStorage) SetObserver(fn func(op string, duration time.Duration, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observer = fn
+}
+
+// record reports an operation to the observer and logs slow calls
+func (s *InstrumentedStorage) record(op string, start time.Time, err error, args ...any) {
+	duration := time.Since(start)
+
+	s.mu.RLock()
+	observer := s.observer
+	s.mu.RUnlock()
+
+	if observer != nil {
+		observer(op, duration, err)
+	}
+
+	if s.slowThreshold > 0 && duration > s.slowThreshold {
+		log := logger.Default().WithComponent("storage-instrumented")
+		fields := append([]any{"op", op, "duration_ms", duration.Milliseconds(), "threshold_ms", s.slowThreshold.Milliseconds()}, args...)
+		log.Warn("Slow storage operation", fields...)
+	}
+}
+
+func (s *InstrumentedStorage) AddPingLog(log models.PingLog) error {
+	start := time.Now()
+	err := s.backend.AddPingLog(log)
+	s.record("add_ping_log", start, err, "site_id", log.SiteID, "target", log.Target)
+	return err
+}
+
+func (s *InstrumentedStorage) GetFilteredLogs(siteIDs []string, success *bool, limit int) ([]models.PingLog, error) {
+	start := time.Now()
+	logs, err := s.backend.GetFilteredLogs(siteIDs, success, limit)
+	s.record("get_filtered_logs", start, err, "site_ids", siteIDs, "success_filter", success, "limit", limit, "rows", len(logs))
+	return logs, err
+}
+
+func (s *InstrumentedStorage) GetFilteredLogsPage(siteIDs []string, success *bool, limit, offset int) ([]models.PingLog, int, error) {
+	start := time.Now()
+	logs, total, err := s.backend.GetFilteredLogsPage(siteIDs, success, limit, offset)
+	s.record("get_filtered_logs_page", start, err, "site_ids", siteIDs, "success_filter", success, "limit", limit, "offset", offset, "rows", len(logs))
+	return logs, total, err
+}
+
+func (s *InstrumentedStorage) GetAllLogs() ([]models.PingLog, error) {
+	start := time.Now()
+	logs, err := s.backend.GetAllLogs()
+	s.record("get_all_logs", start, err, "rows", len(logs))
+	return logs, err
+}
+
+func (s *InstrumentedStorage) GetLogsInRange(start, end time.Time) ([]models.PingLog, error) {
+	begin := time.Now()
+	logs, err := s.backend.GetLogsInRange(start, end)
+	s.record("get_logs_in_range", begin, err, "start", start, "end", end, "rows", len(logs))
+	return logs, err
+}
+
+func (s *InstrumentedStorage) GetRecentLogsPerTarget(siteID string, perTarget int) ([]models.PingLog, error) {
+	start := time.Now()
+	logs, err := s.backend.GetRecentLogsPerTarget(siteID, perTarget)
+	s.record("get_recent_logs_per_target", start, err, "site_id", siteID, "per_target", perTarget, "rows", len(logs))
+	return logs, err
+}
+
+func (s *InstrumentedStorage) ReplaceMaintenanceWindows(source string, windows []models.MaintenanceWindow) error {
+	start := time.Now()
+	err := s.backend.ReplaceMaintenanceWindows(source, windows)
+	s.record("replace_maintenance_windows", start, err, "windows", len(windows))
+	return err
+}
+
+func (s *InstrumentedStorage) GetMaintenanceWindows(start, end time.Time) ([]models.MaintenanceWindow, error) {
+	begin := time.Now()
+	windows, err := s.backend.GetMaintenanceWindows(start, end)
+	s.record("get_maintenance_windows", begin, err, "start", start, "end", end, "rows", len(windows))
+	return windows, err
+}
+
+func (s *InstrumentedStorage) UpsertDiscoveryCandidate(candidate models.DiscoveryCandidate) error {
+	start := time.Now()
+	err := s.backend.UpsertDiscoveryCandidate(candidate)
+	s.record("upsert_discovery_candidate", start, err, "ip", candidate.IP)
+	return err
+}
+
+func (s *InstrumentedStorage) GetDiscoveryCandidates() ([]models.DiscoveryCandidate, error) {
+	start := time.Now()
+	candidates, err := s.backend.GetDiscoveryCandidates()
+	s.record("get_discovery_candidates", start, err, "rows", len(candidates))
+	return candidates, err
+}
+
+func (s *InstrumentedStorage) DeleteDiscoveryCandidate(ip string) error {
+	start := time.Now()
+	err := s.backend.DeleteDiscoveryCandidate(ip)
+	s.record("delete_discovery_candidate", start, err, "ip", ip)
+	return err
+}
+
+func (s *InstrumentedStorage) GetReportLastRun(name string) (time.Time, error) {
+	start := time.Now()
+	lastRun, err := s.backend.GetReportLastRun(name)
+	s.record("get_report_last_run", start, err, "name", name)
+	return lastRun, err
+}
+
+func (s *InstrumentedStorage) SetReportLastRun(name string, lastRun time.Time) error {
+	start := time.Now()
+	err := s.backend.SetReportLastRun(name, lastRun)
+	s.record("set_report_last_run", start, err, "name", name)
+	return err
+}
+
+func (s *InstrumentedStorage) EnsureSiteFirstSeen(siteID string, firstSeen time.Time) error {
+	start := time.Now()
+	err := s.backend.EnsureSiteFirstSeen(siteID, firstSeen)
+	s.record("ensure_site_first_seen", start, err, "site_id", siteID)
+	return err
+}
+
+func (s *InstrumentedStorage) SetSiteFirstSuccess(siteID string, firstSuccess time.Time) error {
+	start := time.Now()
+	err := s.backend.SetSiteFirstSuccess(siteID, firstSuccess)
+	s.record("set_site_first_success", start, err, "site_id", siteID)
+	return err
+}
+
+func (s *InstrumentedStorage) GetSiteMonitoring() (map[string]models.SiteMonitoring, error) {
+	start := time.Now()
+	monitoring, err := s.backend.GetSiteMonitoring()
+	s.record("get_site_monitoring", start, err, "rows", len(monitoring))
+	return monitoring, err
+}
+
+func (s *InstrumentedStorage) AddWebhookDLQ(entry models.DeadLetterEntry) error {
+	start := time.Now()
+	err := s.backend.AddWebhookDLQ(entry)
+	s.record("add_webhook_dlq", start, err, "notifier", entry.NotifierName)
+	return err
+}
+
+func (s *InstrumentedStorage) GetWebhookDLQ(limit, offset int) ([]models.DeadLetterEntry, int, error) {
+	start := time.Now()
+	entries, total, err := s.backend.GetWebhookDLQ(limit, offset)
+	s.record("get_webhook_dlq", start, err, "rows", len(entries), "limit", limit, "offset", offset)
+	return entries, total, err
+}
+
+func (s *InstrumentedStorage) GetWebhookDLQEntry(id int64) (models.DeadLetterEntry, bool, error) {
+	start := time.Now()
+	entry, exists, err := s.backend.GetWebhookDLQEntry(id)
+	s.record("get_webhook_dlq_entry", start, err, "id", id)
+	return entry, exists, err
+}
+
+func (s *InstrumentedStorage) UpdateWebhookDLQRetry(id int64, lastError string, retriedAt time.Time) error {
+	start := time.Now()
+	err := s.backend.UpdateWebhookDLQRetry(id, lastError, retriedAt)
+	s.record("update_webhook_dlq_retry", start, err, "id", id)
+	return err
+}
+
+func (s *InstrumentedStorage) DeleteWebhookDLQ(id int64) error {
+	start := time.Now()
+	err := s.backend.DeleteWebhookDLQ(id)
+	s.record("delete_webhook_dlq", start, err, "id", id)
+	return err
+}
+
+func (s *InstrumentedStorage) PurgeWebhookDLQ(before time.Time) (int, error) {
+	start := time.Now()
+	purged, err := s.backend.PurgeWebhookDLQ(before)
+	s.record("purge_webhook_dlq", start, err, "rows", purged)
+	return purged, err
+}
+
+func (s *InstrumentedStorage) AddHostSample(sample models.HostSample) error {
+	start := time.Now()
+	err := s.backend.AddHostSample(sample)
+	s.record("add_host_sample", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) GetHostSamples(start, end time.Time) ([]models.HostSample, error) {
+	begin := time.Now()
+	samples, err := s.backend.GetHostSamples(start, end)
+	s.record("get_host_samples", begin, err, "start", start, "end", end, "rows", len(samples))
+	return samples, err
+}
+
+func (s *InstrumentedStorage) PurgeHostSamples(before time.Time) (int, error) {
+	start := time.Now()
+	purged, err := s.backend.PurgeHostSamples(before)
+	s.record("purge_host_samples", start, err, "rows", purged)
+	return purged, err
+}
+
+func (s *InstrumentedStorage) AddFleetSnapshot(snapshot models.FleetSnapshot) error {
+	start := time.Now()
+	err := s.backend.AddFleetSnapshot(snapshot)
+	s.record("add_fleet_snapshot", start, err)
+	return err
+}
+
+func (s *InstrumentedStorage) GetFleetSnapshots(start, end time.Time) ([]models.FleetSnapshot, error) {
+	begin := time.Now()
+	snapshots, err := s.backend.GetFleetSnapshots(start, end)
+	s.record("get_fleet_snapshots", begin, err, "start", start, "end", end, "rows", len(snapshots))
+	return snapshots, err
+}
+
+func (s *InstrumentedStorage) PurgeFleetSnapshots(before time.Time) (int, error) {
+	start := time.Now()
+	purged, err := s.backend.PurgeFleetSnapshots(before)
+	s.record("purge_fleet_snapshots", start, err, "rows", purged)
+	return purged, err
+}
+
+func (s *InstrumentedStorage) AddSizeSweep(results []models.SizeSweepResult) error {
+	start := time.Now()
+	err := s.backend.AddSizeSweep(results)
+	s.record("add_size_sweep", start, err, "rows", len(results))
+	return err
+}
+
+func (s *InstrumentedStorage) GetSizeSweeps(siteID string, start, end time.Time) ([]models.SizeSweepResult, error) {
+	begin := time.Now()
+	results, err := s.backend.GetSizeSweeps(siteID, start, end)
+	s.record("get_size_sweeps", begin, err, "site_id", siteID, "start", start, "end", end, "rows", len(results))
+	return results, err
+}
+
+func (s *InstrumentedStorage) PurgeSizeSweeps(before time.Time) (int, error) {
+	start := time.Now()
+	purged, err := s.backend.PurgeSizeSweeps(before)
+	s.record("purge_size_sweeps", start, err, "rows", purged)
+	return purged, err
+}
+
+func (s *InstrumentedStorage) Optimize(vacuum bool) (models.StorageOptimizeResult, error) {
+	start := time.Now()
+	result, err := s.backend.Optimize(vacuum)
+	s.record("optimize", start, err, "vacuum", result.Vacuum, "reclaimed_bytes", result.ReclaimedBytes)
+	return result, err
+}
+
+func (s *InstrumentedStorage) Close() error {
+	start := time.Now()
+	err := s.backend.Close()
+	s.record("close", start, err)
+	return err
+}
@@ -1,22 +1,67 @@
 package storage
 
-import "sitewatch/internal/models"
+import (
+	"time"
+
+	"sitewatch/internal/models"
+)
 
 // Storage interface for pluggable storage backends
 type Storage interface {
 	AddPingLog(log models.PingLog) error
-	GetFilteredLogs(siteID string, success *bool, limit int) ([]models.PingLog, error)
+	GetFilteredLogs(siteIDs []string, success *bool, limit int) ([]models.PingLog, error) // nil siteIDs = all sites, empty = none
+	GetFilteredLogsPage(siteIDs []string, success *bool, limit, offset int) ([]models.PingLog, int, error)
 	GetAllLogs() ([]models.PingLog, error)
+	GetLogsInRange(start, end time.Time) ([]models.PingLog, error)
+	GetRecentLogsPerTarget(siteID string, perTarget int) ([]models.PingLog, error)
+	ReplaceMaintenanceWindows(source string, windows []models.MaintenanceWindow) error
+	GetMaintenanceWindows(start, end time.Time) ([]models.MaintenanceWindow, error)
+	UpsertDiscoveryCandidate(candidate models.DiscoveryCandidate) error
+	GetDiscoveryCandidates() ([]models.DiscoveryCandidate, error)
+	DeleteDiscoveryCandidate(ip string) error
+	GetReportLastRun(name string) (time.Time, error)
+	SetReportLastRun(name string, lastRun time.Time) error
+	EnsureSiteFirstSeen(siteID string, firstSeen time.Time) error
+	SetSiteFirstSuccess(siteID string, firstSuccess time.Time) error
+	GetSiteMonitoring() (map[string]models.SiteMonitoring, error)
+	AddWebhookDLQ(entry models.DeadLetterEntry) error
+	GetWebhookDLQ(limit, offset int) ([]models.DeadLetterEntry, int, error)
+	GetWebhookDLQEntry(id int64) (models.DeadLetterEntry, bool, error)
+	UpdateWebhookDLQRetry(id int64, lastError string, retriedAt time.Time) error
+	DeleteWebhookDLQ(id int64) error
+	PurgeWebhookDLQ(before time.Time) (int, error)
+	AddHostSample(sample models.HostSample) error
+	GetHostSamples(start, end time.Time) ([]models.HostSample, error)
+	PurgeHostSamples(before time.Time) (int, error)
+	AddFleetSnapshot(snapshot models.FleetSnapshot) error
+	GetFleetSnapshots(start, end time.Time) ([]models.FleetSnapshot, error)
+	PurgeFleetSnapshots(before time.Time) (int, error)
+	AddSizeSweep(results []models.SizeSweepResult) error
+	GetSizeSweeps(siteID string, start, end time.Time) ([]models.SizeSweepResult, error)
+	PurgeSizeSweeps(before time.Time) (int, error)
+	Optimize(vacuum bool) (models.StorageOptimizeResult, error)
 	Close() error
 }
 
 // CreateStorage creates a storage instance based on configuration
 func CreateStorage(config models.Config) (Storage, error) {
+	var backend Storage
+	var err error
+	
 	switch config.Storage.Type {
 	case "sqlite":
-		return NewSQLiteStorage(config.Storage.SQLitePath)
+		backend, err = NewSQLiteStorage(config.Storage.SQLitePath)
 	default:
 		// Default to SQLite for all cases
-		return NewSQLiteStorage(config.Storage.SQLitePath)
+		backend, err = NewSQLiteStorage(config.Storage.SQLitePath)
+	}
+	if err != nil {
+		return nil, err
 	}
+	
+	// Record operation latency and errors for whichever backend is in use
+	instrumented := NewInstrumentedStorage(backend, config.Storage.SlowQueryThreshold)
+	
+	// Buffer writes in memory when the backend keeps failing (e.g. disk full)
+	return NewFallbackStorage(instrumented, config.Storage.FallbackThreshold, config.Storage.FallbackBufferSize), nil
 }
\ No newline at end of file
@@ -0,0 +1,531 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"sitewatch/internal/models"
+)
+
+// MemoryStorage implements a bounded in-memory storage backend
+type MemoryStorage struct {
+	logs       []models.PingLog
+	maxLogs    int
+	logCounter int
+	windows    map[string][]models.MaintenanceWindow // source -> maintenance windows
+	windowID   int
+	candidates map[string]models.DiscoveryCandidate // ip -> discovery candidate
+	reportRuns map[string]time.Time                 // report schedule -> last run
+	monitoring map[string]models.SiteMonitoring     // site ID -> monitoring dates
+	deadLetter []models.DeadLetterEntry              // undelivered events, oldest first
+	dlqID      int64
+	samples    []models.HostSample                  // monitoring host samples, oldest first
+	fleet      []models.FleetSnapshot               // site count snapshots, oldest first
+	sweeps     []models.SizeSweepResult             // size sweep results, oldest first
+	mu         sync.RWMutex
+}
+
+// NewMemoryStorage creates a new in-memory storage holding at most maxLogs entries
+func NewMemoryStorage(maxLogs int) *MemoryStorage {
+	if maxLogs <= 0 {
+		maxLogs = 1000
+	}
+	return &MemoryStorage{
+		logs:    make([]models.PingLog, 0, maxLogs),
+		maxLogs: maxLogs,
+	}
+}
+
+func (m *MemoryStorage) AddPingLog(log models.PingLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.logCounter++
+	log.ID = m.logCounter
+
+	// Drop the oldest entry once the buffer is full
+	if len(m.logs) >= m.maxLogs {
+		m.logs = m.logs[1:]
+	}
+	m.logs = append(m.logs, log)
+
+	return nil
+}
+
+// GetFilteredLogs returns up to limit logs of the given sites (nil = all),
+// newest first
+func (m *MemoryStorage) GetFilteredLogs(siteIDs []string, success *bool, limit int) ([]models.PingLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var logs []models.PingLog
+	for _, log := range m.logs {
+		if siteIDs != nil && !slices.Contains(siteIDs, log.SiteID) {
+			continue
+		}
+		if success != nil && log.Success != *success {
+			continue
+		}
+		logs = append(logs, log)
+	}
+
+	// Match SQLite ordering (newest first)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.After(logs[j].Timestamp)
+	})
+
+	if limit > 0 && len(logs) > limit {
+		logs = logs[:limit]
+	}
+
+	return logs, nil
+}
+
+// GetFilteredLogsPage returns up to limit logs starting at offset (newest
+// first) together with the total number of logs matching the filters
+func (m *MemoryStorage) GetFilteredLogsPage(siteIDs []string, success *bool, limit, offset int) ([]models.PingLog, int, error) {
+	logs, _ := m.GetFilteredLogs(siteIDs, success, 0)
+	return pageLogs(logs, limit, offset), len(logs), nil
+}
+
+func (m *MemoryStorage) GetAllLogs() ([]models.PingLog, error) {
+	return m.GetFilteredLogs(nil, nil, 0)
+}
+
+// GetLogsInRange returns all logs with start <= timestamp < end in chronological order
+func (m *MemoryStorage) GetLogsInRange(start, end time.Time) ([]models.PingLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var logs []models.PingLog
+	for _, log := range m.logs {
+		if log.Timestamp.Before(start) || !log.Timestamp.Before(end) {
+			continue
+		}
+		logs = append(logs, log)
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+
+	return logs, nil
+}
+
+// GetRecentLogsPerTarget returns the newest perTarget logs for each target of a site, newest first
+func (m *MemoryStorage) GetRecentLogsPerTarget(siteID string, perTarget int) ([]models.PingLog, error) {
+	logs, err := m.GetFilteredLogs([]string{siteID}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return limitPerTarget(logs, perTarget), nil
+}
+
+// limitPerTarget keeps the first perTarget logs of each target from a newest-first slice
+func limitPerTarget(logs []models.PingLog, perTarget int) []models.PingLog {
+	if perTarget <= 0 {
+		return logs
+	}
+
+	counts := make(map[string]int)
+	result := make([]models.PingLog, 0, len(logs))
+	for _, log := range logs {
+		if counts[log.Target] >= perTarget {
+			continue
+		}
+		counts[log.Target]++
+		result = append(result, log)
+	}
+	return result
+}
+
+// pageLogs returns up to limit logs starting at offset from a newest-first slice
+func pageLogs(logs []models.PingLog, limit, offset int) []models.PingLog {
+	if offset >= len(logs) {
+		return []models.PingLog{}
+	}
+	logs = logs[offset:]
+	if limit > 0 && len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs
+}
+
+// ReplaceMaintenanceWindows replaces all maintenance windows imported from source
+func (m *MemoryStorage) ReplaceMaintenanceWindows(source string, windows []models.MaintenanceWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.windows == nil {
+		m.windows = make(map[string][]models.MaintenanceWindow)
+	}
+	stored := make([]models.MaintenanceWindow, len(windows))
+	for i, window := range windows {
+		m.windowID++
+		window.ID = m.windowID
+		window.Source = source
+		stored[i] = window
+	}
+	m.windows[source] = stored
+	return nil
+}
+
+// GetMaintenanceWindows returns the maintenance windows overlapping start..end ordered by start
+func (m *MemoryStorage) GetMaintenanceWindows(start, end time.Time) ([]models.MaintenanceWindow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	windows := []models.MaintenanceWindow{}
+	for _, stored := range m.windows {
+		for _, window := range stored {
+			if window.Start.Before(end) && window.End.After(start) {
+				windows = append(windows, window)
+			}
+		}
+	}
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	return windows, nil
+}
+
+// UpsertDiscoveryCandidate records a scan reply, keeping the first_seen of a known address
+func (m *MemoryStorage) UpsertDiscoveryCandidate(candidate models.DiscoveryCandidate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.candidates == nil {
+		m.candidates = make(map[string]models.DiscoveryCandidate)
+	}
+	if known, exists := m.candidates[candidate.IP]; exists {
+		candidate.FirstSeen = known.FirstSeen
+	}
+	m.candidates[candidate.IP] = candidate
+	return nil
+}
+
+// GetDiscoveryCandidates returns all discovery candidates, most recently seen first
+func (m *MemoryStorage) GetDiscoveryCandidates() ([]models.DiscoveryCandidate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := make([]models.DiscoveryCandidate, 0, len(m.candidates))
+	for _, candidate := range m.candidates {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].LastSeen.Equal(candidates[j].LastSeen) {
+			return candidates[i].LastSeen.After(candidates[j].LastSeen)
+		}
+		return candidates[i].IP < candidates[j].IP
+	})
+	return candidates, nil
+}
+
+// DeleteDiscoveryCandidate removes a discovery candidate
+func (m *MemoryStorage) DeleteDiscoveryCandidate(ip string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.candidates, ip)
+	return nil
+}
+
+// GetReportLastRun returns the last run of a report schedule, the zero time if it never ran
+func (m *MemoryStorage) GetReportLastRun(name string) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.reportRuns[name], nil
+}
+
+// SetReportLastRun records the last run of a report schedule
+func (m *MemoryStorage) SetReportLastRun(name string, lastRun time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.reportRuns == nil {
+		m.reportRuns = make(map[string]time.Time)
+	}
+	m.reportRuns[name] = lastRun
+	return nil
+}
+
+// Len returns the number of buffered logs
+func (m *MemoryStorage) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.logs)
+}
+
+// Drain removes and returns all buffered logs in insertion order
+func (m *MemoryStorage) Drain() []models.PingLog {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	logs := m.logs
+	m.logs = make([]models.PingLog, 0, m.maxLogs)
+	return logs
+}
+
+// Optimize has nothing to reclaim in memory
+func (m *MemoryStorage) Optimize(vacuum bool) (models.StorageOptimizeResult, error) {
+	return models.StorageOptimizeResult{Vacuum: "skipped"}, nil
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+// EnsureSiteFirstSeen records when a site was first configured, a recorded date is kept
+func (m *MemoryStorage) EnsureSiteFirstSeen(siteID string, firstSeen time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.monitoring == nil {
+		m.monitoring = make(map[string]models.SiteMonitoring)
+	}
+	if _, ok := m.monitoring[siteID]; !ok {
+		m.monitoring[siteID] = models.SiteMonitoring{FirstSeen: firstSeen}
+	}
+	return nil
+}
+
+// SetSiteFirstSuccess records the first successful check of a site, a recorded date is kept
+func (m *MemoryStorage) SetSiteFirstSuccess(siteID string, firstSuccess time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.monitoring == nil {
+		m.monitoring = make(map[string]models.SiteMonitoring)
+	}
+	entry, ok := m.monitoring[siteID]
+	if !ok {
+		entry.FirstSeen = firstSuccess
+	}
+	if entry.FirstSuccess == nil {
+		entry.FirstSuccess = &firstSuccess
+	}
+	m.monitoring[siteID] = entry
+	return nil
+}
+
+// GetSiteMonitoring returns the monitoring dates of all sites ever seen, by site ID
+func (m *MemoryStorage) GetSiteMonitoring() (map[string]models.SiteMonitoring, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	monitoring := make(map[string]models.SiteMonitoring, len(m.monitoring))
+	for siteID, entry := range m.monitoring {
+		monitoring[siteID] = entry
+	}
+	return monitoring, nil
+}
+
+// AddWebhookDLQ stores an undelivered event in the dead letter queue
+func (m *MemoryStorage) AddWebhookDLQ(entry models.DeadLetterEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dlqID++
+	entry.ID = m.dlqID
+	m.deadLetter = append(m.deadLetter, entry)
+	return nil
+}
+
+// GetWebhookDLQ returns a page of dead letter entries, oldest first, and the
+// total number of entries. A limit of 0 returns all entries.
+func (m *MemoryStorage) GetWebhookDLQ(limit, offset int) ([]models.DeadLetterEntry, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := len(m.deadLetter)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	entries := make([]models.DeadLetterEntry, end-offset)
+	copy(entries, m.deadLetter[offset:end])
+	return entries, total, nil
+}
+
+// GetWebhookDLQEntry returns a dead letter entry, exists is false when there is none with the ID
+func (m *MemoryStorage) GetWebhookDLQEntry(id int64) (models.DeadLetterEntry, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, entry := range m.deadLetter {
+		if entry.ID == id {
+			return entry, true, nil
+		}
+	}
+	return models.DeadLetterEntry{}, false, nil
+}
+
+// UpdateWebhookDLQRetry records a failed retry of a dead letter entry
+func (m *MemoryStorage) UpdateWebhookDLQRetry(id int64, lastError string, retriedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.deadLetter {
+		if m.deadLetter[i].ID == id {
+			m.deadLetter[i].LastError = lastError
+			m.deadLetter[i].RetryCount++
+			m.deadLetter[i].LastRetryAt = &retriedAt
+			break
+		}
+	}
+	return nil
+}
+
+// DeleteWebhookDLQ removes a dead letter entry
+func (m *MemoryStorage) DeleteWebhookDLQ(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, entry := range m.deadLetter {
+		if entry.ID == id {
+			m.deadLetter = append(m.deadLetter[:i], m.deadLetter[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// PurgeWebhookDLQ removes the dead letter entries added before the given time
+func (m *MemoryStorage) PurgeWebhookDLQ(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.deadLetter[:0]
+	for _, entry := range m.deadLetter {
+		if !entry.Timestamp.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+	purged := len(m.deadLetter) - len(kept)
+	m.deadLetter = kept
+	return purged, nil
+}
+
+// AddHostSample stores a sample of the metrics of the monitoring host
+func (m *MemoryStorage) AddHostSample(sample models.HostSample) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, sample)
+	return nil
+}
+
+// GetHostSamples returns the host samples taken in [start, end), oldest first
+func (m *MemoryStorage) GetHostSamples(start, end time.Time) ([]models.HostSample, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	samples := []models.HostSample{}
+	for _, sample := range m.samples {
+		if !sample.Timestamp.Before(start) && sample.Timestamp.Before(end) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
+
+// PurgeHostSamples removes the host samples taken before the given time
+func (m *MemoryStorage) PurgeHostSamples(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.samples[:0]
+	for _, sample := range m.samples {
+		if !sample.Timestamp.Before(before) {
+			kept = append(kept, sample)
+		}
+	}
+	purged := len(m.samples) - len(kept)
+	m.samples = kept
+	return purged, nil
+}
+
+// AddFleetSnapshot stores a snapshot of the site counts
+func (m *MemoryStorage) AddFleetSnapshot(snapshot models.FleetSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fleet = append(m.fleet, snapshot)
+	return nil
+}
+
+// GetFleetSnapshots returns the snapshots taken in [start, end), oldest first
+func (m *MemoryStorage) GetFleetSnapshots(start, end time.Time) ([]models.FleetSnapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshots := []models.FleetSnapshot{}
+	for _, snapshot := range m.fleet {
+		if !snapshot.Timestamp.Before(start) && snapshot.Timestamp.Before(end) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+// PurgeFleetSnapshots removes the snapshots taken before the given time
+func (m *MemoryStorage) PurgeFleetSnapshots(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.fleet[:0]
+	for _, snapshot := range m.fleet {
+		if !snapshot.Timestamp.Before(before) {
+			kept = append(kept, snapshot)
+		}
+	}
+	purged := len(m.fleet) - len(kept)
+	m.fleet = kept
+	return purged, nil
+}
+
+// AddSizeSweep stores the results of a size sweep
+func (m *MemoryStorage) AddSizeSweep(results []models.SizeSweepResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweeps = append(m.sweeps, results...)
+	return nil
+}
+
+// GetSizeSweeps returns the size sweep results of a site taken in
+// [start, end), oldest first
+func (m *MemoryStorage) GetSizeSweeps(siteID string, start, end time.Time) ([]models.SizeSweepResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []models.SizeSweepResult{}
+	for _, result := range m.sweeps {
+		if result.SiteID == siteID && !result.Timestamp.Before(start) && result.Timestamp.Before(end) {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// PurgeSizeSweeps removes the size sweep results taken before the given time
+func (m *MemoryStorage) PurgeSizeSweeps(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.sweeps[:0]
+	for _, result := range m.sweeps {
+		if !result.Timestamp.Before(before) {
+			kept = append(kept, result)
+		}
+	}
+	purged := len(m.sweeps) - len(kept)
+	m.sweeps = kept
+	return purged, nil
+}
@@ -0,0 +1,396 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"sitewatch/internal/logger"
+)
+
+// schemaMigration moves the database schema from version-1 to version
+type schemaMigration struct {
+	version     int
+	description string
+	statements  []string
+	// skipIfColumn names a ping_logs column whose presence means the
+	// statements already ran, for a column that older builds added outside
+	// of its own migration. The version is recorded either way.
+	skipIfColumn string
+}
+
+// schemaMigrations are applied in order, each in its own transaction.
+// Never change a released migration, append a new one instead.
+var schemaMigrations = []schemaMigration{
+	{
+		version:     1,
+		description: "create ping_logs",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS ping_logs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				timestamp DATETIME NOT NULL,
+				site_id TEXT NOT NULL,
+				site_name TEXT NOT NULL,
+				target TEXT NOT NULL,
+				ip TEXT NOT NULL,
+				success BOOLEAN NOT NULL,
+				latency REAL,
+				error TEXT,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_timestamp ON ping_logs(timestamp)",
+			"CREATE INDEX IF NOT EXISTS idx_site_id ON ping_logs(site_id)",
+			"CREATE INDEX IF NOT EXISTS idx_site_timestamp ON ping_logs(site_id, timestamp)",
+			"CREATE INDEX IF NOT EXISTS idx_success ON ping_logs(success)",
+			"CREATE INDEX IF NOT EXISTS idx_latency ON ping_logs(latency)",
+		},
+	},
+	{
+		version:     2,
+		description: "add extended ping statistics",
+		statements: []string{
+			"ALTER TABLE ping_logs ADD COLUMN packets_sent INTEGER DEFAULT 0",
+			"ALTER TABLE ping_logs ADD COLUMN packets_recv INTEGER DEFAULT 0",
+			"ALTER TABLE ping_logs ADD COLUMN packets_duplicates INTEGER DEFAULT 0",
+			"ALTER TABLE ping_logs ADD COLUMN packet_loss REAL",
+			"ALTER TABLE ping_logs ADD COLUMN min_latency REAL",
+			"ALTER TABLE ping_logs ADD COLUMN max_latency REAL",
+			"ALTER TABLE ping_logs ADD COLUMN jitter REAL",
+			"CREATE INDEX IF NOT EXISTS idx_packet_loss ON ping_logs(packet_loss)",
+		},
+	},
+	{
+		version:     3,
+		description: "add result source",
+		statements: []string{
+			"ALTER TABLE ping_logs ADD COLUMN source TEXT NOT NULL DEFAULT ''",
+		},
+	},
+	{
+		version:     4,
+		description: "create maintenance_windows",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS maintenance_windows (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				site_id TEXT NOT NULL,
+				summary TEXT NOT NULL,
+				start_time DATETIME NOT NULL,
+				end_time DATETIME NOT NULL,
+				source TEXT NOT NULL,
+				uid TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_maintenance_source ON maintenance_windows(source)",
+			"CREATE INDEX IF NOT EXISTS idx_maintenance_time ON maintenance_windows(start_time, end_time)",
+		},
+	},
+	{
+		version:     5,
+		description: "create discovery_candidates",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS discovery_candidates (
+				ip TEXT PRIMARY KEY,
+				first_seen DATETIME NOT NULL,
+				last_seen DATETIME NOT NULL,
+				latency_ms REAL NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     6,
+		description: "create report_runs",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS report_runs (
+				name TEXT PRIMARY KEY,
+				last_run DATETIME NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     7,
+		description: "add circuit breaker flag",
+		statements: []string{
+			"ALTER TABLE ping_logs ADD COLUMN circuit_open BOOLEAN NOT NULL DEFAULT 0",
+		},
+	},
+	{
+		version:     8,
+		description: "create site_metadata",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS site_metadata (
+				site_id TEXT PRIMARY KEY,
+				first_seen DATETIME NOT NULL,
+				first_success DATETIME
+			)`,
+			// Sites monitored before this migration start at their oldest log
+			`INSERT OR IGNORE INTO site_metadata (site_id, first_seen, first_success)
+				SELECT site_id, MIN(timestamp), MIN(CASE WHEN success THEN timestamp END)
+				FROM ping_logs GROUP BY site_id`,
+		},
+	},
+	{
+		version:     9,
+		description: "create webhook_dead_letter",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS webhook_dead_letter (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				timestamp DATETIME NOT NULL,
+				event_json TEXT NOT NULL,
+				notifier_name TEXT NOT NULL,
+				last_error TEXT NOT NULL,
+				retry_count INTEGER NOT NULL DEFAULT 0,
+				last_retry_at DATETIME
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_webhook_dead_letter_timestamp ON webhook_dead_letter(timestamp)",
+		},
+	},
+	{
+		version:     10,
+		description: "create self_metrics",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS self_metrics (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				timestamp DATETIME NOT NULL,
+				cpu_percent REAL NOT NULL,
+				load1 REAL NOT NULL,
+				load5 REAL NOT NULL,
+				load15 REAL NOT NULL,
+				memory_used_percent REAL NOT NULL,
+				memory_available_bytes INTEGER NOT NULL,
+				network_errors INTEGER NOT NULL
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_self_metrics_timestamp ON self_metrics(timestamp)",
+		},
+	},
+	{
+		version:     11,
+		description: "add config error flag",
+		statements: []string{
+			"ALTER TABLE ping_logs ADD COLUMN config_error BOOLEAN NOT NULL DEFAULT 0",
+		},
+	},
+	{
+		version:     12,
+		description: "create fleet_snapshots",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS fleet_snapshots (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				timestamp DATETIME NOT NULL,
+				total_sites INTEGER NOT NULL,
+				online_sites INTEGER NOT NULL,
+				offline_sites INTEGER NOT NULL,
+				degraded_sites INTEGER NOT NULL
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_fleet_snapshots_timestamp ON fleet_snapshots(timestamp)",
+		},
+	},
+	{
+		version:     13,
+		description: "add ping source address",
+		statements: []string{
+			"ALTER TABLE ping_logs ADD COLUMN source_ip TEXT NOT NULL DEFAULT ''",
+		},
+	},
+	{
+		version:     14,
+		description: "add corrupted replies and size_sweeps",
+		statements: []string{
+			"ALTER TABLE ping_logs ADD COLUMN packets_corrupted INTEGER NOT NULL DEFAULT 0",
+			"ALTER TABLE ping_logs ADD COLUMN degraded BOOLEAN NOT NULL DEFAULT 0",
+			`CREATE TABLE IF NOT EXISTS size_sweeps (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				timestamp DATETIME NOT NULL,
+				site_id TEXT NOT NULL,
+				target TEXT NOT NULL,
+				size INTEGER NOT NULL,
+				packets_sent INTEGER NOT NULL,
+				packets_recv INTEGER NOT NULL,
+				packets_corrupted INTEGER NOT NULL,
+				latency REAL,
+				error TEXT NOT NULL DEFAULT ''
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_size_sweeps_site_timestamp ON size_sweeps(site_id, timestamp)",
+		},
+	},
+	{
+		// Builds before this migration added ttl with migration 2, released
+		// databases do not have it
+		version:      15,
+		description:  "add reply ttl",
+		statements:   []string{"ALTER TABLE ping_logs ADD COLUMN ttl INTEGER"},
+		skipIfColumn: "ttl",
+	},
+}
+
+// extendedStatisticsColumns are the ping_logs columns added by migration 2
+var extendedStatisticsColumns = []string{
+	"packets_sent", "packets_recv", "packets_duplicates", "packet_loss",
+	"min_latency", "max_latency", "jitter",
+}
+
+// LatestSchemaVersion is the schema version after all migrations are applied
+func LatestSchemaVersion() int {
+	return schemaMigrations[len(schemaMigrations)-1].version
+}
+
+// migrateSchema brings the database to the latest schema version. It fails
+// instead of continuing on a schema it does not recognise: a database written
+// by a newer release or one left half-migrated by the pre-versioning startup
+// code.
+func migrateSchema(db *sql.DB) error {
+	log := logger.Default().WithComponent("storage-migrate")
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_version table: %w", err)
+	}
+
+	current, err := currentSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		if current, err = adoptUnversionedSchema(db); err != nil {
+			return err
+		}
+	}
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, LatestSchemaVersion())
+	}
+
+	for _, migration := range schemaMigrations {
+		if migration.version <= current {
+			continue
+		}
+
+		start := time.Now()
+		log.Info("Applying schema migration", "version", migration.version, "description", migration.description)
+		if err := applyMigration(db, migration); err != nil {
+			return fmt.Errorf("schema migration %d (%s): %w", migration.version, migration.description, err)
+		}
+		log.Info("Schema migration applied", "version", migration.version, "duration_ms", time.Since(start).Milliseconds())
+		current = migration.version
+	}
+
+	log.Debug("Database schema up to date", "version", current)
+	return nil
+}
+
+// currentSchemaVersion returns the highest applied version, 0 for an unversioned database
+func currentSchemaVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// applyMigration runs the statements of a migration and records its version in one transaction
+func applyMigration(db *sql.DB, migration schemaMigration) error {
+	skip := false
+	if migration.skipIfColumn != "" {
+		columns, err := tableColumns(db, "ping_logs")
+		if err != nil {
+			return err
+		}
+		skip = columns[migration.skipIfColumn]
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !skip {
+		for _, statement := range migration.statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+	}
+	if err := recordSchemaVersion(tx, migration); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordSchemaVersion marks a migration as applied
+func recordSchemaVersion(tx *sql.Tx, migration schemaMigration) error {
+	_, err := tx.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)",
+		migration.version, migration.description, time.Now().UTC())
+	return err
+}
+
+// adoptUnversionedSchema records the version of a database created before
+// schema versioning: none (new database), 1 (ping_logs without extended
+// statistics) or 2 (all extended statistics columns present, as in the
+// released schema). A ttl column added by later builds is kept, migration
+// 15 skips it. A database with only some of the extended statistics
+// columns is reported as partially migrated.
+func adoptUnversionedSchema(db *sql.DB) (int, error) {
+	columns, err := tableColumns(db, "ping_logs")
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+
+	var missing []string
+	for _, column := range extendedStatisticsColumns {
+		if !columns[column] {
+			missing = append(missing, column)
+		}
+	}
+
+	version := 2
+	switch {
+	case len(missing) == len(extendedStatisticsColumns):
+		version = 1
+	case len(missing) > 0:
+		return 0, fmt.Errorf("ping_logs is partially migrated (missing %s), repair or restore the database before starting",
+			strings.Join(missing, ", "))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, migration := range schemaMigrations[:version] {
+		if err := recordSchemaVersion(tx, migration); err != nil {
+			return 0, fmt.Errorf("recording schema version: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("recording schema version: %w", err)
+	}
+
+	logger.Default().WithComponent("storage-migrate").Info("Existing database adopted into schema versioning", "version", version)
+	return version, nil
+}
+
+// tableColumns returns the column names of a table, empty when it does not exist
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("reading columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("reading columns of %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
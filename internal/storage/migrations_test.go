@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// latestPingLogColumns are the ping_logs columns of the latest schema version
+var latestPingLogColumns = []string{
+	"id", "timestamp", "site_id", "site_name", "target", "ip", "success", "latency", "error", "created_at",
+	"packets_sent", "packets_recv", "packets_duplicates", "packet_loss", "min_latency", "max_latency", "jitter",
+	"ttl", "source", "circuit_open", "config_error", "source_ip", "packets_corrupted", "degraded",
+}
+
+// fixtureDatabase creates a database in a temporary directory from an SQL
+// script in testdata and returns its path
+func fixtureDatabase(t *testing.T, fixture string) string {
+	t.Helper()
+
+	script, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "sitewatch.db")
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("loading fixture %s: %v", fixture, err)
+	}
+	return path
+}
+
+// schemaState returns the ping_logs columns and the recorded schema versions
+func schemaState(t *testing.T, path string) (map[string]bool, []int) {
+	t.Helper()
+
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer db.Close()
+
+	columns, err := tableColumns(db, "ping_logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT version FROM schema_version ORDER BY version")
+	if err != nil {
+		t.Fatalf("reading schema_version: %v", err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, version)
+	}
+	return columns, versions
+}
+
+func assertLatestSchema(t *testing.T, path string) {
+	t.Helper()
+
+	columns, versions := schemaState(t, path)
+	for _, column := range latestPingLogColumns {
+		if !columns[column] {
+			t.Errorf("ping_logs column %s missing after migration", column)
+		}
+	}
+	if len(versions) != LatestSchemaVersion() {
+		t.Fatalf("recorded versions %v, want 1 to %d", versions, LatestSchemaVersion())
+	}
+	for i, version := range versions {
+		if version != i+1 {
+			t.Fatalf("recorded versions %v, want 1 to %d", versions, LatestSchemaVersion())
+		}
+	}
+}
+
+func TestMigrateFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		rows    int
+	}{
+		{"schema_v1.sql", 2},
+		{"schema_baseline.sql", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			path := fixtureDatabase(t, tt.fixture)
+
+			if err := MigrateSQLite(path); err != nil {
+				t.Fatalf("MigrateSQLite: %v", err)
+			}
+			assertLatestSchema(t, path)
+
+			// Existing logs survive and read back with the new columns
+			storage, err := NewSQLiteStorage(path)
+			if err != nil {
+				t.Fatalf("NewSQLiteStorage: %v", err)
+			}
+			defer storage.Close()
+			logs, err := storage.GetAllLogs()
+			if err != nil {
+				t.Fatalf("GetAllLogs: %v", err)
+			}
+			if len(logs) != tt.rows {
+				t.Fatalf("got %d logs, want %d", len(logs), tt.rows)
+			}
+			for _, log := range logs {
+				if log.SiteID != "site-001" || log.TTL != nil || log.Source != "" || log.CircuitOpen {
+					t.Errorf("unexpected migrated log %+v", log)
+				}
+			}
+
+			// Migrating again is a no-op
+			if err := MigrateSQLite(path); err != nil {
+				t.Fatalf("second MigrateSQLite: %v", err)
+			}
+			assertLatestSchema(t, path)
+		})
+	}
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.db")
+	if err := MigrateSQLite(path); err != nil {
+		t.Fatalf("MigrateSQLite: %v", err)
+	}
+	assertLatestSchema(t, path)
+}
+
+// Databases of builds that added ttl with migration 2 already have the
+// column when the ttl migration runs
+func TestMigrateSkipsExistingTTLColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.db")
+	if err := MigrateSQLite(path); err != nil {
+		t.Fatalf("MigrateSQLite: %v", err)
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("DELETE FROM schema_version WHERE version = 15"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if err := MigrateSQLite(path); err != nil {
+		t.Fatalf("MigrateSQLite with ttl present: %v", err)
+	}
+	assertLatestSchema(t, path)
+}
+
+// An unversioned baseline database that already has ttl, written by builds
+// before schema versioning, is adopted as well
+func TestMigrateAdoptsBaselineWithTTL(t *testing.T) {
+	path := fixtureDatabase(t, "schema_baseline.sql")
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("ALTER TABLE ping_logs ADD COLUMN ttl INTEGER"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if err := MigrateSQLite(path); err != nil {
+		t.Fatalf("MigrateSQLite: %v", err)
+	}
+	assertLatestSchema(t, path)
+}
+
+func TestMigrateRejectsPartialSchema(t *testing.T) {
+	path := fixtureDatabase(t, "schema_v1.sql")
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("ALTER TABLE ping_logs ADD COLUMN packets_sent INTEGER DEFAULT 0"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	err = MigrateSQLite(path)
+	if err == nil || !strings.Contains(err.Error(), "partially migrated") {
+		t.Fatalf("MigrateSQLite = %v, want a partially migrated error", err)
+	}
+	if _, versions := schemaState(t, path); len(versions) != 0 {
+		t.Errorf("versions %v recorded for a rejected database", versions)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newer.db")
+	if err := MigrateSQLite(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?, 'future', CURRENT_TIMESTAMP)",
+		LatestSchemaVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if err := MigrateSQLite(path); err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("MigrateSQLite = %v, want a newer schema error", err)
+	}
+}
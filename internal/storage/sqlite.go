@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"sitewatch/internal/logger"
@@ -19,26 +21,19 @@ type SQLiteStorage struct {
 	mu         sync.RWMutex
 }
 
-// NewSQLiteStorage creates a new SQLite storage instance
+// NewSQLiteStorage creates a new SQLite storage instance, migrating the schema to the latest version
 func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
-	// Ensure directory exists
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
-	// Open SQLite database
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_synchronous=NORMAL&_timeout=5000")
+	db, err := openSQLite(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+		return nil, err
 	}
 
 	storage := &SQLiteStorage{db: db}
 
-	// Initialize database schema
-	if err := storage.initSchema(); err != nil {
+	// Bring the schema up to date, refusing to start on an unknown or partial schema
+	if err := migrateSchema(db); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
 	}
 
 	// Get current max ID
@@ -52,63 +47,52 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 	return storage, nil
 }
 
-func (s *SQLiteStorage) initSchema() error {
-	// Create table with extended ping statistics
-	query := `
-	CREATE TABLE IF NOT EXISTS ping_logs (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME NOT NULL,
-		site_id TEXT NOT NULL,
-		site_name TEXT NOT NULL,
-		target TEXT NOT NULL,
-		ip TEXT NOT NULL,
-		success BOOLEAN NOT NULL,
-		latency REAL,
-		error TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		
-		-- Extended ping statistics
-		packets_sent INTEGER DEFAULT 0,
-		packets_recv INTEGER DEFAULT 0,
-		packets_duplicates INTEGER DEFAULT 0,
-		packet_loss REAL,
-		min_latency REAL,
-		max_latency REAL,
-		jitter REAL
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_timestamp ON ping_logs(timestamp);
-	CREATE INDEX IF NOT EXISTS idx_site_id ON ping_logs(site_id);
-	CREATE INDEX IF NOT EXISTS idx_site_timestamp ON ping_logs(site_id, timestamp);
-	CREATE INDEX IF NOT EXISTS idx_success ON ping_logs(success);
-	CREATE INDEX IF NOT EXISTS idx_packet_loss ON ping_logs(packet_loss);
-	CREATE INDEX IF NOT EXISTS idx_latency ON ping_logs(latency);
-	`
-
-	_, err := s.db.Exec(query)
+// MigrateSQLite applies pending schema migrations to the database at dbPath and closes it
+func MigrateSQLite(dbPath string) error {
+	db, err := openSQLite(dbPath)
 	if err != nil {
 		return err
 	}
-	
-	// Add new columns to existing tables (migration)
-	migrationQueries := []string{
-		"ALTER TABLE ping_logs ADD COLUMN packets_sent INTEGER DEFAULT 0",
-		"ALTER TABLE ping_logs ADD COLUMN packets_recv INTEGER DEFAULT 0", 
-		"ALTER TABLE ping_logs ADD COLUMN packets_duplicates INTEGER DEFAULT 0",
-		"ALTER TABLE ping_logs ADD COLUMN packet_loss REAL",
-		"ALTER TABLE ping_logs ADD COLUMN min_latency REAL",
-		"ALTER TABLE ping_logs ADD COLUMN max_latency REAL",
-		"ALTER TABLE ping_logs ADD COLUMN jitter REAL",
-	}
-	
-	// Execute migrations (ignore errors for existing columns)
-	for _, migration := range migrationQueries {
-		s.db.Exec(migration) // Ignore errors - column may already exist
-	}
-	
+	defer db.Close()
+
+	if err := migrateSchema(db); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
 	return nil
 }
 
+// SQLiteFileSize returns the size of the database at dbPath in bytes, including
+// its WAL and shared memory files when present
+func SQLiteFileSize(dbPath string) (int64, error) {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if info, err := os.Stat(dbPath + suffix); err == nil {
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+
+// openSQLite opens the database file, creating its directory if needed
+func openSQLite(dbPath string) (*sql.DB, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	// Open SQLite database
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_synchronous=NORMAL&_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	return db, nil
+}
+
 func (s *SQLiteStorage) loadMaxID() error {
 	var maxID sql.NullInt64
 	err := s.db.QueryRow("SELECT MAX(id) FROM ping_logs").Scan(&maxID)
@@ -131,8 +115,8 @@ func (s *SQLiteStorage) AddPingLog(log models.PingLog) error {
 	INSERT INTO ping_logs (
 		timestamp, site_id, site_name, target, ip, success, latency, error,
 		packets_sent, packets_recv, packets_duplicates, packet_loss,
-		min_latency, max_latency, jitter
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		min_latency, max_latency, jitter, ttl, source, circuit_open, config_error, source_ip, packets_corrupted, degraded
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := s.db.Exec(query,
@@ -151,6 +135,13 @@ func (s *SQLiteStorage) AddPingLog(log models.PingLog) error {
 		log.MinLatency,
 		log.MaxLatency,
 		log.Jitter,
+		log.TTL,
+		log.Source,
+		log.CircuitOpen,
+		log.ConfigError,
+		log.SourceIP,
+		log.PacketsCorrupted,
+		log.Degraded,
 	)
 
 	if err != nil {
@@ -166,44 +157,137 @@ func (s *SQLiteStorage) AddPingLog(log models.PingLog) error {
 	return nil
 }
 
-func (s *SQLiteStorage) GetFilteredLogs(siteID string, success *bool, limit int) ([]models.PingLog, error) {
+// GetFilteredLogs returns up to limit logs of the given sites (nil = all),
+// newest first
+func (s *SQLiteStorage) GetFilteredLogs(siteIDs []string, success *bool, limit int) ([]models.PingLog, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var args []interface{}
+	where, args := logFilterClause(siteIDs, success)
 	query := `SELECT id, timestamp, site_id, site_name, target, ip, success, latency, error,
 		packets_sent, packets_recv, packets_duplicates, packet_loss,
-		min_latency, max_latency, jitter 
-		FROM ping_logs WHERE 1=1`
+		min_latency, max_latency, jitter, ttl, source, circuit_open, config_error, source_ip, packets_corrupted, degraded
+		FROM ping_logs` + where + " ORDER BY timestamp DESC"
 
-	if siteID != "" {
-		query += " AND site_id = ?"
-		args = append(args, siteID)
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ping logs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPingLogs(rows)
+}
+
+// GetFilteredLogsPage returns up to limit logs starting at offset (newest
+// first) together with the total number of logs matching the filters
+func (s *SQLiteStorage) GetFilteredLogsPage(siteIDs []string, success *bool, limit, offset int) ([]models.PingLog, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	where, args := logFilterClause(siteIDs, success)
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM ping_logs"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count ping logs: %w", err)
+	}
+
+	query := `SELECT id, timestamp, site_id, site_name, target, ip, success, latency, error,
+		packets_sent, packets_recv, packets_duplicates, packet_loss,
+		min_latency, max_latency, jitter, ttl, source, circuit_open, config_error, source_ip, packets_corrupted, degraded
+		FROM ping_logs` + where + " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query ping logs page: %w", err)
+	}
+	defer rows.Close()
+
+	logs, err := scanPingLogs(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// logFilterClause builds the WHERE clause shared by the filtered log queries
+func logFilterClause(siteIDs []string, success *bool) (string, []interface{}) {
+	var args []interface{}
+	where := " WHERE 1=1"
+
+	if siteIDs != nil {
+		where += " AND site_id IN (" + strings.TrimSuffix(strings.Repeat("?,", len(siteIDs)), ",") + ")"
+		for _, siteID := range siteIDs {
+			args = append(args, siteID)
+		}
 	}
 
 	if success != nil {
-		query += " AND success = ?"
+		where += " AND success = ?"
 		args = append(args, *success)
 	}
 
-	query += " ORDER BY timestamp DESC"
+	return where, args
+}
 
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
+// GetLogsInRange returns all logs with start <= timestamp < end in chronological order
+func (s *SQLiteStorage) GetLogsInRange(start, end time.Time) ([]models.PingLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `SELECT id, timestamp, site_id, site_name, target, ip, success, latency, error,
+		packets_sent, packets_recv, packets_duplicates, packet_loss,
+		min_latency, max_latency, jitter, ttl, source, circuit_open, config_error, source_ip, packets_corrupted, degraded
+		FROM ping_logs WHERE timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC`
+
+	// Timestamps are stored in local time, compare in the same representation
+	rows, err := s.db.Query(query, start.Local(), end.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ping logs in range: %w", err)
 	}
+	defer rows.Close()
 
-	rows, err := s.db.Query(query, args...)
+	return scanPingLogs(rows)
+}
+
+// GetRecentLogsPerTarget returns the newest perTarget logs for each target of a site, newest first
+func (s *SQLiteStorage) GetRecentLogsPerTarget(siteID string, perTarget int) ([]models.PingLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Rank rows per target so the limit applies to each line independently
+	query := `SELECT id, timestamp, site_id, site_name, target, ip, success, latency, error,
+		packets_sent, packets_recv, packets_duplicates, packet_loss,
+		min_latency, max_latency, jitter, ttl, source, circuit_open, config_error, source_ip, packets_corrupted, degraded
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY target ORDER BY timestamp DESC) AS rn
+			FROM ping_logs WHERE site_id = ?
+		) WHERE rn <= ?
+		ORDER BY timestamp DESC`
+
+	rows, err := s.db.Query(query, siteID, perTarget)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query ping logs: %w", err)
+		return nil, fmt.Errorf("failed to query recent ping logs: %w", err)
 	}
 	defer rows.Close()
 
+	return scanPingLogs(rows)
+}
+
+// scanPingLogs reads ping log rows selected with the standard column list
+func scanPingLogs(rows *sql.Rows) ([]models.PingLog, error) {
 	var logs []models.PingLog
 	for rows.Next() {
 		var log models.PingLog
 		var latency, packetLoss, minLatency, maxLatency, jitter sql.NullFloat64
 		var errorMsg sql.NullString
+		var ttl sql.NullInt64
 
 		err := rows.Scan(
 			&log.ID,
@@ -222,6 +306,13 @@ func (s *SQLiteStorage) GetFilteredLogs(siteID string, success *bool, limit int)
 			&minLatency,
 			&maxLatency,
 			&jitter,
+			&ttl,
+			&log.Source,
+			&log.CircuitOpen,
+			&log.ConfigError,
+			&log.SourceIP,
+			&log.PacketsCorrupted,
+			&log.Degraded,
 		)
 
 		if err != nil {
@@ -247,6 +338,10 @@ func (s *SQLiteStorage) GetFilteredLogs(siteID string, success *bool, limit int)
 		if jitter.Valid {
 			log.Jitter = &jitter.Float64
 		}
+		if ttl.Valid {
+			value := int(ttl.Int64)
+			log.TTL = &value
+		}
 
 		logs = append(logs, log)
 	}
@@ -254,8 +349,564 @@ func (s *SQLiteStorage) GetFilteredLogs(siteID string, success *bool, limit int)
 	return logs, rows.Err()
 }
 
+// ReplaceMaintenanceWindows replaces all maintenance windows imported from source in one transaction
+func (s *SQLiteStorage) ReplaceMaintenanceWindows(source string, windows []models.MaintenanceWindow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin maintenance window transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM maintenance_windows WHERE source = ?", source); err != nil {
+		return fmt.Errorf("failed to delete maintenance windows: %w", err)
+	}
+	for _, window := range windows {
+		_, err := tx.Exec(`INSERT INTO maintenance_windows (site_id, summary, start_time, end_time, source, uid)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			window.SiteID, window.Summary, window.Start.Local(), window.End.Local(), source, window.UID)
+		if err != nil {
+			return fmt.Errorf("failed to insert maintenance window: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit maintenance windows: %w", err)
+	}
+	return nil
+}
+
+// GetMaintenanceWindows returns the maintenance windows overlapping start..end ordered by start
+func (s *SQLiteStorage) GetMaintenanceWindows(start, end time.Time) ([]models.MaintenanceWindow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Times are stored in local time like the ping log timestamps
+	rows, err := s.db.Query(`SELECT id, site_id, summary, start_time, end_time, source, uid
+		FROM maintenance_windows WHERE start_time < ? AND end_time > ?
+		ORDER BY start_time ASC`, end.Local(), start.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := []models.MaintenanceWindow{}
+	for rows.Next() {
+		var window models.MaintenanceWindow
+		if err := rows.Scan(&window.ID, &window.SiteID, &window.Summary, &window.Start, &window.End, &window.Source, &window.UID); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+// UpsertDiscoveryCandidate records a scan reply, keeping the first_seen of a known address
+func (s *SQLiteStorage) UpsertDiscoveryCandidate(candidate models.DiscoveryCandidate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO discovery_candidates (ip, first_seen, last_seen, latency_ms)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(ip) DO UPDATE SET last_seen = excluded.last_seen, latency_ms = excluded.latency_ms`,
+		candidate.IP, candidate.FirstSeen.Local(), candidate.LastSeen.Local(), candidate.LatencyMs)
+	if err != nil {
+		return fmt.Errorf("failed to upsert discovery candidate: %w", err)
+	}
+	return nil
+}
+
+// GetDiscoveryCandidates returns all discovery candidates, most recently seen first
+func (s *SQLiteStorage) GetDiscoveryCandidates() ([]models.DiscoveryCandidate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT ip, first_seen, last_seen, latency_ms
+		FROM discovery_candidates ORDER BY last_seen DESC, ip ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discovery candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := []models.DiscoveryCandidate{}
+	for rows.Next() {
+		var candidate models.DiscoveryCandidate
+		if err := rows.Scan(&candidate.IP, &candidate.FirstSeen, &candidate.LastSeen, &candidate.LatencyMs); err != nil {
+			return nil, fmt.Errorf("failed to scan discovery candidate: %w", err)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// DeleteDiscoveryCandidate removes a discovery candidate, e.g. after it was promoted to a site
+func (s *SQLiteStorage) DeleteDiscoveryCandidate(ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM discovery_candidates WHERE ip = ?", ip); err != nil {
+		return fmt.Errorf("failed to delete discovery candidate: %w", err)
+	}
+	return nil
+}
+
+// GetReportLastRun returns the last run of a report schedule, the zero time if it never ran
+func (s *SQLiteStorage) GetReportLastRun(name string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var lastRun time.Time
+	err := s.db.QueryRow("SELECT last_run FROM report_runs WHERE name = ?", name).Scan(&lastRun)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query report run: %w", err)
+	}
+	return lastRun, nil
+}
+
+// SetReportLastRun records the last run of a report schedule
+func (s *SQLiteStorage) SetReportLastRun(name string, lastRun time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO report_runs (name, last_run) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET last_run = excluded.last_run`, name, lastRun.Local())
+	if err != nil {
+		return fmt.Errorf("failed to record report run: %w", err)
+	}
+	return nil
+}
+
+// EnsureSiteFirstSeen records when a site was first configured, a recorded date is kept
+func (s *SQLiteStorage) EnsureSiteFirstSeen(siteID string, firstSeen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("INSERT OR IGNORE INTO site_metadata (site_id, first_seen) VALUES (?, ?)", siteID, firstSeen.Local())
+	if err != nil {
+		return fmt.Errorf("failed to record site first seen: %w", err)
+	}
+	return nil
+}
+
+// SetSiteFirstSuccess records the first successful check of a site, a recorded date is kept
+func (s *SQLiteStorage) SetSiteFirstSuccess(siteID string, firstSuccess time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO site_metadata (site_id, first_seen, first_success) VALUES (?, ?, ?)
+		ON CONFLICT(site_id) DO UPDATE SET first_success = excluded.first_success WHERE first_success IS NULL`,
+		siteID, firstSuccess.Local(), firstSuccess.Local())
+	if err != nil {
+		return fmt.Errorf("failed to record site first success: %w", err)
+	}
+	return nil
+}
+
+// GetSiteMonitoring returns the monitoring dates of all sites ever seen, by site ID
+func (s *SQLiteStorage) GetSiteMonitoring() (map[string]models.SiteMonitoring, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT site_id, first_seen, first_success FROM site_metadata")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query site metadata: %w", err)
+	}
+	defer rows.Close()
+
+	monitoring := make(map[string]models.SiteMonitoring)
+	for rows.Next() {
+		var siteID string
+		var entry models.SiteMonitoring
+		var firstSuccess sql.NullTime
+		if err := rows.Scan(&siteID, &entry.FirstSeen, &firstSuccess); err != nil {
+			return nil, fmt.Errorf("failed to scan site metadata: %w", err)
+		}
+		if firstSuccess.Valid {
+			entry.FirstSuccess = &firstSuccess.Time
+		}
+		monitoring[siteID] = entry
+	}
+	return monitoring, rows.Err()
+}
+
+// AddWebhookDLQ stores an undelivered event in the dead letter queue
+func (s *SQLiteStorage) AddWebhookDLQ(entry models.DeadLetterEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO webhook_dead_letter (timestamp, event_json, notifier_name, last_error, retry_count)
+		VALUES (?, ?, ?, ?, ?)`, entry.Timestamp.Local(), entry.EventJSON, entry.NotifierName, entry.LastError, entry.RetryCount)
+	if err != nil {
+		return fmt.Errorf("failed to add dead letter entry: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDLQ returns a page of dead letter entries, oldest first, and the
+// total number of entries. A limit of 0 returns all entries.
+func (s *SQLiteStorage) GetWebhookDLQ(limit, offset int) ([]models.DeadLetterEntry, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM webhook_dead_letter").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letter entries: %w", err)
+	}
+
+	query := `SELECT id, timestamp, event_json, notifier_name, last_error, retry_count, last_retry_at
+		FROM webhook_dead_letter ORDER BY id`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query dead letter entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.DeadLetterEntry{}
+	for rows.Next() {
+		entry, err := scanDeadLetterEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// GetWebhookDLQEntry returns a dead letter entry, exists is false when there is none with the ID
+func (s *SQLiteStorage) GetWebhookDLQEntry(id int64) (models.DeadLetterEntry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row := s.db.QueryRow(`SELECT id, timestamp, event_json, notifier_name, last_error, retry_count, last_retry_at
+		FROM webhook_dead_letter WHERE id = ?`, id)
+	entry, err := scanDeadLetterEntry(row)
+	if err == sql.ErrNoRows {
+		return models.DeadLetterEntry{}, false, nil
+	}
+	if err != nil {
+		return models.DeadLetterEntry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// scanDeadLetterEntry reads a webhook_dead_letter row
+func scanDeadLetterEntry(row interface{ Scan(dest ...any) error }) (models.DeadLetterEntry, error) {
+	var entry models.DeadLetterEntry
+	var lastRetryAt sql.NullTime
+	err := row.Scan(&entry.ID, &entry.Timestamp, &entry.EventJSON, &entry.NotifierName,
+		&entry.LastError, &entry.RetryCount, &lastRetryAt)
+	if err == sql.ErrNoRows {
+		return entry, err
+	}
+	if err != nil {
+		return entry, fmt.Errorf("failed to scan dead letter entry: %w", err)
+	}
+	if lastRetryAt.Valid {
+		entry.LastRetryAt = &lastRetryAt.Time
+	}
+	return entry, nil
+}
+
+// UpdateWebhookDLQRetry records a failed retry of a dead letter entry
+func (s *SQLiteStorage) UpdateWebhookDLQRetry(id int64, lastError string, retriedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`UPDATE webhook_dead_letter SET last_error = ?, retry_count = retry_count + 1, last_retry_at = ?
+		WHERE id = ?`, lastError, retriedAt.Local(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update dead letter entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookDLQ removes a dead letter entry, e.g. after it was delivered
+func (s *SQLiteStorage) DeleteWebhookDLQ(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM webhook_dead_letter WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete dead letter entry: %w", err)
+	}
+	return nil
+}
+
+// PurgeWebhookDLQ removes the dead letter entries added before the given time
+// and returns how many were removed
+func (s *SQLiteStorage) PurgeWebhookDLQ(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM webhook_dead_letter WHERE timestamp < ?", before.Local())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letter entries: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letter entries: %w", err)
+	}
+	return int(purged), nil
+}
+
+// AddHostSample stores a sample of the metrics of the monitoring host
+func (s *SQLiteStorage) AddHostSample(sample models.HostSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO self_metrics (timestamp, cpu_percent, load1, load5, load15,
+		memory_used_percent, memory_available_bytes, network_errors)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sample.Timestamp.Local(), sample.CPUPercent, sample.Load1, sample.Load5, sample.Load15,
+		sample.MemoryUsedPercent, sample.MemoryAvailableBytes, sample.NetworkErrors)
+	if err != nil {
+		return fmt.Errorf("failed to add host sample: %w", err)
+	}
+	return nil
+}
+
+// GetHostSamples returns the host samples taken in [start, end), oldest first
+func (s *SQLiteStorage) GetHostSamples(start, end time.Time) ([]models.HostSample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT timestamp, cpu_percent, load1, load5, load15,
+		memory_used_percent, memory_available_bytes, network_errors
+		FROM self_metrics WHERE timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC`, start.Local(), end.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query host samples: %w", err)
+	}
+	defer rows.Close()
+
+	samples := []models.HostSample{}
+	for rows.Next() {
+		var sample models.HostSample
+		if err := rows.Scan(&sample.Timestamp, &sample.CPUPercent, &sample.Load1, &sample.Load5, &sample.Load15,
+			&sample.MemoryUsedPercent, &sample.MemoryAvailableBytes, &sample.NetworkErrors); err != nil {
+			return nil, fmt.Errorf("failed to scan host sample: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
+
+// PurgeHostSamples removes the host samples taken before the given time and
+// returns how many were removed
+func (s *SQLiteStorage) PurgeHostSamples(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM self_metrics WHERE timestamp < ?", before.Local())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge host samples: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge host samples: %w", err)
+	}
+	return int(purged), nil
+}
+
+// AddFleetSnapshot stores a snapshot of the site counts
+func (s *SQLiteStorage) AddFleetSnapshot(snapshot models.FleetSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO fleet_snapshots (timestamp, total_sites, online_sites, offline_sites, degraded_sites)
+		VALUES (?, ?, ?, ?, ?)`,
+		snapshot.Timestamp.Local(), snapshot.TotalSites, snapshot.OnlineSites, snapshot.OfflineSites, snapshot.DegradedSites)
+	if err != nil {
+		return fmt.Errorf("failed to add fleet snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetFleetSnapshots returns the snapshots taken in [start, end), oldest first
+func (s *SQLiteStorage) GetFleetSnapshots(start, end time.Time) ([]models.FleetSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT timestamp, total_sites, online_sites, offline_sites, degraded_sites
+		FROM fleet_snapshots WHERE timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC`, start.Local(), end.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fleet snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []models.FleetSnapshot{}
+	for rows.Next() {
+		var snapshot models.FleetSnapshot
+		if err := rows.Scan(&snapshot.Timestamp, &snapshot.TotalSites, &snapshot.OnlineSites,
+			&snapshot.OfflineSites, &snapshot.DegradedSites); err != nil {
+			return nil, fmt.Errorf("failed to scan fleet snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// PurgeFleetSnapshots removes the snapshots taken before the given time and
+// returns how many were removed
+func (s *SQLiteStorage) PurgeFleetSnapshots(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM fleet_snapshots WHERE timestamp < ?", before.Local())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge fleet snapshots: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge fleet snapshots: %w", err)
+	}
+	return int(purged), nil
+}
+
+// AddSizeSweep stores the results of a size sweep
+func (s *SQLiteStorage) AddSizeSweep(results []models.SizeSweepResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to add size sweep: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, result := range results {
+		_, err := tx.Exec(`INSERT INTO size_sweeps (timestamp, site_id, target, size, packets_sent, packets_recv, packets_corrupted, latency, error)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			result.Timestamp.Local(), result.SiteID, result.Target, result.Size,
+			result.PacketsSent, result.PacketsRecv, result.PacketsCorrupted, result.Latency, result.Error)
+		if err != nil {
+			return fmt.Errorf("failed to add size sweep: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add size sweep: %w", err)
+	}
+	return nil
+}
+
+// GetSizeSweeps returns the size sweep results of a site taken in
+// [start, end), oldest first
+func (s *SQLiteStorage) GetSizeSweeps(siteID string, start, end time.Time) ([]models.SizeSweepResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT timestamp, site_id, target, size, packets_sent, packets_recv, packets_corrupted, latency, error
+		FROM size_sweeps WHERE site_id = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC, id ASC`, siteID, start.Local(), end.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query size sweeps: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.SizeSweepResult{}
+	for rows.Next() {
+		var result models.SizeSweepResult
+		var latency sql.NullFloat64
+		if err := rows.Scan(&result.Timestamp, &result.SiteID, &result.Target, &result.Size,
+			&result.PacketsSent, &result.PacketsRecv, &result.PacketsCorrupted, &latency, &result.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan size sweep: %w", err)
+		}
+		if latency.Valid {
+			result.Latency = &latency.Float64
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// PurgeSizeSweeps removes the size sweep results taken before the given time
+// and returns how many were removed
+func (s *SQLiteStorage) PurgeSizeSweeps(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM size_sweeps WHERE timestamp < ?", before.Local())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge size sweeps: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge size sweeps: %w", err)
+	}
+	return int(purged), nil
+}
+
 func (s *SQLiteStorage) GetAllLogs() ([]models.PingLog, error) {
-	return s.GetFilteredLogs("", nil, 0)
+	return s.GetFilteredLogs(nil, nil, 0)
+}
+
+// Optimize runs PRAGMA optimize and, if vacuum is set, reclaims the free pages:
+// with VACUUM, or with incremental_vacuum on databases created with
+// auto_vacuum. Writes wait until the run is finished.
+func (s *SQLiteStorage) Optimize(vacuum bool) (models.StorageOptimizeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	result := models.StorageOptimizeResult{Vacuum: "skipped"}
+
+	var err error
+	if result.SizeBefore, err = s.databaseSize(); err != nil {
+		return result, err
+	}
+
+	if _, err := s.db.Exec("PRAGMA optimize"); err != nil {
+		return result, fmt.Errorf("failed to optimize database: %w", err)
+	}
+
+	if vacuum {
+		var autoVacuum int
+		if err := s.db.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
+			return result, fmt.Errorf("failed to read auto_vacuum mode: %w", err)
+		}
+		statement := "VACUUM"
+		result.Vacuum = "full"
+		if autoVacuum != 0 {
+			statement = "PRAGMA incremental_vacuum"
+			result.Vacuum = "incremental"
+		}
+		if _, err := s.db.Exec(statement); err != nil {
+			return result, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		// The database file only shrinks once the WAL is written back
+		if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			return result, fmt.Errorf("failed to checkpoint database: %w", err)
+		}
+	}
+
+	if result.SizeAfter, err = s.databaseSize(); err != nil {
+		return result, err
+	}
+	result.ReclaimedBytes = result.SizeBefore - result.SizeAfter
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// databaseSize returns the size of the database pages in bytes
+func (s *SQLiteStorage) databaseSize() (int64, error) {
+	var pageCount, pageSize int64
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pageCount * pageSize, nil
 }
 
 func (s *SQLiteStorage) Close() error {
@@ -0,0 +1,32 @@
+-- ping_logs as created by the released schema: extended ping statistics
+-- without ttl, no schema_version table (adopted as schema version 2)
+CREATE TABLE ping_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME NOT NULL,
+	site_id TEXT NOT NULL,
+	site_name TEXT NOT NULL,
+	target TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	success BOOLEAN NOT NULL,
+	latency REAL,
+	error TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	packets_sent INTEGER DEFAULT 0,
+	packets_recv INTEGER DEFAULT 0,
+	packets_duplicates INTEGER DEFAULT 0,
+	packet_loss REAL,
+	min_latency REAL,
+	max_latency REAL,
+	jitter REAL
+);
+CREATE INDEX idx_timestamp ON ping_logs(timestamp);
+CREATE INDEX idx_site_id ON ping_logs(site_id);
+CREATE INDEX idx_site_timestamp ON ping_logs(site_id, timestamp);
+CREATE INDEX idx_success ON ping_logs(success);
+CREATE INDEX idx_packet_loss ON ping_logs(packet_loss);
+CREATE INDEX idx_latency ON ping_logs(latency);
+
+INSERT INTO ping_logs (timestamp, site_id, site_name, target, ip, success, latency, error,
+	packets_sent, packets_recv, packets_duplicates, packet_loss, min_latency, max_latency, jitter)
+VALUES ('2024-01-15 10:30:00', 'site-001', 'Main Office', 'primary', '192.168.1.1', 1, 12.5, NULL,
+	3, 3, 0, 0, 11.9, 13.2, 0.5);
@@ -0,0 +1,22 @@
+-- ping_logs as created before the extended ping statistics (schema version 1)
+CREATE TABLE ping_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME NOT NULL,
+	site_id TEXT NOT NULL,
+	site_name TEXT NOT NULL,
+	target TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	success BOOLEAN NOT NULL,
+	latency REAL,
+	error TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_timestamp ON ping_logs(timestamp);
+CREATE INDEX idx_site_id ON ping_logs(site_id);
+CREATE INDEX idx_site_timestamp ON ping_logs(site_id, timestamp);
+CREATE INDEX idx_success ON ping_logs(success);
+CREATE INDEX idx_latency ON ping_logs(latency);
+
+INSERT INTO ping_logs (timestamp, site_id, site_name, target, ip, success, latency, error)
+VALUES ('2024-01-15 10:30:00', 'site-001', 'Main Office', 'primary', '192.168.1.1', 1, 12.5, NULL),
+       ('2024-01-15 10:30:30', 'site-001', 'Main Office', 'secondary', '192.168.1.2', 0, NULL, 'no packets received');
@@ -0,0 +1,107 @@
+// Package testutil holds the fixtures the tests of several packages share:
+// the app state they run against, a manual clock and the TestMain setup
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sitewatch/internal/clock"
+	"sitewatch/internal/config"
+	"sitewatch/internal/i18n"
+	"sitewatch/internal/logger"
+	"sitewatch/internal/models"
+	"sitewatch/internal/storage"
+)
+
+// Now is the time of the manual clock unless a test chooses its own
+var Now = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+// Main initializes the logger and loads the shipped translations from the
+// repository root at root like main does, then runs the tests of m
+func Main(m *testing.M, root string) {
+	logger.InitDefault()
+	if err := i18n.Load(filepath.Join(root, i18n.DefaultDir), i18n.FallbackLocale); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// Options selects what NewAppState sets up besides the defaults
+type Options struct {
+	Sites  []models.Site
+	Logs   []models.PingLog // Added to the memory storage
+	Prober models.Prober
+
+	// Now is the start time of the app state and the time of its manual
+	// clock, testutil.Now when zero
+	Now time.Time
+
+	// Clock makes a manual clock set to Now the clock of the application
+	Clock bool
+
+	// Global installs the app state as config.GlobalAppState, for code that
+	// reads it like the handlers
+	Global bool
+}
+
+// NewAppState returns an app state with the sites and logs of opts in memory
+// storage. Every line changes state with a single result. Whatever opts
+// installs globally is restored when the test ends.
+func NewAppState(t testing.TB, opts Options) *config.AppState {
+	t.Helper()
+
+	now := opts.Now
+	if now.IsZero() {
+		now = Now
+	}
+
+	appState := &config.AppState{
+		Sites:          opts.Sites,
+		SiteStatus:     make(map[string]*models.SiteStatus),
+		Storage:        storage.NewMemoryStorage(1000 + len(opts.Logs)),
+		StartTime:      now,
+		ResultChan:     make(chan models.PingResult, 64),
+		NextChecks:     make(map[string]time.Time),
+		SiteMonitoring: make(map[string]models.SiteMonitoring),
+		Prober:         opts.Prober,
+	}
+	appState.Config.Ping.DefaultInterval = 30 * time.Second
+	appState.Config.Ping.Timeout = time.Second
+	appState.Config.Ping.PacketCount = 3
+	appState.Config.Ping.MaxConcurrent = 8
+	appState.Config.Ping.FailuresBeforeDown = 1
+	appState.Config.Ping.SuccessesBeforeUp = 1
+	for _, site := range opts.Sites {
+		appState.SiteStatus[site.ID] = &models.SiteStatus{SiteID: site.ID}
+	}
+	for _, log := range opts.Logs {
+		if err := appState.Storage.AddPingLog(log); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if opts.Clock {
+		UseManualClock(t, now)
+	}
+	if opts.Global {
+		previous := config.GlobalAppState
+		config.GlobalAppState = appState
+		t.Cleanup(func() { config.GlobalAppState = previous })
+	}
+	return appState
+}
+
+// UseManualClock makes a manual clock set to now the clock of the
+// application until the test ends
+func UseManualClock(t testing.TB, now time.Time) *clock.Manual {
+	t.Helper()
+
+	clk := clock.NewManual(now)
+	previous := clock.Default()
+	clock.Set(clk)
+	t.Cleanup(func() { clock.Set(previous) })
+	return clk
+}
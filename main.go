@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
@@ -12,11 +13,25 @@ import (
 	"sitewatch/internal/config"
 	"sitewatch/internal/logger"
 	"sitewatch/internal/middleware"
+	"sitewatch/internal/services/chartimage"
+	"sitewatch/internal/services/discovery"
+	"sitewatch/internal/services/export"
+	"sitewatch/internal/services/housekeeping"
+	"sitewatch/internal/services/jobs"
+	"sitewatch/internal/services/maintenance"
+	"sitewatch/internal/services/notify"
 	"sitewatch/internal/services/ping"
+	"sitewatch/internal/services/reports"
+	"sitewatch/internal/services/selfmetrics"
+	"sitewatch/internal/services/stats"
 	"sitewatch/internal/models"
+	"sitewatch/internal/storage"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "Apply database schema migrations and exit (for pre-deploy pipelines)")
+	flag.Parse()
+	
 	// Initialize structured logging first
 	logger.InitDefault()
 	log := logger.Default().WithComponent("main")
@@ -32,11 +47,21 @@ func main() {
 	appState := config.GlobalAppState
 
 	// Load configuration
+	phaseStart := time.Now()
 	if err := appState.LoadConfig(); err != nil {
 		log.Error("Failed to load config", "error", err)
 		os.Exit(1)
 	}
 	log.Info("✅ Configuration loaded")
+	
+	if *migrateOnly {
+		if err := storage.MigrateSQLite(appState.Config.Storage.SQLitePath); err != nil {
+			log.Error("Schema migration failed", "error", err)
+			os.Exit(1)
+		}
+		log.Info("✅ Database schema up to date", "version", storage.LatestSchemaVersion())
+		return
+	}
 
 	// Load sites
 	if err := appState.LoadSites(); err != nil {
@@ -44,27 +69,142 @@ func main() {
 		os.Exit(1)
 	}
 	log.Info("✅ Sites loaded", "count", len(appState.Sites))
+	
+	// Network namespaces referenced by sites must exist
+	if err := ping.ValidateNetworkNamespaces(appState); err != nil {
+		log.Error("Invalid site network namespace", "error", err)
+		os.Exit(1)
+	}
+	
+	// Lines whose source is unavailable fail their checks, they are not probed via the default route
+	ping.CheckPingSources(appState)
+	var durations models.StartupDurations
+	durations.ConfigLoad = millisecondsSince(phaseStart)
 
 	// Initialize storage
+	phaseStart = time.Now()
 	if err := appState.InitStorage(); err != nil {
 		log.Error("Failed to initialize storage", "error", err)
 		os.Exit(1)
 	}
 
+	durations.StorageInit = millisecondsSince(phaseStart)
+
 	// Initialize site status
 	appState.InitializeSiteStatus()
+	if err := appState.LoadSiteMonitoring(); err != nil {
+		log.Warn("Failed to load site monitoring dates, uptime windows are not annotated", "error", err)
+	}
 	log.Info("✅ Application state initialized")
 
 	// Start ping workers
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Start notification delivery before results are processed
+	phaseStart = time.Now()
+	if err := notify.Setup(ctx, appState, stats.CalculateSiteStatistics, chartimage.NotificationChart); err != nil {
+		log.Error("Invalid notification template", "error", err)
+		os.Exit(1)
+	}
+
+	// Probe with ICMP echo requests, tests substitute a ping.FakeProber
+	appState.Prober = ping.ICMPProber{}
 	ping.StartPingWorkers(ctx, appState)
+	durations.WorkerStart = millisecondsSince(phaseStart)
 	log.Info("✅ Ping workers started")
 	
-	// Start metrics updater
-	middleware.StartMetricsUpdater(30 * time.Second)
-	log.Info("✅ Metrics updater started")
+	// Start latency baseline updater
+	if appState.Config.LatencyBaseline.Enabled {
+		stats.StartLatencyBaselineUpdater(ctx, appState)
+	}
+	
+	// Register failure pattern detection
+	if appState.Config.FailurePatterns.Enabled {
+		if err := stats.RegisterFailurePatternUpdater(appState); err != nil {
+			log.Error("Failed to register failure pattern updater", "error", err)
+			os.Exit(1)
+		}
+	}
+	
+	// Start maintenance window import
+	if len(appState.Config.Maintenance.ICalFeeds) > 0 {
+		maintenance.StartICalImporter(ctx, appState)
+	}
+	
+	// Start subnet discovery
+	if appState.Config.Discovery.SubnetScan.Enabled {
+		discovery.StartSubnetScanner(ctx, appState)
+	}
+	
+	// Register database maintenance
+	if err := housekeeping.RegisterStorageMaintenance(appState); err != nil {
+		log.Error("Failed to register storage maintenance", "error", err)
+		os.Exit(1)
+	}
+	
+	// Register monitoring host sampling
+	if err := selfmetrics.RegisterSampler(appState); err != nil {
+		log.Error("Failed to register self metrics sampler", "error", err)
+		os.Exit(1)
+	}
+	
+	// Register the removal of expired size sweep results
+	if err := ping.RegisterSizeSweepPurge(appState); err != nil {
+		log.Error("Failed to register size sweep purge", "error", err)
+		os.Exit(1)
+	}
+	
+	// Register fleet availability snapshots
+	if err := stats.RegisterFleetSnapshots(appState); err != nil {
+		log.Error("Failed to register fleet snapshots", "error", err)
+		os.Exit(1)
+	}
+	
+	// Register metrics updater
+	if err := middleware.RegisterMetricsUpdater(appState, appState.Config.Metrics.UpdateInterval); err != nil {
+		log.Error("Failed to register metrics updater", "error", err)
+		os.Exit(1)
+	}
+	
+	// Start export scheduler
+	if appState.Config.Export.Enabled {
+		export.StartExportScheduler(ctx, appState)
+		log.Info("✅ Export scheduler started")
+	}
+	
+	// Start scheduled availability reports
+	if len(appState.Config.Reports.Schedules) > 0 {
+		reports.StartReportScheduler(ctx, appState)
+	}
+	
+	// Start registered background jobs
+	jobs.Start(ctx)
+	log.Info("✅ Background jobs started")
+
+	// Summarize the effective runtime in a single record
+	durations.Total = millisecondsSince(appState.StartTime)
+	summary := appState.BuildRuntimeSummary(durations)
+	log.Info("startup_summary",
+		"version", summary.Version,
+		"config_path", summary.ConfigPath,
+		"sites_path", summary.SitesPath,
+		"storage_type", summary.StorageType,
+		"db_path", summary.DBPath,
+		"db_size_bytes", summary.DBSizeBytes,
+		"sites_total", summary.Sites.Total,
+		"sites_enabled", summary.Sites.Enabled,
+		"sites_disabled", summary.Sites.Disabled,
+		"sites_dual_line", summary.Sites.DualLine,
+		"ping_enabled", summary.PingEnabled,
+		"auth_enabled", summary.AuthEnabled,
+		"metrics_enabled", summary.MetricsEnabled,
+		"metrics_path", summary.MetricsPath,
+		"listen_address", summary.ListenAddress,
+		"config_load_ms", durations.ConfigLoad,
+		"storage_init_ms", durations.StorageInit,
+		"worker_start_ms", durations.WorkerStart,
+		"total_ms", durations.Total)
 
 	// Setup graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -74,8 +214,14 @@ func main() {
 	srv := server.SetupFiberApp(appState)
 	go func() {
 		addr := fmt.Sprintf("%s:%d", appState.Config.Server.Host, appState.Config.Server.Port)
-		log.Info("🌐 Server starting", "address", addr)
-		if err := srv.Listen(addr); err != nil {
+		log.Info("🌐 Server starting", "address", addr, "tls", appState.Config.Server.TLS.Enabled)
+		var err error
+		if appState.Config.Server.TLS.Enabled {
+			err = server.ListenTLS(srv, appState, addr)
+		} else {
+			err = srv.Listen(addr)
+		}
+		if err != nil {
 			log.Error("Server error", "error", err)
 		}
 	}()
@@ -87,6 +233,14 @@ func main() {
 	// Cancel context to stop workers
 	cancel()
 
+	// Wait for running background jobs, so none of them uses the storage after it is closed
+	if !jobs.Wait(10 * time.Second) {
+		log.Warn("Background jobs did not stop in time")
+	}
+
+	// Flush pending notifications
+	notify.Close()
+	
 	// Shutdown server gracefully
 	log.Info("⏳ Shutting down server")
 	if err := srv.Shutdown(); err != nil {
@@ -95,20 +249,21 @@ func main() {
 
 	// Close storage backend
 	if appState.Storage != nil {
-		if storageImpl, ok := appState.Storage.(interface{ Close() error }); ok {
-			if err := storageImpl.Close(); err != nil {
-				log.Error("Storage close error", "error", err)
-			} else {
-				log.Info("✅ Storage closed")
-			}
+		if err := appState.Storage.Close(); err != nil {
+			log.Error("Storage close error", "error", err)
+		} else {
+			log.Info("✅ Storage closed")
 		}
 	}
 
-	// Close result channel
-	if appState.ResultChan != nil {
-		close(appState.ResultChan)
-		log.Info("✅ Result channel closed")
-	}
+	// The result channel stays open: pings still in flight discard their
+	// result through SendResult, a send on a closed channel would panic
 
 	log.Info("👋 SiteWatch stopped")
+	logger.Close()
+}
+
+// millisecondsSince returns the elapsed time since start in milliseconds
+func millisecondsSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
 }
@@ -47,13 +47,15 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run tools/token-gen/main.go generate --name=\"Telegraf\" --permissions=\"metrics\"")
+	fmt.Println("  go run tools/token-gen/main.go generate --name=\"Prometheus\" --permissions=\"scrape\" --allowed-ips=\"10.0.5.20\"")
 	fmt.Println("  go run tools/token-gen/main.go ui-secret")
 }
 
 func generateToken() {
 	fs := flag.NewFlagSet("generate", flag.ExitOnError)
 	name := fs.String("name", "", "Token name/description (required)")
-	permissions := fs.String("permissions", "metrics", "Comma-separated permissions (metrics,read,test,admin)")
+	permissions := fs.String("permissions", "metrics", "Comma-separated permissions (scrape,metrics,status,read,test,ingest,admin)")
+	allowedIPs := fs.String("allowed-ips", "", "Comma-separated source IPs/CIDRs the token is accepted from (optional)")
 	expires := fs.String("expires", "", "Expiration date (YYYY-MM-DD format, optional)")
 	prefix := fs.String("prefix", "sw", "Token prefix")
 
@@ -83,6 +85,13 @@ func generateToken() {
 	fmt.Printf("- token: \"%s\"\n", token)
 	fmt.Printf("  name: \"%s\"\n", *name)
 	fmt.Printf("  permissions: [%s]\n", strings.Join(permList, ", "))
+	if *allowedIPs != "" {
+		ipList := strings.Split(*allowedIPs, ",")
+		for i, ip := range ipList {
+			ipList[i] = "\"" + strings.TrimSpace(ip) + "\""
+		}
+		fmt.Printf("  allowed_ips: [%s]\n", strings.Join(ipList, ", "))
+	}
 	if *expires != "" {
 		// Validate date format
 		if _, err := time.Parse("2006-01-02", *expires); err != nil {
@@ -148,9 +157,10 @@ func showExample() {
 	fmt.Println("    expires_hours: 24")
 	fmt.Println("  api:")
 	fmt.Println("    tokens:")
-	fmt.Println("      - token: \"sw_telegraf_a1b2c3d4e5f6...\"")
-	fmt.Println("        name: \"Telegraf Monitoring\"")
-	fmt.Println("        permissions: [\"metrics\"]")
+	fmt.Println("      - token: \"sw_prometheus_a1b2c3d4e5f6...\"")
+	fmt.Println("        name: \"Prometheus Scrape\"")
+	fmt.Println("        permissions: [\"scrape\"]")
+	fmt.Println("        allowed_ips: [\"10.0.5.20\", \"10.0.6.0/24\"]")
 	fmt.Println("        expires: \"2025-12-31\"")
 	fmt.Println("      - token: \"sw_admin_f6e5d4c3b2a1...\"")
 	fmt.Println("        name: \"Admin Access\"")
@@ -158,9 +168,12 @@ func showExample() {
 	fmt.Println("        # expires: null  # Never expires")
 	fmt.Println()
 	fmt.Println("Available permissions:")
+	fmt.Println("  - scrape:  Access to /metrics only, cannot be combined with other permissions")
 	fmt.Println("  - metrics: Access to /metrics, /health only")
+	fmt.Println("  - status:  Access to /api/sites and site status/details without IPs, providers and metadata")
 	fmt.Println("  - read:    Access to /api/sites, /api/logs, /api/health")
 	fmt.Println("  - test:    Access to read endpoints + /api/sites/:id/test")
+	fmt.Println("  - ingest:  Access to /api/ingest for submitting external results")
 	fmt.Println("  - admin:   Access to all endpoints (includes all permissions)")
 	fmt.Println()
 	fmt.Println("Usage Examples:")